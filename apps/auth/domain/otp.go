@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// Channel is the medium used to deliver one-time codes.
+type Channel string
+
+const (
+	// ChannelEmail delivers codes by email.
+	ChannelEmail Channel = "email"
+	// ChannelSMS delivers codes by text message.
+	ChannelSMS Channel = "sms"
+)
+
+// OTPCode is a hashed one-time code issued to a user to log in.
+// The plain code is never stored.
+type OTPCode struct {
+	ID         string     // ID is the unique identifier of the code.
+	UserID     string     // UserID is the user the code was issued to.
+	Channel    Channel    // Channel is the medium the code was sent through.
+	CodeHash   string     // CodeHash is the keyed hash of the code.
+	Attempts   int        // Attempts counts verifications, successful or not.
+	ExpiresAt  time.Time  // ExpiresAt is when the code stops being valid.
+	ConsumedAt *time.Time // ConsumedAt is set once the code is used or invalidated.
+	CreatedAt  time.Time  // CreatedAt records when the code was issued.
+}
+
+// Active reports whether the code can still be verified at the given time.
+func (c *OTPCode) Active(now time.Time, maxAttempts int) bool {
+	return c.ConsumedAt == nil && now.Before(c.ExpiresAt) && c.Attempts < maxAttempts
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// OTPRepository defines access methods for one-time codes.
+type OTPRepository interface {
+
+	// Create persists a new code.
+	Create(ctx context.Context, code *OTPCode) error
+
+	// GetLatest returns the most recent unconsumed code of the user, or nil if none.
+	GetLatest(ctx context.Context, userID string) (*OTPCode, error)
+
+	// CountSince returns how many codes were issued to the user since the given time.
+	CountSince(ctx context.Context, userID string, since time.Time) (int, error)
+
+	// Attempt atomically counts a verification of the code, i.e. UPDATE ... SET
+	// attempts = attempts + 1 WHERE id = ? AND attempts < maxAttempts AND
+	// consumed_at IS NULL AND expires_at > now, reporting false when no row matched.
+	Attempt(ctx context.Context, id string, maxAttempts int, now time.Time) (bool, error)
+
+	// Consume atomically marks the code as used, i.e. UPDATE ... SET consumed_at = at
+	// WHERE id = ? AND consumed_at IS NULL, reporting false when it was already used.
+	Consume(ctx context.Context, id string, at time.Time) (bool, error)
+
+	// InvalidateAll consumes every pending code of the user.
+	InvalidateAll(ctx context.Context, userID string) error
+}
+
+// RefreshTokenRepository defines access methods for refresh tokens.
+type RefreshTokenRepository interface {
+
+	// Create persists a new refresh token.
+	Create(ctx context.Context, token *RefreshToken) error
+
+	// GetByHash returns the token with the given hash, or nil if not found.
+	GetByHash(ctx context.Context, hash string) (*RefreshToken, error)
+
+	// Rotate atomically marks the token as rotated, i.e. UPDATE ... SET rotated_at = at
+	// WHERE id = ? AND rotated_at IS NULL AND revoked_at IS NULL, reporting false
+	// when it was already rotated or revoked.
+	Rotate(ctx context.Context, id string, at time.Time) (bool, error)
+
+	// RevokeFamily revokes every token of a family.
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// RevokeUser revokes every token of a user.
+	RevokeUser(ctx context.Context, userID string) error
+}
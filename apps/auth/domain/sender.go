@@ -0,0 +1,10 @@
+package domain
+
+import "context"
+
+// CodeSender delivers one-time codes to users.
+type CodeSender interface {
+
+	// Send delivers the plain code to the user through the channel.
+	Send(ctx context.Context, userID string, channel Channel, code string) error
+}
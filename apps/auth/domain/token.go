@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// RefreshToken is a hashed, single-use refresh token.
+// Tokens issued by rotation share the FamilyID of the original login, so reuse of
+// an already rotated token can revoke the whole family.
+type RefreshToken struct {
+	ID        string     // ID is the unique identifier of the token.
+	UserID    string     // UserID is the owner of the token.
+	FamilyID  string     // FamilyID groups the tokens descending from the same login.
+	TokenHash string     // TokenHash is the SHA-256 hash of the opaque token.
+	ExpiresAt time.Time  // ExpiresAt is when the token stops being valid.
+	RotatedAt *time.Time // RotatedAt is set once the token was exchanged for a new one.
+	RevokedAt *time.Time // RevokedAt is set once the token was revoked.
+	CreatedAt time.Time  // CreatedAt records when the token was issued.
+}
+
+// TokenPair is returned to clients after a successful login or refresh.
+type TokenPair struct {
+	AccessToken      string    // AccessToken is the signed JWT used on API calls.
+	AccessExpiresAt  time.Time // AccessExpiresAt is when the access token expires.
+	RefreshToken     string    // RefreshToken is the opaque token used to obtain a new pair.
+	RefreshExpiresAt time.Time // RefreshExpiresAt is when the refresh token expires.
+}
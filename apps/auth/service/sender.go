@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ianfedev/civicspot-backend/apps/auth/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/notify"
+)
+
+// FakeSender records codes in memory instead of delivering them.
+// It is meant for local development and tests.
+type FakeSender struct {
+	mu    sync.Mutex
+	codes map[string]string
+}
+
+// NewFakeSender creates an empty FakeSender.
+func NewFakeSender() *FakeSender {
+	return &FakeSender{codes: make(map[string]string)}
+}
+
+// Send records the latest code of the user.
+func (f *FakeSender) Send(_ context.Context, userID string, _ domain.Channel, code string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.codes[userID] = code
+	return nil
+}
+
+// LastCode returns the latest code sent to the user.
+func (f *FakeSender) LastCode(userID string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	code, ok := f.codes[userID]
+	return code, ok
+}
+
+// NotifySender delivers codes through the notification dispatcher using the "otp" template.
+type NotifySender struct {
+	dispatcher *notify.Dispatcher
+	recipients notify.RecipientResolver
+	channels   map[domain.Channel]notify.ChannelKind
+}
+
+// NewNotifySender creates a NotifySender. Channels maps OTP channels to notification
+// channels; email is mapped to notify.Email by default.
+func NewNotifySender(d *notify.Dispatcher, recipients notify.RecipientResolver, channels map[domain.Channel]notify.ChannelKind) *NotifySender {
+	m := map[domain.Channel]notify.ChannelKind{domain.ChannelEmail: notify.Email}
+	for k, v := range channels {
+		m[k] = v
+	}
+	return &NotifySender{dispatcher: d, recipients: recipients, channels: m}
+}
+
+// Send renders the "otp" template with the code and delivers it urgently on the mapped channel.
+func (s *NotifySender) Send(ctx context.Context, userID string, channel domain.Channel, code string) error {
+	kind, ok := s.channels[channel]
+	if !ok {
+		return fmt.Errorf("auth: unsupported code channel %q", channel)
+	}
+
+	rcpt, err := s.recipients(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.dispatcher.Send(ctx, notify.Notification{
+		Recipient: rcpt,
+		Template:  "otp",
+		Data:      map[string]any{"Code": code},
+		Channels:  []notify.ChannelKind{kind},
+		Urgent:    true,
+	})
+	return err
+}
@@ -0,0 +1,325 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/apps/auth/domain"
+	users "github.com/ianfedev/civicspot-backend/apps/users/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+var (
+	// ErrInvalidCode is returned when a code is wrong, expired or already used.
+	ErrInvalidCode = transport.Unauthorized("invalid or expired code")
+	// ErrTooManyAttempts is returned when the code attempt limit was reached.
+	ErrTooManyAttempts = transport.New(429, "too many attempts, request a new code", nil)
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or revoked.
+	ErrInvalidRefreshToken = transport.Unauthorized("invalid refresh token")
+	// ErrRefreshTokenReused is returned when a rotated refresh token is presented again.
+	ErrRefreshTokenReused = transport.Unauthorized("refresh token reuse detected, session revoked")
+)
+
+// Config defines the OTP and token policies.
+type Config struct {
+	Pepper      []byte        // Pepper keys the code hashes; it must be kept secret.
+	CodeLength  int           // CodeLength is the number of digits of a code (default: 6).
+	CodeTTL     time.Duration // CodeTTL is the lifetime of a code (default: 10 minutes).
+	MaxAttempts int           // MaxAttempts is the number of guesses allowed per code (default: 5).
+	MaxCodes    int           // MaxCodes is the number of codes issued per user within CodeWindow (default: 5).
+	CodeWindow  time.Duration // CodeWindow is the period MaxCodes applies to (default: 1 hour).
+	RefreshTTL  time.Duration // RefreshTTL is the lifetime of refresh tokens (default: 30 days).
+
+	// Roles resolves the roles written to access tokens (default: citizen).
+	Roles func(ctx context.Context, userID string) ([]string, error)
+}
+
+// UserDirectory finds citizens by their identity document.
+// It is implemented by the users service.
+type UserDirectory interface {
+	GetByDocument(ctx context.Context, docType users.DocumentType, docID string) (*users.User, error)
+}
+
+// AuthService implements passwordless login with one-time codes and rotating refresh tokens.
+type AuthService struct {
+	users  UserDirectory
+	codes  domain.OTPRepository
+	tokens domain.RefreshTokenRepository
+	sender domain.CodeSender
+	issuer *auth.Issuer
+	cfg    Config
+	now    func() time.Time
+}
+
+// NewAuthService creates a new instance of AuthService.
+func NewAuthService(
+	directory UserDirectory,
+	codes domain.OTPRepository,
+	tokens domain.RefreshTokenRepository,
+	sender domain.CodeSender,
+	issuer *auth.Issuer,
+	cfg Config,
+) *AuthService {
+	if cfg.CodeLength == 0 {
+		cfg.CodeLength = 6
+	}
+	if cfg.CodeTTL == 0 {
+		cfg.CodeTTL = 10 * time.Minute
+	}
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.MaxCodes == 0 {
+		cfg.MaxCodes = 5
+	}
+	if cfg.CodeWindow == 0 {
+		cfg.CodeWindow = time.Hour
+	}
+	if cfg.RefreshTTL == 0 {
+		cfg.RefreshTTL = 30 * 24 * time.Hour
+	}
+	if cfg.Roles == nil {
+		cfg.Roles = func(context.Context, string) ([]string, error) { return []string{"citizen"}, nil }
+	}
+	return &AuthService{users: directory, codes: codes, tokens: tokens, sender: sender, issuer: issuer, cfg: cfg, now: time.Now}
+}
+
+// RequestCode issues a new code for the citizen identified by document and sends it.
+// Previous codes are invalidated. At most MaxCodes are issued per CodeWindow, which
+// bounds guesses to MaxCodes*MaxAttempts per window. Unknown documents and
+// requests over the limit succeed silently to avoid user enumeration.
+func (s *AuthService) RequestCode(ctx context.Context, docType users.DocumentType, docID string, channel domain.Channel) error {
+	u, err := s.users.GetByDocument(ctx, docType, docID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return nil
+	}
+
+	now := s.now()
+	issued, err := s.codes.CountSince(ctx, u.ID, now.Add(-s.cfg.CodeWindow))
+	if err != nil {
+		return err
+	}
+	if issued >= s.cfg.MaxCodes {
+		return nil
+	}
+
+	if err := s.codes.InvalidateAll(ctx, u.ID); err != nil {
+		return err
+	}
+
+	code, err := randomDigits(s.cfg.CodeLength)
+	if err != nil {
+		return err
+	}
+
+	id, err := types.NewID()
+	if err != nil {
+		return err
+	}
+
+	err = s.codes.Create(ctx, &domain.OTPCode{
+		ID:        id,
+		UserID:    u.ID,
+		Channel:   channel,
+		CodeHash:  s.hashCode(u.ID, code),
+		ExpiresAt: now.Add(s.cfg.CodeTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.sender.Send(ctx, u.ID, channel, code)
+}
+
+// Login exchanges a valid code for a new token pair.
+func (s *AuthService) Login(ctx context.Context, docType users.DocumentType, docID, code string) (*domain.TokenPair, error) {
+	u, err := s.users.GetByDocument(ctx, docType, docID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrInvalidCode
+	}
+
+	otp, err := s.codes.GetLatest(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if otp == nil {
+		return nil, ErrInvalidCode
+	}
+
+	now := s.now()
+	if otp.Attempts >= s.cfg.MaxAttempts {
+		return nil, ErrTooManyAttempts
+	}
+	if !otp.Active(now, s.cfg.MaxAttempts) {
+		return nil, ErrInvalidCode
+	}
+
+	// Count the attempt before checking the code, so parallel guesses cannot
+	// exceed the limit by all reading the same count.
+	ok, err := s.codes.Attempt(ctx, otp.ID, s.cfg.MaxAttempts, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTooManyAttempts
+	}
+
+	if !hmac.Equal([]byte(otp.CodeHash), []byte(s.hashCode(u.ID, strings.TrimSpace(code)))) {
+		return nil, ErrInvalidCode
+	}
+
+	ok, err = s.codes.Consume(ctx, otp.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	family, err := types.NewID()
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, u.ID, family)
+}
+
+// Refresh rotates a refresh token, returning a new pair. Presenting a token that
+// was already rotated revokes its whole family, since it indicates theft.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	tok, err := s.tokens.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil || tok.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if tok.RotatedAt != nil {
+		if err := s.tokens.RevokeFamily(ctx, tok.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	now := s.now()
+	if !now.Before(tok.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Only one of concurrent refreshes of a token wins the rotation; the others
+	// are reuse of a rotated token.
+	ok, err := s.tokens.Rotate(ctx, tok.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.tokens.RevokeFamily(ctx, tok.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	return s.issue(ctx, tok.UserID, tok.FamilyID)
+}
+
+// Logout revokes the session the refresh token belongs to.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	tok, err := s.tokens.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return ErrInvalidRefreshToken
+	}
+	return s.tokens.RevokeFamily(ctx, tok.FamilyID)
+}
+
+// RevokeAll revokes every session of a user.
+func (s *AuthService) RevokeAll(ctx context.Context, userID string) error {
+	return s.tokens.RevokeUser(ctx, userID)
+}
+
+// issue creates an access token and a refresh token in the given family.
+func (s *AuthService) issue(ctx context.Context, userID, family string) (*domain.TokenPair, error) {
+	roles, err := s.cfg.Roles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	access, accessExp, err := s.issuer.Issue(userID, roles, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+
+	id, err := types.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	tok := &domain.RefreshToken{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  family,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: now.Add(s.cfg.RefreshTTL),
+		CreatedAt: now,
+	}
+	if err := s.tokens.Create(ctx, tok); err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: tok.ExpiresAt,
+	}, nil
+}
+
+// hashCode returns the keyed hash of a code bound to its user.
+func (s *AuthService) hashCode(userID, code string) string {
+	mac := hmac.New(sha256.New, s.cfg.Pepper)
+	mac.Write([]byte(userID + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hashToken returns the SHA-256 hash of an opaque refresh token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomDigits returns n uniformly random decimal digits.
+func randomDigits(n int) (string, error) {
+	var b strings.Builder
+	for range n {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte('0' + d.Int64()))
+	}
+	return b.String(), nil
+}
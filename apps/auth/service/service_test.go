@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ianfedev/civicspot-backend/apps/auth/domain"
+	users "github.com/ianfedev/civicspot-backend/apps/users/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUsers is a UserDirectory holding a single citizen.
+type memoryUsers struct {
+	user users.User
+}
+
+func (m *memoryUsers) GetByDocument(_ context.Context, docType users.DocumentType, docID string) (*users.User, error) {
+	if docType != m.user.DocumentType || docID != m.user.DocumentID {
+		return nil, nil
+	}
+	u := m.user
+	return &u, nil
+}
+
+// memoryCodes is an OTPRepository applying its conditional updates under a lock.
+type memoryCodes struct {
+	mu    sync.Mutex
+	codes []*domain.OTPCode
+}
+
+func (m *memoryCodes) Create(_ context.Context, code *domain.OTPCode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *code
+	m.codes = append(m.codes, &c)
+	return nil
+}
+
+func (m *memoryCodes) GetLatest(_ context.Context, userID string) (*domain.OTPCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.codes) - 1; i >= 0; i-- {
+		if c := m.codes[i]; c.UserID == userID && c.ConsumedAt == nil {
+			out := *c
+			return &out, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryCodes) CountSince(_ context.Context, userID string, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, c := range m.codes {
+		if c.UserID == userID && !c.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryCodes) Attempt(_ context.Context, id string, maxAttempts int, now time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.codes {
+		if c.ID == id && c.Attempts < maxAttempts && c.ConsumedAt == nil && now.Before(c.ExpiresAt) {
+			c.Attempts++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryCodes) Consume(_ context.Context, id string, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.codes {
+		if c.ID == id && c.ConsumedAt == nil {
+			c.ConsumedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryCodes) InvalidateAll(_ context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, c := range m.codes {
+		if c.UserID == userID && c.ConsumedAt == nil {
+			c.ConsumedAt = &now
+		}
+	}
+	return nil
+}
+
+// memoryTokens is a RefreshTokenRepository applying its conditional updates under a lock.
+type memoryTokens struct {
+	mu     sync.Mutex
+	tokens []*domain.RefreshToken
+}
+
+func (m *memoryTokens) Create(_ context.Context, token *domain.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := *token
+	m.tokens = append(m.tokens, &t)
+	return nil
+}
+
+func (m *memoryTokens) GetByHash(_ context.Context, hash string) (*domain.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tokens {
+		if t.TokenHash == hash {
+			out := *t
+			return &out, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryTokens) Rotate(_ context.Context, id string, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tokens {
+		if t.ID == id && t.RotatedAt == nil && t.RevokedAt == nil {
+			t.RotatedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryTokens) RevokeFamily(_ context.Context, familyID string) error {
+	return m.revoke(func(t *domain.RefreshToken) bool { return t.FamilyID == familyID })
+}
+
+func (m *memoryTokens) RevokeUser(_ context.Context, userID string) error {
+	return m.revoke(func(t *domain.RefreshToken) bool { return t.UserID == userID })
+}
+
+func (m *memoryTokens) revoke(match func(*domain.RefreshToken) bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, t := range m.tokens {
+		if match(t) && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// newTestService returns a service for a single citizen and its code sender.
+func newTestService(cfg Config) (*AuthService, *FakeSender) {
+	sender := NewFakeSender()
+	cfg.Pepper = []byte("pepper")
+	issuer := auth.NewIssuer(auth.IssuerConfig{Method: jwt.SigningMethodHS256, Key: []byte("secret"), TTL: time.Minute})
+	u := &memoryUsers{user: users.User{ID: "u1", DocumentType: users.CC, DocumentID: "1020"}}
+	return NewAuthService(u, &memoryCodes{}, &memoryTokens{}, sender, issuer, cfg), sender
+}
+
+// TestLogin checks a code logs in once and wrong guesses are limited, even in parallel.
+func TestLogin(t *testing.T) {
+	svc, sender := newTestService(Config{MaxAttempts: 3})
+	ctx := context.Background()
+
+	require.NoError(t, svc.RequestCode(ctx, users.CC, "1020", domain.ChannelEmail))
+	code, ok := sender.LastCode("u1")
+	require.True(t, ok)
+
+	pair, err := svc.Login(ctx, users.CC, "1020", code)
+	require.NoError(t, err)
+	assert.NotEmpty(t, pair.AccessToken)
+	_, err = svc.Login(ctx, users.CC, "1020", code)
+	assert.ErrorIs(t, err, ErrInvalidCode, "codes are single use")
+
+	require.NoError(t, svc.RequestCode(ctx, users.CC, "1020", domain.ChannelEmail))
+	code, _ = sender.LastCode("u1")
+	var wrong, limited atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch _, err := svc.Login(ctx, users.CC, "1020", "bad"); err {
+			case ErrInvalidCode:
+				wrong.Add(1)
+			case ErrTooManyAttempts:
+				limited.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), wrong.Load())
+	assert.Equal(t, int32(17), limited.Load())
+
+	_, err = svc.Login(ctx, users.CC, "1020", code)
+	assert.ErrorIs(t, err, ErrTooManyAttempts, "the right code is refused once attempts are spent")
+}
+
+// TestRequestCodeLimit checks codes issued per window are limited without revealing it.
+func TestRequestCodeLimit(t *testing.T) {
+	svc, sender := newTestService(Config{MaxCodes: 2})
+	ctx := context.Background()
+
+	require.NoError(t, svc.RequestCode(ctx, users.CC, "1020", domain.ChannelEmail))
+	require.NoError(t, svc.RequestCode(ctx, users.CC, "1020", domain.ChannelEmail))
+	code, _ := sender.LastCode("u1")
+
+	require.NoError(t, svc.RequestCode(ctx, users.CC, "1020", domain.ChannelEmail))
+	again, _ := sender.LastCode("u1")
+	assert.Equal(t, code, again, "no code is sent over the limit")
+	_, err := svc.Login(ctx, users.CC, "1020", code)
+	require.NoError(t, err, "the last code stays valid")
+
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	require.NoError(t, svc.RequestCode(ctx, users.CC, "1020", domain.ChannelEmail))
+	again, _ = sender.LastCode("u1")
+	_, err = svc.Login(ctx, users.CC, "1020", again)
+	assert.NoError(t, err, "codes are issued again in the next window")
+}
+
+// TestRefreshRotation checks a refresh token rotates once and its reuse revokes the family.
+func TestRefreshRotation(t *testing.T) {
+	svc, sender := newTestService(Config{})
+	ctx := context.Background()
+
+	require.NoError(t, svc.RequestCode(ctx, users.CC, "1020", domain.ChannelEmail))
+	code, _ := sender.LastCode("u1")
+	pair, err := svc.Login(ctx, users.CC, "1020", code)
+	require.NoError(t, err)
+
+	var won, reused, invalid atomic.Int32
+	var next atomic.Value
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := svc.Refresh(ctx, pair.RefreshToken)
+			switch err {
+			case nil:
+				won.Add(1)
+				next.Store(p.RefreshToken)
+			case ErrRefreshTokenReused:
+				reused.Add(1)
+			case ErrInvalidRefreshToken:
+				invalid.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), won.Load())
+	assert.GreaterOrEqual(t, reused.Load(), int32(1), "a losing refresh is detected as reuse")
+	assert.Equal(t, int32(9), reused.Load()+invalid.Load())
+
+	_, err = svc.Refresh(ctx, next.Load().(string))
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken, "reuse revoked the tokens issued by rotation")
+}
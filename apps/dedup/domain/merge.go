@@ -0,0 +1,14 @@
+package domain
+
+import types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+
+// Merge records that a report was closed as a duplicate of another.
+type Merge struct {
+	types.Auditable
+	DuplicateID    string // DuplicateID is the report closed as duplicate.
+	CanonicalID    string // CanonicalID is the report kept open.
+	MergedBy       string // MergedBy is the official who merged the reports.
+	Reason         string // Reason explains the merge (optional).
+	VotesMoved     int    // VotesMoved is the number of votes added to the canonical report.
+	FollowersMoved int    // FollowersMoved is the number of followers added to the canonical report.
+}
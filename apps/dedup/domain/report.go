@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/geo"
+)
+
+// Draft is a report being written, checked for duplicates before submission.
+type Draft struct {
+	Title       string    // Title is the report title.
+	Description string    // Description is the report body.
+	Category    string    // Category is the report category (optional).
+	Location    geo.Point // Location is where the problem is.
+	At          time.Time // At is the submission time; zero means now.
+}
+
+// Candidate is an existing open report that may duplicate a draft.
+type Candidate struct {
+	ID          string    // ID identifies the report.
+	Title       string    // Title is the report title.
+	Description string    // Description is the report body.
+	Category    string    // Category is the report category.
+	Location    geo.Point // Location is where the problem is.
+	CreatedAt   time.Time // CreatedAt is when the report was submitted.
+	Votes       int       // Votes is the number of supporting votes.
+	Followers   int       // Followers is the number of users following the report.
+}
+
+// Suggestion is a candidate scored against a draft.
+type Suggestion struct {
+	Candidate
+	Score     float64 // Score combines the text, distance and time scores, from 0 to 1.
+	TextScore float64 // TextScore is the TF-IDF cosine similarity of the texts.
+	Distance  float64 // Distance is the distance to the draft location in meters.
+}
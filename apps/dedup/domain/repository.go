@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/geo"
+)
+
+// ReportRepository defines the report access needed to detect and merge duplicates.
+type ReportRepository interface {
+
+	// Nearby returns the open reports inside the box created after since,
+	// restricted to the category when it is not empty.
+	Nearby(ctx context.Context, box geo.Box, since time.Time, category string) ([]Candidate, error)
+
+	// DuplicateOf returns the canonical report ID of a merged report, or nil if the report is not merged.
+	DuplicateOf(ctx context.Context, id string) (*string, error)
+
+	// Merge closes the duplicate report and atomically moves its votes and followers to
+	// the canonical report, skipping users present in both. It returns the numbers moved.
+	Merge(ctx context.Context, duplicateID, canonicalID string) (votes, followers int, err error)
+}
+
+// MergeRepository defines access methods for merge records.
+type MergeRepository interface {
+
+	// ListByCanonical returns the merges into a report, oldest first.
+	ListByCanonical(ctx context.Context, canonicalID string) ([]Merge, error)
+
+	// Create persists a new merge record.
+	Create(ctx context.Context, m *Merge) error
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/apps/dedup/domain"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/geo"
+	"github.com/ianfedev/civicspot-backend/pkg/common/text"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// Config defines how candidates are searched and scored.
+type Config struct {
+	Radius     float64       // Radius is the search distance in meters (default: 150).
+	Window     time.Duration // Window is how far back reports are considered (default: 30 days).
+	MinScore   float64       // MinScore is the lowest score suggested (default: 0.35).
+	Limit      int           // Limit is the maximum number of suggestions (default: 5).
+	TextWeight float64       // TextWeight is the share of the text score in the final score (default: 0.7).
+}
+
+// DedupService suggests likely duplicates of new reports and merges confirmed ones.
+type DedupService struct {
+	reports domain.ReportRepository
+	merges  domain.MergeRepository
+	cfg     Config
+	now     func() time.Time
+}
+
+// NewDedupService creates a new instance of DedupService.
+func NewDedupService(reports domain.ReportRepository, merges domain.MergeRepository, cfg Config) *DedupService {
+	if cfg.Radius <= 0 {
+		cfg.Radius = 150
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 30 * 24 * time.Hour
+	}
+	if cfg.MinScore <= 0 {
+		cfg.MinScore = 0.35
+	}
+	if cfg.Limit <= 0 {
+		cfg.Limit = 5
+	}
+	if cfg.TextWeight <= 0 || cfg.TextWeight > 1 {
+		cfg.TextWeight = 0.7
+	}
+	return &DedupService{reports: reports, merges: merges, cfg: cfg, now: time.Now}
+}
+
+// Suggest returns the open reports likely describing the same problem as the draft,
+// best first. Candidates are the reports within the radius and time window; their
+// score weighs TF-IDF text similarity against proximity and recency.
+func (s *DedupService) Suggest(ctx context.Context, d domain.Draft) ([]domain.Suggestion, error) {
+	at := d.At
+	if at.IsZero() {
+		at = s.now()
+	}
+
+	candidates, err := s.reports.Nearby(ctx, geo.Around(d.Location, s.cfg.Radius), at.Add(-s.cfg.Window), d.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	// IDF is computed over the local candidates, so words common in the area
+	// (e.g., the street name) weigh less than the description of the problem.
+	terms := make([][]string, len(candidates))
+	corpus := text.NewCorpus()
+	for i, c := range candidates {
+		terms[i] = text.Terms(c.Title + " " + c.Description)
+		corpus.Add(terms[i])
+	}
+	query := corpus.Vector(text.Terms(d.Title + " " + d.Description))
+
+	var out []domain.Suggestion
+	for i, c := range candidates {
+		dist := geo.Distance(d.Location, c.Location)
+		if dist > s.cfg.Radius {
+			continue
+		}
+		textScore := text.Cosine(query, corpus.Vector(terms[i]))
+		proximity := 1 - dist/s.cfg.Radius
+		recency := max(0, 1-at.Sub(c.CreatedAt).Hours()/s.cfg.Window.Hours())
+		rest := 1 - s.cfg.TextWeight
+
+		score := s.cfg.TextWeight*textScore + rest*(2*proximity+recency)/3
+		if score < s.cfg.MinScore {
+			continue
+		}
+		out = append(out, domain.Suggestion{Candidate: c, Score: score, TextScore: textScore, Distance: dist})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > s.cfg.Limit {
+		out = out[:s.cfg.Limit]
+	}
+	return out, nil
+}
+
+// Merge closes a report as a duplicate of another, combining votes and followers.
+// Merging into a report that was itself merged targets the report it was merged into.
+func (s *DedupService) Merge(ctx context.Context, duplicateID, canonicalID, by, reason string) (*domain.Merge, error) {
+	if duplicateID == "" || canonicalID == "" {
+		return nil, transport.BadRequest("duplicate and canonical reports are required")
+	}
+
+	merged, err := s.reports.DuplicateOf(ctx, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	if merged != nil {
+		return nil, transport.Conflict("report was already merged")
+	}
+
+	canonicalID, err = s.root(ctx, canonicalID)
+	if err != nil {
+		return nil, err
+	}
+	if canonicalID == duplicateID {
+		return nil, transport.BadRequest("a report cannot be merged into itself")
+	}
+
+	votes, followers, err := s.reports.Merge(ctx, duplicateID, canonicalID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := types.NewID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	m := &domain.Merge{
+		DuplicateID:    duplicateID,
+		CanonicalID:    canonicalID,
+		MergedBy:       by,
+		Reason:         reason,
+		VotesMoved:     votes,
+		FollowersMoved: followers,
+	}
+	m.ID = id
+	m.CreatedAt = now
+	m.UpdatedAt = now
+	if err := s.merges.Create(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Merges returns the reports merged into a report, oldest first.
+func (s *DedupService) Merges(ctx context.Context, canonicalID string) ([]domain.Merge, error) {
+	return s.merges.ListByCanonical(ctx, canonicalID)
+}
+
+// maxHops bounds the merge chain followed by root.
+const maxHops = 16
+
+// root follows merges from a report to the report still open.
+func (s *DedupService) root(ctx context.Context, id string) (string, error) {
+	for range maxHops {
+		next, err := s.reports.DuplicateOf(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if next == nil {
+			return id, nil
+		}
+		id = *next
+	}
+	return "", transport.Conflict("merge chain is too long")
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/apps/dedup/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/geo"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryReport is a report with the users voting and following it.
+type memoryReport struct {
+	domain.Candidate
+	voters    []string
+	followers []string
+	mergedTo  *string
+}
+
+// memoryReports is a ReportRepository keeping reports by ID.
+type memoryReports map[string]*memoryReport
+
+func (m memoryReports) Nearby(_ context.Context, box geo.Box, since time.Time, category string) ([]domain.Candidate, error) {
+	var out []domain.Candidate
+	for _, r := range m {
+		p := r.Location
+		if r.mergedTo != nil || r.CreatedAt.Before(since) || (category != "" && r.Category != category) {
+			continue
+		}
+		if p.Lat >= box.MinLat && p.Lat <= box.MaxLat && p.Lon >= box.MinLon && p.Lon <= box.MaxLon {
+			out = append(out, r.Candidate)
+		}
+	}
+	return out, nil
+}
+
+func (m memoryReports) DuplicateOf(_ context.Context, id string) (*string, error) {
+	return m[id].mergedTo, nil
+}
+
+func (m memoryReports) Merge(_ context.Context, duplicateID, canonicalID string) (int, int, error) {
+	dup, canonical := m[duplicateID], m[canonicalID]
+	move := func(from []string, to *[]string) int {
+		n := 0
+		for _, u := range from {
+			if !slices.Contains(*to, u) {
+				*to = append(*to, u)
+				n++
+			}
+		}
+		return n
+	}
+	dup.mergedTo = &canonicalID
+	return move(dup.voters, &canonical.voters), move(dup.followers, &canonical.followers), nil
+}
+
+// memoryMerges is a MergeRepository backed by a slice, oldest first.
+type memoryMerges []domain.Merge
+
+func (m *memoryMerges) ListByCanonical(_ context.Context, canonicalID string) ([]domain.Merge, error) {
+	var out []domain.Merge
+	for _, mg := range *m {
+		if mg.CanonicalID == canonicalID {
+			out = append(out, mg)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryMerges) Create(_ context.Context, mg *domain.Merge) error {
+	*m = append(*m, *mg)
+	return nil
+}
+
+// plaza is the location of the test reports.
+var plaza = geo.Point{Lat: 4.5981, Lon: -74.0758}
+
+// newTestService returns a service over three open reports at the same place.
+func newTestService() (*DedupService, memoryReports) {
+	reports := memoryReports{}
+	for _, id := range []string{"a", "b", "c"} {
+		reports[id] = &memoryReport{Candidate: domain.Candidate{ID: id, Location: plaza}}
+	}
+	return NewDedupService(reports, &memoryMerges{}, Config{}), reports
+}
+
+// TestMerge checks votes and followers move once and merges target the open root report.
+func TestMerge(t *testing.T) {
+	s, reports := newTestService()
+	ctx := context.Background()
+	reports["a"].voters = []string{"u1"}
+	reports["b"].voters = []string{"u1", "u2"}
+	reports["b"].followers = []string{"u3"}
+
+	m, err := s.Merge(ctx, "b", "a", "official", "same pothole")
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.VotesMoved, "users voting both reports count once")
+	assert.Equal(t, 1, m.FollowersMoved)
+	assert.Equal(t, []string{"u1", "u2"}, reports["a"].voters)
+
+	m, err = s.Merge(ctx, "c", "b", "official", "")
+	require.NoError(t, err)
+	assert.Equal(t, "a", m.CanonicalID, "merging into a merged report targets its root")
+
+	_, err = s.Merge(ctx, "b", "c", "official", "")
+	assert.Equal(t, 409, transport.CodeOf(err), "merged reports cannot be merged again")
+
+	merges, err := s.Merges(ctx, "a")
+	require.NoError(t, err)
+	assert.Len(t, merges, 2)
+}
+
+// TestMergeCycles checks a report is never merged into itself, directly or through a chain.
+func TestMergeCycles(t *testing.T) {
+	s, reports := newTestService()
+	ctx := context.Background()
+
+	_, err := s.Merge(ctx, "a", "a", "official", "")
+	assert.Equal(t, 400, transport.CodeOf(err))
+
+	_, err = s.Merge(ctx, "b", "a", "official", "")
+	require.NoError(t, err)
+	_, err = s.Merge(ctx, "a", "b", "official", "")
+	assert.Equal(t, 400, transport.CodeOf(err), "b resolves to a itself")
+
+	// A cycle left by inconsistent data stops after a bounded number of hops.
+	b, c := "b", "c"
+	reports["c"].mergedTo = &b
+	reports["b"].mergedTo = &c
+	reports["a"].mergedTo = nil
+	_, err = s.Merge(ctx, "a", "c", "official", "")
+	assert.Equal(t, 409, transport.CodeOf(err))
+}
+
+// TestSuggest checks similar nearby reports are suggested best first.
+func TestSuggest(t *testing.T) {
+	s, reports := newTestService()
+	now := time.Now()
+	reports["a"].Title, reports["a"].Description, reports["a"].CreatedAt = "Hueco en la carrera 7", "hueco profundo frente al parque", now.Add(-time.Hour)
+	reports["b"].Title, reports["b"].Description, reports["b"].CreatedAt = "Semáforo dañado", "el semáforo de la carrera 7 no funciona", now.Add(-time.Hour)
+	reports["c"].Title, reports["c"].CreatedAt = "Hueco profundo", now.Add(-60*24*time.Hour)
+
+	out, err := s.Suggest(context.Background(), domain.Draft{Title: "Hueco profundo", Description: "un hueco en la carrera 7", Location: plaza, At: now})
+	require.NoError(t, err)
+	require.NotEmpty(t, out)
+	assert.Equal(t, "a", out[0].ID)
+	for _, sg := range out {
+		assert.NotEqual(t, "c", sg.ID, "reports outside the window are not candidates")
+	}
+}
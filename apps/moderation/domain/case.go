@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+)
+
+// CaseStatus is the state of a moderation case.
+type CaseStatus string
+
+const (
+	// CasePending awaits a moderator; the content stays hidden meanwhile.
+	CasePending CaseStatus = "pending"
+	// CaseApproved publishes the content.
+	CaseApproved CaseStatus = "approved"
+	// CaseRejected keeps the content hidden.
+	CaseRejected CaseStatus = "rejected"
+)
+
+// Content identifies a piece of user text owned by another module.
+type Content struct {
+	Type     string // Type is the kind of content (e.g., "comment", "report").
+	ID       string // ID identifies the content within its type.
+	AuthorID string // AuthorID is the user who wrote the content.
+	Text     string // Text is the content as written.
+}
+
+// Case is a piece of content held for review, either by the rule chain or by abuse flags.
+type Case struct {
+	types.Auditable
+	ContentType string     // ContentType is the kind of held content.
+	ContentID   string     // ContentID identifies the held content.
+	AuthorID    string     // AuthorID is the user who wrote the content.
+	Text        string     // Text is the content as written, when known.
+	Categories  []string   // Categories are the rule categories matched by the text.
+	Reason      string     // Reason explains why the content was held.
+	FlagCount   int        // FlagCount is the number of abuse flags received.
+	Status      CaseStatus // Status is the current state of the case.
+	ModeratorID *string    // ModeratorID is the user who resolved the case.
+	ResolvedAt  *time.Time // ResolvedAt is when the case was resolved.
+	Resolution  string     // Resolution is the moderator note or rejection reason.
+}
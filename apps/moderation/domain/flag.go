@@ -0,0 +1,39 @@
+package domain
+
+import types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+
+// FlagReason classifies an abuse flag.
+type FlagReason string
+
+const (
+	// FlagOffensive reports insults, harassment or hate speech.
+	FlagOffensive FlagReason = "offensive"
+	// FlagPersonalData reports exposed personal data.
+	FlagPersonalData FlagReason = "personal_data"
+	// FlagSpam reports advertising or repeated content.
+	FlagSpam FlagReason = "spam"
+	// FlagFalse reports content known to be false.
+	FlagFalse FlagReason = "false"
+	// FlagOther reports anything else; details are required.
+	FlagOther FlagReason = "other"
+)
+
+// Valid reports whether the reason is known.
+func (r FlagReason) Valid() bool {
+	switch r {
+	case FlagOffensive, FlagPersonalData, FlagSpam, FlagFalse, FlagOther:
+		return true
+	}
+	return false
+}
+
+// Flag is an abuse report submitted by a user about a piece of content.
+type Flag struct {
+	types.Auditable
+	ContentType string     // ContentType is the kind of flagged content.
+	ContentID   string     // ContentID identifies the flagged content.
+	ReporterID  string     // ReporterID is the user who flagged the content.
+	Reason      FlagReason // Reason classifies the flag.
+	Details     string     // Details describe the problem (optional unless Reason is "other").
+	CaseID      *string    // CaseID is the case opened or updated by the flag.
+}
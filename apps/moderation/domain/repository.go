@@ -0,0 +1,55 @@
+package domain
+
+import "context"
+
+// CaseRepository defines access methods for moderation cases. Cases belong to
+// the tenant of the context creating them and are only found in it, e.g., by
+// storing them in rows embedding tenant.Owned.
+type CaseRepository interface {
+
+	// GetByID returns the case with the given ID, or an error if not found.
+	GetByID(ctx context.Context, id string) (*Case, error)
+
+	// GetPending returns the pending case of a piece of content, or nil if none.
+	GetPending(ctx context.Context, contentType, contentID string) (*Case, error)
+
+	// ListPending returns pending cases, most flagged first and then oldest first.
+	ListPending(ctx context.Context, limit, offset int) ([]Case, error)
+
+	// Create persists a new case.
+	Create(ctx context.Context, c *Case) error
+
+	// Update saves changes to a case.
+	Update(ctx context.Context, c *Case) error
+}
+
+// FlagRepository defines access methods for abuse flags. Like cases, flags
+// belong to the tenant of the context creating them.
+type FlagRepository interface {
+
+	// Exists reports whether the reporter already flagged the content.
+	Exists(ctx context.Context, contentType, contentID, reporterID string) (bool, error)
+
+	// CountUnassigned returns the number of flags of the content not attached to a case.
+	CountUnassigned(ctx context.Context, contentType, contentID string) (int, error)
+
+	// Assign attaches the unassigned flags of the content to a case.
+	Assign(ctx context.Context, contentType, contentID, caseID string) error
+
+	// Create persists a new flag.
+	Create(ctx context.Context, f *Flag) error
+
+	// ListByReporter returns the flags submitted by a user, newest first.
+	ListByReporter(ctx context.Context, reporterID string) ([]Flag, error)
+
+	// ClearReporter removes the reporter and details of the flags submitted by a
+	// user, returning the number of flags changed. The flags keep counting for their cases.
+	ClearReporter(ctx context.Context, reporterID string) (int, error)
+}
+
+// Publisher controls the visibility of content in its owning module.
+type Publisher interface {
+
+	// SetVisible publishes or hides the content, replacing its text when text is not nil.
+	SetVisible(ctx context.Context, contentType, contentID string, visible bool, text *string) error
+}
@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/apps/moderation/domain"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/moderation"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// Verdict is the outcome of screening a piece of content before publishing it.
+type Verdict struct {
+	Action  moderation.Action // Action is the action taken.
+	Text    string            // Text is the text to store, with personal data and insults masked.
+	Visible bool              // Visible is false while the content awaits review.
+	Case    *domain.Case      // Case is the review case of held content.
+}
+
+// Config defines the moderation thresholds.
+type Config struct {
+	FlagThreshold int // FlagThreshold is the number of flags hiding content until reviewed (default: 3).
+}
+
+// ModerationService screens user content, manages the review queue and collects abuse flags.
+type ModerationService struct {
+	cases     domain.CaseRepository
+	flags     domain.FlagRepository
+	publisher domain.Publisher
+	chain     *moderation.Chain
+	cfg       Config
+	now       func() time.Time
+}
+
+// NewModerationService creates a new instance of ModerationService.
+func NewModerationService(
+	cases domain.CaseRepository,
+	flags domain.FlagRepository,
+	publisher domain.Publisher,
+	chain *moderation.Chain,
+	cfg Config,
+) *ModerationService {
+	if cfg.FlagThreshold <= 0 {
+		cfg.FlagThreshold = 3
+	}
+	return &ModerationService{cases: cases, flags: flags, publisher: publisher, chain: chain, cfg: cfg, now: time.Now}
+}
+
+// Screen evaluates content before it is stored. Rejected content returns a 422 error;
+// held content opens a review case and must be stored hidden.
+func (s *ModerationService) Screen(ctx context.Context, c domain.Content) (*Verdict, error) {
+	res := s.chain.Evaluate(c.Text)
+	v := &Verdict{Action: res.Action, Text: res.Text, Visible: true}
+
+	switch res.Action {
+	case moderation.Reject:
+		return nil, transport.New(422, "content violates the community guidelines ("+strings.Join(res.Categories(), ", ")+")", nil)
+	case moderation.Hold:
+		held, err := s.open(ctx, &domain.Case{
+			ContentType: c.Type,
+			ContentID:   c.ID,
+			AuthorID:    c.AuthorID,
+			Text:        c.Text,
+			Categories:  res.Categories(),
+			Reason:      "held by rules: " + strings.Join(res.Categories(), ", "),
+		})
+		if err != nil {
+			return nil, err
+		}
+		v.Visible = false
+		v.Case = held
+	}
+	return v, nil
+}
+
+// Pending returns the review queue, most flagged first and then oldest first.
+func (s *ModerationService) Pending(ctx context.Context, limit, offset int) ([]domain.Case, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.cases.ListPending(ctx, limit, max(offset, 0))
+}
+
+// Approve publishes held content. Its text is republished with the rule chain masks applied.
+func (s *ModerationService) Approve(ctx context.Context, id, moderatorID, note string) (*domain.Case, error) {
+	c, err := s.pending(ctx, id, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var text *string
+	if c.Text != "" {
+		masked := s.chain.Evaluate(c.Text).Text
+		text = &masked
+	}
+	if err := s.publisher.SetVisible(ctx, c.ContentType, c.ContentID, true, text); err != nil {
+		return nil, err
+	}
+	if err := s.resolve(ctx, c, moderatorID, domain.CaseApproved, note); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reject keeps held content hidden. A reason is required.
+func (s *ModerationService) Reject(ctx context.Context, id, moderatorID, reason string) (*domain.Case, error) {
+	if reason == "" {
+		return nil, transport.BadRequest("a reason is required to reject content")
+	}
+	c, err := s.pending(ctx, id, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.publisher.SetVisible(ctx, c.ContentType, c.ContentID, false, nil); err != nil {
+		return nil, err
+	}
+	if err := s.resolve(ctx, c, moderatorID, domain.CaseRejected, reason); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Flag records an abuse report. Content reaching the flag threshold is hidden and queued for review.
+func (s *ModerationService) Flag(ctx context.Context, contentType, contentID, reporterID, reason, details string) (*domain.Flag, error) {
+	r := domain.FlagReason(reason)
+	if contentType == "" || contentID == "" || !r.Valid() {
+		return nil, transport.BadRequest("content type, content ID and a valid reason are required")
+	}
+	if r == domain.FlagOther && details == "" {
+		return nil, transport.BadRequest("details are required for other reasons")
+	}
+
+	exists, err := s.flags.Exists(ctx, contentType, contentID, reporterID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, transport.Conflict("content already flagged by the user")
+	}
+
+	open, err := s.cases.GetPending(ctx, contentType, contentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	f := &domain.Flag{ContentType: contentType, ContentID: contentID, ReporterID: reporterID, Reason: r, Details: details}
+	if f.ID, err = types.NewID(); err != nil {
+		return nil, err
+	}
+	f.CreatedAt, f.UpdatedAt = now, now
+
+	if open != nil {
+		f.CaseID = &open.ID
+		open.FlagCount++
+		open.UpdatedAt = now
+		if err := s.cases.Update(ctx, open); err != nil {
+			return nil, err
+		}
+		if err := s.flags.Create(ctx, f); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	if err := s.flags.Create(ctx, f); err != nil {
+		return nil, err
+	}
+	n, err := s.flags.CountUnassigned(ctx, contentType, contentID)
+	if err != nil {
+		return nil, err
+	}
+	if n < s.cfg.FlagThreshold {
+		return f, nil
+	}
+
+	held, err := s.open(ctx, &domain.Case{
+		ContentType: contentType,
+		ContentID:   contentID,
+		FlagCount:   n,
+		Reason:      "flagged by " + strconv.Itoa(n) + " users",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.flags.Assign(ctx, contentType, contentID, held.ID); err != nil {
+		return nil, err
+	}
+	f.CaseID = &held.ID
+	if err := s.publisher.SetVisible(ctx, contentType, contentID, false, nil); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// FlagsBy returns the abuse flags submitted by a user, newest first.
+func (s *ModerationService) FlagsBy(ctx context.Context, reporterID string) ([]domain.Flag, error) {
+	return s.flags.ListByReporter(ctx, reporterID)
+}
+
+// ForgetReporter unlinks the abuse flags of a user from them on erasure,
+// returning the number of flags anonymized.
+func (s *ModerationService) ForgetReporter(ctx context.Context, reporterID string) (int, error) {
+	return s.flags.ClearReporter(ctx, reporterID)
+}
+
+// open persists a pending case.
+func (s *ModerationService) open(ctx context.Context, c *domain.Case) (*domain.Case, error) {
+	id, err := types.NewID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	c.ID = id
+	c.Status = domain.CasePending
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	if err := s.cases.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// pending loads a case that the moderator is allowed to resolve.
+func (s *ModerationService) pending(ctx context.Context, id, moderatorID string) (*domain.Case, error) {
+	c, err := s.cases.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != domain.CasePending {
+		return nil, transport.Conflict("case is not pending")
+	}
+	if c.AuthorID != "" && c.AuthorID == moderatorID {
+		return nil, transport.Forbidden("moderators cannot review their own content")
+	}
+	return c, nil
+}
+
+// resolve records the moderator decision on a case.
+func (s *ModerationService) resolve(ctx context.Context, c *domain.Case, moderatorID string, status domain.CaseStatus, resolution string) error {
+	now := s.now()
+	c.Status = status
+	c.ModeratorID = &moderatorID
+	c.ResolvedAt = &now
+	c.Resolution = resolution
+	c.UpdatedAt = now
+	return s.cases.Update(ctx, c)
+}
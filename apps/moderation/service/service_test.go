@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ianfedev/civicspot-backend/apps/moderation/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/moderation"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCases is a CaseRepository backed by a slice.
+type memoryCases []domain.Case
+
+func (m *memoryCases) GetByID(_ context.Context, id string) (*domain.Case, error) {
+	for _, c := range *m {
+		if c.ID == id {
+			return &c, nil
+		}
+	}
+	return nil, transport.NotFound("case not found")
+}
+
+func (m *memoryCases) GetPending(_ context.Context, contentType, contentID string) (*domain.Case, error) {
+	for _, c := range *m {
+		if c.ContentType == contentType && c.ContentID == contentID && c.Status == domain.CasePending {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryCases) ListPending(context.Context, int, int) ([]domain.Case, error) {
+	var out []domain.Case
+	for _, c := range *m {
+		if c.Status == domain.CasePending {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryCases) Create(_ context.Context, c *domain.Case) error {
+	*m = append(*m, *c)
+	return nil
+}
+
+func (m *memoryCases) Update(_ context.Context, c *domain.Case) error {
+	for i := range *m {
+		if (*m)[i].ID == c.ID {
+			(*m)[i] = *c
+		}
+	}
+	return nil
+}
+
+// memoryFlags is a FlagRepository backed by a slice.
+type memoryFlags []domain.Flag
+
+func (m *memoryFlags) Exists(_ context.Context, contentType, contentID, reporterID string) (bool, error) {
+	for _, f := range *m {
+		if f.ContentType == contentType && f.ContentID == contentID && f.ReporterID == reporterID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryFlags) CountUnassigned(_ context.Context, contentType, contentID string) (int, error) {
+	n := 0
+	for _, f := range *m {
+		if f.ContentType == contentType && f.ContentID == contentID && f.CaseID == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryFlags) Assign(_ context.Context, contentType, contentID, caseID string) error {
+	for i := range *m {
+		if f := &(*m)[i]; f.ContentType == contentType && f.ContentID == contentID && f.CaseID == nil {
+			f.CaseID = &caseID
+		}
+	}
+	return nil
+}
+
+func (m *memoryFlags) Create(_ context.Context, f *domain.Flag) error {
+	*m = append(*m, *f)
+	return nil
+}
+
+func (m *memoryFlags) ListByReporter(_ context.Context, reporterID string) ([]domain.Flag, error) {
+	var out []domain.Flag
+	for i := len(*m) - 1; i >= 0; i-- {
+		if (*m)[i].ReporterID == reporterID {
+			out = append(out, (*m)[i])
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryFlags) ClearReporter(_ context.Context, reporterID string) (int, error) {
+	n := 0
+	for i := range *m {
+		if f := &(*m)[i]; f.ReporterID == reporterID {
+			f.ReporterID, f.Details = "", ""
+			n++
+		}
+	}
+	return n, nil
+}
+
+// memoryPublisher records the visibility and text of content by ID.
+type memoryPublisher struct {
+	visible map[string]bool
+	text    map[string]string
+}
+
+func (p *memoryPublisher) SetVisible(_ context.Context, _, contentID string, visible bool, text *string) error {
+	p.visible[contentID] = visible
+	if text != nil {
+		p.text[contentID] = *text
+	}
+	return nil
+}
+
+// newTestService returns a service holding "tombo", masking "bobo" and rejecting "compre ya".
+func newTestService(cfg Config) (*ModerationService, *memoryCases, *memoryFlags, *memoryPublisher) {
+	chain := moderation.NewChain(
+		moderation.NewWordList("insults", "profanity", moderation.Redact, "bobo"),
+		moderation.NewWordList("slurs", "slur", moderation.Hold, "tombo"),
+		moderation.NewWordList("banned", "spam", moderation.Reject, "compre ya"),
+	)
+	cases, flags := &memoryCases{}, &memoryFlags{}
+	publisher := &memoryPublisher{visible: map[string]bool{}, text: map[string]string{}}
+	return NewModerationService(cases, flags, publisher, chain, cfg), cases, flags, publisher
+}
+
+// TestScreen checks content is published, held for review or rejected by the rule chain.
+func TestScreen(t *testing.T) {
+	s, cases, _, _ := newTestService(Config{})
+	ctx := context.Background()
+
+	v, err := s.Screen(ctx, domain.Content{Type: "comment", ID: "c1", Text: "qué bobo el semáforo"})
+	require.NoError(t, err)
+	assert.True(t, v.Visible)
+	assert.Equal(t, "qué **** el semáforo", v.Text)
+
+	v, err = s.Screen(ctx, domain.Content{Type: "comment", ID: "c2", AuthorID: "u1", Text: "llegó el tombo"})
+	require.NoError(t, err)
+	assert.False(t, v.Visible)
+	require.NotNil(t, v.Case)
+	assert.Equal(t, []string{"slur"}, v.Case.Categories)
+	assert.Len(t, *cases, 1)
+
+	_, err = s.Screen(ctx, domain.Content{Type: "comment", ID: "c3", Text: "compre ya!"})
+	assert.Equal(t, 422, transport.CodeOf(err))
+}
+
+// TestFlagThreshold checks content is hidden and queued once it reaches the flag threshold.
+func TestFlagThreshold(t *testing.T) {
+	s, cases, flags, publisher := newTestService(Config{FlagThreshold: 3})
+	ctx := context.Background()
+
+	_, err := s.Flag(ctx, "comment", "c1", "u1", "other", "")
+	assert.Equal(t, 400, transport.CodeOf(err), "other reasons need details")
+	_, err = s.Flag(ctx, "comment", "c1", "u1", "rude", "")
+	assert.Equal(t, 400, transport.CodeOf(err))
+
+	for _, u := range []string{"u1", "u2"} {
+		f, err := s.Flag(ctx, "comment", "c1", u, "spam", "")
+		require.NoError(t, err)
+		assert.Nil(t, f.CaseID)
+	}
+	_, err = s.Flag(ctx, "comment", "c1", "u1", "spam", "")
+	assert.Equal(t, 409, transport.CodeOf(err), "a user flags content once")
+	assert.Empty(t, *cases)
+	assert.NotContains(t, publisher.visible, "c1")
+
+	f, err := s.Flag(ctx, "comment", "c1", "u3", "offensive", "")
+	require.NoError(t, err)
+	require.NotNil(t, f.CaseID)
+	require.Len(t, *cases, 1)
+	held := (*cases)[0]
+	assert.Equal(t, 3, held.FlagCount)
+	assert.Equal(t, domain.CasePending, held.Status)
+	assert.False(t, publisher.visible["c1"])
+	for _, f := range *flags {
+		assert.Equal(t, held.ID, *f.CaseID)
+	}
+
+	f, err = s.Flag(ctx, "comment", "c1", "u4", "spam", "")
+	require.NoError(t, err)
+	assert.Equal(t, held.ID, *f.CaseID)
+	assert.Len(t, *cases, 1, "later flags join the open case")
+	assert.Equal(t, 4, (*cases)[0].FlagCount)
+}
+
+// TestReview checks moderators resolve pending cases of others and approved text is republished masked.
+func TestReview(t *testing.T) {
+	s, _, _, publisher := newTestService(Config{})
+	ctx := context.Background()
+
+	v, err := s.Screen(ctx, domain.Content{Type: "comment", ID: "c1", AuthorID: "u1", Text: "el tombo bobo"})
+	require.NoError(t, err)
+	id := v.Case.ID
+
+	_, err = s.Approve(ctx, id, "u1", "")
+	assert.Equal(t, 403, transport.CodeOf(err), "authors cannot review their own content")
+	_, err = s.Reject(ctx, id, "mod", "")
+	assert.Equal(t, 400, transport.CodeOf(err), "rejections need a reason")
+
+	c, err := s.Approve(ctx, id, "mod", "context is fine")
+	require.NoError(t, err)
+	assert.Equal(t, domain.CaseApproved, c.Status)
+	assert.True(t, publisher.visible["c1"])
+	assert.Equal(t, "el ***** ****", publisher.text["c1"])
+
+	_, err = s.Reject(ctx, id, "mod", "late")
+	assert.Equal(t, 409, transport.CodeOf(err))
+}
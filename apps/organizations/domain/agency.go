@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"slices"
+
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+)
+
+// AgencyKind classifies public entities.
+type AgencyKind string
+
+const (
+	// Alcaldia represents a municipal mayor's office.
+	Alcaldia AgencyKind = "alcaldia"
+	// Gobernacion represents a departmental government.
+	Gobernacion AgencyKind = "gobernacion"
+	// Secretaria represents a secretariat under a mayor's office or government.
+	Secretaria AgencyKind = "secretaria"
+	// ServiciosPublicos represents a public utilities company.
+	ServiciosPublicos AgencyKind = "servicios_publicos"
+)
+
+// Agency is a public entity responsible for handling citizen reports.
+type Agency struct {
+	types.Auditable
+	Name         string     // Name is the official name of the entity.
+	Kind         AgencyKind // Kind classifies the entity (alcaldía, secretaría, etc.).
+	ParentID     *string    // ParentID references the entity this one belongs to (optional).
+	Municipality string     // Municipality is the municipality the entity operates in.
+	State        string     // State is the department the entity operates in.
+	Categories   []string   // Categories lists the report categories the entity handles.
+	Active       bool       // Active is false once the entity stops operating.
+}
+
+// Covers reports whether the agency handles the category in the municipality.
+func (a *Agency) Covers(category, municipality string) bool {
+	return a.Active && a.Municipality == municipality && slices.Contains(a.Categories, category)
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+)
+
+// StaffRole is the role a member holds within an agency.
+type StaffRole string
+
+const (
+	// RoleHead is the person in charge of the agency.
+	RoleHead StaffRole = "head"
+	// RoleSupervisor oversees agents and receives escalations.
+	RoleSupervisor StaffRole = "supervisor"
+	// RoleAgent handles reports assigned to the agency.
+	RoleAgent StaffRole = "agent"
+)
+
+// Membership links a user to an agency for a period of time.
+type Membership struct {
+	types.Auditable
+	AgencyID   string     // AgencyID is the agency the user works for.
+	UserID     string     // UserID is the staff member.
+	Position   string     // Position is the job title (e.g., "Secretario de Movilidad").
+	Role       StaffRole  // Role is the staff role within the agency.
+	Categories []string   // Categories restricts the member to a subset of the agency categories (optional).
+	ValidFrom  time.Time  // ValidFrom is when the appointment starts.
+	ValidUntil *time.Time // ValidUntil is when the appointment ends; nil means open-ended.
+}
+
+// ActiveAt reports whether the membership is valid at the given time.
+func (m *Membership) ActiveAt(t time.Time) bool {
+	if t.Before(m.ValidFrom) {
+		return false
+	}
+	return m.ValidUntil == nil || t.Before(*m.ValidUntil)
+}
+
+// Handles reports whether the member handles the category, given the agency covers it.
+func (m *Membership) Handles(category string) bool {
+	return len(m.Categories) == 0 || slices.Contains(m.Categories, category)
+}
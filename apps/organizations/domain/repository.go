@@ -0,0 +1,57 @@
+package domain
+
+import "context"
+
+// AgencyRepository defines access methods for agencies.
+type AgencyRepository interface {
+
+	// GetByID returns the agency with the given ID, or an error if not found.
+	GetByID(ctx context.Context, id string) (*Agency, error)
+
+	// ListByMunicipality returns the active agencies of a municipality.
+	ListByMunicipality(ctx context.Context, municipality string) ([]Agency, error)
+
+	// Create persists a new agency.
+	Create(ctx context.Context, agency *Agency) error
+
+	// Update saves changes to an agency.
+	Update(ctx context.Context, agency *Agency) error
+}
+
+// MembershipRepository defines access methods for staff memberships.
+type MembershipRepository interface {
+
+	// GetByID returns the membership with the given ID, or an error if not found.
+	GetByID(ctx context.Context, id string) (*Membership, error)
+
+	// ListByAgency returns every membership of an agency.
+	ListByAgency(ctx context.Context, agencyID string) ([]Membership, error)
+
+	// ListByUser returns every membership of a user.
+	ListByUser(ctx context.Context, userID string) ([]Membership, error)
+
+	// Create persists a new membership.
+	Create(ctx context.Context, m *Membership) error
+
+	// Update saves changes to a membership.
+	Update(ctx context.Context, m *Membership) error
+}
+
+// VerificationRepository defines access methods for official verifications.
+type VerificationRepository interface {
+
+	// GetByID returns the verification with the given ID, or an error if not found.
+	GetByID(ctx context.Context, id string) (*Verification, error)
+
+	// GetLatest returns the latest verification of a user for an agency, or nil if none.
+	GetLatest(ctx context.Context, userID, agencyID string) (*Verification, error)
+
+	// ListByStatus returns the verifications in the given status.
+	ListByStatus(ctx context.Context, status VerificationStatus) ([]Verification, error)
+
+	// Create persists a new verification request.
+	Create(ctx context.Context, v *Verification) error
+
+	// Update saves the review of a verification.
+	Update(ctx context.Context, v *Verification) error
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+)
+
+// VerificationStatus is the state of an official verification request.
+type VerificationStatus string
+
+const (
+	// VerificationPending awaits review.
+	VerificationPending VerificationStatus = "pending"
+	// VerificationApproved confirms the user is an official of the agency.
+	VerificationApproved VerificationStatus = "approved"
+	// VerificationRejected denies the request.
+	VerificationRejected VerificationStatus = "rejected"
+	// VerificationRevoked withdraws a previous approval.
+	VerificationRevoked VerificationStatus = "revoked"
+)
+
+// Verification is a request by a user to be recognized as an official of an agency.
+type Verification struct {
+	types.Auditable
+	UserID     string             // UserID is the applicant.
+	AgencyID   string             // AgencyID is the agency the applicant claims to work for.
+	Evidence   string             // Evidence is a URL to supporting documents (e.g., appointment decree).
+	Status     VerificationStatus // Status is the current state of the request.
+	ReviewerID *string            // ReviewerID is the user who reviewed the request.
+	ReviewedAt *time.Time         // ReviewedAt is when the request was reviewed.
+	Reason     string             // Reason explains rejections and revocations.
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/apps/organizations/domain"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// Official is a verified staff member able to act on behalf of an agency.
+type Official struct {
+	UserID   string           // UserID is the staff member.
+	AgencyID string           // AgencyID is the agency the member acts for.
+	Position string           // Position is the member job title.
+	Role     domain.StaffRole // Role is the member staff role.
+}
+
+// OrganizationService defines application use cases for agencies and their staff.
+type OrganizationService struct {
+	agencies      domain.AgencyRepository
+	memberships   domain.MembershipRepository
+	verifications domain.VerificationRepository
+	now           func() time.Time
+}
+
+// NewOrganizationService creates a new instance of OrganizationService.
+func NewOrganizationService(
+	agencies domain.AgencyRepository,
+	memberships domain.MembershipRepository,
+	verifications domain.VerificationRepository,
+) *OrganizationService {
+	return &OrganizationService{agencies: agencies, memberships: memberships, verifications: verifications, now: time.Now}
+}
+
+// CreateAgency validates and persists a new agency.
+func (s *OrganizationService) CreateAgency(ctx context.Context, a *domain.Agency) error {
+	if a.Name == "" || a.Municipality == "" {
+		return transport.BadRequest("agency name and municipality are required")
+	}
+	if a.ParentID != nil {
+		if _, err := s.agencies.GetByID(ctx, *a.ParentID); err != nil {
+			return transport.New(400, "parent agency not found", err)
+		}
+	}
+	return s.agencies.Create(ctx, a)
+}
+
+// AddMember validates and persists a staff membership.
+// Member categories must be a subset of the agency categories.
+func (s *OrganizationService) AddMember(ctx context.Context, m *domain.Membership) error {
+	agency, err := s.agencies.GetByID(ctx, m.AgencyID)
+	if err != nil {
+		return transport.New(404, "agency not found", err)
+	}
+	if m.ValidUntil != nil && !m.ValidUntil.After(m.ValidFrom) {
+		return transport.BadRequest("membership must end after it starts")
+	}
+	for _, c := range m.Categories {
+		if !slices.Contains(agency.Categories, c) {
+			return transport.BadRequest("agency does not handle category " + c)
+		}
+	}
+	return s.memberships.Create(ctx, m)
+}
+
+// EndMembership closes a membership at the given time.
+func (s *OrganizationService) EndMembership(ctx context.Context, id string, at time.Time) error {
+	m, err := s.memberships.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !at.After(m.ValidFrom) {
+		return transport.BadRequest("membership must end after it starts")
+	}
+	m.ValidUntil = &at
+	return s.memberships.Update(ctx, m)
+}
+
+// RequestVerification opens a verification request for a user claiming to work for an agency.
+func (s *OrganizationService) RequestVerification(ctx context.Context, userID, agencyID, evidence string) (*domain.Verification, error) {
+	if _, err := s.agencies.GetByID(ctx, agencyID); err != nil {
+		return nil, transport.New(404, "agency not found", err)
+	}
+
+	latest, err := s.verifications.GetLatest(ctx, userID, agencyID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && (latest.Status == domain.VerificationPending || latest.Status == domain.VerificationApproved) {
+		return nil, transport.Conflict("a verification is already " + string(latest.Status))
+	}
+
+	id, err := types.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &domain.Verification{UserID: userID, AgencyID: agencyID, Evidence: evidence, Status: domain.VerificationPending}
+	v.ID = id
+	v.CreatedAt = s.now()
+	if err := s.verifications.Create(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ReviewVerification approves or rejects a pending verification.
+func (s *OrganizationService) ReviewVerification(ctx context.Context, id, reviewerID string, approve bool, reason string) error {
+	v, err := s.verifications.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if v.Status != domain.VerificationPending {
+		return transport.Conflict("verification is not pending")
+	}
+	if v.UserID == reviewerID {
+		return transport.Forbidden("users cannot review their own verification")
+	}
+
+	status := domain.VerificationRejected
+	if approve {
+		status = domain.VerificationApproved
+	} else if reason == "" {
+		return transport.BadRequest("a reason is required to reject a verification")
+	}
+	return s.review(ctx, v, reviewerID, status, reason)
+}
+
+// RevokeVerification withdraws an approved verification.
+func (s *OrganizationService) RevokeVerification(ctx context.Context, id, reviewerID, reason string) error {
+	v, err := s.verifications.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if v.Status != domain.VerificationApproved {
+		return transport.Conflict("only approved verifications can be revoked")
+	}
+	return s.review(ctx, v, reviewerID, domain.VerificationRevoked, reason)
+}
+
+// IsVerified reports whether the user is a verified official of the agency.
+func (s *OrganizationService) IsVerified(ctx context.Context, userID, agencyID string) (bool, error) {
+	v, err := s.verifications.GetLatest(ctx, userID, agencyID)
+	if err != nil {
+		return false, err
+	}
+	return v != nil && v.Status == domain.VerificationApproved, nil
+}
+
+// OfficialsCovering lists the verified officials with an active membership in an agency
+// of the municipality handling the category at the given time.
+func (s *OrganizationService) OfficialsCovering(ctx context.Context, category, municipality string, at time.Time) ([]Official, error) {
+	agencies, err := s.agencies.ListByMunicipality(ctx, municipality)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Official
+	for i := range agencies {
+		agency := &agencies[i]
+		if !agency.Covers(category, municipality) {
+			continue
+		}
+
+		members, err := s.memberships.ListByAgency(ctx, agency.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range members {
+			m := &members[j]
+			if !m.ActiveAt(at) || !m.Handles(category) {
+				continue
+			}
+			ok, err := s.IsVerified(ctx, m.UserID, agency.ID)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				out = append(out, Official{UserID: m.UserID, AgencyID: agency.ID, Position: m.Position, Role: m.Role})
+			}
+		}
+	}
+	return out, nil
+}
+
+// Roles returns the roles of a user for access tokens: "citizen" for everyone, plus
+// "official" and the staff role for each verified active membership.
+func (s *OrganizationService) Roles(ctx context.Context, userID string) ([]string, error) {
+	roles := []string{"citizen"}
+
+	members, err := s.memberships.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for i := range members {
+		m := &members[i]
+		if !m.ActiveAt(now) {
+			continue
+		}
+		ok, err := s.IsVerified(ctx, userID, m.AgencyID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		for _, r := range []string{"official", string(m.Role)} {
+			if !slices.Contains(roles, r) {
+				roles = append(roles, r)
+			}
+		}
+	}
+	return roles, nil
+}
+
+// review records the outcome of a verification review.
+func (s *OrganizationService) review(ctx context.Context, v *domain.Verification, reviewerID string, status domain.VerificationStatus, reason string) error {
+	now := s.now()
+	v.Status = status
+	v.ReviewerID = &reviewerID
+	v.ReviewedAt = &now
+	v.Reason = reason
+	v.UpdatedAt = now
+	return s.verifications.Update(ctx, v)
+}
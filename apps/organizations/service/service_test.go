@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/apps/organizations/domain"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAgencies is an AgencyRepository backed by a slice.
+type memoryAgencies []domain.Agency
+
+func (m *memoryAgencies) GetByID(_ context.Context, id string) (*domain.Agency, error) {
+	for _, a := range *m {
+		if a.ID == id {
+			return &a, nil
+		}
+	}
+	return nil, transport.NotFound("agency not found")
+}
+
+func (m *memoryAgencies) ListByMunicipality(_ context.Context, municipality string) ([]domain.Agency, error) {
+	var out []domain.Agency
+	for _, a := range *m {
+		if a.Municipality == municipality && a.Active {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryAgencies) Create(_ context.Context, a *domain.Agency) error {
+	*m = append(*m, *a)
+	return nil
+}
+
+func (m *memoryAgencies) Update(_ context.Context, a *domain.Agency) error {
+	for i := range *m {
+		if (*m)[i].ID == a.ID {
+			(*m)[i] = *a
+		}
+	}
+	return nil
+}
+
+// memoryMemberships is a MembershipRepository backed by a slice.
+type memoryMemberships []domain.Membership
+
+func (m *memoryMemberships) GetByID(_ context.Context, id string) (*domain.Membership, error) {
+	for _, ms := range *m {
+		if ms.ID == id {
+			return &ms, nil
+		}
+	}
+	return nil, transport.NotFound("membership not found")
+}
+
+func (m *memoryMemberships) ListByAgency(_ context.Context, agencyID string) ([]domain.Membership, error) {
+	return m.filter(func(ms domain.Membership) bool { return ms.AgencyID == agencyID }), nil
+}
+
+func (m *memoryMemberships) ListByUser(_ context.Context, userID string) ([]domain.Membership, error) {
+	return m.filter(func(ms domain.Membership) bool { return ms.UserID == userID }), nil
+}
+
+func (m *memoryMemberships) Create(_ context.Context, ms *domain.Membership) error {
+	*m = append(*m, *ms)
+	return nil
+}
+
+func (m *memoryMemberships) Update(_ context.Context, ms *domain.Membership) error {
+	for i := range *m {
+		if (*m)[i].ID == ms.ID {
+			(*m)[i] = *ms
+		}
+	}
+	return nil
+}
+
+func (m *memoryMemberships) filter(match func(domain.Membership) bool) []domain.Membership {
+	var out []domain.Membership
+	for _, ms := range *m {
+		if match(ms) {
+			out = append(out, ms)
+		}
+	}
+	return out
+}
+
+// memoryVerifications is a VerificationRepository backed by a slice, oldest first.
+type memoryVerifications []domain.Verification
+
+func (m *memoryVerifications) GetByID(_ context.Context, id string) (*domain.Verification, error) {
+	for _, v := range *m {
+		if v.ID == id {
+			return &v, nil
+		}
+	}
+	return nil, transport.NotFound("verification not found")
+}
+
+func (m *memoryVerifications) GetLatest(_ context.Context, userID, agencyID string) (*domain.Verification, error) {
+	for i := len(*m) - 1; i >= 0; i-- {
+		if v := (*m)[i]; v.UserID == userID && v.AgencyID == agencyID {
+			return &v, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryVerifications) ListByStatus(_ context.Context, status domain.VerificationStatus) ([]domain.Verification, error) {
+	var out []domain.Verification
+	for _, v := range *m {
+		if v.Status == status {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryVerifications) Create(_ context.Context, v *domain.Verification) error {
+	*m = append(*m, *v)
+	return nil
+}
+
+func (m *memoryVerifications) Update(_ context.Context, v *domain.Verification) error {
+	for i := range *m {
+		if (*m)[i].ID == v.ID {
+			(*m)[i] = *v
+		}
+	}
+	return nil
+}
+
+// newTestService returns a service with a mobility secretariat of Bogotá.
+func newTestService() *OrganizationService {
+	agencies := &memoryAgencies{{
+		Auditable:    types.Auditable{ID: "movilidad"},
+		Name:         "Secretaría de Movilidad",
+		Kind:         domain.Secretaria,
+		Municipality: "Bogotá",
+		Categories:   []string{"pothole", "traffic_light"},
+		Active:       true,
+	}}
+	return NewOrganizationService(agencies, &memoryMemberships{}, &memoryVerifications{})
+}
+
+// verify requests and approves the verification of a user for an agency.
+func verify(t *testing.T, s *OrganizationService, userID, agencyID string) {
+	ctx := context.Background()
+	v, err := s.RequestVerification(ctx, userID, agencyID, "https://decretos.example/1")
+	require.NoError(t, err)
+	require.NoError(t, s.ReviewVerification(ctx, v.ID, "admin", true, ""))
+}
+
+// TestAddMember checks memberships are limited to the agency categories and end after they start.
+func TestAddMember(t *testing.T) {
+	s := newTestService()
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	err := s.AddMember(ctx, &domain.Membership{AgencyID: "movilidad", UserID: "u1", ValidFrom: start, Categories: []string{"garbage"}})
+	assert.Equal(t, 400, transport.CodeOf(err))
+
+	end := start.Add(-time.Hour)
+	err = s.AddMember(ctx, &domain.Membership{AgencyID: "movilidad", UserID: "u1", ValidFrom: start, ValidUntil: &end})
+	assert.Equal(t, 400, transport.CodeOf(err))
+
+	err = s.AddMember(ctx, &domain.Membership{AgencyID: "missing", UserID: "u1", ValidFrom: start})
+	assert.Equal(t, 404, transport.CodeOf(err))
+
+	m := &domain.Membership{Auditable: types.Auditable{ID: "m1"}, AgencyID: "movilidad", UserID: "u1", ValidFrom: start, Categories: []string{"pothole"}}
+	require.NoError(t, s.AddMember(ctx, m))
+	assert.Equal(t, 400, transport.CodeOf(s.EndMembership(ctx, "m1", start)))
+	require.NoError(t, s.EndMembership(ctx, "m1", start.Add(time.Hour)))
+}
+
+// TestVerification checks the lifecycle of a verification request.
+func TestVerification(t *testing.T) {
+	s := newTestService()
+	ctx := context.Background()
+
+	v, err := s.RequestVerification(ctx, "u1", "movilidad", "https://decretos.example/1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.VerificationPending, v.Status)
+
+	_, err = s.RequestVerification(ctx, "u1", "movilidad", "")
+	assert.Equal(t, 409, transport.CodeOf(err), "a pending request blocks new ones")
+
+	assert.Equal(t, 403, transport.CodeOf(s.ReviewVerification(ctx, v.ID, "u1", true, "")))
+	assert.Equal(t, 400, transport.CodeOf(s.ReviewVerification(ctx, v.ID, "admin", false, "")))
+
+	require.NoError(t, s.ReviewVerification(ctx, v.ID, "admin", true, ""))
+	ok, err := s.IsVerified(ctx, "u1", "movilidad")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 409, transport.CodeOf(s.ReviewVerification(ctx, v.ID, "admin", false, "late")))
+
+	require.NoError(t, s.RevokeVerification(ctx, v.ID, "admin", "left the agency"))
+	ok, err = s.IsVerified(ctx, "u1", "movilidad")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = s.RequestVerification(ctx, "u1", "movilidad", "https://decretos.example/2")
+	assert.NoError(t, err, "revoked users can request again")
+}
+
+// TestOfficialsCovering checks only verified, active members handling the category are listed.
+func TestOfficialsCovering(t *testing.T) {
+	s := newTestService()
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ended := start.Add(24 * time.Hour)
+
+	for _, m := range []domain.Membership{
+		{UserID: "agent", Role: domain.RoleAgent, ValidFrom: start},
+		{UserID: "lights", Role: domain.RoleAgent, ValidFrom: start, Categories: []string{"traffic_light"}},
+		{UserID: "former", Role: domain.RoleAgent, ValidFrom: start, ValidUntil: &ended},
+		{UserID: "unverified", Role: domain.RoleAgent, ValidFrom: start},
+	} {
+		m.AgencyID = "movilidad"
+		require.NoError(t, s.AddMember(ctx, &m))
+	}
+	for _, u := range []string{"agent", "lights", "former"} {
+		verify(t, s, u, "movilidad")
+	}
+
+	at := start.Add(48 * time.Hour)
+	officials, err := s.OfficialsCovering(ctx, "pothole", "Bogotá", at)
+	require.NoError(t, err)
+	require.Len(t, officials, 1)
+	assert.Equal(t, "agent", officials[0].UserID)
+
+	officials, err = s.OfficialsCovering(ctx, "pothole", "Medellín", at)
+	require.NoError(t, err)
+	assert.Empty(t, officials)
+}
+
+// TestRoles checks staff roles are granted only by verified active memberships.
+func TestRoles(t *testing.T) {
+	s := newTestService()
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return start.Add(time.Hour) }
+
+	require.NoError(t, s.AddMember(ctx, &domain.Membership{AgencyID: "movilidad", UserID: "u1", Role: domain.RoleSupervisor, ValidFrom: start}))
+	roles, err := s.Roles(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"citizen"}, roles)
+
+	verify(t, s, "u1", "movilidad")
+	roles, err = s.Roles(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"citizen", "official", "supervisor"}, roles)
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "context"
+
+// RequestRepository defines access methods for data subject requests.
+type RequestRepository interface {
+
+	// GetByID returns the request with the given ID, or an error if not found.
+	GetByID(ctx context.Context, id string) (*Request, error)
+
+	// ListBySubject returns the requests of a subject, newest first.
+	ListBySubject(ctx context.Context, subjectID string) ([]Request, error)
+
+	// Create persists a new request.
+	Create(ctx context.Context, r *Request) error
+
+	// Update saves the request status.
+	Update(ctx context.Context, r *Request) error
+}
+
+// EventRepository stores the audit trail of requests. Events are never modified.
+type EventRepository interface {
+
+	// Append persists a new event.
+	Append(ctx context.Context, e *Event) error
+
+	// List returns the events of a request, oldest first.
+	List(ctx context.Context, requestID string) ([]Event, error)
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"time"
+
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+)
+
+// RequestKind is the right exercised by a data subject under Ley 1581 de 2012.
+type RequestKind string
+
+const (
+	// Access asks for a copy of every personal data held about the subject (consulta).
+	Access RequestKind = "access"
+	// Erasure asks for the suppression of the subject personal data (reclamo de supresión).
+	Erasure RequestKind = "erasure"
+)
+
+// RequestStatus is the processing state of a request.
+type RequestStatus string
+
+const (
+	// Pending requests await processing.
+	Pending RequestStatus = "pending"
+	// Completed requests were fulfilled.
+	Completed RequestStatus = "completed"
+	// Rejected requests were denied with a reason, e.g., a legal duty to retain the data.
+	Rejected RequestStatus = "rejected"
+	// Failed requests stopped on an error and can be processed again.
+	Failed RequestStatus = "failed"
+)
+
+// Request is a data subject request and its legal deadline.
+type Request struct {
+	types.Auditable
+	SubjectID   string        // SubjectID is the user whose data is requested.
+	Kind        RequestKind   // Kind is the right exercised.
+	Status      RequestStatus // Status is the processing state.
+	RequestedBy string        // RequestedBy is the user filing the request (the subject or a representative).
+	Reason      string        // Reason is the subject motivation or the rejection reason.
+	DueAt       time.Time     // DueAt is the legal response deadline.
+	CompletedAt *time.Time    // CompletedAt is when the request was completed or rejected.
+}
+
+// Open reports whether the request can still be processed.
+func (r *Request) Open() bool {
+	return r.Status == Pending || r.Status == Failed
+}
+
+// Event is an entry of the audit trail of a request.
+type Event struct {
+	ID        string    // ID is the unique identifier of the event.
+	RequestID string    // RequestID is the audited request.
+	Action    string    // Action is what happened (e.g., "submitted", "exported", "anonymized").
+	Source    string    // Source is the data source involved, if any.
+	Actor     string    // Actor is the user or system performing the action.
+	Detail    string    // Detail describes the outcome.
+	At        time.Time // At is when the action happened.
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "context"
+
+// Outcome summarizes the erasure performed by a source.
+type Outcome struct {
+	Deleted    int // Deleted is the number of records removed.
+	Anonymized int // Anonymized is the number of records kept with their personal data replaced.
+}
+
+// Source holds personal data of users. Every service storing such data registers one.
+type Source interface {
+
+	// Name identifies the source in exports and in the audit trail.
+	Name() string
+
+	// Export returns the subject data in a JSON-serializable form, or nil when there is none.
+	Export(ctx context.Context, subjectID string) (any, error)
+
+	// Erase removes the subject personal data. Records referenced by public content
+	// (e.g., the author of a published report) must be anonymized rather than deleted,
+	// so the public record stays consistent.
+	Erase(ctx context.Context, subjectID string) (Outcome, error)
+}
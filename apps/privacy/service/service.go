@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/apps/privacy/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/calendar"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// ErrClosed is returned when processing a request that was already completed or rejected.
+var ErrClosed = transport.Conflict("the request is already closed")
+
+// Config defines the legal response terms.
+type Config struct {
+	Calendar    *calendar.Calendar // Calendar counts business days (default: every day counts).
+	AccessDays  int                // AccessDays is the term to answer an access request (default: 10 business days).
+	ErasureDays int                // ErasureDays is the term to answer an erasure request (default: 15 business days).
+}
+
+// Package is the machine-readable export of the data held about a subject.
+type Package struct {
+	RequestID   string         `json:"request_id"`
+	SubjectID   string         `json:"subject_id"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Sources     map[string]any `json:"sources"`
+}
+
+// PrivacyService handles data subject requests under Ley 1581 de 2012 (Habeas Data).
+// Every step is recorded in the audit trail of the request.
+type PrivacyService struct {
+	requests domain.RequestRepository
+	events   domain.EventRepository
+	sources  []domain.Source
+	cfg      Config
+	now      func() time.Time
+}
+
+// NewPrivacyService creates a new instance of PrivacyService collecting data from sources.
+func NewPrivacyService(
+	requests domain.RequestRepository,
+	events domain.EventRepository,
+	cfg Config,
+	sources ...domain.Source,
+) *PrivacyService {
+	if cfg.AccessDays == 0 {
+		cfg.AccessDays = 10
+	}
+	if cfg.ErasureDays == 0 {
+		cfg.ErasureDays = 15
+	}
+	return &PrivacyService{requests: requests, events: events, sources: sources, cfg: cfg, now: time.Now}
+}
+
+// Submit files a request and sets its legal deadline.
+func (s *PrivacyService) Submit(ctx context.Context, subjectID string, kind domain.RequestKind, by, reason string) (*domain.Request, error) {
+	if subjectID == "" {
+		return nil, transport.BadRequest("a subject is required")
+	}
+	days := s.cfg.AccessDays
+	switch kind {
+	case domain.Access:
+	case domain.Erasure:
+		days = s.cfg.ErasureDays
+	default:
+		return nil, transport.BadRequest(fmt.Sprintf("unsupported request kind %q", kind))
+	}
+
+	id, err := types.NewID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	r := &domain.Request{
+		SubjectID:   subjectID,
+		Kind:        kind,
+		Status:      domain.Pending,
+		RequestedBy: by,
+		Reason:      reason,
+		DueAt:       s.due(now, days),
+	}
+	r.ID, r.CreatedAt, r.UpdatedAt = id, now, now
+	if err := s.requests.Create(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, s.record(ctx, r.ID, "submitted", "", by, string(kind))
+}
+
+// Export fulfils an access request, assembling the data of every source.
+func (s *PrivacyService) Export(ctx context.Context, requestID, actor string) (*Package, error) {
+	r, err := s.open(ctx, requestID, domain.Access)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &Package{RequestID: r.ID, SubjectID: r.SubjectID, GeneratedAt: s.now(), Sources: map[string]any{}}
+	for _, src := range s.sources {
+		data, err := src.Export(ctx, r.SubjectID)
+		if err != nil {
+			return nil, s.fail(ctx, r, src.Name(), actor, err)
+		}
+		if data == nil {
+			continue
+		}
+		pkg.Sources[src.Name()] = data
+		if err := s.record(ctx, r.ID, "exported", src.Name(), actor, ""); err != nil {
+			return nil, err
+		}
+	}
+	return pkg, s.complete(ctx, r, actor)
+}
+
+// Erase fulfils an erasure request on every source. A failed source leaves the
+// request failed so it can be processed again; sources must be idempotent.
+func (s *PrivacyService) Erase(ctx context.Context, requestID, actor string) error {
+	r, err := s.open(ctx, requestID, domain.Erasure)
+	if err != nil {
+		return err
+	}
+
+	for _, src := range s.sources {
+		out, err := src.Erase(ctx, r.SubjectID)
+		if err != nil {
+			return s.fail(ctx, r, src.Name(), actor, err)
+		}
+		detail := fmt.Sprintf("%d deleted, %d anonymized", out.Deleted, out.Anonymized)
+		if err := s.record(ctx, r.ID, "erased", src.Name(), actor, detail); err != nil {
+			return err
+		}
+	}
+	return s.complete(ctx, r, actor)
+}
+
+// Reject denies a request, e.g., when the law requires keeping the data.
+func (s *PrivacyService) Reject(ctx context.Context, requestID, actor, reason string) error {
+	if reason == "" {
+		return transport.BadRequest("a reason is required to reject a request")
+	}
+	r, err := s.requests.GetByID(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	if !r.Open() {
+		return ErrClosed
+	}
+	now := s.now()
+	r.Status, r.Reason, r.CompletedAt, r.UpdatedAt = domain.Rejected, reason, &now, now
+	if err := s.requests.Update(ctx, r); err != nil {
+		return err
+	}
+	return s.record(ctx, r.ID, "rejected", "", actor, reason)
+}
+
+// Requests returns the requests of a subject, newest first.
+func (s *PrivacyService) Requests(ctx context.Context, subjectID string) ([]domain.Request, error) {
+	return s.requests.ListBySubject(ctx, subjectID)
+}
+
+// Trail returns the audit trail of a request, oldest first.
+func (s *PrivacyService) Trail(ctx context.Context, requestID string) ([]domain.Event, error) {
+	return s.events.List(ctx, requestID)
+}
+
+// open returns an open request of the given kind.
+func (s *PrivacyService) open(ctx context.Context, id string, kind domain.RequestKind) (*domain.Request, error) {
+	r, err := s.requests.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Kind != kind {
+		return nil, transport.BadRequest(fmt.Sprintf("the request is not an %s request", kind))
+	}
+	if !r.Open() {
+		return nil, ErrClosed
+	}
+	return r, nil
+}
+
+// complete closes a fulfilled request.
+func (s *PrivacyService) complete(ctx context.Context, r *domain.Request, actor string) error {
+	now := s.now()
+	r.Status, r.CompletedAt, r.UpdatedAt = domain.Completed, &now, now
+	if err := s.requests.Update(ctx, r); err != nil {
+		return err
+	}
+	return s.record(ctx, r.ID, "completed", "", actor, "")
+}
+
+// fail marks the request failed on a source error and returns the error.
+func (s *PrivacyService) fail(ctx context.Context, r *domain.Request, source, actor string, cause error) error {
+	r.Status, r.UpdatedAt = domain.Failed, s.now()
+	return errors.Join(cause,
+		s.requests.Update(ctx, r),
+		s.record(ctx, r.ID, "failed", source, actor, cause.Error()),
+	)
+}
+
+// record appends an event to the audit trail of a request.
+func (s *PrivacyService) record(ctx context.Context, requestID, action, source, actor, detail string) error {
+	id, err := types.NewID()
+	if err != nil {
+		return err
+	}
+	return s.events.Append(ctx, &domain.Event{
+		ID:        id,
+		RequestID: requestID,
+		Action:    action,
+		Source:    source,
+		Actor:     actor,
+		Detail:    detail,
+		At:        s.now(),
+	})
+}
+
+// due returns the deadline after the given number of business days.
+func (s *PrivacyService) due(from time.Time, days int) time.Time {
+	t := from
+	for days > 0 {
+		t = t.AddDate(0, 0, 1)
+		if s.cfg.Calendar == nil || s.cfg.Calendar.IsBusinessDay(t) {
+			days--
+		}
+	}
+	return t
+}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/apps/privacy/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRequests is a RequestRepository backed by a slice.
+type memoryRequests []domain.Request
+
+func (m *memoryRequests) GetByID(_ context.Context, id string) (*domain.Request, error) {
+	for _, r := range *m {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, transport.NotFound("request not found")
+}
+
+func (m *memoryRequests) ListBySubject(_ context.Context, subjectID string) ([]domain.Request, error) {
+	var out []domain.Request
+	for i := len(*m) - 1; i >= 0; i-- {
+		if (*m)[i].SubjectID == subjectID {
+			out = append(out, (*m)[i])
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryRequests) Create(_ context.Context, r *domain.Request) error {
+	*m = append(*m, *r)
+	return nil
+}
+
+func (m *memoryRequests) Update(_ context.Context, r *domain.Request) error {
+	for i := range *m {
+		if (*m)[i].ID == r.ID {
+			(*m)[i] = *r
+		}
+	}
+	return nil
+}
+
+// memoryEvents is an EventRepository backed by a slice.
+type memoryEvents []domain.Event
+
+func (m *memoryEvents) Append(_ context.Context, e *domain.Event) error {
+	*m = append(*m, *e)
+	return nil
+}
+
+func (m *memoryEvents) List(_ context.Context, requestID string) ([]domain.Event, error) {
+	var out []domain.Event
+	for _, e := range *m {
+		if e.RequestID == requestID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// fakeSource holds data for a single subject and fails while err is set.
+type fakeSource struct {
+	name   string
+	data   any
+	err    error
+	erased int
+}
+
+func (f *fakeSource) Name() string { return f.name }
+
+func (f *fakeSource) Export(context.Context, string) (any, error) {
+	return f.data, f.err
+}
+
+func (f *fakeSource) Erase(context.Context, string) (domain.Outcome, error) {
+	if f.err != nil {
+		return domain.Outcome{}, f.err
+	}
+	f.erased++
+	return domain.Outcome{Deleted: 1}, nil
+}
+
+// actions returns the actions of a trail in order.
+func actions(events []domain.Event) []string {
+	out := make([]string, len(events))
+	for i, e := range events {
+		out[i] = e.Action
+		if e.Source != "" {
+			out[i] += ":" + e.Source
+		}
+	}
+	return out
+}
+
+// TestSubmit checks requests get their legal deadline and unknown kinds are refused.
+func TestSubmit(t *testing.T) {
+	s := NewPrivacyService(&memoryRequests{}, &memoryEvents{}, Config{})
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	r, err := s.Submit(ctx, "u1", domain.Access, "u1", "")
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 10), r.DueAt)
+
+	r, err = s.Submit(ctx, "u1", domain.Erasure, "u1", "")
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 15), r.DueAt)
+
+	_, err = s.Submit(ctx, "u1", "rectification", "u1", "")
+	assert.Equal(t, 400, transport.CodeOf(err))
+	_, err = s.Submit(ctx, "", domain.Access, "u1", "")
+	assert.Equal(t, 400, transport.CodeOf(err))
+}
+
+// TestExportFailure checks a failing source leaves the request failed and a retry completes it.
+func TestExportFailure(t *testing.T) {
+	profile := &fakeSource{name: "profile", data: map[string]string{"name": "Ana"}}
+	flags := &fakeSource{name: "flags", err: errors.New("database is down")}
+	empty := &fakeSource{name: "empty"}
+	requests, events := &memoryRequests{}, &memoryEvents{}
+	s := NewPrivacyService(requests, events, Config{}, profile, flags, empty)
+	ctx := context.Background()
+
+	r, err := s.Submit(ctx, "u1", domain.Access, "u1", "")
+	require.NoError(t, err)
+
+	_, err = s.Export(ctx, r.ID, "dpo")
+	assert.ErrorIs(t, err, flags.err)
+	stored, err := requests.GetByID(ctx, r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Failed, stored.Status)
+
+	flags.err, flags.data = nil, []string{"spam"}
+	pkg, err := s.Export(ctx, r.ID, "dpo")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"profile": profile.data, "flags": flags.data}, pkg.Sources, "sources without data are left out")
+
+	_, err = s.Export(ctx, r.ID, "dpo")
+	assert.ErrorIs(t, err, ErrClosed)
+
+	trail, err := s.Trail(ctx, r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"submitted", "exported:profile", "failed:flags",
+		"exported:profile", "exported:flags", "completed",
+	}, actions(trail))
+}
+
+// TestEraseFailure checks a failing source leaves the request failed and a retry erases every source again.
+func TestEraseFailure(t *testing.T) {
+	profile := &fakeSource{name: "profile"}
+	media := &fakeSource{name: "media", err: errors.New("store unavailable")}
+	requests, events := &memoryRequests{}, &memoryEvents{}
+	s := NewPrivacyService(requests, events, Config{}, profile, media)
+	ctx := context.Background()
+
+	access, err := s.Submit(ctx, "u1", domain.Access, "u1", "")
+	require.NoError(t, err)
+	assert.Equal(t, 400, transport.CodeOf(s.Erase(ctx, access.ID, "dpo")), "only erasure requests are erased")
+
+	r, err := s.Submit(ctx, "u1", domain.Erasure, "u1", "")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, s.Erase(ctx, r.ID, "dpo"), media.err)
+	stored, err := requests.GetByID(ctx, r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Failed, stored.Status)
+	assert.Nil(t, stored.CompletedAt)
+
+	media.err = nil
+	require.NoError(t, s.Erase(ctx, r.ID, "dpo"))
+	assert.Equal(t, 2, profile.erased, "sources are erased again on retry")
+	assert.Equal(t, 1, media.erased)
+	stored, err = requests.GetByID(ctx, r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Completed, stored.Status)
+
+	assert.ErrorIs(t, s.Reject(ctx, r.ID, "dpo", "late"), ErrClosed)
+	assert.Equal(t, 400, transport.CodeOf(s.Reject(ctx, access.ID, "dpo", "")))
+
+	trail, err := s.Trail(ctx, r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"submitted", "erased:profile", "failed:media",
+		"erased:profile", "erased:media", "completed",
+	}, actions(trail))
+}
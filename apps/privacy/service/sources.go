@@ -0,0 +1,281 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	auth "github.com/ianfedev/civicspot-backend/apps/auth/service"
+	moderation "github.com/ianfedev/civicspot-backend/apps/moderation/service"
+	"github.com/ianfedev/civicspot-backend/apps/privacy/domain"
+	users "github.com/ianfedev/civicspot-backend/apps/users/service"
+	"github.com/ianfedev/civicspot-backend/pkg/common/consent"
+	"github.com/ianfedev/civicspot-backend/pkg/common/history"
+	"github.com/ianfedev/civicspot-backend/pkg/common/notify"
+	"github.com/ianfedev/civicspot-backend/pkg/common/storage"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// userProfile is the export of a user profile.
+type userProfile struct {
+	ID             string `json:"id"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	DocumentType   string `json:"document_type"`
+	DocumentID     string `json:"document_id"`
+	City           string `json:"city"`
+	State          string `json:"state"`
+	Address        string `json:"address"`
+	ProfilePhotoID *uint  `json:"profile_photo_id,omitempty"`
+	RegisteredAt   string `json:"registered_at"`
+}
+
+// userSource exposes the user profiles to privacy requests.
+type userSource struct {
+	users    *users.UserService
+	media    *storage.Media
+	sessions *auth.AuthService
+}
+
+// NewUserSource returns the Source of user profiles. Profiles are anonymized on
+// erasure since published reports reference their author; the profile photo is
+// deleted and every session of the user is revoked.
+func NewUserSource(svc *users.UserService, media *storage.Media, sessions *auth.AuthService) domain.Source {
+	return &userSource{users: svc, media: media, sessions: sessions}
+}
+
+// Name returns the source name.
+func (s *userSource) Name() string { return "profile" }
+
+// Export returns the profile of the subject.
+func (s *userSource) Export(ctx context.Context, subjectID string) (any, error) {
+	u, err := s.users.GetByID(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	return userProfile{
+		ID:             u.ID,
+		FirstName:      u.FirstName,
+		LastName:       u.LastName,
+		DocumentType:   string(u.DocumentType),
+		DocumentID:     u.DocumentID,
+		City:           u.City,
+		State:          u.State,
+		Address:        u.Address,
+		ProfilePhotoID: u.ProfilePhotoID,
+		RegisteredAt:   u.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// Erase deletes the profile photo of the subject, anonymizes the profile and
+// revokes the sessions of the subject.
+func (s *userSource) Erase(ctx context.Context, subjectID string) (domain.Outcome, error) {
+	u, err := s.users.GetByID(ctx, subjectID)
+	if err != nil {
+		return domain.Outcome{}, err
+	}
+
+	var out domain.Outcome
+	if u.ProfilePhotoID != nil {
+		err := s.media.Delete(ctx, *u.ProfilePhotoID)
+		if err != nil && transport.CodeOf(err) != http.StatusNotFound {
+			return domain.Outcome{}, err
+		}
+		if err == nil {
+			out.Deleted++
+		}
+	}
+	if err := s.users.Anonymize(ctx, subjectID); err != nil {
+		return out, err
+	}
+	out.Anonymized++
+	return out, s.sessions.RevokeAll(ctx, subjectID)
+}
+
+// consentSource exposes the policy acceptances to privacy requests.
+type consentSource struct {
+	consent *consent.Service
+}
+
+// NewConsentSource returns the Source of policy acceptances. Acceptances are
+// kept as evidence of the consent given, without their client data, on erasure.
+func NewConsentSource(svc *consent.Service) domain.Source {
+	return &consentSource{consent: svc}
+}
+
+// Name returns the source name.
+func (s *consentSource) Name() string { return "consent" }
+
+// Export returns the acceptance history of the subject.
+func (s *consentSource) Export(ctx context.Context, subjectID string) (any, error) {
+	history, err := s.consent.History(ctx, subjectID)
+	if err != nil || len(history) == 0 {
+		return nil, err
+	}
+	return history, nil
+}
+
+// Erase clears the client data of the acceptances of the subject.
+func (s *consentSource) Erase(ctx context.Context, subjectID string) (domain.Outcome, error) {
+	n, err := s.consent.Forget(ctx, subjectID)
+	return domain.Outcome{Anonymized: int(n)}, err
+}
+
+// inboxSource exposes the in-app notifications to privacy requests.
+type inboxSource struct {
+	inbox *notify.InboxChannel
+}
+
+// NewInboxSource returns the Source of in-app notifications, deleted on erasure.
+func NewInboxSource(inbox *notify.InboxChannel) domain.Source {
+	return &inboxSource{inbox: inbox}
+}
+
+// Name returns the source name.
+func (s *inboxSource) Name() string { return "notifications" }
+
+// Export returns the inbox of the subject.
+func (s *inboxSource) Export(ctx context.Context, subjectID string) (any, error) {
+	items, err := s.inbox.List(ctx, subjectID)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Erase deletes the inbox of the subject.
+func (s *inboxSource) Erase(ctx context.Context, subjectID string) (domain.Outcome, error) {
+	n, err := s.inbox.Erase(ctx, subjectID)
+	return domain.Outcome{Deleted: int(n)}, err
+}
+
+// flagSource exposes the abuse flags to privacy requests.
+type flagSource struct {
+	moderation *moderation.ModerationService
+}
+
+// NewFlagSource returns the Source of abuse flags. Flags are anonymized on
+// erasure since they still count for the moderation cases they opened.
+func NewFlagSource(svc *moderation.ModerationService) domain.Source {
+	return &flagSource{moderation: svc}
+}
+
+// Name returns the source name.
+func (s *flagSource) Name() string { return "flags" }
+
+// Export returns the abuse flags submitted by the subject.
+func (s *flagSource) Export(ctx context.Context, subjectID string) (any, error) {
+	flags, err := s.moderation.FlagsBy(ctx, subjectID)
+	if err != nil || len(flags) == 0 {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// Erase unlinks the abuse flags from the subject.
+func (s *flagSource) Erase(ctx context.Context, subjectID string) (domain.Outcome, error) {
+	n, err := s.moderation.ForgetReporter(ctx, subjectID)
+	return domain.Outcome{Anonymized: n}, err
+}
+
+// attachmentExport is the export of an uploaded file.
+type attachmentExport struct {
+	ID          uint   `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	EntityType  string `json:"entity_type,omitempty"`
+	EntityID    string `json:"entity_id,omitempty"`
+	UploadedAt  string `json:"uploaded_at"`
+}
+
+// mediaSource exposes the uploaded files to privacy requests.
+type mediaSource struct {
+	media *storage.Media
+}
+
+// NewMediaSource returns the Source of uploaded files. Files attached to an
+// entity are disowned on erasure since public content shows them; the others are deleted.
+func NewMediaSource(media *storage.Media) domain.Source {
+	return &mediaSource{media: media}
+}
+
+// Name returns the source name.
+func (s *mediaSource) Name() string { return "media" }
+
+// Export returns the metadata of the files uploaded by the subject.
+func (s *mediaSource) Export(ctx context.Context, subjectID string) (any, error) {
+	owned, err := s.media.ListByOwner(ctx, subjectID)
+	if err != nil || len(owned) == 0 {
+		return nil, err
+	}
+	out := make([]attachmentExport, len(owned))
+	for i, a := range owned {
+		out[i] = attachmentExport{
+			ID:          a.ID,
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+			EntityType:  a.EntityType,
+			EntityID:    a.EntityID,
+			UploadedAt:  a.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return out, nil
+}
+
+// Erase deletes the unattached files of the subject and disowns the others.
+func (s *mediaSource) Erase(ctx context.Context, subjectID string) (domain.Outcome, error) {
+	owned, err := s.media.ListByOwner(ctx, subjectID)
+	if err != nil {
+		return domain.Outcome{}, err
+	}
+	var out domain.Outcome
+	for _, a := range owned {
+		if a.EntityType == "" {
+			if err := s.media.Delete(ctx, a.ID); err != nil {
+				return out, err
+			}
+			out.Deleted++
+			continue
+		}
+		if err := s.media.Disown(ctx, a.ID); err != nil {
+			return out, err
+		}
+		out.Anonymized++
+	}
+	return out, nil
+}
+
+// historySource exposes the entity history tables to privacy requests.
+type historySource struct {
+	tracker *history.Tracker
+	tables  []string
+}
+
+// NewHistorySource returns the Source of the past versions of the tracked tables
+// keyed by the subject ID, e.g., "users". Versions are purged on erasure; it
+// must be the last source so versions written by the erasure of the others are
+// purged too.
+func NewHistorySource(tracker *history.Tracker, tables ...string) domain.Source {
+	return &historySource{tracker: tracker, tables: tables}
+}
+
+// Name returns the source name.
+func (s *historySource) Name() string { return "history" }
+
+// Export returns nil since the current state is exported by the other sources.
+func (s *historySource) Export(context.Context, string) (any, error) { return nil, nil }
+
+// Erase purges the versions of the subject from every table.
+func (s *historySource) Erase(ctx context.Context, subjectID string) (domain.Outcome, error) {
+	var out domain.Outcome
+	for _, table := range s.tables {
+		n, err := s.tracker.Purge(ctx, table, subjectID)
+		if err != nil {
+			return out, err
+		}
+		out.Deleted += int(n)
+	}
+	return out, nil
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+)
+
+// Assignment records who is responsible for an item during a period.
+// The assignment without EndedAt is the current one; ended ones form the history.
+type Assignment struct {
+	types.Auditable
+	ItemID     string     // ItemID is the routed item.
+	AgencyID   string     // AgencyID is the responsible agency.
+	AssigneeID *string    // AssigneeID is the responsible staff member (optional).
+	RuleID     *string    // RuleID is the rule that produced an automatic assignment.
+	AssignedBy *string    // AssignedBy is the user who reassigned the item; nil when automatic.
+	Reason     string     // Reason explains the assignment.
+	EndedAt    *time.Time // EndedAt is set when the item is reassigned.
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "context"
+
+// RuleRepository defines access methods for routing rules.
+type RuleRepository interface {
+
+	// ListActive returns the active rules ordered by priority.
+	ListActive(ctx context.Context) ([]Rule, error)
+
+	// Create persists a new rule.
+	Create(ctx context.Context, rule *Rule) error
+
+	// Update saves changes to a rule.
+	Update(ctx context.Context, rule *Rule) error
+}
+
+// AssignmentRepository defines access methods for assignments.
+type AssignmentRepository interface {
+
+	// Current returns the open assignment of an item, or nil if none.
+	Current(ctx context.Context, itemID string) (*Assignment, error)
+
+	// History returns every assignment of an item, oldest first.
+	History(ctx context.Context, itemID string) ([]Assignment, error)
+
+	// OpenCounts returns the number of open assignments of each given user.
+	OpenCounts(ctx context.Context, userIDs []string) (map[string]int, error)
+
+	// Create persists a new assignment.
+	Create(ctx context.Context, a *Assignment) error
+
+	// Update saves changes to an assignment.
+	Update(ctx context.Context, a *Assignment) error
+}
+
+// CursorStore persists round-robin positions so rotation survives restarts.
+type CursorStore interface {
+
+	// Next returns the next position in [0, n) for the key and advances it.
+	Next(ctx context.Context, key string, n int) (int, error)
+}
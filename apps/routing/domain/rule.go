@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/text"
+)
+
+// Strategy selects the staff member receiving an assignment.
+type Strategy string
+
+const (
+	// RoundRobin rotates assignments among the eligible staff.
+	RoundRobin Strategy = "round_robin"
+	// LeastLoaded assigns to the eligible staff member with the fewest open items.
+	LeastLoaded Strategy = "least_loaded"
+	// AgencyOnly assigns to the agency without picking a staff member.
+	AgencyOnly Strategy = "agency_only"
+)
+
+// Item is an incoming report to be routed.
+type Item struct {
+	ID           string // ID identifies the item.
+	Category     string // Category is the report category.
+	Municipality string // Municipality is where the report is located.
+	Title        string // Title is the report title.
+	Description  string // Description is the report body.
+}
+
+// Rule routes matching items to an agency. Empty criteria match any item.
+type Rule struct {
+	types.Auditable
+	Name           string   // Name identifies the rule in assignment reasons.
+	Priority       int      // Priority orders evaluation; lower values are evaluated first.
+	Categories     []string // Categories the rule applies to.
+	Municipalities []string // Municipalities the rule applies to.
+	Keywords       []string // Keywords of which at least one must appear in the title or description.
+	AgencyID       string   // AgencyID is the agency receiving matching items.
+	Strategy       Strategy // Strategy picks the staff member within the agency team.
+	Active         bool     // Active rules are the only ones evaluated.
+}
+
+// Matches reports whether the rule applies to the item.
+func (r *Rule) Matches(item Item) bool {
+	if !r.Active {
+		return false
+	}
+	if len(r.Categories) > 0 && !slices.Contains(r.Categories, item.Category) {
+		return false
+	}
+	if len(r.Municipalities) > 0 && !slices.Contains(r.Municipalities, item.Municipality) {
+		return false
+	}
+	if len(r.Keywords) == 0 {
+		return true
+	}
+	body := text.Fold(item.Title + " " + item.Description)
+	return slices.ContainsFunc(r.Keywords, func(k string) bool {
+		return strings.Contains(body, text.Fold(k))
+	})
+}
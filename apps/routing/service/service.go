@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	orgs "github.com/ianfedev/civicspot-backend/apps/organizations/service"
+	"github.com/ianfedev/civicspot-backend/apps/routing/domain"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// ErrNoRoute is returned when no active rule matches an item.
+var ErrNoRoute = transport.New(422, "no routing rule matches the item", nil)
+
+// StaffDirectory lists the officials able to handle a category in a municipality.
+// It is implemented by the organizations service.
+type StaffDirectory interface {
+	OfficialsCovering(ctx context.Context, category, municipality string, at time.Time) ([]orgs.Official, error)
+}
+
+// RoutingService assigns items to agencies and staff and keeps their assignment history.
+type RoutingService struct {
+	rules       domain.RuleRepository
+	assignments domain.AssignmentRepository
+	cursors     domain.CursorStore
+	staff       StaffDirectory
+	now         func() time.Time
+}
+
+// NewRoutingService creates a new instance of RoutingService.
+func NewRoutingService(
+	rules domain.RuleRepository,
+	assignments domain.AssignmentRepository,
+	cursors domain.CursorStore,
+	staff StaffDirectory,
+) *RoutingService {
+	return &RoutingService{rules: rules, assignments: assignments, cursors: cursors, staff: staff, now: time.Now}
+}
+
+// Route evaluates the rules by priority and assigns the item using the first match.
+// When the agency has no eligible staff the item is assigned to the agency only.
+func (s *RoutingService) Route(ctx context.Context, item domain.Item) (*domain.Assignment, error) {
+	rules, err := s.rules.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority < rules[j].Priority })
+
+	idx := slices.IndexFunc(rules, func(r domain.Rule) bool { return r.Matches(item) })
+	if idx < 0 {
+		return nil, ErrNoRoute
+	}
+	rule := rules[idx]
+
+	assignee, err := s.pick(ctx, rule, item)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &domain.Assignment{
+		ItemID:     item.ID,
+		AgencyID:   rule.AgencyID,
+		AssigneeID: assignee,
+		RuleID:     &rule.ID,
+		Reason:     "matched rule " + rule.Name,
+	}
+	if err := s.replace(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Reassign manually moves an item to another agency or staff member. A reason is required
+// and the staff member must be an official of the agency covering the item.
+func (s *RoutingService) Reassign(ctx context.Context, item domain.Item, agencyID string, assigneeID *string, by, reason string) (*domain.Assignment, error) {
+	if reason == "" {
+		return nil, transport.BadRequest("a reason is required to reassign an item")
+	}
+	if agencyID == "" {
+		return nil, transport.BadRequest("an agency is required to reassign an item")
+	}
+	if assigneeID != nil {
+		officials, err := s.staff.OfficialsCovering(ctx, item.Category, item.Municipality, s.now())
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(officials, func(o orgs.Official) bool { return o.UserID == *assigneeID && o.AgencyID == agencyID }) {
+			return nil, transport.BadRequest("the assignee is not an official of the agency covering the item")
+		}
+	}
+
+	a := &domain.Assignment{
+		ItemID:     item.ID,
+		AgencyID:   agencyID,
+		AssigneeID: assigneeID,
+		AssignedBy: &by,
+		Reason:     reason,
+	}
+	if err := s.replace(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Current returns the open assignment of an item, or nil if it was never routed.
+func (s *RoutingService) Current(ctx context.Context, itemID string) (*domain.Assignment, error) {
+	return s.assignments.Current(ctx, itemID)
+}
+
+// History returns every assignment of an item, oldest first.
+func (s *RoutingService) History(ctx context.Context, itemID string) ([]domain.Assignment, error) {
+	return s.assignments.History(ctx, itemID)
+}
+
+// pick selects the staff member according to the rule strategy.
+func (s *RoutingService) pick(ctx context.Context, rule domain.Rule, item domain.Item) (*string, error) {
+	if rule.Strategy == domain.AgencyOnly {
+		return nil, nil
+	}
+
+	officials, err := s.staff.OfficialsCovering(ctx, item.Category, item.Municipality, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	var team []string
+	for _, o := range officials {
+		if o.AgencyID == rule.AgencyID && !slices.Contains(team, o.UserID) {
+			team = append(team, o.UserID)
+		}
+	}
+	if len(team) == 0 {
+		return nil, nil
+	}
+	sort.Strings(team)
+
+	switch rule.Strategy {
+	case domain.LeastLoaded:
+		counts, err := s.assignments.OpenCounts(ctx, team)
+		if err != nil {
+			return nil, err
+		}
+		best := team[0]
+		for _, id := range team[1:] {
+			if counts[id] < counts[best] {
+				best = id
+			}
+		}
+		return &best, nil
+	default:
+		i, err := s.cursors.Next(ctx, rule.AgencyID+":"+item.Category, len(team))
+		if err != nil {
+			return nil, err
+		}
+		return &team[i], nil
+	}
+}
+
+// replace ends the current assignment of the item and stores the new one.
+func (s *RoutingService) replace(ctx context.Context, a *domain.Assignment) error {
+	now := s.now()
+
+	current, err := s.assignments.Current(ctx, a.ItemID)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		current.EndedAt = &now
+		current.UpdatedAt = now
+		if err := s.assignments.Update(ctx, current); err != nil {
+			return err
+		}
+	}
+
+	id, err := types.NewID()
+	if err != nil {
+		return err
+	}
+	a.ID = id
+	a.CreatedAt = now
+	a.UpdatedAt = now
+	return s.assignments.Create(ctx, a)
+}
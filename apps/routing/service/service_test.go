@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	orgs "github.com/ianfedev/civicspot-backend/apps/organizations/service"
+	"github.com/ianfedev/civicspot-backend/apps/routing/domain"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRules is a RuleRepository backed by a slice.
+type memoryRules []domain.Rule
+
+func (m *memoryRules) ListActive(context.Context) ([]domain.Rule, error) {
+	var out []domain.Rule
+	for _, r := range *m {
+		if r.Active {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryRules) Create(_ context.Context, r *domain.Rule) error {
+	*m = append(*m, *r)
+	return nil
+}
+
+func (m *memoryRules) Update(_ context.Context, r *domain.Rule) error {
+	for i := range *m {
+		if (*m)[i].ID == r.ID {
+			(*m)[i] = *r
+		}
+	}
+	return nil
+}
+
+// memoryAssignments is an AssignmentRepository backed by a slice, oldest first.
+type memoryAssignments []domain.Assignment
+
+func (m *memoryAssignments) Current(_ context.Context, itemID string) (*domain.Assignment, error) {
+	for _, a := range *m {
+		if a.ItemID == itemID && a.EndedAt == nil {
+			return &a, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryAssignments) History(_ context.Context, itemID string) ([]domain.Assignment, error) {
+	var out []domain.Assignment
+	for _, a := range *m {
+		if a.ItemID == itemID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryAssignments) OpenCounts(_ context.Context, userIDs []string) (map[string]int, error) {
+	out := make(map[string]int)
+	for _, a := range *m {
+		if a.EndedAt == nil && a.AssigneeID != nil {
+			out[*a.AssigneeID]++
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryAssignments) Create(_ context.Context, a *domain.Assignment) error {
+	*m = append(*m, *a)
+	return nil
+}
+
+func (m *memoryAssignments) Update(_ context.Context, a *domain.Assignment) error {
+	for i := range *m {
+		if (*m)[i].ID == a.ID {
+			(*m)[i] = *a
+		}
+	}
+	return nil
+}
+
+// memoryCursors is a CursorStore keeping positions in a map.
+type memoryCursors map[string]int
+
+func (m memoryCursors) Next(_ context.Context, key string, n int) (int, error) {
+	i := m[key] % n
+	m[key] = i + 1
+	return i, nil
+}
+
+// staticStaff is a StaffDirectory returning the same officials for every category.
+type staticStaff []orgs.Official
+
+func (s staticStaff) OfficialsCovering(context.Context, string, string, time.Time) ([]orgs.Official, error) {
+	return s, nil
+}
+
+// newTestService returns a service routing potholes of Bogotá to a two-agent mobility team.
+func newTestService(rules ...domain.Rule) (*RoutingService, *memoryAssignments) {
+	staff := staticStaff{
+		{UserID: "ana", AgencyID: "movilidad"},
+		{UserID: "luis", AgencyID: "movilidad"},
+		{UserID: "eva", AgencyID: "ambiente"},
+	}
+	assignments := &memoryAssignments{}
+	r := memoryRules(rules)
+	return NewRoutingService(&r, assignments, memoryCursors{}, staff), assignments
+}
+
+// TestRouteRuleSelection checks the highest priority matching rule wins.
+func TestRouteRuleSelection(t *testing.T) {
+	s, _ := newTestService(
+		domain.Rule{Auditable: types.Auditable{ID: "any"}, Name: "fallback", Priority: 10, AgencyID: "ambiente", Strategy: domain.AgencyOnly, Active: true},
+		domain.Rule{Auditable: types.Auditable{ID: "holes"}, Name: "huecos", Priority: 1, Categories: []string{"pothole"}, Keywords: []string{"hueco"}, AgencyID: "movilidad", Strategy: domain.AgencyOnly, Active: true},
+		domain.Rule{Auditable: types.Auditable{ID: "off"}, Name: "off", Priority: 0, AgencyID: "nadie", Active: false},
+	)
+	ctx := context.Background()
+
+	a, err := s.Route(ctx, domain.Item{ID: "i1", Category: "pothole", Municipality: "Bogotá", Title: "HUECO en la calle"})
+	require.NoError(t, err)
+	assert.Equal(t, "movilidad", a.AgencyID)
+	assert.Equal(t, "holes", *a.RuleID)
+	assert.Nil(t, a.AssigneeID)
+
+	a, err = s.Route(ctx, domain.Item{ID: "i2", Category: "pothole", Municipality: "Bogotá", Title: "Calle rota"})
+	require.NoError(t, err)
+	assert.Equal(t, "ambiente", a.AgencyID, "keywords must match")
+
+	s, _ = newTestService(domain.Rule{Name: "cali", Municipalities: []string{"Cali"}, AgencyID: "movilidad", Active: true})
+	_, err = s.Route(ctx, domain.Item{ID: "i3", Municipality: "Bogotá"})
+	assert.ErrorIs(t, err, ErrNoRoute)
+}
+
+// TestRouteStrategies checks round-robin rotation and least-loaded selection within the agency team.
+func TestRouteStrategies(t *testing.T) {
+	ctx := context.Background()
+
+	s, _ := newTestService(domain.Rule{Name: "rr", AgencyID: "movilidad", Strategy: domain.RoundRobin, Active: true})
+	var got []string
+	for _, id := range []string{"i1", "i2", "i3"} {
+		a, err := s.Route(ctx, domain.Item{ID: id, Category: "pothole"})
+		require.NoError(t, err)
+		got = append(got, *a.AssigneeID)
+	}
+	assert.Equal(t, []string{"ana", "luis", "ana"}, got, "only the agency team rotates")
+
+	s, assignments := newTestService(domain.Rule{Name: "ll", AgencyID: "movilidad", Strategy: domain.LeastLoaded, Active: true})
+	busy := "ana"
+	*assignments = append(*assignments, domain.Assignment{Auditable: types.Auditable{ID: "old"}, ItemID: "old", AgencyID: "movilidad", AssigneeID: &busy})
+	a, err := s.Route(ctx, domain.Item{ID: "i1", Category: "pothole"})
+	require.NoError(t, err)
+	assert.Equal(t, "luis", *a.AssigneeID)
+
+	s, _ = newTestService(domain.Rule{Name: "empty", AgencyID: "acueducto", Strategy: domain.RoundRobin, Active: true})
+	a, err = s.Route(ctx, domain.Item{ID: "i1", Category: "pothole"})
+	require.NoError(t, err)
+	assert.Nil(t, a.AssigneeID, "agencies without staff get the item alone")
+}
+
+// TestReassign checks manual reassignments end the current assignment and require an official of the agency.
+func TestReassign(t *testing.T) {
+	s, _ := newTestService(domain.Rule{Name: "rr", AgencyID: "movilidad", Strategy: domain.RoundRobin, Active: true})
+	ctx := context.Background()
+	item := domain.Item{ID: "i1", Category: "pothole", Municipality: "Bogotá"}
+
+	_, err := s.Route(ctx, item)
+	require.NoError(t, err)
+
+	_, err = s.Reassign(ctx, item, "ambiente", nil, "sup", "")
+	assert.Equal(t, 400, transport.CodeOf(err), "a reason is required")
+	luis := "luis"
+	_, err = s.Reassign(ctx, item, "ambiente", &luis, "sup", "wrong team")
+	assert.Equal(t, 400, transport.CodeOf(err), "the assignee must belong to the agency")
+	stranger := "stranger"
+	_, err = s.Reassign(ctx, item, "movilidad", &stranger, "sup", "unknown")
+	assert.Equal(t, 400, transport.CodeOf(err), "the assignee must cover the item")
+
+	eva := "eva"
+	a, err := s.Reassign(ctx, item, "ambiente", &eva, "sup", "tree fell on the road")
+	require.NoError(t, err)
+	assert.Equal(t, "sup", *a.AssignedBy)
+
+	current, err := s.Current(ctx, "i1")
+	require.NoError(t, err)
+	assert.Equal(t, a.ID, current.ID)
+
+	history, err := s.History(ctx, "i1")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.NotNil(t, history[0].EndedAt)
+	assert.Nil(t, history[1].EndedAt)
+}
@@ -0,0 +1,13 @@
+package domain
+
+import types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+
+// Escalation records that a breached report was brought to the attention of its agency leadership.
+type Escalation struct {
+	types.Auditable
+	ItemID     string   // ItemID is the escalated report.
+	AgencyID   string   // AgencyID is the agency responsible for the report.
+	Level      int      // Level is the escalation level (1 for supervisors, 2 for agency heads).
+	Recipients []string // Recipients are the users that were notified.
+	Reason     string   // Reason explains the escalation.
+}
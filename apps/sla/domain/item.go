@@ -0,0 +1,12 @@
+package domain
+
+import "github.com/ianfedev/civicspot-backend/pkg/common/sla"
+
+// Item is a report under service level tracking.
+type Item struct {
+	ID           string       // ID identifies the report.
+	Category     string       // Category is the report category.
+	Municipality string       // Municipality is where the report is located.
+	AgencyID     string       // AgencyID is the agency the report is assigned to.
+	Tracking     sla.Tracking // Tracking holds the report service level state.
+}
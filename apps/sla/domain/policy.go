@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+)
+
+// Policy defines the service level targets of reports. Empty criteria match any report;
+// the most specific active policy applies.
+type Policy struct {
+	types.Auditable
+	Name          string        // Name identifies the policy.
+	Category      string        // Category the policy applies to (optional).
+	AgencyID      string        // AgencyID the policy applies to (optional).
+	FirstResponse time.Duration // FirstResponse is the time allowed until the first official response.
+	Resolution    time.Duration // Resolution is the time allowed until the report is resolved.
+	BusinessHours bool          // BusinessHours counts only working hours of the business calendar.
+	Active        bool          // Active policies are the only ones applied.
+}
+
+// Matches reports whether the policy applies to the item.
+func (p *Policy) Matches(item Item) bool {
+	return p.Active &&
+		(p.Category == "" || p.Category == item.Category) &&
+		(p.AgencyID == "" || p.AgencyID == item.AgencyID)
+}
+
+// Specificity ranks matching policies: agency and category, agency, category, then default.
+func (p *Policy) Specificity() int {
+	n := 0
+	if p.AgencyID != "" {
+		n += 2
+	}
+	if p.Category != "" {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/sla"
+)
+
+// PolicyRepository defines access methods for SLA policies.
+type PolicyRepository interface {
+
+	// ListActive returns the active policies.
+	ListActive(ctx context.Context) ([]Policy, error)
+
+	// Create persists a new policy.
+	Create(ctx context.Context, p *Policy) error
+
+	// Update saves changes to a policy.
+	Update(ctx context.Context, p *Policy) error
+}
+
+// ItemRepository gives access to the tracking state of reports.
+type ItemRepository interface {
+
+	// ListDue returns unresolved items whose pending deadline is before the given time,
+	// including items already breached.
+	ListDue(ctx context.Context, before time.Time) ([]Item, error)
+
+	// SaveTracking persists the tracking state of an item.
+	SaveTracking(ctx context.Context, itemID string, t sla.Tracking) error
+}
+
+// EscalationRepository defines access methods for escalation records.
+type EscalationRepository interface {
+
+	// Latest returns the most recent escalation of an item, or nil if none.
+	Latest(ctx context.Context, itemID string) (*Escalation, error)
+
+	// Create persists a new escalation.
+	Create(ctx context.Context, e *Escalation) error
+}
+
+// Escalator notifies users about a breached report.
+type Escalator interface {
+	Escalate(ctx context.Context, e Escalation) error
+}
@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	orgdomain "github.com/ianfedev/civicspot-backend/apps/organizations/domain"
+	orgs "github.com/ianfedev/civicspot-backend/apps/organizations/service"
+	"github.com/ianfedev/civicspot-backend/apps/sla/domain"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/sla"
+)
+
+// StaffDirectory lists the officials able to handle a category in a municipality.
+// It is implemented by the organizations service.
+type StaffDirectory interface {
+	OfficialsCovering(ctx context.Context, category, municipality string, at time.Time) ([]orgs.Official, error)
+}
+
+// Config defines the SLA monitor behaviour.
+type Config struct {
+	Calendar   sla.Clock     // Calendar measures business-hours policies (default: sla.WallClock).
+	Interval   time.Duration // Interval is the time between monitor runs (default: 5 minutes).
+	Reescalate time.Duration // Reescalate is the wait before escalating to agency heads (default: 24 hours).
+	OnError    func(error)   // OnError receives monitor run failures, which are retried on the next run (optional).
+}
+
+// SLAService applies SLA policies to reports and escalates breaches.
+type SLAService struct {
+	policies    domain.PolicyRepository
+	items       domain.ItemRepository
+	escalations domain.EscalationRepository
+	staff       StaffDirectory
+	escalator   domain.Escalator
+	cfg         Config
+	now         func() time.Time
+}
+
+// NewSLAService creates a new instance of SLAService.
+func NewSLAService(
+	policies domain.PolicyRepository,
+	items domain.ItemRepository,
+	escalations domain.EscalationRepository,
+	staff StaffDirectory,
+	escalator domain.Escalator,
+	cfg Config,
+) *SLAService {
+	if cfg.Calendar == nil {
+		cfg.Calendar = sla.WallClock{}
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = 5 * time.Minute
+	}
+	if cfg.Reescalate == 0 {
+		cfg.Reescalate = 24 * time.Hour
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+	return &SLAService{
+		policies:    policies,
+		items:       items,
+		escalations: escalations,
+		staff:       staff,
+		escalator:   escalator,
+		cfg:         cfg,
+		now:         time.Now,
+	}
+}
+
+// Apply starts tracking an item with the most specific matching policy.
+// Items without a matching policy are left untracked.
+func (s *SLAService) Apply(ctx context.Context, item *domain.Item, at time.Time) (*domain.Policy, error) {
+	policies, err := s.policies.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *domain.Policy
+	for i := range policies {
+		p := &policies[i]
+		if p.Matches(*item) && (best == nil || p.Specificity() > best.Specificity()) {
+			best = p
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	targets := sla.Targets{PolicyID: best.ID, FirstResponse: best.FirstResponse, Resolution: best.Resolution}
+	if best.BusinessHours {
+		targets.Clock = s.cfg.Calendar
+	}
+	item.Tracking.Start(targets, at)
+	return best, s.items.SaveTracking(ctx, item.ID, item.Tracking)
+}
+
+// Start runs the monitor every interval until the context is cancelled.
+func (s *SLAService) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.cfg.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce re-evaluates open items, persists status changes and escalates breaches.
+// Items due within a full re-escalation window are evaluated so at-risk states stay current.
+func (s *SLAService) RunOnce(ctx context.Context) error {
+	now := s.now()
+
+	items, err := s.items.ListDue(ctx, now.Add(s.cfg.Reescalate))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range items {
+		if err := s.check(ctx, &items[i], now); err != nil {
+			errs = append(errs, fmt.Errorf("item %s: %w", items[i].ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// check evaluates one item and escalates it when needed.
+func (s *SLAService) check(ctx context.Context, item *domain.Item, now time.Time) error {
+	t := &item.Tracking
+	before := *t
+
+	if t.Evaluate(now) == sla.Breached {
+		if err := s.escalate(ctx, item, now); err != nil {
+			return err
+		}
+	}
+
+	if before.SLAStatus == t.SLAStatus && before.BreachedAt == t.BreachedAt && before.EscalationLevel == t.EscalationLevel {
+		return nil
+	}
+	return s.items.SaveTracking(ctx, item.ID, *t)
+}
+
+// escalate notifies supervisors on the first breach and agency heads once the
+// re-escalation window elapses without resolution.
+func (s *SLAService) escalate(ctx context.Context, item *domain.Item, now time.Time) error {
+	latest, err := s.escalations.Latest(ctx, item.ID)
+	if err != nil {
+		return err
+	}
+
+	level := 1
+	if latest != nil {
+		if latest.Level >= 2 || now.Sub(latest.CreatedAt) < s.cfg.Reescalate {
+			return nil
+		}
+		level = latest.Level + 1
+	}
+
+	recipients, err := s.recipients(ctx, item, level, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := types.NewID()
+	if err != nil {
+		return err
+	}
+
+	e := domain.Escalation{
+		ItemID:     item.ID,
+		AgencyID:   item.AgencyID,
+		Level:      level,
+		Recipients: recipients,
+		Reason:     "SLA breached at " + item.Tracking.BreachedAt.Format(time.RFC3339),
+	}
+	e.ID = id
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	if len(recipients) > 0 {
+		if err := s.escalator.Escalate(ctx, e); err != nil {
+			return err
+		}
+	}
+	if err := s.escalations.Create(ctx, &e); err != nil {
+		return err
+	}
+	item.Tracking.EscalationLevel = level
+	return nil
+}
+
+// recipients returns the agency staff receiving an escalation level. Supervisors
+// receive level 1 and heads level 2; when an agency has no supervisors its heads
+// receive the first level as well.
+func (s *SLAService) recipients(ctx context.Context, item *domain.Item, level int, now time.Time) ([]string, error) {
+	officials, err := s.staff.OfficialsCovering(ctx, item.Category, item.Municipality, now)
+	if err != nil {
+		return nil, err
+	}
+
+	byRole := make(map[orgdomain.StaffRole][]string)
+	for _, o := range officials {
+		if o.AgencyID == item.AgencyID {
+			byRole[o.Role] = append(byRole[o.Role], o.UserID)
+		}
+	}
+
+	if level == 1 && len(byRole[orgdomain.RoleSupervisor]) > 0 {
+		return byRole[orgdomain.RoleSupervisor], nil
+	}
+	return byRole[orgdomain.RoleHead], nil
+}
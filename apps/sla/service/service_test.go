@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	orgdomain "github.com/ianfedev/civicspot-backend/apps/organizations/domain"
+	orgs "github.com/ianfedev/civicspot-backend/apps/organizations/service"
+	"github.com/ianfedev/civicspot-backend/apps/sla/domain"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/sla"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPolicies is a PolicyRepository backed by a slice.
+type memoryPolicies []domain.Policy
+
+func (m *memoryPolicies) ListActive(context.Context) ([]domain.Policy, error) {
+	var out []domain.Policy
+	for _, p := range *m {
+		if p.Active {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryPolicies) Create(_ context.Context, p *domain.Policy) error {
+	*m = append(*m, *p)
+	return nil
+}
+
+func (m *memoryPolicies) Update(context.Context, *domain.Policy) error { return nil }
+
+// memoryItems is an ItemRepository keeping items by ID.
+type memoryItems map[string]*domain.Item
+
+func (m memoryItems) ListDue(_ context.Context, before time.Time) ([]domain.Item, error) {
+	var out []domain.Item
+	for _, it := range m {
+		due := it.Tracking.NextDue()
+		if it.Tracking.ResolvedAt == nil && (it.Tracking.SLAStatus == sla.Breached || (due != nil && due.Before(before))) {
+			out = append(out, *it)
+		}
+	}
+	return out, nil
+}
+
+func (m memoryItems) SaveTracking(_ context.Context, itemID string, t sla.Tracking) error {
+	m[itemID].Tracking = t
+	return nil
+}
+
+// memoryEscalations is an EscalationRepository backed by a slice, oldest first.
+type memoryEscalations []domain.Escalation
+
+func (m *memoryEscalations) Latest(_ context.Context, itemID string) (*domain.Escalation, error) {
+	for i := len(*m) - 1; i >= 0; i-- {
+		if e := (*m)[i]; e.ItemID == itemID {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryEscalations) Create(_ context.Context, e *domain.Escalation) error {
+	*m = append(*m, *e)
+	return nil
+}
+
+// staticStaff is a StaffDirectory returning the same officials for every category.
+type staticStaff []orgs.Official
+
+func (s staticStaff) OfficialsCovering(context.Context, string, string, time.Time) ([]orgs.Official, error) {
+	return s, nil
+}
+
+// recordingEscalator records the escalations it receives.
+type recordingEscalator []domain.Escalation
+
+func (r *recordingEscalator) Escalate(_ context.Context, e domain.Escalation) error {
+	*r = append(*r, e)
+	return nil
+}
+
+// TestApply checks the most specific active policy is applied.
+func TestApply(t *testing.T) {
+	policies := &memoryPolicies{
+		{Auditable: types.Auditable{ID: "default"}, Resolution: 72 * time.Hour, Active: true},
+		{Auditable: types.Auditable{ID: "holes"}, Category: "pothole", Resolution: 48 * time.Hour, Active: true},
+		{Auditable: types.Auditable{ID: "mov-holes"}, Category: "pothole", AgencyID: "movilidad", Resolution: 24 * time.Hour, Active: true},
+		{Auditable: types.Auditable{ID: "off"}, Category: "pothole", AgencyID: "movilidad", Resolution: time.Hour},
+	}
+	items := memoryItems{"i1": {ID: "i1", Category: "pothole", AgencyID: "movilidad"}}
+	s := NewSLAService(policies, items, &memoryEscalations{}, staticStaff{}, &recordingEscalator{}, Config{})
+	at := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	p, err := s.Apply(context.Background(), items["i1"], at)
+	require.NoError(t, err)
+	assert.Equal(t, "mov-holes", p.ID)
+	assert.Equal(t, at.Add(24*time.Hour), *items["i1"].Tracking.ResolutionDueAt)
+
+	*policies = (*policies)[1:]
+	p, err = s.Apply(context.Background(), &domain.Item{ID: "i2", Category: "graffiti"}, at)
+	require.NoError(t, err)
+	assert.Nil(t, p, "items without a matching policy are not tracked")
+}
+
+// TestEscalationLevels checks breaches go to supervisors, then to heads once the
+// re-escalation window elapses, and stop there.
+func TestEscalationLevels(t *testing.T) {
+	staff := staticStaff{
+		{UserID: "sup", AgencyID: "movilidad", Role: orgdomain.RoleSupervisor},
+		{UserID: "head", AgencyID: "movilidad", Role: orgdomain.RoleHead},
+		{UserID: "other", AgencyID: "ambiente", Role: orgdomain.RoleSupervisor},
+	}
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	item := &domain.Item{ID: "i1", Category: "pothole", AgencyID: "movilidad"}
+	item.Tracking.Start(sla.Targets{Resolution: time.Hour}, start)
+
+	escalations := &memoryEscalations{}
+	escalator := &recordingEscalator{}
+	s := NewSLAService(&memoryPolicies{}, memoryItems{"i1": item}, escalations, staff, escalator, Config{Reescalate: 24 * time.Hour})
+	ctx := context.Background()
+	run := func(at time.Time) {
+		s.now = func() time.Time { return at }
+		require.NoError(t, s.RunOnce(ctx))
+	}
+
+	run(start.Add(2 * time.Hour))
+	require.Len(t, *escalator, 1)
+	assert.Equal(t, 1, (*escalator)[0].Level)
+	assert.Equal(t, []string{"sup"}, (*escalator)[0].Recipients)
+	assert.Equal(t, sla.Breached, item.Tracking.SLAStatus)
+	assert.Equal(t, 1, item.Tracking.EscalationLevel)
+
+	run(start.Add(3 * time.Hour))
+	assert.Len(t, *escalator, 1, "no re-escalation within the window")
+
+	run(start.Add(27 * time.Hour))
+	require.Len(t, *escalator, 2)
+	assert.Equal(t, 2, (*escalator)[1].Level)
+	assert.Equal(t, []string{"head"}, (*escalator)[1].Recipients)
+	assert.Equal(t, 2, item.Tracking.EscalationLevel)
+
+	run(start.Add(72 * time.Hour))
+	assert.Len(t, *escalator, 2, "heads are the last level")
+	assert.Len(t, *escalations, 2)
+}
+
+// TestEscalationWithoutSupervisors checks heads receive the first level when the agency has no supervisors.
+func TestEscalationWithoutSupervisors(t *testing.T) {
+	staff := staticStaff{{UserID: "head", AgencyID: "movilidad", Role: orgdomain.RoleHead}}
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	item := &domain.Item{ID: "i1", AgencyID: "movilidad"}
+	item.Tracking.Start(sla.Targets{FirstResponse: time.Hour}, start)
+
+	escalator := &recordingEscalator{}
+	s := NewSLAService(&memoryPolicies{}, memoryItems{"i1": item}, &memoryEscalations{}, staff, escalator, Config{})
+	s.now = func() time.Time { return start.Add(2 * time.Hour) }
+
+	require.NoError(t, s.RunOnce(context.Background()))
+	require.Len(t, *escalator, 1)
+	assert.Equal(t, 1, (*escalator)[0].Level)
+	assert.Equal(t, []string{"head"}, (*escalator)[0].Recipients)
+}
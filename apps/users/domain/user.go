@@ -18,17 +18,39 @@ const (
 )
 
 // User contains personal and geographic information of a system-registered citizen or official.
-// It does not manage authentication or authorization concerns.
+// It does not manage authentication or authorization concerns. Persistence models
+// store the names, DocumentID and Address with the crypto "encrypted" serializer.
 type User struct {
 	types.Auditable
-	ID           string       // ID is the unique identifier of the user (UUID or ULID).
-	FirstName    string       // FirstName is the user's given name.
-	LastName     string       // LastName is the user's family name.
-	DocumentType DocumentType // DocumentType specifies the type of identification (CC, TI, etc.).
-	DocumentID   string       // DocumentID is the actual identification number (e.g., cédula).
-	City         string       // City is the city of residence of the user.
-	State        string       // State refers to the broader region or administrative division.
-	Address      string       // Address is the detailed location within the city (e.g., street address).
-	ProfilePhoto *string      // ProfilePhoto contains the URL to the user's profile picture. It is optional.
-	CreatedAt    time.Time    // CreatedAt records the timestamp when the user was first registered.
+	ID             string       // ID is the unique identifier of the user (UUID or ULID).
+	FirstName      string       // FirstName is the user's given name.
+	LastName       string       // LastName is the user's family name.
+	DocumentType   DocumentType // DocumentType specifies the type of identification (CC, TI, etc.).
+	DocumentID     string       // DocumentID is the actual identification number (e.g., cédula).
+	DocumentIndex  string       // DocumentIndex is the blind index of DocumentType and DocumentID, used for lookups.
+	City           string       // City is the city of residence of the user.
+	State          string       // State refers to the broader region or administrative division.
+	Address        string       // Address is the detailed location within the city (e.g., street address).
+	ProfilePhotoID *uint        // ProfilePhotoID references the uploaded profile picture attachment. It is optional.
+	CreatedAt      time.Time    // CreatedAt records the timestamp when the user was first registered.
+}
+
+// Anonymize replaces the personal fields with placeholders. The record is kept so
+// public content authored by the user still resolves, but no longer identifies them.
+// City and State are kept since they only locate the user within a region.
+func (u *User) Anonymize() {
+	u.FirstName = "Anónimo"
+	u.LastName = ""
+	u.DocumentID = "ANON-" + u.ID
+	u.DocumentIndex = ""
+	u.Address = ""
+	u.ProfilePhotoID = nil
+}
+
+// DocumentPurpose is the blind index purpose of user documents.
+const DocumentPurpose = "user-document"
+
+// DocumentKey returns the value indexed in DocumentIndex for a document.
+func DocumentKey(docType DocumentType, docID string) string {
+	return string(docType) + ":" + docID
 }
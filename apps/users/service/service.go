@@ -4,24 +4,29 @@ import (
 	"context"
 
 	"github.com/ianfedev/civicspot-backend/apps/users/domain"
+	"github.com/ianfedev/civicspot-backend/pkg/common/crypto"
 )
 
 // UserService defines application use cases related to the User domain.
 type UserService struct {
-	repo domain.UserRepository
+	repo  domain.UserRepository
+	index *crypto.BlindIndex
 }
 
-// NewUserService creates a new instance of UserService.
-func NewUserService(repo domain.UserRepository) *UserService {
-	return &UserService{repo: repo}
+// NewUserService creates a new instance of UserService. The index computes the
+// DocumentIndex of registered users.
+func NewUserService(repo domain.UserRepository, index *crypto.BlindIndex) *UserService {
+	return &UserService{repo: repo, index: index}
 }
 
 // RegisterIfNotExists creates a user if they don't exist by document type and ID.
 func (s *UserService) RegisterIfNotExists(ctx context.Context, u *domain.User) error {
-	existing, _ := s.repo.GetByDocument(ctx, u.DocumentType, u.DocumentID)
+	index := s.documentIndex(u.DocumentType, u.DocumentID)
+	existing, _ := s.repo.GetByDocumentIndex(ctx, index)
 	if existing != nil {
 		return nil
 	}
+	u.DocumentIndex = index
 	return s.repo.Create(ctx, u)
 }
 
@@ -30,12 +35,31 @@ func (s *UserService) GetByID(ctx context.Context, id string) (*domain.User, err
 	return s.repo.GetByID(ctx, id)
 }
 
-// GetByDocument retrieves a user by document type and document ID.
+// GetByDocument retrieves a user by document type and document ID, or nil if not found.
 func (s *UserService) GetByDocument(ctx context.Context, docType domain.DocumentType, docID string) (*domain.User, error) {
-	return s.repo.GetByDocument(ctx, docType, docID)
+	return s.repo.GetByDocumentIndex(ctx, s.documentIndex(docType, docID))
 }
 
 // Deactivate disables the user, either via soft delete or status change.
 func (s *UserService) Deactivate(ctx context.Context, id string) error {
 	return s.repo.Deactivate(ctx, id)
 }
+
+// Anonymize scrubs the personal data of the user and deactivates it, keeping the
+// record referenced by the content the user published.
+func (s *UserService) Anonymize(ctx context.Context, id string) error {
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	u.Anonymize()
+	if err := s.repo.Update(ctx, u); err != nil {
+		return err
+	}
+	return s.repo.Deactivate(ctx, id)
+}
+
+// documentIndex returns the blind index of a document.
+func (s *UserService) documentIndex(docType domain.DocumentType, docID string) string {
+	return s.index.Sum(domain.DocumentPurpose, domain.DocumentKey(docType, docID))
+}
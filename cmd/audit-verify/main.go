@@ -0,0 +1,42 @@
+// Command audit-verify walks the audit log hash chain of the configured
+// database and exits with status 1 when it finds tampering.
+//
+//	DB_DIALECT=postgres DB_DSN=... audit-verify -batch 5000
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/audit"
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+)
+
+func main() {
+	batch := flag.Int("batch", 1000, "entries read per query")
+	flag.Parse()
+
+	config.Init("", config.SetDefaults())
+	gdb, err := db.SetupEnvironmentDatabase()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "audit-verify:", err)
+		os.Exit(2)
+	}
+
+	report, err := audit.Verify(context.Background(), gdb, *batch)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "audit-verify:", err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("%d entries checked\n", report.Checked)
+	for _, p := range report.Problems {
+		fmt.Printf("entry %d: %s\n", p.Seq, p.Reason)
+	}
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,216 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/sla"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"gorm.io/gorm"
+)
+
+// Issue is the analytics read model of a report. Modules owning reports record
+// every change so aggregates never query their tables directly.
+type Issue struct {
+	tenant.Owned
+	ID           string     `gorm:"primaryKey;size:64"` // ID is the report ID.
+	Title        string     // Title is shown in rankings.
+	Category     string     `gorm:"size:64;index"` // Category is the report category.
+	Status       string     `gorm:"size:32;index"` // Status is the report workflow status.
+	Municipality string     `gorm:"size:64;index"` // Municipality is the report jurisdiction.
+	AgencyID     string     `gorm:"size:64;index"` // AgencyID is the responsible agency.
+	Votes        int        // Votes is the number of supporting votes.
+	SLAStatus    sla.Status `gorm:"size:16"` // SLAStatus is the service level state of the report.
+	CreatedAt    time.Time  `gorm:"index"`   // CreatedAt is when the report was submitted.
+	ResolvedAt   *time.Time `gorm:"index"`   // ResolvedAt is nil while the issue is open.
+	UpdatedAt    time.Time  // UpdatedAt is when the read model row last changed.
+}
+
+// TableName returns the read model table.
+func (Issue) TableName() string { return "analytics_issues" }
+
+// Migrate creates the analytics tables.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Issue{})
+}
+
+// Filter restricts the issues aggregated. Empty fields match every issue.
+type Filter struct {
+	Municipality string    // Municipality is the jurisdiction.
+	Category     string    // Category is the report category.
+	AgencyID     string    // AgencyID is the responsible agency.
+	From         time.Time // From is the first instant considered (inclusive).
+	To           time.Time // To is the last instant considered (exclusive).
+}
+
+// scope applies the dimension filters and the time range on column.
+func (f Filter) scope(column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if f.Municipality != "" {
+			db = db.Where("municipality = ?", f.Municipality)
+		}
+		if f.Category != "" {
+			db = db.Where("category = ?", f.Category)
+		}
+		if f.AgencyID != "" {
+			db = db.Where("agency_id = ?", f.AgencyID)
+		}
+		if !f.From.IsZero() {
+			db = db.Where(column+" >= ?", f.From)
+		}
+		if !f.To.IsZero() {
+			db = db.Where(column+" < ?", f.To)
+		}
+		return db
+	}
+}
+
+// Config defines the analytics cache.
+type Config struct {
+	TTL        time.Duration  // TTL bounds the staleness of cached results computed by other instances (default: 10 minutes).
+	Location   *time.Location // Location defines the bucket boundaries (default: America/Bogota).
+	MaxEntries int            // MaxEntries bounds each cache, evicting the least recently used results (default: 1000).
+}
+
+// Service computes aggregates over the issue read model and caches them per
+// tenant and filter. Recording an issue invalidates the cached results it
+// affects: only the time buckets containing its creation or resolution are recomputed.
+type Service struct {
+	db  *gorm.DB
+	cfg Config
+	now func() time.Time
+
+	mu     sync.Mutex
+	seq    uint64              // seq counts recorded changes.
+	dirty  []mark              // dirty lists the instants changed since the caches were built.
+	values *lru[cachedValue]   // values caches the non-series aggregates.
+	series *lru[*cachedSeries] // series caches time series by bucket.
+}
+
+// mark is an instant whose buckets must be recomputed.
+type mark struct {
+	seq uint64
+	at  time.Time
+}
+
+// cachedValue is a cached aggregate.
+type cachedValue struct {
+	value any
+	seq   uint64
+	at    time.Time
+}
+
+// maxDirty bounds the dirty list; beyond it every cache is dropped.
+const maxDirty = 4096
+
+// New creates a Service on the database. Run Migrate first.
+func New(db *gorm.DB, cfg Config) *Service {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 10 * time.Minute
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 1000
+	}
+	if cfg.Location == nil {
+		loc, err := time.LoadLocation("America/Bogota")
+		if err != nil {
+			loc = time.FixedZone("COT", -5*60*60)
+		}
+		cfg.Location = loc
+	}
+	return &Service{
+		db:     db,
+		cfg:    cfg,
+		now:    time.Now,
+		values: newLRU[cachedValue](cfg.MaxEntries),
+		series: newLRU[*cachedSeries](cfg.MaxEntries),
+	}
+}
+
+// Record adds or replaces an issue in the read model.
+func (s *Service) Record(ctx context.Context, issue *Issue) error {
+	if issue.ID == "" {
+		return transport.BadRequest("issue ID is required")
+	}
+
+	var prev Issue
+	err := s.db.WithContext(ctx).First(&prev, "id = ?", issue.ID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Save(issue).Error; err != nil {
+		return err
+	}
+
+	s.touch(&prev.CreatedAt, prev.ResolvedAt, &issue.CreatedAt, issue.ResolvedAt)
+	return nil
+}
+
+// Forget removes an issue from the read model.
+func (s *Service) Forget(ctx context.Context, id string) error {
+	var prev Issue
+	err := s.db.WithContext(ctx).First(&prev, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Delete(&prev).Error; err != nil {
+		return err
+	}
+
+	s.touch(&prev.CreatedAt, prev.ResolvedAt)
+	return nil
+}
+
+// touch marks the given instants as changed. Nil and zero instants are ignored.
+func (s *Service) touch(instants ...*time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	for _, t := range instants {
+		if t != nil && !t.IsZero() {
+			s.dirty = append(s.dirty, mark{seq: s.seq, at: *t})
+		}
+	}
+	if len(s.dirty) > maxDirty {
+		s.dirty = nil
+		s.series = newLRU[*cachedSeries](s.cfg.MaxEntries)
+	}
+}
+
+// cacheKey returns key qualified by the tenant of ctx, whose rows the aggregates cover.
+func cacheKey(ctx context.Context, key string) string {
+	id, _ := tenant.FromContext(ctx)
+	return id + "|" + key
+}
+
+// cached returns the cached value of key for the tenant of ctx, computing it when
+// missing, stale or invalidated by a recorded change.
+func (s *Service) cached(ctx context.Context, key string, compute func() (any, error)) (any, error) {
+	key = cacheKey(ctx, key)
+
+	s.mu.Lock()
+	c, ok := s.values.get(key)
+	seq := s.seq
+	s.mu.Unlock()
+
+	if ok && c.seq == seq && s.now().Sub(c.at) < s.cfg.TTL {
+		return c.value, nil
+	}
+
+	v, err := compute()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.values.put(key, cachedValue{value: v, seq: seq, at: s.now()})
+	s.mu.Unlock()
+	return v, nil
+}
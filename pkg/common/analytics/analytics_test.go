@@ -0,0 +1,172 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/sla"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newService returns a Service on a migrated sqlite database, with the clock on 2025-03-20.
+func newService(t *testing.T) *Service {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "analytics.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(gdb))
+
+	s := New(gdb, Config{Location: time.UTC})
+	s.now = func() time.Time { return time.Date(2025, 3, 20, 12, 0, 0, 0, time.UTC) }
+	return s
+}
+
+// at returns a time in March 2025.
+func at(day, hour int) time.Time {
+	return time.Date(2025, 3, day, hour, 0, 0, 0, time.UTC)
+}
+
+// record stores the issues of the scenario.
+func record(t *testing.T, s *Service) {
+	resolved := func(day, hour int) *time.Time {
+		r := at(day, hour)
+		return &r
+	}
+	issues := []Issue{
+		{ID: "1", Title: "Hueco", Category: "vias", Status: "resolved", Municipality: "bogota", Votes: 3, SLAStatus: sla.Met, CreatedAt: at(3, 8), ResolvedAt: resolved(3, 18)},
+		{ID: "2", Title: "Semáforo", Category: "semaforos", Status: "resolved", Municipality: "bogota", Votes: 1, SLAStatus: sla.Breached, CreatedAt: at(4, 8), ResolvedAt: resolved(6, 8)},
+		{ID: "3", Title: "Basuras", Category: "aseo", Status: "open", Municipality: "bogota", Votes: 12, SLAStatus: sla.AtRisk, CreatedAt: at(11, 8)},
+		{ID: "4", Title: "Andén", Category: "vias", Status: "open", Municipality: "bogota", Votes: 7, SLAStatus: sla.OnTrack, CreatedAt: at(12, 8)},
+		{ID: "5", Title: "Poste", Category: "vias", Status: "open", Municipality: "medellin", Votes: 30, CreatedAt: at(12, 9)},
+	}
+	for i := range issues {
+		require.NoError(t, s.Record(context.Background(), &issues[i]))
+	}
+}
+
+// TestAggregates checks counts, resolution times, compliance and rankings.
+func TestAggregates(t *testing.T) {
+	s := newService(t)
+	record(t, s)
+	ctx := context.Background()
+	bogota := Filter{Municipality: "bogota"}
+
+	counts, err := s.Counts(ctx, bogota, "status")
+	require.NoError(t, err)
+	assert.Equal(t, 4, counts.Total)
+	assert.Equal(t, []Count{{Key: "open", Value: 2}, {Key: "resolved", Value: 2}}, counts.Data)
+
+	_, err = s.Counts(ctx, bogota, "password")
+	assert.Error(t, err)
+
+	res, err := s.Resolution(ctx, bogota)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Resolved)
+	assert.Equal(t, 2, res.Open)
+	assert.InDelta(t, 29, res.Median, 1e-9)
+	assert.InDelta(t, 10, percentile([]float64{10}, 0.5), 1e-9)
+
+	comp, err := s.Compliance(ctx, bogota)
+	require.NoError(t, err)
+	assert.Equal(t, Compliance{Met: 1, Breached: 1, AtRisk: 1, Rate: 0.5}, *comp)
+
+	top, err := s.TopVoted(ctx, Filter{}, 2)
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	assert.Equal(t, "5", top[0].ID)
+	assert.Equal(t, "3", top[1].ID)
+
+	// Recording a change invalidates the cached aggregates.
+	require.NoError(t, s.Record(ctx, &Issue{ID: "6", Status: "open", Municipality: "bogota", CreatedAt: at(13, 8)}))
+	counts, err = s.Counts(ctx, bogota, "status")
+	require.NoError(t, err)
+	assert.Equal(t, 5, counts.Total)
+}
+
+// TestSeries checks bucketing and the incremental refresh of cached buckets.
+func TestSeries(t *testing.T) {
+	s := newService(t)
+	record(t, s)
+	ctx := context.Background()
+	f := Filter{Municipality: "bogota", From: at(3, 0), To: at(17, 0)}
+
+	series, err := s.Series(ctx, f, Week)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{at(3, 0), at(10, 0)}, series.Labels)
+	assert.Equal(t, 2.0, *series.Values["created"][0])
+	assert.Equal(t, 2.0, *series.Values["created"][1])
+	assert.Equal(t, 2.0, *series.Values["resolved"][0])
+	assert.InDelta(t, 29, *series.Values["median_resolution_hours"][0], 1e-9)
+	assert.Nil(t, series.Values["median_resolution_hours"][1])
+
+	// Resolving issue 3 only invalidates the buckets of its creation and resolution.
+	c, ok := s.series.get(cacheKey(ctx, "series|week|"+fmtFilter(Filter{Municipality: "bogota"})))
+	require.True(t, ok)
+	c.points[at(3, 0)] = Point{Created: 99}
+
+	resolvedAt := at(14, 8)
+	require.NoError(t, s.Record(ctx, &Issue{ID: "3", Category: "aseo", Status: "resolved", Municipality: "bogota", CreatedAt: at(11, 8), ResolvedAt: &resolvedAt}))
+
+	series, err = s.Series(ctx, f, Week)
+	require.NoError(t, err)
+	assert.Equal(t, 99.0, *series.Values["created"][0], "untouched buckets come from the cache")
+	assert.Equal(t, 1.0, *series.Values["resolved"][1])
+	assert.InDelta(t, 72, *series.Values["median_resolution_hours"][1], 1e-9)
+
+	series, err = s.Series(ctx, Filter{}, Month)
+	require.NoError(t, err)
+	assert.Len(t, series.Labels, 12)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), series.Labels[11])
+}
+
+// TestCacheKeys checks cached results are bounded and kept apart per tenant.
+func TestCacheKeys(t *testing.T) {
+	s := newService(t)
+	s.values = newLRU[cachedValue](2)
+	record(t, s)
+	ctx := context.Background()
+
+	for day := 1; day <= 5; day++ {
+		_, err := s.Counts(ctx, Filter{From: at(day, 0)}, "status")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, s.values.len(), "the least recently used results are evicted")
+
+	_, err := s.Counts(tenant.NewContext(ctx, "bogota"), Filter{From: at(5, 0)}, "status")
+	require.NoError(t, err)
+	_, ok := s.values.get(cacheKey(tenant.NewContext(ctx, "medellin"), fmt.Sprintf("counts|status|%+v", Filter{From: at(5, 0)})))
+	assert.False(t, ok, "results are cached per tenant")
+}
+
+// TestTenants checks aggregates only count the issues of the tenant of the context.
+func TestTenants(t *testing.T) {
+	s := newService(t)
+	require.NoError(t, tenant.Register(s.db))
+	bogota := tenant.NewContext(context.Background(), "bogota")
+	require.NoError(t, s.Record(bogota, &Issue{ID: "1", Category: "vias", Status: "open", CreatedAt: at(3, 8)}))
+
+	counts, err := s.Counts(tenant.NewContext(context.Background(), "cali"), Filter{}, "status")
+	require.NoError(t, err)
+	assert.Zero(t, counts.Total)
+	counts, err = s.Counts(bogota, Filter{}, "status")
+	require.NoError(t, err)
+	assert.Equal(t, 1, counts.Total)
+}
+
+// TestIntervalTruncate checks bucket boundaries.
+func TestIntervalTruncate(t *testing.T) {
+	sunday := time.Date(2025, 3, 16, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, at(10, 0), Week.Truncate(sunday, time.UTC))
+	assert.Equal(t, at(16, 0), Day.Truncate(sunday, time.UTC))
+	assert.Equal(t, at(1, 0), Month.Truncate(sunday, time.UTC))
+}
+
+// fmtFilter formats a filter as in the cache keys.
+func fmtFilter(f Filter) string {
+	return fmt.Sprintf("%+v", f)
+}
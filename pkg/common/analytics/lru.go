@@ -0,0 +1,53 @@
+package analytics
+
+import "container/list"
+
+// lru is a map evicting its least recently used keys beyond size entries.
+// It is not safe for concurrent use; the Service guards it with its mutex.
+type lru[V any] struct {
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// lruEntry is a value held by an lru.
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+// newLRU creates an lru holding up to size keys.
+func newLRU[V any](size int) *lru[V] {
+	return &lru[V]{size: size, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+// get returns the value of key and marks it as recently used.
+func (l *lru[V]) get(key string) (V, bool) {
+	el, ok := l.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	l.ll.MoveToFront(el)
+	return el.Value.(*lruEntry[V]).value, true
+}
+
+// put stores the value of key, evicting the least recently used key when full.
+func (l *lru[V]) put(key string, value V) {
+	if el, ok := l.items[key]; ok {
+		el.Value.(*lruEntry[V]).value = value
+		l.ll.MoveToFront(el)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&lruEntry[V]{key: key, value: value})
+	if l.ll.Len() > l.size {
+		back := l.ll.Back()
+		l.ll.Remove(back)
+		delete(l.items, back.Value.(*lruEntry[V]).key)
+	}
+}
+
+// len returns the number of keys held.
+func (l *lru[V]) len() int {
+	return l.ll.Len()
+}
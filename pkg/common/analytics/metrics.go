@@ -0,0 +1,238 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/sla"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// dimensions maps the grouping dimensions to their columns.
+var dimensions = map[string]string{
+	"status":       "status",
+	"category":     "category",
+	"municipality": "municipality",
+	"agency":       "agency_id",
+}
+
+// Count is the number of issues with a dimension value.
+type Count struct {
+	Key   string `json:"key"`   // Key is the dimension value.
+	Value int    `json:"value"` // Value is the number of issues.
+}
+
+// Counts is the distribution of issues over a dimension.
+type Counts struct {
+	By    string  `json:"by"`    // By is the dimension.
+	Total int     `json:"total"` // Total is the number of issues.
+	Data  []Count `json:"data"`  // Data are the counts, largest first.
+}
+
+// Resolution summarizes the time to resolve issues, in hours.
+type Resolution struct {
+	Resolved int     `json:"resolved"`     // Resolved is the number of resolved issues.
+	Open     int     `json:"open"`         // Open is the number of issues still open.
+	Median   float64 `json:"median_hours"` // Median is the median time to resolution.
+	P90      float64 `json:"p90_hours"`    // P90 is the 90th percentile time to resolution.
+	Mean     float64 `json:"mean_hours"`   // Mean is the average time to resolution.
+}
+
+// Compliance summarizes the issues closed within their service level targets.
+type Compliance struct {
+	Met      int     `json:"met"`      // Met is the number of issues resolved within their targets.
+	Breached int     `json:"breached"` // Breached is the number of issues that missed a target.
+	AtRisk   int     `json:"at_risk"`  // AtRisk is the number of open issues close to a deadline.
+	Rate     float64 `json:"rate"`     // Rate is Met over Met plus Breached, from 0 to 1.
+}
+
+// Ranked is an open issue in a ranking.
+type Ranked struct {
+	ID           string    `json:"id"`           // ID is the report ID.
+	Title        string    `json:"title"`        // Title is the report title.
+	Category     string    `json:"category"`     // Category is the report category.
+	Municipality string    `json:"municipality"` // Municipality is the report jurisdiction.
+	Votes        int       `json:"votes"`        // Votes is the number of supporting votes.
+	CreatedAt    time.Time `json:"created_at"`   // CreatedAt is when the report was submitted.
+}
+
+// Counts returns the issues created in the filter range grouped by a dimension
+// ("status", "category", "municipality" or "agency").
+func (s *Service) Counts(ctx context.Context, f Filter, by string) (*Counts, error) {
+	column, ok := dimensions[by]
+	if !ok {
+		return nil, transport.BadRequest("unknown dimension " + by)
+	}
+
+	v, err := s.cached(ctx, fmt.Sprintf("counts|%s|%+v", by, f), func() (any, error) {
+		var groups []struct {
+			Dim string
+			N   int
+		}
+		err := s.db.WithContext(ctx).Model(&Issue{}).Scopes(f.scope("created_at")).
+			Select(column + " AS dim, COUNT(*) AS n").
+			Group(column).
+			Scan(&groups).Error
+		if err != nil {
+			return nil, err
+		}
+
+		rows := make([]Count, len(groups))
+		for i, g := range groups {
+			rows[i] = Count{Key: g.Dim, Value: g.N}
+		}
+
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Value != rows[j].Value {
+				return rows[i].Value > rows[j].Value
+			}
+			return rows[i].Key < rows[j].Key
+		})
+		out := &Counts{By: by, Data: rows}
+		for _, r := range rows {
+			out.Total += r.Value
+		}
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Counts), nil
+}
+
+// Resolution returns the time to resolution of the issues resolved in the filter range.
+func (s *Service) Resolution(ctx context.Context, f Filter) (*Resolution, error) {
+	v, err := s.cached(ctx, fmt.Sprintf("resolution|%+v", f), func() (any, error) {
+		var rows []Issue
+		err := s.db.WithContext(ctx).Scopes(f.scope("resolved_at")).
+			Where("resolved_at IS NOT NULL").
+			Select("created_at", "resolved_at").
+			Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		// Open issues are counted regardless of the range, which applies to resolutions.
+		current := f
+		current.From, current.To = time.Time{}, time.Time{}
+
+		var open int64
+		err = s.db.WithContext(ctx).Model(&Issue{}).Scopes(current.scope("created_at")).
+			Where("resolved_at IS NULL").
+			Count(&open).Error
+		if err != nil {
+			return nil, err
+		}
+
+		hours := make([]float64, len(rows))
+		for i, r := range rows {
+			hours[i] = r.ResolvedAt.Sub(r.CreatedAt).Hours()
+		}
+		out := summarize(hours)
+		out.Open = int(open)
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Resolution), nil
+}
+
+// Compliance returns the service level compliance of the issues created in the filter range.
+func (s *Service) Compliance(ctx context.Context, f Filter) (*Compliance, error) {
+	v, err := s.cached(ctx, fmt.Sprintf("compliance|%+v", f), func() (any, error) {
+		var rows []struct {
+			SLAStatus sla.Status
+			N         int
+		}
+		err := s.db.WithContext(ctx).Model(&Issue{}).Scopes(f.scope("created_at")).
+			Select("sla_status, COUNT(*) AS n").
+			Group("sla_status").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		out := &Compliance{}
+		for _, r := range rows {
+			switch r.SLAStatus {
+			case sla.Met:
+				out.Met = r.N
+			case sla.Breached:
+				out.Breached = r.N
+			case sla.AtRisk:
+				out.AtRisk = r.N
+			}
+		}
+		if closed := out.Met + out.Breached; closed > 0 {
+			out.Rate = float64(out.Met) / float64(closed)
+		}
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Compliance), nil
+}
+
+// TopVoted returns the most voted open issues created in the filter range.
+func (s *Service) TopVoted(ctx context.Context, f Filter, limit int) ([]Ranked, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	v, err := s.cached(ctx, fmt.Sprintf("top|%d|%+v", limit, f), func() (any, error) {
+		var rows []Issue
+		err := s.db.WithContext(ctx).Scopes(f.scope("created_at")).
+			Where("resolved_at IS NULL").
+			Order("votes DESC").Order("created_at ASC").
+			Limit(limit).
+			Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		out := make([]Ranked, len(rows))
+		for i, r := range rows {
+			out[i] = Ranked{ID: r.ID, Title: r.Title, Category: r.Category, Municipality: r.Municipality, Votes: r.Votes, CreatedAt: r.CreatedAt}
+		}
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Ranked), nil
+}
+
+// summarize computes the resolution statistics of durations in hours.
+func summarize(hours []float64) *Resolution {
+	out := &Resolution{Resolved: len(hours)}
+	if len(hours) == 0 {
+		return out
+	}
+	sort.Float64s(hours)
+
+	var sum float64
+	for _, h := range hours {
+		sum += h
+	}
+	out.Mean = sum / float64(len(hours))
+	out.Median = percentile(hours, 0.5)
+	out.P90 = percentile(hours, 0.9)
+	return out
+}
+
+// percentile returns the p-th percentile of sorted values by linear interpolation.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := p * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
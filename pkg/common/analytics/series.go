@@ -0,0 +1,218 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// Interval is the width of a time bucket.
+type Interval string
+
+const (
+	// Day buckets start at midnight.
+	Day Interval = "day"
+	// Week buckets start on Monday at midnight.
+	Week Interval = "week"
+	// Month buckets start on the first day of the month at midnight.
+	Month Interval = "month"
+)
+
+// Truncate returns the start of the bucket containing t in loc.
+func (i Interval) Truncate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	switch i {
+	case Week:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case Month:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return day
+	}
+}
+
+// Next returns the start of the bucket following start.
+func (i Interval) Next(start time.Time) time.Time {
+	switch i {
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// valid reports whether the interval is known.
+func (i Interval) valid() bool {
+	return i == Day || i == Week || i == Month
+}
+
+// Point holds the aggregates of a bucket.
+type Point struct {
+	Created  int      // Created is the number of issues submitted in the bucket.
+	Resolved int      // Resolved is the number of issues resolved in the bucket.
+	Median   *float64 // Median is the median time to resolution of the issues resolved in the bucket, in hours.
+}
+
+// Series is a time series shaped for charting: one label per bucket and one value
+// per bucket in each series. Buckets without resolutions have a null median.
+type Series struct {
+	Interval Interval              `json:"interval"` // Interval is the bucket width.
+	Labels   []time.Time           `json:"labels"`   // Labels are the bucket starts.
+	Values   map[string][]*float64 `json:"series"`   // Values are "created", "resolved" and "median_resolution_hours".
+}
+
+// cachedSeries holds the computed buckets of a series.
+type cachedSeries struct {
+	points map[time.Time]Point
+	seq    uint64
+	at     time.Time
+}
+
+// maxBuckets bounds the length of a series.
+const maxBuckets = 400
+
+// Series returns the issues created and resolved per bucket between f.From and
+// f.To (default: the last 12 buckets). Cached buckets are reused; only buckets
+// touched by recorded changes, or never computed, are queried again.
+func (s *Service) Series(ctx context.Context, f Filter, interval Interval) (*Series, error) {
+	if interval == "" {
+		interval = Week
+	}
+	if !interval.valid() {
+		return nil, transport.BadRequest("unknown interval " + string(interval))
+	}
+
+	loc := s.cfg.Location
+	to := f.To
+	if to.IsZero() {
+		to = interval.Next(interval.Truncate(s.now(), loc))
+	}
+	from := f.From
+	if from.IsZero() {
+		from = to
+		for range 12 {
+			from = from.AddDate(0, 0, -1)
+			from = interval.Truncate(from, loc)
+		}
+	}
+
+	var labels []time.Time
+	for b := interval.Truncate(from, loc); b.Before(to); b = interval.Next(b) {
+		labels = append(labels, b)
+		if len(labels) > maxBuckets {
+			return nil, transport.BadRequest("the range has too many buckets")
+		}
+	}
+
+	dims := f
+	dims.From, dims.To = time.Time{}, time.Time{}
+	points, err := s.points(ctx, cacheKey(ctx, fmt.Sprintf("series|%s|%+v", interval, dims)), dims, interval, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Series{Interval: interval, Labels: labels, Values: map[string][]*float64{}}
+	for _, b := range labels {
+		p := points[b]
+		created, resolved := float64(p.Created), float64(p.Resolved)
+		out.Values["created"] = append(out.Values["created"], &created)
+		out.Values["resolved"] = append(out.Values["resolved"], &resolved)
+		out.Values["median_resolution_hours"] = append(out.Values["median_resolution_hours"], p.Median)
+	}
+	return out, nil
+}
+
+// points returns the buckets of a series, computing the missing and invalidated ones.
+func (s *Service) points(ctx context.Context, key string, f Filter, interval Interval, labels []time.Time) (map[time.Time]Point, error) {
+	loc := s.cfg.Location
+
+	s.mu.Lock()
+	c, ok := s.series.get(key)
+	if !ok || s.now().Sub(c.at) >= s.cfg.TTL {
+		c = &cachedSeries{points: map[time.Time]Point{}, at: s.now()}
+		s.series.put(key, c)
+	}
+	for _, m := range s.dirty {
+		if m.seq > c.seq {
+			delete(c.points, interval.Truncate(m.at, loc))
+		}
+	}
+	c.seq = s.seq
+
+	var missing []time.Time
+	for _, b := range labels {
+		if _, ok := c.points[b]; !ok {
+			missing = append(missing, b)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(missing) > 0 {
+		computed, err := s.compute(ctx, f, interval, missing[0], interval.Next(missing[len(missing)-1]))
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		for _, b := range missing {
+			c.points[b] = computed[b]
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[time.Time]Point, len(labels))
+	for _, b := range labels {
+		out[b] = c.points[b]
+	}
+	return out, nil
+}
+
+// compute aggregates the buckets between from and to from the read model.
+func (s *Service) compute(ctx context.Context, f Filter, interval Interval, from, to time.Time) (map[time.Time]Point, error) {
+	loc := s.cfg.Location
+	out := map[time.Time]Point{}
+
+	var created []time.Time
+	err := s.db.WithContext(ctx).Model(&Issue{}).Scopes(f.scope("created_at")).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Pluck("created_at", &created).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range created {
+		b := interval.Truncate(t, loc)
+		p := out[b]
+		p.Created++
+		out[b] = p
+	}
+
+	var resolved []Issue
+	err = s.db.WithContext(ctx).Scopes(f.scope("resolved_at")).
+		Where("resolved_at >= ? AND resolved_at < ?", from, to).
+		Select("created_at", "resolved_at").
+		Find(&resolved).Error
+	if err != nil {
+		return nil, err
+	}
+	hours := map[time.Time][]float64{}
+	for _, r := range resolved {
+		b := interval.Truncate(*r.ResolvedAt, loc)
+		hours[b] = append(hours[b], r.ResolvedAt.Sub(r.CreatedAt).Hours())
+	}
+	for b, h := range hours {
+		sort.Float64s(h)
+		median := percentile(h, 0.5)
+		p := out[b]
+		p.Resolved = len(h)
+		p.Median = &median
+		out[b] = p
+	}
+	return out, nil
+}
@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"gorm.io/gorm"
+)
+
+// Action is the kind of mutation audited.
+type Action string
+
+const (
+	// Create records an inserted entity.
+	Create Action = "create"
+	// Update records a modified entity.
+	Update Action = "update"
+	// Delete records a deleted entity, including soft deletes.
+	Delete Action = "delete"
+)
+
+// Change holds the values of a field before and after a mutation.
+type Change struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// Entry is an audit log record. Entries are chained: each hash covers the
+// entry and the hash of the previous one, so editing, removing or reordering
+// entries breaks the chain.
+type Entry struct {
+	Seq       uint64    `gorm:"primaryKey;autoIncrement:false" json:"seq"`        // Seq is the position of the entry in the chain, starting at 1.
+	At        time.Time `gorm:"precision:6;index" json:"at"`                      // At is when the mutation happened.
+	Actor     string    `gorm:"size:64;index" json:"actor"`                       // Actor is the user or system performing the mutation.
+	Action    Action    `gorm:"size:16" json:"action"`                            // Action is the mutation kind.
+	Entity    string    `gorm:"size:64;index:idx_audit_entity" json:"entity"`     // Entity is the table of the mutated record.
+	EntityID  string    `gorm:"size:128;index:idx_audit_entity" json:"entity_id"` // EntityID is the primary key of the mutated record.
+	RequestID string    `gorm:"size:64;index" json:"request_id,omitempty"`        // RequestID correlates the entries of a request.
+	Changes   string    `gorm:"type:text" json:"changes"`                         // Changes is the JSON object of changed fields.
+	PrevHash  string    `gorm:"size:64" json:"prev_hash"`                         // PrevHash is the hash of the previous entry.
+	Hash      string    `gorm:"size:64" json:"hash"`                              // Hash is the SHA-256 of the entry and PrevHash.
+}
+
+// TableName returns the audit log table.
+func (Entry) TableName() string { return "audit_entries" }
+
+// head is the single row tracking the end of the chain. Updating it first
+// serializes appends across connections and instances.
+type head struct {
+	ID   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Seq  uint64 // Seq is the sequence of the last entry.
+	Hash string `gorm:"size:64"` // Hash is the hash of the last entry.
+}
+
+// TableName returns the chain head table.
+func (head) TableName() string { return "audit_chain" }
+
+// Migrate creates the audit tables and, where supported, triggers rejecting
+// updates and deletes of entries.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Entry{}, &head{}); err != nil {
+		return err
+	}
+	if err := db.Where(head{ID: 1}).FirstOrCreate(&head{ID: 1}).Error; err != nil {
+		return err
+	}
+
+	var stmts []string
+	switch db.Dialector.Name() {
+	case "postgres":
+		stmts = []string{
+			`CREATE OR REPLACE FUNCTION audit_entries_append_only() RETURNS trigger AS $$
+BEGIN RAISE EXCEPTION 'audit entries are append-only'; END; $$ LANGUAGE plpgsql`,
+			`DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries`,
+			`CREATE TRIGGER audit_entries_append_only BEFORE UPDATE OR DELETE ON audit_entries
+FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only()`,
+		}
+	case "mysql":
+		for _, op := range []string{"UPDATE", "DELETE"} {
+			stmts = append(stmts, `CREATE TRIGGER IF NOT EXISTS audit_entries_no_`+op+` BEFORE `+op+` ON audit_entries
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit entries are append-only'`)
+		}
+	case "sqlite":
+		for _, op := range []string{"UPDATE", "DELETE"} {
+			stmts = append(stmts, `CREATE TRIGGER IF NOT EXISTS audit_entries_no_`+op+` BEFORE `+op+` ON audit_entries
+BEGIN SELECT RAISE(ABORT, 'audit entries are append-only'); END`)
+		}
+	}
+	for _, s := range stmts {
+		if err := db.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type actorKey struct{}
+
+type requestKey struct{}
+
+// WithActor returns a copy of ctx attributing mutations to actor, e.g., a job name.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestKey{}).(string)
+	return id
+}
+
+// Actor returns the actor of ctx: the one set by WithActor, else the
+// authenticated principal, else "system".
+func Actor(ctx context.Context) string {
+	if a, ok := ctx.Value(actorKey{}).(string); ok && a != "" {
+		return a
+	}
+	if p, ok := auth.FromContext(ctx); ok && p.Subject != "" {
+		return p.Subject
+	}
+	return "system"
+}
@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// report is an audited model with a secret and an ignored field.
+type report struct {
+	ID        uint   `gorm:"primaryKey"`
+	Title     string `gorm:"size:64"`
+	Status    string `gorm:"size:16"`
+	Phone     string `audit:"redact"`
+	Views     int    `audit:"-"`
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+// newDB returns a sqlite database with auditing registered.
+func newDB(t *testing.T) *gorm.DB {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(gdb))
+	require.NoError(t, gdb.AutoMigrate(&report{}))
+	require.NoError(t, Register(gdb, Config{}))
+	return gdb
+}
+
+// entries returns the audit log in order.
+func entries(t *testing.T, gdb *gorm.DB) []Entry {
+	var out []Entry
+	require.NoError(t, gdb.Order("seq").Find(&out).Error)
+	return out
+}
+
+// changes decodes the changes of an entry.
+func changes(t *testing.T, e Entry) map[string]Change {
+	out := map[string]Change{}
+	require.NoError(t, json.Unmarshal([]byte(e.Changes), &out))
+	return out
+}
+
+// TestRegister checks repository mutations are recorded with actor, request ID and diffs.
+func TestRegister(t *testing.T) {
+	gdb := newDB(t)
+	repo := db.NewRepository[report](gdb)
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Subject: "u1"})
+	ctx = WithRequestID(ctx, "req-1")
+
+	r := &report{Title: "Pothole", Status: "open", Phone: "3001234567"}
+	require.NoError(t, repo.Create(ctx, r))
+	r.Status = "closed"
+	r.Views = 10
+	require.NoError(t, repo.Update(ctx, r))
+	require.NoError(t, repo.Update(ctx, r), "saving without changes records nothing")
+	require.NoError(t, repo.Delete(WithActor(context.Background(), "cleanup"), r.ID))
+
+	log := entries(t, gdb)
+	require.Len(t, log, 3)
+
+	assert.Equal(t, Create, log[0].Action)
+	assert.Equal(t, "u1", log[0].Actor)
+	assert.Equal(t, "req-1", log[0].RequestID)
+	assert.Equal(t, "reports", log[0].Entity)
+	assert.Equal(t, "1", log[0].EntityID)
+	created := changes(t, log[0])
+	assert.Equal(t, "Pothole", created["title"].After)
+	assert.Equal(t, redacted, created["phone"].After)
+	assert.NotContains(t, log[0].Changes, "3001234567")
+
+	assert.Equal(t, Update, log[1].Action)
+	assert.Equal(t, map[string]Change{"status": {Before: "open", After: "closed"}}, changes(t, log[1]))
+
+	assert.Equal(t, Delete, log[2].Action)
+	assert.Equal(t, "cleanup", log[2].Actor)
+	assert.Empty(t, log[2].RequestID)
+	assert.Equal(t, "Pothole", changes(t, log[2])["title"].Before)
+
+	for i, e := range log {
+		assert.Equal(t, uint64(i+1), e.Seq)
+		if i > 0 {
+			assert.Equal(t, log[i-1].Hash, e.PrevHash)
+		}
+	}
+
+	rep, err := Verify(context.Background(), gdb, 2)
+	require.NoError(t, err)
+	assert.True(t, rep.OK(), rep.Problems)
+	assert.Equal(t, uint64(3), rep.Checked)
+}
+
+// TestBulk checks bulk updates record every affected row and failed mutations record nothing.
+func TestBulk(t *testing.T) {
+	gdb := newDB(t)
+	require.NoError(t, gdb.Create([]report{{Title: "A", Status: "open"}, {Title: "B", Status: "open"}, {Title: "C", Status: "done"}}).Error)
+	require.NoError(t, gdb.Model(&report{}).Where("status = ?", "open").Update("status", "review").Error)
+
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&report{}).Where("1 = 1").Update("title", "X").Error; err != nil {
+			return err
+		}
+		return assert.AnError
+	})
+	require.ErrorIs(t, err, assert.AnError)
+
+	log := entries(t, gdb)
+	require.Len(t, log, 5)
+	for _, e := range log[3:] {
+		assert.Equal(t, Update, e.Action)
+		assert.Equal(t, "review", changes(t, e)["status"].After)
+	}
+	assert.Equal(t, "system", log[0].Actor)
+}
+
+// TestMaxRows checks bulk mutations larger than MaxRows fail instead of going unrecorded.
+func TestMaxRows(t *testing.T) {
+	gdb := newDB(t)
+	gdb.Callback().Update().Remove("audit:before_update")
+	require.NoError(t, gdb.Callback().Update().Before("gorm:update").Register("audit:before_update", (&auditor{cfg: Config{MaxRows: 2, Now: time.Now}}).before))
+	require.NoError(t, gdb.Create([]report{{Title: "A", Status: "open"}, {Title: "B", Status: "open"}, {Title: "C", Status: "open"}}).Error)
+
+	err := gdb.Model(&report{}).Where("status = ?", "open").Update("status", "review").Error
+	assert.ErrorIs(t, err, ErrTooManyRows)
+	var n int64
+	require.NoError(t, gdb.Model(&report{}).Where("status = ?", "review").Count(&n).Error)
+	assert.Zero(t, n, "nothing is changed")
+
+	require.NoError(t, gdb.Model(&report{}).Where("title IN ?", []string{"A", "B"}).Update("status", "review").Error)
+	assert.Len(t, entries(t, gdb), 5)
+}
+
+// TestVerify checks edits, removals and truncation are detected.
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	gdb := newDB(t)
+	for _, title := range []string{"A", "B", "C", "D"} {
+		require.NoError(t, gdb.Create(&report{Title: title}).Error)
+	}
+
+	assert.Error(t, gdb.Exec("UPDATE audit_entries SET actor = 'x' WHERE seq = 2").Error, "entries are append-only")
+	assert.Error(t, gdb.Exec("DELETE FROM audit_entries WHERE seq = 2").Error, "entries are append-only")
+
+	require.NoError(t, gdb.Exec("DROP TRIGGER audit_entries_no_UPDATE").Error)
+	require.NoError(t, gdb.Exec("DROP TRIGGER audit_entries_no_DELETE").Error)
+
+	require.NoError(t, gdb.Exec("UPDATE audit_entries SET actor = 'x' WHERE seq = 2").Error)
+	rep, err := Verify(ctx, gdb, 0)
+	require.NoError(t, err)
+	require.Len(t, rep.Problems, 1)
+	assert.Equal(t, uint64(2), rep.Problems[0].Seq)
+
+	require.NoError(t, gdb.Exec("DELETE FROM audit_entries WHERE seq = 3").Error)
+	rep, err = Verify(ctx, gdb, 0)
+	require.NoError(t, err)
+	assert.Len(t, rep.Problems, 2)
+	assert.Equal(t, uint64(3), rep.Problems[1].Seq)
+
+	require.NoError(t, gdb.Exec("DELETE FROM audit_entries WHERE seq = 4").Error)
+	rep, err = Verify(ctx, gdb, 0)
+	require.NoError(t, err)
+	last := rep.Problems[len(rep.Problems)-1]
+	assert.Equal(t, uint64(4), last.Seq, "truncation is detected by the chain head")
+}
@@ -0,0 +1,288 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	commondb "github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// redacted replaces the values of sensitive fields in the changes.
+const redacted = "[redacted]"
+
+// beforeKey stores the rows loaded before an update or delete in the statement.
+const beforeKey = "audit:before"
+
+// ErrTooManyRows is returned by audited updates and deletes whose rows cannot all
+// be loaded for their before and after states within Config.MaxRows.
+var ErrTooManyRows = errors.New("audit: statement matches more rows than MaxRows")
+
+// Config defines which mutations are audited.
+type Config struct {
+	Exclude []string         // Exclude lists tables not audited, e.g., high-churn job queues.
+	MaxRows int              // MaxRows bounds the rows of a single bulk update or delete, failing larger ones (default: 1000).
+	Now     func() time.Time // Now returns the entry time (default: time.Now).
+}
+
+// auditor holds the callbacks of a database.
+type auditor struct {
+	cfg Config
+}
+
+// Register audits every create, update and delete made through GORM models on
+// db, which includes every db.Repository. Entries are appended in the
+// transaction of the mutation, so keep the default GORM transactions enabled.
+// Fields tagged `audit:"-"` are ignored and fields tagged `audit:"redact"` or
+// using the encrypted serializer are recorded without their values.
+func Register(db *gorm.DB, cfg Config) error {
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 1000
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	cfg.Exclude = append(cfg.Exclude, Entry{}.TableName(), head{}.TableName())
+	a := &auditor{cfg: cfg}
+
+	const commit = "gorm:commit_or_rollback_transaction"
+	cb := db.Callback()
+	return errorsOf(
+		cb.Create().After("gorm:create").Before(commit).Register("audit:after_create", a.afterCreate),
+		cb.Update().Before("gorm:update").Register("audit:before_update", a.before),
+		cb.Update().After("gorm:update").Before(commit).Register("audit:after_update", a.afterUpdate),
+		cb.Delete().Before("gorm:delete").Register("audit:before_delete", a.before),
+		cb.Delete().After("gorm:delete").Before(commit).Register("audit:after_delete", a.afterDelete),
+	)
+}
+
+// errorsOf returns the first non-nil error.
+func errorsOf(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// audited reports whether the statement mutates an audited model.
+func (a *auditor) audited(db *gorm.DB) bool {
+	s := db.Statement.Schema
+	return db.Error == nil && s != nil && s.PrioritizedPrimaryField != nil && !slices.Contains(a.cfg.Exclude, s.Table)
+}
+
+// before loads the rows targeted by an update or delete, failing the statement
+// with ErrTooManyRows when they exceed MaxRows.
+func (a *auditor) before(db *gorm.DB) {
+	if !a.audited(db) {
+		return
+	}
+	stmt := db.Statement
+	s := stmt.Schema
+
+	var conds []clause.Expression
+	if w, ok := stmt.Clauses["WHERE"]; ok && w.Expression != nil {
+		conds = append(conds, w.Expression)
+	}
+	if ids := commondb.PrimaryKeys(stmt.Context, s, stmt.ReflectValue); len(ids) > 0 {
+		conds = append(conds, clause.IN{Column: clause.Column{Name: s.PrioritizedPrimaryField.DBName}, Values: ids})
+	}
+	if len(conds) == 0 {
+		return
+	}
+
+	q := db.Session(&gorm.Session{NewDB: true}).Clauses(conds...).Limit(a.cfg.MaxRows + 1)
+	if stmt.Unscoped {
+		q = q.Unscoped()
+	}
+	rows := reflect.New(reflect.SliceOf(s.ModelType))
+	if err := q.Find(rows.Interface()).Error; err != nil {
+		_ = db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	if rows.Elem().Len() > a.cfg.MaxRows {
+		_ = db.AddError(ErrTooManyRows)
+		return
+	}
+	db.InstanceSet(beforeKey, rows.Elem())
+}
+
+// afterCreate records the created rows.
+func (a *auditor) afterCreate(db *gorm.DB) {
+	if !a.audited(db) || db.Statement.RowsAffected == 0 {
+		return
+	}
+	stmt := db.Statement
+	rv := reflect.Indirect(stmt.ReflectValue)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		a.record(db, Create, rv, nil, snapshot(stmt.Context, stmt.Schema, rv))
+		return
+	}
+	for i := 0; i < rv.Len(); i++ {
+		row := reflect.Indirect(rv.Index(i))
+		a.record(db, Create, row, nil, snapshot(stmt.Context, stmt.Schema, row))
+	}
+}
+
+// afterUpdate records the changes of the rows loaded before the update.
+func (a *auditor) afterUpdate(db *gorm.DB) {
+	rows, ok := a.loaded(db)
+	if !ok {
+		return
+	}
+	stmt := db.Statement
+	s := stmt.Schema
+
+	ids := commondb.PrimaryKeys(stmt.Context, s, rows)
+	after := reflect.New(reflect.SliceOf(s.ModelType))
+	err := db.Session(&gorm.Session{NewDB: true}).Unscoped().
+		Where(clause.IN{Column: clause.Column{Name: s.PrioritizedPrimaryField.DBName}, Values: ids}).
+		Find(after.Interface()).Error
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+
+	current := map[string]reflect.Value{}
+	for i := 0; i < after.Elem().Len(); i++ {
+		row := after.Elem().Index(i)
+		current[entityID(stmt.Context, s, row)] = row
+	}
+	for i := 0; i < rows.Len(); i++ {
+		old := rows.Index(i)
+		row, ok := current[entityID(stmt.Context, s, old)]
+		if !ok {
+			continue
+		}
+		a.record(db, Update, row, snapshot(stmt.Context, s, old), snapshot(stmt.Context, s, row))
+	}
+}
+
+// afterDelete records the rows loaded before the delete.
+func (a *auditor) afterDelete(db *gorm.DB) {
+	rows, ok := a.loaded(db)
+	if !ok {
+		return
+	}
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		a.record(db, Delete, row, snapshot(db.Statement.Context, db.Statement.Schema, row), nil)
+	}
+}
+
+// loaded returns the rows stored by before when the mutation succeeded.
+func (a *auditor) loaded(db *gorm.DB) (reflect.Value, bool) {
+	if !a.audited(db) || db.Statement.RowsAffected == 0 {
+		return reflect.Value{}, false
+	}
+	v, ok := db.InstanceGet(beforeKey)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	rows := v.(reflect.Value)
+	return rows, rows.Len() > 0
+}
+
+// record appends the entry of a mutated row, unless nothing changed.
+func (a *auditor) record(db *gorm.DB, action Action, row reflect.Value, before, after map[string]any) {
+	s := db.Statement.Schema
+	changes := diff(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	for column, c := range changes {
+		if f := s.LookUpField(column); f != nil && sensitive(f) {
+			changes[column] = Change{Before: redact(c.Before), After: redact(c.After)}
+		}
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+
+	ctx := db.Statement.Context
+	e := &Entry{
+		At:        a.cfg.Now(),
+		Actor:     Actor(ctx),
+		Action:    action,
+		Entity:    s.Table,
+		EntityID:  entityID(ctx, s, row),
+		RequestID: RequestID(ctx),
+		Changes:   string(data),
+	}
+	if err := Append(db.Session(&gorm.Session{NewDB: true}), e); err != nil {
+		_ = db.AddError(fmt.Errorf("audit: %w", err))
+	}
+}
+
+// Diff returns the audited fields whose values differ between two rows of a
+// schema, without redacting sensitive fields.
+func Diff(ctx context.Context, s *schema.Schema, before, after reflect.Value) map[string]Change {
+	return diff(snapshot(ctx, s, before), snapshot(ctx, s, after))
+}
+
+// snapshot returns the non-zero audited fields of a row by column.
+func snapshot(ctx context.Context, s *schema.Schema, row reflect.Value) map[string]any {
+	out := map[string]any{}
+	for _, f := range s.Fields {
+		if f.DBName == "" || f.Tag.Get("audit") == "-" || f.AutoUpdateTime > 0 {
+			continue
+		}
+		if v, zero := f.ValueOf(ctx, row); !zero {
+			out[f.DBName] = v
+		}
+	}
+	return out
+}
+
+// sensitive reports whether the values of a field must not be recorded.
+func sensitive(f *schema.Field) bool {
+	return f.Tag.Get("audit") == "redact" || strings.EqualFold(f.TagSettings["SERIALIZER"], "encrypted")
+}
+
+// redact hides a value, keeping whether it was set.
+func redact(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redacted
+}
+
+// diff returns the fields whose values differ between two snapshots.
+func diff(before, after map[string]any) map[string]Change {
+	out := map[string]Change{}
+	for k, v := range before {
+		if !equal(v, after[k]) {
+			out[k] = Change{Before: v, After: after[k]}
+		}
+	}
+	for k, v := range after {
+		if _, ok := before[k]; !ok {
+			out[k] = Change{After: v}
+		}
+	}
+	return out
+}
+
+// equal compares two values by their JSON form.
+func equal(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+// entityID returns the primary key of a row as text.
+func entityID(ctx context.Context, s *schema.Schema, row reflect.Value) string {
+	v, _ := s.PrioritizedPrimaryField.ValueOf(ctx, reflect.Indirect(row))
+	return fmt.Sprint(v)
+}
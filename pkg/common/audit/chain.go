@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotMigrated is returned when appending before Migrate created the chain head.
+var ErrNotMigrated = errors.New("audit: chain head missing, run audit.Migrate")
+
+// Append adds an entry at the end of the chain, setting its sequence and hashes.
+// The chain head is updated first, which locks it until the transaction of tx
+// ends, so concurrent appends cannot fork the chain.
+func Append(tx *gorm.DB, e *Entry) error {
+	res := tx.Exec("UPDATE audit_chain SET seq = seq + 1 WHERE id = 1")
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotMigrated
+	}
+	var h head
+	if err := tx.Take(&h, 1).Error; err != nil {
+		return err
+	}
+
+	e.Seq = h.Seq
+	e.PrevHash = h.Hash
+	e.At = e.At.UTC().Truncate(time.Microsecond)
+	e.Hash = hash(e)
+	if err := tx.Create(e).Error; err != nil {
+		return err
+	}
+	return tx.Model(&head{}).Where("id = 1").Update("hash", e.Hash).Error
+}
+
+// hash returns the hash of the entry content and its previous hash.
+func hash(e *Entry) string {
+	data, _ := json.Marshal([]any{
+		e.Seq, e.At.UTC().Format(time.RFC3339Nano), e.Actor, e.Action,
+		e.Entity, e.EntityID, e.RequestID, e.Changes, e.PrevHash,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Problem is an inconsistency found in the chain.
+type Problem struct {
+	Seq    uint64 `json:"seq"`    // Seq is the entry where the chain breaks.
+	Reason string `json:"reason"` // Reason describes the inconsistency.
+}
+
+// Report is the outcome of a chain verification.
+type Report struct {
+	Checked  uint64    `json:"checked"`  // Checked is the number of entries verified.
+	Problems []Problem `json:"problems"` // Problems lists every inconsistency found.
+}
+
+// OK reports whether the chain is intact.
+func (r *Report) OK() bool { return len(r.Problems) == 0 }
+
+// Verify walks the chain in batches and reports modified, missing, inserted or
+// truncated entries.
+func Verify(ctx context.Context, db *gorm.DB, batch int) (*Report, error) {
+	if batch <= 0 {
+		batch = 1000
+	}
+	db = db.WithContext(ctx)
+	r := &Report{}
+
+	var last uint64
+	prev := ""
+	for {
+		var entries []Entry
+		if err := db.Where("seq > ?", last).Order("seq").Limit(batch).Find(&entries).Error; err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			e := &entries[i]
+			switch {
+			case e.Seq != last+1:
+				r.Problems = append(r.Problems, Problem{Seq: last + 1, Reason: fmt.Sprintf("entries %d to %d are missing", last+1, e.Seq-1)})
+			case e.PrevHash != prev:
+				r.Problems = append(r.Problems, Problem{Seq: e.Seq, Reason: "previous hash does not match the previous entry"})
+			}
+			if hash(e) != e.Hash {
+				r.Problems = append(r.Problems, Problem{Seq: e.Seq, Reason: "entry content does not match its hash"})
+			}
+			last, prev = e.Seq, e.Hash
+			r.Checked++
+		}
+		if len(entries) < batch {
+			break
+		}
+	}
+
+	var h head
+	if err := db.Take(&h, 1).Error; err != nil {
+		return nil, err
+	}
+	switch {
+	case h.Seq != last:
+		r.Problems = append(r.Problems, Problem{Seq: h.Seq, Reason: fmt.Sprintf("the chain ends at %d but its head is at %d", last, h.Seq)})
+	case h.Hash != prev:
+		r.Problems = append(r.Problems, Problem{Seq: h.Seq, Reason: "the last entry does not match the chain head"})
+	}
+	return r, nil
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// BearerToken extracts the token of an "Authorization: Bearer <token>" header.
+func BearerToken(c *fiber.Ctx) string {
+	header := c.Get(fiber.HeaderAuthorization)
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Middleware returns a fiber handler validating the bearer token and storing the
+// principal in the request user context. When required is false, requests without
+// token continue anonymously, but invalid tokens are always rejected.
+func Middleware(v *Validator, required bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := BearerToken(c)
+		if token == "" && !required {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		p, err := v.Validate(ctx, token)
+		if err != nil {
+			return c.Status(transport.CodeOf(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		c.SetUserContext(NewContext(ctx, p))
+		return c.Next()
+	}
+}
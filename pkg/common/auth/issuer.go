@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"maps"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IssuerConfig defines how access tokens are signed.
+type IssuerConfig struct {
+	Method   jwt.SigningMethod // Method is the signing algorithm, e.g. jwt.SigningMethodHS256.
+	Key      any               // Key is the signing key: []byte, *rsa.PrivateKey or ed25519.PrivateKey.
+	KeyID    string            // KeyID is written to the "kid" header (optional).
+	Issuer   string            // Issuer is written to the "iss" claim (optional).
+	Audience string            // Audience is written to the "aud" claim (optional).
+	TTL      time.Duration     // TTL is the token lifetime.
+}
+
+// Issuer signs access tokens understood by the Validator.
+type Issuer struct {
+	cfg IssuerConfig
+	now func() time.Time
+}
+
+// NewIssuer creates an Issuer with the given configuration.
+func NewIssuer(cfg IssuerConfig) *Issuer {
+	return &Issuer{cfg: cfg, now: time.Now}
+}
+
+// Issue signs a token for the subject with the given roles and extra claims.
+// It returns the token and its expiration time.
+func (i *Issuer) Issue(subject string, roles []string, extra map[string]any) (string, time.Time, error) {
+	now := i.now()
+	exp := now.Add(i.cfg.TTL)
+
+	claims := jwt.MapClaims{}
+	maps.Copy(claims, extra)
+	claims["sub"] = subject
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = exp.Unix()
+	if len(roles) > 0 {
+		claims["roles"] = roles
+	}
+	if i.cfg.Issuer != "" {
+		claims["iss"] = i.cfg.Issuer
+	}
+	if i.cfg.Audience != "" {
+		claims["aud"] = i.cfg.Audience
+	}
+
+	tok := jwt.NewWithClaims(i.cfg.Method, claims)
+	if i.cfg.KeyID != "" {
+		tok.Header["kid"] = i.cfg.KeyID
+	}
+
+	signed, err := tok.SignedString(i.cfg.Key)
+	return signed, exp, err
+}
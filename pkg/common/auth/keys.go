@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound is returned when no key matches the token key ID and algorithm.
+var ErrKeyNotFound = errors.New("auth: signing key not found")
+
+// Key is a verification key usable for a family of algorithms.
+type Key struct {
+	ID       string // ID matches the token "kid" header; empty matches tokens without kid.
+	Material any    // Material is []byte (HMAC), *rsa.PublicKey or ed25519.PublicKey.
+}
+
+// KeySet resolves the verification key of a token.
+type KeySet interface {
+	Key(ctx context.Context, kid, alg string) (any, error)
+}
+
+// staticKeySet is an immutable list of keys.
+type staticKeySet []Key
+
+// NewStaticKeySet returns a KeySet holding the given keys.
+func NewStaticKeySet(keys ...Key) KeySet {
+	return staticKeySet(keys)
+}
+
+// Key returns the first key matching kid whose material fits the algorithm.
+func (s staticKeySet) Key(_ context.Context, kid, alg string) (any, error) {
+	return findKey(s, kid, alg)
+}
+
+// findKey looks up a key by ID and algorithm family.
+func findKey(keys []Key, kid, alg string) (any, error) {
+	for _, k := range keys {
+		if k.ID == kid && fitsAlgorithm(k.Material, alg) {
+			return k.Material, nil
+		}
+	}
+	// Tokens without kid match any key of the right family when the set is unambiguous.
+	if kid == "" {
+		var match []any
+		for _, k := range keys {
+			if fitsAlgorithm(k.Material, alg) {
+				match = append(match, k.Material)
+			}
+		}
+		if len(match) == 1 {
+			return match[0], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: kid=%q alg=%s", ErrKeyNotFound, kid, alg)
+}
+
+// fitsAlgorithm reports whether the key material can verify the algorithm.
+func fitsAlgorithm(material any, alg string) bool {
+	switch material.(type) {
+	case []byte:
+		return strings.HasPrefix(alg, "HS")
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	default:
+		return false
+	}
+}
+
+// jwk is the JSON representation of a single JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS parses a JSON Web Key Set with RSA, Ed25519 (OKP) and symmetric (oct) keys.
+// Keys of unsupported types or meant for encryption are ignored.
+func ParseJWKS(data []byte) ([]Key, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("auth: invalid JWKS: %w", err)
+	}
+
+	var out []Key
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		material, err := k.material()
+		if err != nil {
+			return nil, fmt.Errorf("auth: key %q: %w", k.Kid, err)
+		}
+		if material != nil {
+			out = append(out, Key{ID: k.Kid, Material: material})
+		}
+	}
+	return out, nil
+}
+
+// material decodes the key material, returning nil for unsupported key types.
+func (k jwk) material() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return decodeSegment(k.K)
+	default:
+		return nil, nil
+	}
+}
+
+// decodeSegment decodes unpadded base64url data.
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// LoadJWKSFile reads a JWKS document from the local file system.
+func LoadJWKSFile(path string) (KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticKeySet(keys...), nil
+}
+
+// remoteKeySet fetches a JWKS document over HTTP and caches it.
+type remoteKeySet struct {
+	url      string
+	client   *http.Client
+	ttl      time.Duration
+	mu       sync.Mutex
+	keys     []Key
+	fetched  time.Time
+	now      func() time.Time
+	minFetch time.Duration
+}
+
+// NewRemoteKeySet returns a KeySet backed by a JWKS URL. Keys are refreshed after ttl,
+// and on unknown key IDs at most once per minute to pick up rotations.
+func NewRemoteKeySet(url string, client *http.Client, ttl time.Duration) KeySet {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &remoteKeySet{url: url, client: client, ttl: ttl, now: time.Now, minFetch: time.Minute}
+}
+
+// Key returns the key matching kid and alg, refreshing the cached set when needed.
+func (r *remoteKeySet) Key(ctx context.Context, kid, alg string) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	age := r.now().Sub(r.fetched)
+	if r.keys == nil || age > r.ttl {
+		if err := r.refresh(ctx); err != nil {
+			return nil, err
+		}
+		return findKey(r.keys, kid, alg)
+	}
+
+	key, err := findKey(r.keys, kid, alg)
+	if errors.Is(err, ErrKeyNotFound) && age > r.minFetch {
+		if err := r.refresh(ctx); err != nil {
+			return nil, err
+		}
+		return findKey(r.keys, kid, alg)
+	}
+	return key, err
+}
+
+// refresh downloads the JWKS document.
+func (r *remoteKeySet) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth: fetching JWKS: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth: fetching JWKS: status %d", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	r.keys = keys
+	r.fetched = r.now()
+	return nil
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+
+	gk "github.com/go-kit/kit/endpoint"
+)
+
+// NewParser returns endpoint middleware validating the bearer token stored with
+// ContextWithToken and injecting its principal into the context.
+// Requests without token continue anonymously; combine with Required to reject them.
+func NewParser(v *Validator) gk.Middleware {
+	return func(next gk.Endpoint) gk.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			token, ok := TokenFromContext(ctx)
+			if !ok {
+				return next(ctx, request)
+			}
+			p, err := v.Validate(ctx, token)
+			if err != nil {
+				return nil, err
+			}
+			return next(NewContext(ctx, p), request)
+		}
+	}
+}
+
+// Required returns endpoint middleware rejecting requests without an authenticated principal.
+func Required() gk.Middleware {
+	return func(next gk.Endpoint) gk.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if _, ok := FromContext(ctx); !ok {
+				return nil, ErrMissingToken
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Principal identifies the authenticated caller of a request.
+type Principal struct {
+	Subject string         // Subject is the user ID carried in the token "sub" claim.
+	Roles   []string       // Roles are the roles granted to the caller.
+	Claims  map[string]any // Claims holds every claim of the validated token.
+}
+
+// HasRole reports whether the principal was granted role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+type principalKey struct{}
+
+type tokenKey struct{}
+
+// NewContext returns a copy of ctx carrying the principal.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// ContextWithToken returns a copy of ctx carrying a raw bearer token for endpoint middleware.
+func ContextWithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the raw bearer token carried by ctx, if any.
+func TokenFromContext(ctx context.Context) (string, bool) {
+	t, ok := ctx.Value(tokenKey{}).(string)
+	return t, ok && t != ""
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+)
+
+// SetupEnvironmentValidator creates a token validator from the provided environment.
+// JWT_JWKS accepts a local file path or an http(s) URL; JWT_SECRET is used when it is empty.
+func SetupEnvironmentValidator() (*Validator, error) {
+
+	cfg := config.Get()
+	jwks := cfg.GetString(config.JwtJwks)
+	secret := cfg.GetString(config.JwtSecret)
+
+	var keys KeySet
+	switch {
+	case strings.HasPrefix(jwks, "http://") || strings.HasPrefix(jwks, "https://"):
+		keys = NewRemoteKeySet(jwks, nil, time.Hour)
+	case jwks != "":
+		set, err := LoadJWKSFile(jwks)
+		if err != nil {
+			return nil, err
+		}
+		keys = set
+	case secret != "":
+		keys = NewStaticKeySet(Key{Material: []byte(secret)})
+	default:
+		return nil, errors.New("auth: either JWT_JWKS or JWT_SECRET must be configured")
+	}
+
+	return NewValidator(ValidatorConfig{
+		Keys:       keys,
+		Algorithms: strings.Split(config.MustGet(config.JwtAlgorithms), ","),
+		Issuer:     cfg.GetString(config.JwtIssuer),
+		Audience:   cfg.GetString(config.JwtAudience),
+	}), nil
+
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// ErrMissingToken is returned when a request carries no bearer token.
+var ErrMissingToken = transport.Unauthorized("missing bearer token")
+
+// ValidatorConfig defines the token validation rules.
+type ValidatorConfig struct {
+	Keys       KeySet        // Keys resolves verification keys.
+	Algorithms []string      // Algorithms allowed (default: HS256, RS256, EdDSA).
+	Issuer     string        // Issuer is the expected "iss" claim (optional).
+	Audience   string        // Audience is the expected "aud" claim (optional).
+	Leeway     time.Duration // Leeway tolerates clock skew on time-based claims.
+}
+
+// Validator validates JWTs and turns them into principals.
+type Validator struct {
+	cfg ValidatorConfig
+}
+
+// NewValidator creates a Validator with the given configuration.
+func NewValidator(cfg ValidatorConfig) *Validator {
+	if len(cfg.Algorithms) == 0 {
+		cfg.Algorithms = []string{"HS256", "RS256", "EdDSA"}
+	}
+	return &Validator{cfg: cfg}
+}
+
+// Validate verifies the token signature and claims and returns its principal.
+// Roles are read from the "roles" claim.
+func (v *Validator) Validate(ctx context.Context, token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(v.cfg.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.cfg.Leeway),
+	}
+	if v.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.cfg.Issuer))
+	}
+	if v.cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.cfg.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.NewParser(opts...).ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.cfg.Keys.Key(ctx, kid, t.Method.Alg())
+	})
+	if err != nil {
+		return nil, transport.New(401, "invalid bearer token", err)
+	}
+
+	sub, _ := claims.GetSubject()
+	p := &Principal{Subject: sub, Claims: claims}
+	if roles, ok := claims["roles"].([]any); ok {
+		for _, r := range roles {
+			if s, ok := r.(string); ok {
+				p.Roles = append(p.Roles, s)
+			}
+		}
+	}
+	return p, nil
+}
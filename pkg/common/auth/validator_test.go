@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sign creates a token with the given method, key and expiration offset.
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, ttl time.Duration) string {
+	tok := jwt.NewWithClaims(method, jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   "civicspot",
+		"exp":   time.Now().Add(ttl).Unix(),
+		"roles": []string{"citizen"},
+	})
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+// TestValidateHS256 covers a valid token, an expired token and a wrong secret.
+func TestValidateHS256(t *testing.T) {
+	v := NewValidator(ValidatorConfig{Keys: NewStaticKeySet(Key{Material: []byte("secret")}), Issuer: "civicspot"})
+	ctx := context.Background()
+
+	p, err := v.Validate(ctx, sign(t, jwt.SigningMethodHS256, []byte("secret"), "", time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", p.Subject)
+	assert.True(t, p.HasRole("citizen"))
+
+	_, err = v.Validate(ctx, sign(t, jwt.SigningMethodHS256, []byte("secret"), "", -time.Minute))
+	assert.Equal(t, 401, transport.CodeOf(err))
+
+	_, err = v.Validate(ctx, sign(t, jwt.SigningMethodHS256, []byte("other"), "", time.Minute))
+	assert.Error(t, err)
+
+	_, err = v.Validate(ctx, "")
+	assert.ErrorIs(t, err, ErrMissingToken)
+}
+
+// TestValidateJWKS verifies RS256 keys from a JWKS file and EdDSA keys from a JWKS URL.
+func TestValidateJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	rsaJWKS := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":"r1","use":"sig","n":%q,"e":%q}]}`,
+		b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()))
+	edJWKS := fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"e1","x":%q}]}`, b64(edPub))
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(rsaJWKS), 0o600))
+	fileKeys, err := LoadJWKSFile(path)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(edJWKS))
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+
+	p, err := NewValidator(ValidatorConfig{Keys: fileKeys}).
+		Validate(ctx, sign(t, jwt.SigningMethodRS256, rsaKey, "r1", time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", p.Subject)
+
+	remote := NewValidator(ValidatorConfig{Keys: NewRemoteKeySet(srv.URL, srv.Client(), time.Hour)})
+	_, err = remote.Validate(ctx, sign(t, jwt.SigningMethodEdDSA, edPriv, "e1", time.Minute))
+	require.NoError(t, err)
+
+	_, err = remote.Validate(ctx, sign(t, jwt.SigningMethodEdDSA, edPriv, "unknown", time.Minute))
+	assert.Error(t, err)
+
+	// An RSA token must not validate against an algorithm outside the allow list.
+	_, err = NewValidator(ValidatorConfig{Keys: fileKeys, Algorithms: []string{"EdDSA"}}).
+		Validate(ctx, sign(t, jwt.SigningMethodRS256, rsaKey, "r1", time.Minute))
+	assert.Error(t, err)
+}
+
+// TestMiddleware checks the fiber middleware in optional and required modes.
+func TestMiddleware(t *testing.T) {
+	v := NewValidator(ValidatorConfig{Keys: NewStaticKeySet(Key{Material: []byte("secret")})})
+	token := sign(t, jwt.SigningMethodHS256, []byte("secret"), "", time.Minute)
+
+	app := fiber.New()
+	whoami := func(c *fiber.Ctx) error {
+		p, ok := FromContext(c.UserContext())
+		if !ok {
+			return c.SendString("anonymous")
+		}
+		return c.SendString(p.Subject)
+	}
+	app.Get("/optional", Middleware(v, false), whoami)
+	app.Get("/required", Middleware(v, true), whoami)
+
+	cases := []struct {
+		path, token string
+		status      int
+	}{
+		{"/optional", "", 200},
+		{"/optional", token, 200},
+		{"/optional", "garbage", 401},
+		{"/required", "", 401},
+		{"/required", token, 200},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, tc.status, res.StatusCode, "%s with token %q", tc.path, tc.token)
+	}
+}
+
+// TestEndpointMiddleware checks the go-kit parser and required middleware.
+func TestEndpointMiddleware(t *testing.T) {
+	v := NewValidator(ValidatorConfig{Keys: NewStaticKeySet(Key{Material: []byte("secret")})})
+	next := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		p, _ := FromContext(ctx)
+		return p.Subject, nil
+	}
+	ep := NewParser(v)(Required()(next))
+
+	_, err := ep(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrMissingToken)
+
+	ctx := ContextWithToken(context.Background(), sign(t, jwt.SigningMethodHS256, []byte("secret"), "", time.Minute))
+	sub, err := ep(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", sub)
+}
+
+// TestIssuerRoundTrip verifies issued tokens validate with the matching key.
+func TestIssuerRoundTrip(t *testing.T) {
+	key := []byte("secret")
+	iss := NewIssuer(IssuerConfig{Method: jwt.SigningMethodHS256, Key: key, Issuer: "civicspot", TTL: time.Minute})
+	token, exp, err := iss.Issue("user-9", []string{"official"}, map[string]any{"jurisdiction": "bogota"})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), exp, 2*time.Second)
+
+	p, err := NewValidator(ValidatorConfig{Keys: NewStaticKeySet(Key{Material: key}), Issuer: "civicspot"}).
+		Validate(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-9", p.Subject)
+	assert.True(t, p.HasRole("official"))
+	assert.Equal(t, "bogota", p.Claims["jurisdiction"])
+}
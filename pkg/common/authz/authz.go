@@ -0,0 +1,163 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+)
+
+// Permission names an action on a resource type, e.g. "reports:transition".
+type Permission string
+
+// Effect is the outcome a policy produces when it matches.
+type Effect string
+
+const (
+	// Allow grants the action when the policy matches.
+	Allow Effect = "allow"
+	// Deny forbids the action when the policy matches, overriding any allow.
+	Deny Effect = "deny"
+)
+
+// Role groups permissions granted unconditionally to its members.
+type Role struct {
+	Name        string       // Name is the role identifier carried in the principal roles.
+	Permissions []Permission // Permissions are granted to every member of the role.
+	Inherits    []string     // Inherits lists roles whose permissions are also granted.
+}
+
+// Resource describes the target of an authorization request.
+type Resource struct {
+	Type       string         // Type is the resource kind, e.g. "reports".
+	ID         string         // ID identifies the resource instance (optional).
+	OwnerID    string         // OwnerID is the user owning the resource (optional).
+	Attributes map[string]any // Attributes are used by attribute-based conditions.
+}
+
+// Request is a question asked to the Enforcer.
+type Request struct {
+	Principal *auth.Principal // Principal is the caller; nil for anonymous requests.
+	Action    Permission      // Action is the permission being exercised.
+	Resource  Resource        // Resource is the target of the action.
+}
+
+// Condition decides whether a policy applies to a request.
+type Condition func(ctx context.Context, req Request) bool
+
+// Policy grants or denies actions to roles under a condition.
+type Policy struct {
+	Name      string       // Name identifies the policy in decision logs.
+	Effect    Effect       // Effect is applied when the policy matches.
+	Actions   []Permission // Actions the policy applies to.
+	Roles     []string     // Roles the policy applies to; empty means any authenticated principal.
+	Condition Condition    // Condition restricts the policy further (optional).
+}
+
+// Decision is the result of evaluating a request.
+type Decision struct {
+	Allowed   bool       // Allowed reports whether the action is permitted.
+	Policy    string     // Policy is the deciding policy, or "role:<name>" for role grants.
+	Reason    string     // Reason explains the decision.
+	Subject   string     // Subject is the principal subject, empty for anonymous requests.
+	Action    Permission // Action is the requested permission.
+	Resource  Resource   // Resource is the requested resource.
+	Timestamp time.Time  // Timestamp is when the decision was taken.
+}
+
+// IsOwner is a Condition matching when the principal owns the resource.
+func IsOwner(_ context.Context, req Request) bool {
+	return req.Principal != nil && req.Resource.OwnerID != "" && req.Principal.Subject == req.Resource.OwnerID
+}
+
+// AttributeEquals returns a Condition matching when the resource attribute
+// equals the principal claim. Numbers match regardless of their type, and a
+// list on either side matches when it holds the other value or shares one
+// with the other list, e.g., a claim listing every jurisdiction of an official.
+func AttributeEquals(attribute, claim string) Condition {
+	return func(_ context.Context, req Request) bool {
+		if req.Principal == nil {
+			return false
+		}
+		want, ok := req.Principal.Claims[claim]
+		if !ok {
+			return false
+		}
+		got, ok := req.Resource.Attributes[attribute]
+		if !ok {
+			return false
+		}
+		for _, g := range items(got) {
+			for _, w := range items(want) {
+				if equal(g, w) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// items returns the elements of a list value, or the value itself.
+func items(v any) []any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{v}
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+// equal compares two scalar values without panicking on uncomparable ones.
+func equal(a, b any) bool {
+	na, aok := number(a)
+	nb, bok := number(b)
+	if aok || bok {
+		return aok && bok && na.Cmp(nb) == 0
+	}
+	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if ra.IsValid() && rb.IsValid() && ra.Type() == rb.Type() && ra.Comparable() {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// number returns v as an exact number when it is one.
+func number(v any) (*big.Float, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Float).SetInt64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Float).SetUint64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); !math.IsNaN(f) {
+			return new(big.Float).SetFloat64(f), true
+		}
+	case reflect.String:
+		if n, ok := v.(json.Number); ok {
+			f, _, err := big.ParseFloat(n.String(), 10, 256, big.ToNearestEven)
+			return f, err == nil
+		}
+	}
+	return nil, false
+}
+
+// All returns a Condition matching when every given condition matches.
+func All(conds ...Condition) Condition {
+	return func(ctx context.Context, req Request) bool {
+		for _, c := range conds {
+			if !c(ctx, req) {
+				return false
+			}
+		}
+		return true
+	}
+}
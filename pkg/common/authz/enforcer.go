@@ -0,0 +1,133 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Enforcer evaluates authorization requests against roles and policies.
+//
+// Evaluation order: a matching deny policy forbids the action; otherwise a role
+// granting the permission or a matching allow policy permits it; otherwise the
+// action is denied by default. Every decision is sent to the decision log.
+type Enforcer struct {
+	roles    map[string]Role
+	policies []Policy
+	log      DecisionLog
+	now      func() time.Time
+}
+
+// NewEnforcer creates an Enforcer. A nil log discards decisions.
+func NewEnforcer(roles []Role, policies []Policy, log DecisionLog) *Enforcer {
+	idx := make(map[string]Role, len(roles))
+	for _, r := range roles {
+		idx[r.Name] = r
+	}
+	if log == nil {
+		log = discardLog{}
+	}
+	return &Enforcer{roles: idx, policies: policies, log: log, now: time.Now}
+}
+
+// Authorize evaluates the request and records the decision.
+func (e *Enforcer) Authorize(ctx context.Context, req Request) Decision {
+	d := e.evaluate(ctx, req)
+	d.Action = req.Action
+	d.Resource = req.Resource
+	d.Timestamp = e.now()
+	if req.Principal != nil {
+		d.Subject = req.Principal.Subject
+	}
+	e.log.Record(ctx, d)
+	return d
+}
+
+// evaluate applies deny policies, role grants and allow policies in order.
+func (e *Enforcer) evaluate(ctx context.Context, req Request) Decision {
+	for _, p := range e.policies {
+		if p.Effect == Deny && e.matches(ctx, p, req) {
+			return Decision{Allowed: false, Policy: p.Name, Reason: "denied by policy"}
+		}
+	}
+
+	if req.Principal == nil {
+		return Decision{Allowed: false, Reason: "anonymous principal"}
+	}
+
+	for _, role := range req.Principal.Roles {
+		if e.grants(role, req.Action, map[string]bool{}) {
+			return Decision{Allowed: true, Policy: "role:" + role, Reason: "granted by role"}
+		}
+	}
+
+	for _, p := range e.policies {
+		if p.Effect != Deny && e.matches(ctx, p, req) {
+			return Decision{Allowed: true, Policy: p.Name, Reason: "allowed by policy"}
+		}
+	}
+
+	return Decision{Allowed: false, Reason: fmt.Sprintf("no role or policy grants %s", req.Action)}
+}
+
+// grants reports whether the role, or a role it inherits, holds the permission.
+func (e *Enforcer) grants(name string, action Permission, visited map[string]bool) bool {
+	if visited[name] {
+		return false
+	}
+	visited[name] = true
+
+	role, ok := e.roles[name]
+	if !ok {
+		return false
+	}
+	if slices.Contains(role.Permissions, action) {
+		return true
+	}
+	for _, parent := range role.Inherits {
+		if e.grants(parent, action, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the policy applies to the request.
+func (e *Enforcer) matches(ctx context.Context, p Policy, req Request) bool {
+	if !slices.Contains(p.Actions, req.Action) {
+		return false
+	}
+	if len(p.Roles) > 0 {
+		if req.Principal == nil || !slices.ContainsFunc(p.Roles, e.hasRole(req)) {
+			return false
+		}
+	}
+	return p.Condition == nil || p.Condition(ctx, req)
+}
+
+// hasRole returns a predicate reporting whether the principal holds a role, directly or by inheritance.
+func (e *Enforcer) hasRole(req Request) func(string) bool {
+	return func(want string) bool {
+		for _, held := range req.Principal.Roles {
+			if held == want || e.inherits(held, want, map[string]bool{}) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// inherits reports whether role name inherits from role want.
+func (e *Enforcer) inherits(name, want string, visited map[string]bool) bool {
+	if visited[name] {
+		return false
+	}
+	visited[name] = true
+	for _, parent := range e.roles[name].Inherits {
+		if parent == want || e.inherits(parent, want, visited) {
+			return true
+		}
+	}
+	return false
+}
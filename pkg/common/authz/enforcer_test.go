@@ -0,0 +1,155 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const definitions = `
+roles:
+  - name: citizen
+    permissions: [reports:read]
+  - name: moderator
+    inherits: [citizen]
+    permissions: [comments:delete]
+  - name: official
+    inherits: [citizen]
+policies:
+  - name: owner-updates-profile
+    effect: allow
+    actions: [users:update]
+    conditions:
+      owner: true
+  - name: official-transitions-in-jurisdiction
+    effect: allow
+    actions: [reports:transition]
+    roles: [official]
+    conditions:
+      match:
+        jurisdiction: jurisdiction
+  - name: suspended-users-cannot-comment
+    effect: deny
+    actions: [comments:create]
+    conditions:
+      custom: [suspended]
+`
+
+// memoryLog collects decisions in memory.
+type memoryLog struct {
+	decisions []Decision
+}
+
+func (m *memoryLog) Record(_ context.Context, d Decision) { m.decisions = append(m.decisions, d) }
+
+// newTestEnforcer builds an Enforcer from the YAML definitions above.
+func newTestEnforcer(t *testing.T, log DecisionLog) *Enforcer {
+	defs, err := ParseYAML([]byte(definitions), map[string]Condition{
+		"suspended": func(_ context.Context, req Request) bool {
+			return req.Principal != nil && req.Principal.Claims["suspended"] == true
+		},
+	})
+	require.NoError(t, err)
+	defs.Policies = append(defs.Policies, Policy{
+		Name:    "citizens-comment",
+		Effect:  Allow,
+		Actions: []Permission{"comments:create"},
+		Roles:   []string{"citizen"},
+	})
+	return NewEnforcer(defs.Roles, defs.Policies, log)
+}
+
+// TestEnforcerDecisions covers role grants, inheritance, owner and attribute policies and deny overrides.
+func TestEnforcerDecisions(t *testing.T) {
+	log := &memoryLog{}
+	e := newTestEnforcer(t, log)
+	ctx := context.Background()
+
+	citizen := &auth.Principal{Subject: "u1", Roles: []string{"citizen"}}
+	moderator := &auth.Principal{Subject: "u2", Roles: []string{"moderator"}}
+	official := &auth.Principal{Subject: "u3", Roles: []string{"official"}, Claims: map[string]any{"jurisdiction": "bogota"}}
+	suspended := &auth.Principal{Subject: "u4", Roles: []string{"citizen"}, Claims: map[string]any{"suspended": true}}
+	report := func(j string) Resource {
+		return Resource{Type: "reports", Attributes: map[string]any{"jurisdiction": j}}
+	}
+
+	cases := []struct {
+		name    string
+		req     Request
+		allowed bool
+	}{
+		{"role grant", Request{Principal: citizen, Action: "reports:read"}, true},
+		{"inherited grant", Request{Principal: moderator, Action: "reports:read"}, true},
+		{"missing permission", Request{Principal: citizen, Action: "comments:delete"}, false},
+		{"anonymous", Request{Action: "reports:read"}, false},
+		{"owner updates own profile", Request{Principal: citizen, Action: "users:update", Resource: Resource{OwnerID: "u1"}}, true},
+		{"owner updates other profile", Request{Principal: citizen, Action: "users:update", Resource: Resource{OwnerID: "u2"}}, false},
+		{"official in jurisdiction", Request{Principal: official, Action: "reports:transition", Resource: report("bogota")}, true},
+		{"official out of jurisdiction", Request{Principal: official, Action: "reports:transition", Resource: report("medellin")}, false},
+		{"citizen cannot transition", Request{Principal: citizen, Action: "reports:transition", Resource: report("bogota")}, false},
+		{"inherited role policy", Request{Principal: official, Action: "comments:create"}, true},
+		{"deny overrides allow", Request{Principal: suspended, Action: "comments:create"}, false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.allowed, e.Authorize(ctx, tc.req).Allowed, tc.name)
+	}
+
+	require.Len(t, log.decisions, len(cases))
+	last := log.decisions[len(log.decisions)-1]
+	assert.Equal(t, "suspended-users-cannot-comment", last.Policy)
+	assert.Equal(t, "u4", last.Subject)
+	assert.False(t, last.Timestamp.IsZero())
+}
+
+// TestMiddleware checks status codes returned for anonymous and forbidden callers.
+func TestMiddleware(t *testing.T) {
+	e := newTestEnforcer(t, nil)
+	next := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	ep := Middleware(e, "comments:delete", StaticResource("comments"))(next)
+
+	_, err := ep(context.Background(), nil)
+	assert.Equal(t, 401, transport.CodeOf(err))
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Subject: "u1", Roles: []string{"citizen"}})
+	_, err = ep(ctx, nil)
+	assert.Equal(t, 403, transport.CodeOf(err))
+
+	ctx = auth.NewContext(context.Background(), &auth.Principal{Subject: "u2", Roles: []string{"moderator"}})
+	out, err := ep(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out)
+}
+
+// TestAttributeEquals covers numeric claims, list claims and uncomparable values.
+func TestAttributeEquals(t *testing.T) {
+	match := func(attr, claim any) bool {
+		return AttributeEquals("district", "district")(context.Background(), Request{
+			Principal: &auth.Principal{Claims: map[string]any{"district": claim}},
+			Resource:  Resource{Attributes: map[string]any{"district": attr}},
+		})
+	}
+
+	assert.True(t, match(3, float64(3)))
+	assert.True(t, match(uint8(3), json.Number("3")))
+	assert.False(t, match(3, 3.5))
+	assert.False(t, match("3", 3))
+	assert.True(t, match("norte", []any{"sur", "norte"}))
+	assert.True(t, match([]string{"centro", "norte"}, []any{"norte"}))
+	assert.False(t, match(4, []any{float64(3), "4"}))
+	assert.False(t, match(map[string]any{"a": 1}, []any{"a"}))
+	assert.True(t, match(map[string]any{"a": 1}, map[string]any{"a": 1}))
+}
+
+// TestParseYAMLErrors rejects invalid effects and unknown custom conditions.
+func TestParseYAMLErrors(t *testing.T) {
+	_, err := ParseYAML([]byte("policies: [{name: p, effect: maybe}]"), nil)
+	assert.Error(t, err)
+
+	_, err = ParseYAML([]byte("policies: [{name: p, effect: allow, conditions: {custom: [nope]}}]"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DecisionLog records authorization decisions for auditing.
+type DecisionLog interface {
+	Record(ctx context.Context, d Decision)
+}
+
+// discardLog drops every decision.
+type discardLog struct{}
+
+// Record does nothing.
+func (discardLog) Record(context.Context, Decision) {}
+
+// zapLog writes decisions to a zap logger.
+type zapLog struct {
+	log *zap.Logger
+}
+
+// NewZapLog returns a DecisionLog writing structured entries to the given logger.
+func NewZapLog(log *zap.Logger) DecisionLog {
+	return &zapLog{log}
+}
+
+// Record logs the decision, at warn level when access is denied.
+func (l *zapLog) Record(_ context.Context, d Decision) {
+	fields := []zap.Field{
+		zap.Bool("allowed", d.Allowed),
+		zap.String("subject", d.Subject),
+		zap.String("action", string(d.Action)),
+		zap.String("resource_type", d.Resource.Type),
+		zap.String("resource_id", d.Resource.ID),
+		zap.String("policy", d.Policy),
+		zap.String("reason", d.Reason),
+	}
+	if d.Allowed {
+		l.log.Info("authorization decision", fields...)
+		return
+	}
+	l.log.Warn("authorization decision", fields...)
+}
+
+// DecisionRecord is a persisted authorization decision.
+type DecisionRecord struct {
+	db.BaseModel
+	Subject      string    `gorm:"index"` // Subject is the principal subject.
+	Action       string    `gorm:"index"` // Action is the requested permission.
+	ResourceType string    // ResourceType is the resource kind.
+	ResourceID   string    // ResourceID identifies the resource instance.
+	Allowed      bool      // Allowed is the decision outcome.
+	Policy       string    // Policy is the deciding policy.
+	Reason       string    // Reason explains the decision.
+	Attributes   string    // Attributes holds the resource attributes as JSON.
+	DecidedAt    time.Time `gorm:"index"` // DecidedAt is when the decision was taken.
+}
+
+// dbLog persists decisions through a repository.
+type dbLog struct {
+	repo    db.Repository[DecisionRecord]
+	onError func(error)
+}
+
+// NewDBLog returns a DecisionLog persisting decisions. Write errors are passed to onError when set.
+func NewDBLog(gdb *gorm.DB, onError func(error)) DecisionLog {
+	return &dbLog{repo: db.NewRepository[DecisionRecord](gdb), onError: onError}
+}
+
+// Record stores the decision.
+func (l *dbLog) Record(ctx context.Context, d Decision) {
+	attrs, _ := json.Marshal(d.Resource.Attributes)
+	err := l.repo.Create(ctx, &DecisionRecord{
+		Subject:      d.Subject,
+		Action:       string(d.Action),
+		ResourceType: d.Resource.Type,
+		ResourceID:   d.Resource.ID,
+		Allowed:      d.Allowed,
+		Policy:       d.Policy,
+		Reason:       d.Reason,
+		Attributes:   string(attrs),
+		DecidedAt:    d.Timestamp,
+	})
+	if err != nil && l.onError != nil {
+		l.onError(err)
+	}
+}
+
+// multiLog fans decisions out to several logs.
+type multiLog []DecisionLog
+
+// NewMultiLog returns a DecisionLog recording into every given log.
+func NewMultiLog(logs ...DecisionLog) DecisionLog {
+	return multiLog(logs)
+}
+
+// Record forwards the decision to every log.
+func (m multiLog) Record(ctx context.Context, d Decision) {
+	for _, l := range m {
+		l.Record(ctx, d)
+	}
+}
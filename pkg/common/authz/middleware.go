@@ -0,0 +1,49 @@
+package authz
+
+import (
+	"context"
+
+	gk "github.com/go-kit/kit/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+var (
+	// ErrUnauthenticated is returned when an anonymous caller needs a permission.
+	ErrUnauthenticated = transport.Unauthorized("authentication required")
+	// ErrForbidden is returned when the caller lacks the permission.
+	ErrForbidden = transport.Forbidden("forbidden")
+)
+
+// ResourceResolver extracts the resource targeted by an endpoint request.
+type ResourceResolver func(ctx context.Context, request interface{}) (Resource, error)
+
+// StaticResource returns a ResourceResolver for a resource type without instance attributes.
+func StaticResource(resourceType string) ResourceResolver {
+	return func(context.Context, interface{}) (Resource, error) {
+		return Resource{Type: resourceType}, nil
+	}
+}
+
+// Middleware returns endpoint middleware authorizing the action on the resolved resource.
+// It expects the principal injected by the auth package.
+func Middleware(e *Enforcer, action Permission, resolve ResourceResolver) gk.Middleware {
+	return func(next gk.Endpoint) gk.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			res, err := resolve(ctx, request)
+			if err != nil {
+				return nil, err
+			}
+
+			p, _ := auth.FromContext(ctx)
+			d := e.Authorize(ctx, Request{Principal: p, Action: action, Resource: res})
+			if d.Allowed {
+				return next(ctx, request)
+			}
+			if p == nil {
+				return nil, ErrUnauthenticated
+			}
+			return nil, ErrForbidden
+		}
+	}
+}
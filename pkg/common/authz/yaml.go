@@ -0,0 +1,98 @@
+package authz
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Definitions holds roles and policies loaded from YAML.
+type Definitions struct {
+	Roles    []Role
+	Policies []Policy
+}
+
+// yamlDefinitions is the YAML document layout.
+type yamlDefinitions struct {
+	Roles []struct {
+		Name        string       `yaml:"name"`
+		Permissions []Permission `yaml:"permissions"`
+		Inherits    []string     `yaml:"inherits"`
+	} `yaml:"roles"`
+	Policies []struct {
+		Name       string       `yaml:"name"`
+		Effect     Effect       `yaml:"effect"`
+		Actions    []Permission `yaml:"actions"`
+		Roles      []string     `yaml:"roles"`
+		Conditions struct {
+			Owner  bool              `yaml:"owner"`
+			Match  map[string]string `yaml:"match"`
+			Custom []string          `yaml:"custom"`
+		} `yaml:"conditions"`
+	} `yaml:"policies"`
+}
+
+// ParseYAML parses role and policy definitions.
+//
+// Policy conditions support "owner: true" (IsOwner), "match" mapping resource
+// attributes to principal claims (AttributeEquals) and "custom" referencing
+// conditions registered in code by name. All listed conditions must hold.
+func ParseYAML(data []byte, custom map[string]Condition) (Definitions, error) {
+	var doc yamlDefinitions
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Definitions{}, fmt.Errorf("authz: invalid definitions: %w", err)
+	}
+
+	var defs Definitions
+	for _, r := range doc.Roles {
+		defs.Roles = append(defs.Roles, Role{Name: r.Name, Permissions: r.Permissions, Inherits: r.Inherits})
+	}
+
+	for _, p := range doc.Policies {
+		if p.Effect != Allow && p.Effect != Deny {
+			return Definitions{}, fmt.Errorf("authz: policy %q has invalid effect %q", p.Name, p.Effect)
+		}
+
+		var conds []Condition
+		if p.Conditions.Owner {
+			conds = append(conds, IsOwner)
+		}
+
+		// Sort attribute names so condition order is deterministic.
+		attrs := make([]string, 0, len(p.Conditions.Match))
+		for attr := range p.Conditions.Match {
+			attrs = append(attrs, attr)
+		}
+		sort.Strings(attrs)
+		for _, attr := range attrs {
+			conds = append(conds, AttributeEquals(attr, p.Conditions.Match[attr]))
+		}
+
+		for _, name := range p.Conditions.Custom {
+			c, ok := custom[name]
+			if !ok {
+				return Definitions{}, fmt.Errorf("authz: policy %q references unknown condition %q", p.Name, name)
+			}
+			conds = append(conds, c)
+		}
+
+		policy := Policy{Name: p.Name, Effect: p.Effect, Actions: p.Actions, Roles: p.Roles}
+		if len(conds) > 0 {
+			policy.Condition = All(conds...)
+		}
+		defs.Policies = append(defs.Policies, policy)
+	}
+
+	return defs, nil
+}
+
+// LoadFile reads role and policy definitions from a YAML file.
+func LoadFile(path string, custom map[string]Condition) (Definitions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Definitions{}, err
+	}
+	return ParseYAML(data, custom)
+}
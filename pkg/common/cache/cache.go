@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrMiss is returned by Store.Get when a key is absent or expired.
+var ErrMiss = errors.New("cache: miss")
+
+// Store is a key-value cache backend.
+type Store interface {
+
+	// Get returns the value of key, or ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores the value of key for ttl; zero keeps it until evicted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes the given keys.
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// entry is a value held by the LRU store.
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// LRU is an in-memory Store evicting the least recently used keys.
+type LRU struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+	now   func() time.Time
+}
+
+// NewLRU creates an LRU store holding up to size keys (default: 10000).
+func NewLRU(size int) *LRU {
+	if size <= 0 {
+		size = 10000
+	}
+	return &LRU{size: size, ll: list.New(), items: map[string]*list.Element{}, now: time.Now}
+}
+
+// Get returns the value of key and marks it as recently used.
+func (l *LRU) Get(_ context.Context, key string) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.items[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	e := el.Value.(*entry)
+	if !e.expires.IsZero() && !l.now().Before(e.expires) {
+		l.remove(el)
+		return nil, ErrMiss
+	}
+	l.ll.MoveToFront(el)
+	return e.value, nil
+}
+
+// Set stores the value of key, evicting the least recently used key when full.
+func (l *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := &entry{key: key, value: value}
+	if ttl > 0 {
+		e.expires = l.now().Add(ttl)
+	}
+	if el, ok := l.items[key]; ok {
+		el.Value = e
+		l.ll.MoveToFront(el)
+		return nil
+	}
+	l.items[key] = l.ll.PushFront(e)
+	if l.ll.Len() > l.size {
+		l.remove(l.ll.Back())
+	}
+	return nil
+}
+
+// Delete removes the given keys.
+func (l *LRU) Delete(_ context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, k := range keys {
+		if el, ok := l.items[k]; ok {
+			l.remove(el)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of keys held, including expired ones not yet evicted.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
+
+// remove drops an element; the caller holds the lock.
+func (l *LRU) remove(el *list.Element) {
+	l.ll.Remove(el)
+	delete(l.items, el.Value.(*entry).key)
+}
+
+// Redis is a Store backed by Redis or a compatible server.
+type Redis struct {
+	client redis.UniversalClient
+}
+
+// NewRedis creates a Redis store using client.
+func NewRedis(client redis.UniversalClient) *Redis {
+	return &Redis{client: client}
+}
+
+// Get returns the value of key.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return b, err
+}
+
+// Set stores the value of key.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete removes the given keys.
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
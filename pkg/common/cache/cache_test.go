@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// profile is a cached model with a field hidden from JSON.
+type profile struct {
+	ID           uint `gorm:"primaryKey"`
+	Name         string
+	PasswordHash string `json:"-"`
+}
+
+// counting counts the GetByID calls reaching the database.
+type counting struct {
+	db.Repository[profile]
+	calls atomic.Int32
+	delay time.Duration
+	after func() // after runs once a record is read, if set.
+}
+
+func (c *counting) GetByID(ctx context.Context, id any, fns ...func(*gorm.DB) *gorm.DB) (*profile, error) {
+	c.calls.Add(1)
+	time.Sleep(c.delay)
+	m, err := c.Repository.GetByID(ctx, id, fns...)
+	if c.after != nil {
+		c.after()
+	}
+	return m, err
+}
+
+// newRepo returns a cached repository over sqlite and the counting repository below it.
+func newRepo(t *testing.T, store Store) (db.Repository[profile], *counting) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cache.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&profile{}))
+	base := &counting{Repository: db.NewRepository[profile](gdb)}
+	repo, err := NewRepository[profile](base, store, Config{})
+	require.NoError(t, err)
+	return repo, base
+}
+
+// stores returns the backends under test.
+func stores(t *testing.T) map[string]Store {
+	srv := miniredis.RunT(t)
+	return map[string]Store{
+		"lru":   NewLRU(100),
+		"redis": NewRedis(redis.NewClient(&redis.Options{Addr: srv.Addr()})),
+	}
+}
+
+// TestRepository checks cache-aside reads, invalidation and negative caching on every backend.
+func TestRepository(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			repo, base := newRepo(t, store)
+			ctx := context.Background()
+
+			p := &profile{Name: "Ana", PasswordHash: "hash"}
+			require.NoError(t, repo.Create(ctx, p))
+			for range 3 {
+				got, err := repo.GetByID(ctx, p.ID)
+				require.NoError(t, err)
+				assert.Equal(t, *p, *got)
+			}
+			assert.Equal(t, int32(1), base.calls.Load())
+
+			got, _ := repo.GetByID(ctx, p.ID)
+			got.Name = "mutated"
+			again, _ := repo.GetByID(ctx, p.ID)
+			assert.Equal(t, "Ana", again.Name, "callers get copies")
+
+			p.Name = "Ana María"
+			require.NoError(t, repo.Update(ctx, p))
+			got, err := repo.GetByID(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, "Ana María", got.Name)
+			assert.Equal(t, int32(2), base.calls.Load())
+
+			require.NoError(t, repo.Delete(ctx, p.ID))
+			for range 2 {
+				_, err = repo.GetByID(ctx, p.ID)
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+			}
+			assert.Equal(t, int32(3), base.calls.Load(), "misses are cached")
+
+			require.NoError(t, repo.Create(ctx, &profile{ID: p.ID, Name: "Luis"}))
+			got, err = repo.GetByID(ctx, p.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Luis", got.Name, "creates forget misses")
+
+			_, err = repo.GetByID(ctx, p.ID, func(q *gorm.DB) *gorm.DB { return q })
+			require.NoError(t, err)
+			assert.Equal(t, int32(5), base.calls.Load(), "query functions bypass the cache")
+
+			_, err = repo.GetByID(tenant.NewContext(ctx, "cali"), p.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int32(6), base.calls.Load(), "tenants do not share keys")
+
+			res := <-repo.Async().GetByIDAsync(ctx, p.ID)
+			require.NoError(t, res.Err)
+			assert.Equal(t, int32(6), base.calls.Load())
+		})
+	}
+}
+
+// TestBulkInvalidation checks batch and bulk writes invalidate every record they touch.
+func TestBulkInvalidation(t *testing.T) {
+	repo, base := newRepo(t, NewLRU(10))
+	ctx := context.Background()
+
+	res, err := repo.CreateBatch(ctx, []*profile{{Name: "Ana"}, {Name: "Beto"}, {Name: "Caro"}})
+	require.NoError(t, err)
+	assert.Empty(t, res.Errors)
+	warm := func() {
+		for id := 1; id <= 3; id++ {
+			_, _ = repo.GetByID(ctx, id)
+		}
+	}
+	warm()
+
+	_, err = repo.UpsertBatch(ctx, []*profile{{ID: 1, Name: "Ana María"}})
+	require.NoError(t, err)
+	got, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Ana María", got.Name)
+
+	n, err := repo.UpdateWhere(ctx, map[string]any{"name": "anon"}, func(q *gorm.DB) *gorm.DB { return q.Where("id > ?", 1) })
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	got, err = repo.GetByID(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "anon", got.Name)
+
+	warm()
+	calls := base.calls.Load()
+	n, err = repo.DeleteWhere(ctx, func(q *gorm.DB) *gorm.DB { return q.Where("name = ?", "anon") })
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	_, err = repo.GetByID(ctx, 2)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, calls+1, base.calls.Load(), "untouched records stay cached")
+}
+
+// TestSingleflight checks concurrent misses of a key share one query.
+func TestSingleflight(t *testing.T) {
+	repo, base := newRepo(t, NewLRU(10))
+	base.delay = 50 * time.Millisecond
+	require.NoError(t, repo.Create(context.Background(), &profile{Name: "Ana"}))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := repo.GetByID(context.Background(), 1)
+			assert.NoError(t, err)
+			assert.Equal(t, "Ana", got.Name)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), base.calls.Load())
+}
+
+// TestInvalidatedLoad checks a miss invalidated while loading is not cached.
+func TestInvalidatedLoad(t *testing.T) {
+	repo, base := newRepo(t, NewLRU(10))
+	ctx := context.Background()
+	p := &profile{Name: "Ana"}
+	require.NoError(t, repo.Create(ctx, p))
+
+	base.after = func() {
+		base.after = nil
+		require.NoError(t, repo.Update(ctx, &profile{ID: p.ID, Name: "Bea"}))
+	}
+	got, err := repo.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ana", got.Name)
+
+	got, err = repo.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Bea", got.Name, "the stale load was not cached")
+	assert.Equal(t, int32(2), base.calls.Load())
+}
+
+// TestLRU checks eviction by recency and expiry.
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	l := NewLRU(2)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	require.NoError(t, l.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, l.Set(ctx, "b", []byte("2"), time.Minute))
+	_, err := l.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, l.Set(ctx, "c", []byte("3"), 0))
+	_, err = l.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss, "the least recently used key is evicted")
+	assert.Equal(t, 2, l.Len())
+
+	require.NoError(t, l.Set(ctx, "d", []byte("4"), time.Minute))
+	now = now.Add(time.Minute)
+	_, err = l.Get(ctx, "d")
+	assert.ErrorIs(t, err, ErrMiss, "expired keys are misses")
+	require.NoError(t, l.Delete(ctx, "c"))
+	_, err = l.Get(ctx, "c")
+	assert.ErrorIs(t, err, ErrMiss)
+}
@@ -0,0 +1,282 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// missing is the cached value of an ID with no record; records are stored
+// gob-encoded after a found marker, keeping fields hidden from JSON.
+var missing, found = []byte{0}, byte(1)
+
+// Config defines how a repository is cached.
+type Config struct {
+	Prefix      string        // Prefix namespaces the keys of the repository (default: the table name).
+	TTL         time.Duration // TTL bounds how long a record is cached (default: 5m).
+	NegativeTTL time.Duration // NegativeTTL bounds how long a missing record is remembered (default: 30s, negative disables).
+}
+
+// repository caches GetByID of a db.Repository.
+type repository[T any] struct {
+	db.Repository[T]
+	store Store
+	cfg   Config
+	pk    *schema.Field
+	group singleflight.Group
+	mu    sync.Mutex
+	loads map[string]*load // loads are the misses of each key being loaded.
+}
+
+// load is a miss being loaded; stale is set when its key is invalidated meanwhile.
+type load struct {
+	stale bool
+}
+
+// NewRepository decorates repo with cache-aside reads of GetByID: hits skip
+// the database, concurrent misses of a key share a single query and missing
+// records are remembered for NegativeTTL. Create, Update and Delete through
+// the decorator invalidate the key, as do the batch and bulk writes for every
+// record they touch; misses are loaded from the primary and not cached when
+// invalidated meanwhile. Writes made elsewhere are seen after TTL.
+// Keys include the tenant of the context. Calls with query functions, List
+// and Stream are not cached. T must have a single primary key. Records are
+// cached decrypted, so keep models with encrypted fields out of shared stores.
+func NewRepository[T any](repo db.Repository[T], store Store, cfg Config) (db.Repository[T], error) {
+	s, err := schema.Parse(new(T), &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	if s.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("cache: %s has no single primary key", s.Name)
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = s.Table
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Minute
+	}
+	if cfg.NegativeTTL == 0 {
+		cfg.NegativeTTL = 30 * time.Second
+	}
+	return &repository[T]{Repository: repo, store: store, cfg: cfg, pk: s.PrioritizedPrimaryField, loads: map[string]*load{}}, nil
+}
+
+// key returns the cache key of an ID for the tenant of ctx.
+func (r *repository[T]) key(ctx context.Context, id any) string {
+	t, _ := tenant.FromContext(ctx)
+	return fmt.Sprintf("%s:%s:%v", r.cfg.Prefix, t, id)
+}
+
+// GetByID returns a record from the cache, loading it on a miss.
+func (r *repository[T]) GetByID(ctx context.Context, id any, queryFns ...func(*gorm.DB) *gorm.DB) (*T, error) {
+	if len(queryFns) > 0 {
+		return r.Repository.GetByID(ctx, id, queryFns...)
+	}
+	key := r.key(ctx, id)
+	if b, err := r.store.Get(ctx, key); err == nil {
+		return decode[T](b)
+	}
+
+	v, err, _ := r.group.Do(key, func() (any, error) {
+		l := r.begin(key)
+		m, err := r.Repository.GetByID(db.WithPrimary(ctx), id)
+		fresh := r.end(key, l)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if fresh && r.cfg.NegativeTTL > 0 {
+				_ = r.store.Set(ctx, key, missing, r.cfg.NegativeTTL)
+			}
+			return missing, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf := bytes.NewBuffer([]byte{found})
+		if err := gob.NewEncoder(buf).Encode(m); err != nil {
+			return nil, fmt.Errorf("cache: %w", err)
+		}
+		if fresh {
+			_ = r.store.Set(ctx, key, buf.Bytes(), r.cfg.TTL)
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decode[T](v.([]byte))
+}
+
+// begin registers the load of a key.
+func (r *repository[T]) begin(key string) *load {
+	l := &load{}
+	r.mu.Lock()
+	r.loads[key] = l
+	r.mu.Unlock()
+	return l
+}
+
+// end unregisters the load of a key and reports whether it can be cached.
+func (r *repository[T]) end(key string, l *load) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.loads[key] == l {
+		delete(r.loads, key)
+	}
+	return !l.stale
+}
+
+// decode returns a copy of a cached record, or gorm.ErrRecordNotFound.
+func decode[T any](b []byte) (*T, error) {
+	if len(b) == 0 || b[0] != found {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var out T
+	if err := gob.NewDecoder(bytes.NewReader(b[1:])).Decode(&out); err != nil {
+		return nil, fmt.Errorf("cache: %w", err)
+	}
+	return &out, nil
+}
+
+// Create creates the record and forgets it was missing.
+func (r *repository[T]) Create(ctx context.Context, model *T) error {
+	if err := r.Repository.Create(ctx, model); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, model)
+}
+
+// Update updates the record and invalidates it.
+func (r *repository[T]) Update(ctx context.Context, model *T) error {
+	if err := r.Repository.Update(ctx, model); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, model)
+}
+
+// Delete deletes the record and invalidates it.
+func (r *repository[T]) Delete(ctx context.Context, id any) error {
+	if err := r.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.forget(ctx, id)
+}
+
+// CreateBatch creates the records and forgets they were missing.
+func (r *repository[T]) CreateBatch(ctx context.Context, models []*T, opts ...db.BatchOption) (*db.BatchResult, error) {
+	res, err := r.Repository.CreateBatch(ctx, models, opts...)
+	return res, errors.Join(err, r.invalidateAll(ctx, models))
+}
+
+// UpsertBatch creates or updates the records and invalidates them.
+func (r *repository[T]) UpsertBatch(ctx context.Context, models []*T, opts ...db.BatchOption) (*db.BatchResult, error) {
+	res, err := r.Repository.UpsertBatch(ctx, models, opts...)
+	return res, errors.Join(err, r.invalidateAll(ctx, models))
+}
+
+// UpdateWhere updates the matched records and invalidates them.
+func (r *repository[T]) UpdateWhere(ctx context.Context, values map[string]any, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	ids, err := r.matched(ctx, queryFns)
+	if err != nil {
+		return 0, err
+	}
+	n, err := r.Repository.UpdateWhere(ctx, values, queryFns...)
+	if err != nil {
+		return n, err
+	}
+	return n, r.forget(ctx, ids...)
+}
+
+// DeleteWhere deletes the matched records and invalidates them.
+func (r *repository[T]) DeleteWhere(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	ids, err := r.matched(ctx, queryFns)
+	if err != nil {
+		return 0, err
+	}
+	n, err := r.Repository.DeleteWhere(ctx, queryFns...)
+	if err != nil {
+		return n, err
+	}
+	return n, r.forget(ctx, ids...)
+}
+
+// matched returns the IDs of the records matched by the query functions,
+// read from the primary so none is missed to replication lag.
+func (r *repository[T]) matched(ctx context.Context, queryFns []func(*gorm.DB) *gorm.DB) ([]any, error) {
+	fns := append(append([]func(*gorm.DB) *gorm.DB{}, queryFns...), func(q *gorm.DB) *gorm.DB {
+		return q.Select(r.pk.DBName)
+	})
+	rows, err := r.Repository.List(db.WithPrimary(ctx), fns...)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]any, 0, len(rows))
+	for i := range rows {
+		if id, zero := r.pk.ValueOf(ctx, reflect.ValueOf(&rows[i]).Elem()); !zero {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Async returns an async wrapper using the cache.
+func (r *repository[T]) Async() db.AsyncRepository[T] {
+	return db.NewAsyncRepository[T](r)
+}
+
+// invalidate removes the cached value of a record.
+func (r *repository[T]) invalidate(ctx context.Context, model *T) error {
+	id, zero := r.pk.ValueOf(ctx, reflect.ValueOf(model).Elem())
+	if zero {
+		return nil
+	}
+	return r.forget(ctx, id)
+}
+
+// invalidateAll removes the cached values of the records.
+func (r *repository[T]) invalidateAll(ctx context.Context, models []*T) error {
+	ids := make([]any, 0, len(models))
+	for _, m := range models {
+		if m == nil {
+			continue
+		}
+		if id, zero := r.pk.ValueOf(ctx, reflect.ValueOf(m).Elem()); !zero {
+			ids = append(ids, id)
+		}
+	}
+	return r.forget(ctx, ids...)
+}
+
+// forget removes the cached values of the IDs.
+func (r *repository[T]) forget(ctx context.Context, ids ...any) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	r.mu.Lock()
+	for i, id := range ids {
+		keys[i] = r.key(ctx, id)
+		if l, ok := r.loads[keys[i]]; ok {
+			l.stale = true
+		}
+		r.group.Forget(keys[i])
+	}
+	r.mu.Unlock()
+	if err := r.store.Delete(ctx, keys...); err != nil {
+		if len(keys) > 1 {
+			return fmt.Errorf("cache: invalidate %s and %d more: %w", keys[0], len(keys)-1, err)
+		}
+		return fmt.Errorf("cache: invalidate %s: %w", keys[0], err)
+	}
+	return nil
+}
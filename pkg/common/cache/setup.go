@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// SetupEnvironmentStore creates a Store from the provided environment.
+// CACHE_DRIVER selects "memory" (an LRU of CACHE_SIZE keys per instance) or
+// "redis" (shared by every instance, at REDIS_ADDR).
+func SetupEnvironmentStore() (Store, error) {
+
+	cfg := config.Get()
+	switch driver := config.MustGet(config.CacheDriver); driver {
+	case "memory":
+		return NewLRU(cfg.GetInt(config.CacheSize)), nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     config.MustGet(config.RedisAddr),
+			Password: cfg.GetString(config.RedisPassword),
+			DB:       cfg.GetInt(config.RedisDB),
+		})
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			return nil, fmt.Errorf("cache: %w", err)
+		}
+		return NewRedis(client), nil
+	default:
+		return nil, fmt.Errorf("unsupported cache driver: %s", driver)
+	}
+
+}
@@ -0,0 +1,156 @@
+package calendar
+
+import (
+	"sync"
+	"time"
+)
+
+// Calendar computes working time for business-hours service levels.
+type Calendar struct {
+	loc      *time.Location
+	start    time.Duration
+	end      time.Duration
+	weekdays map[time.Weekday]bool
+	holidays HolidayFunc
+
+	mu    sync.Mutex
+	cache map[int]map[string]bool
+}
+
+// Config defines a business calendar.
+type Config struct {
+	Location *time.Location // Location is the calendar timezone (default: UTC).
+	Start    time.Duration  // Start is the offset from midnight where the working day begins.
+	End      time.Duration  // End is the offset from midnight where the working day ends (default: 24h).
+	Weekdays []time.Weekday // Weekdays are the working days (default: Monday to Friday).
+	Holidays HolidayFunc    // Holidays lists non-working days (optional).
+}
+
+// New creates a Calendar with the given configuration.
+func New(cfg Config) *Calendar {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	if cfg.End <= cfg.Start {
+		cfg.Start, cfg.End = 0, 24*time.Hour
+	}
+	if len(cfg.Weekdays) == 0 {
+		cfg.Weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	}
+	days := make(map[time.Weekday]bool, len(cfg.Weekdays))
+	for _, d := range cfg.Weekdays {
+		days[d] = true
+	}
+	return &Calendar{
+		loc:      cfg.Location,
+		start:    cfg.Start,
+		end:      cfg.End,
+		weekdays: days,
+		holidays: cfg.Holidays,
+		cache:    make(map[int]map[string]bool),
+	}
+}
+
+// Colombia returns the calendar of Colombian public offices: Monday to Friday,
+// 8:00 to 17:00 in America/Bogota, excluding national holidays.
+func Colombia() (*Calendar, error) {
+	loc, err := time.LoadLocation("America/Bogota")
+	if err != nil {
+		return nil, err
+	}
+	return New(Config{
+		Location: loc,
+		Start:    8 * time.Hour,
+		End:      17 * time.Hour,
+		Holidays: ColombianHolidays,
+	}), nil
+}
+
+// IsBusinessDay reports whether the day of t is a working day.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.loc)
+	return c.weekdays[t.Weekday()] && !c.isHoliday(t)
+}
+
+// Add returns the instant after working for d starting at t.
+func (c *Calendar) Add(t time.Time, d time.Duration) time.Time {
+	t = t.In(c.loc)
+	for {
+		open, close := c.window(t)
+		if !c.IsBusinessDay(t) || !t.Before(close) {
+			next := open.AddDate(0, 0, 1)
+			t, _ = c.window(next)
+			continue
+		}
+		if t.Before(open) {
+			t = open
+		}
+		left := close.Sub(t)
+		if d <= left {
+			return t.Add(d)
+		}
+		d -= left
+		t = close
+	}
+}
+
+// Between returns the working time elapsed from a to b, or zero when b is not after a.
+func (c *Calendar) Between(a, b time.Time) time.Duration {
+	if !b.After(a) {
+		return 0
+	}
+	a, b = a.In(c.loc), b.In(c.loc)
+
+	var total time.Duration
+	for day := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, c.loc); day.Before(b); day = day.AddDate(0, 0, 1) {
+		if !c.IsBusinessDay(day) {
+			continue
+		}
+		open, close := c.window(day)
+		from, to := maxTime(open, a), minTime(close, b)
+		if to.After(from) {
+			total += to.Sub(from)
+		}
+	}
+	return total
+}
+
+// window returns the working hours of the day of t.
+func (c *Calendar) window(t time.Time) (time.Time, time.Time) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+	return midnight.Add(c.start), midnight.Add(c.end)
+}
+
+// isHoliday reports whether the local day of t is a holiday.
+func (c *Calendar) isHoliday(t time.Time) bool {
+	if c.holidays == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	days, ok := c.cache[t.Year()]
+	if !ok {
+		days = make(map[string]bool)
+		for _, h := range c.holidays(t.Year()) {
+			days[h.Date.Format(time.DateOnly)] = true
+		}
+		c.cache[t.Year()] = days
+	}
+	return days[t.Format(time.DateOnly)]
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,59 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestColombianHolidays2025 checks the official holiday list, including Ley Emiliani moves.
+func TestColombianHolidays2025(t *testing.T) {
+	var got []string
+	for _, h := range ColombianHolidays(2025) {
+		got = append(got, h.Date.Format(time.DateOnly))
+	}
+
+	assert.Equal(t, []string{
+		"2025-01-01", "2025-01-06", "2025-03-24", "2025-04-17", "2025-04-18",
+		"2025-05-01", "2025-06-02", "2025-06-23", "2025-06-30", "2025-06-30",
+		"2025-07-20", "2025-08-07", "2025-08-18", "2025-10-13", "2025-11-03",
+		"2025-11-17", "2025-12-08", "2025-12-25",
+	}, got)
+}
+
+// TestEasterSunday checks the Easter computation on known years.
+func TestEasterSunday(t *testing.T) {
+	assert.Equal(t, "2024-03-31", easterSunday(2024).Format(time.DateOnly))
+	assert.Equal(t, "2025-04-20", easterSunday(2025).Format(time.DateOnly))
+	assert.Equal(t, "2026-04-05", easterSunday(2026).Format(time.DateOnly))
+}
+
+// TestCalendarAdd checks that working time skips nights, weekends and holidays.
+func TestCalendarAdd(t *testing.T) {
+	cal, err := Colombia()
+	require.NoError(t, err)
+	loc := cal.loc
+
+	// Friday 16:00 + 2h: one hour on Friday, the rest on Tuesday since Monday 2025-03-24 is a holiday.
+	start := time.Date(2025, time.March, 21, 16, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2025, time.March, 25, 9, 0, 0, 0, loc), cal.Add(start, 2*time.Hour))
+
+	// Saturday night starts counting on Monday morning.
+	start = time.Date(2025, time.March, 1, 22, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2025, time.March, 3, 8, 30, 0, 0, loc), cal.Add(start, 30*time.Minute))
+}
+
+// TestCalendarBetween checks that elapsed working time matches Add.
+func TestCalendarBetween(t *testing.T) {
+	cal, err := Colombia()
+	require.NoError(t, err)
+	loc := cal.loc
+
+	start := time.Date(2025, time.March, 21, 16, 0, 0, 0, loc)
+	end := cal.Add(start, 12*time.Hour)
+	assert.Equal(t, 12*time.Hour, cal.Between(start, end))
+	assert.Zero(t, cal.Between(end, start))
+	assert.False(t, cal.IsBusinessDay(time.Date(2025, time.December, 25, 10, 0, 0, 0, loc)))
+}
@@ -0,0 +1,83 @@
+package calendar
+
+import (
+	"sort"
+	"time"
+)
+
+// Holiday is a non-working day.
+type Holiday struct {
+	Date time.Time // Date is the observed date at midnight UTC.
+	Name string    // Name is the holiday name.
+}
+
+// HolidayFunc returns the holidays of a year.
+type HolidayFunc func(year int) []Holiday
+
+// ColombianHolidays returns the public holidays of Colombia for a year.
+//
+// Fixed holidays are always observed on their date; holidays covered by Ley 51 de 1983
+// ("Ley Emiliani") and most Easter-based holidays move to the following Monday.
+func ColombianHolidays(year int) []Holiday {
+	fixed := func(m time.Month, d int, name string) Holiday {
+		return Holiday{Date: date(year, m, d), Name: name}
+	}
+	moved := func(t time.Time, name string) Holiday {
+		return Holiday{Date: nextMonday(t), Name: name}
+	}
+
+	easter := easterSunday(year)
+	out := []Holiday{
+		fixed(time.January, 1, "Año Nuevo"),
+		moved(date(year, time.January, 6), "Día de los Reyes Magos"),
+		moved(date(year, time.March, 19), "Día de San José"),
+		{Date: easter.AddDate(0, 0, -3), Name: "Jueves Santo"},
+		{Date: easter.AddDate(0, 0, -2), Name: "Viernes Santo"},
+		fixed(time.May, 1, "Día del Trabajo"),
+		moved(easter.AddDate(0, 0, 39), "Ascensión del Señor"),
+		moved(easter.AddDate(0, 0, 60), "Corpus Christi"),
+		moved(easter.AddDate(0, 0, 68), "Sagrado Corazón de Jesús"),
+		moved(date(year, time.June, 29), "San Pedro y San Pablo"),
+		fixed(time.July, 20, "Día de la Independencia"),
+		fixed(time.August, 7, "Batalla de Boyacá"),
+		moved(date(year, time.August, 15), "La Asunción de la Virgen"),
+		moved(date(year, time.October, 12), "Día de la Raza"),
+		moved(date(year, time.November, 1), "Todos los Santos"),
+		moved(date(year, time.November, 11), "Independencia de Cartagena"),
+		fixed(time.December, 8, "Día de la Inmaculada Concepción"),
+		fixed(time.December, 25, "Navidad"),
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Date.Before(out[j].Date) })
+	return out
+}
+
+// easterSunday computes the Gregorian Easter date (anonymous Gregorian algorithm).
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
+
+// nextMonday returns t if it is a Monday, or the following Monday otherwise.
+func nextMonday(t time.Time) time.Time {
+	offset := (int(time.Monday) - int(t.Weekday()) + 7) % 7
+	return t.AddDate(0, 0, offset)
+}
+
+// date returns midnight UTC of the given day.
+func date(year int, m time.Month, d int) time.Time {
+	return time.Date(year, m, d, 0, 0, 0, 0, time.UTC)
+}
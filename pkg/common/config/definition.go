@@ -13,8 +13,15 @@ var (
 
 // Environment definitions for database
 var (
-	DatabaseDialect = "DB_DIALECT"
-	DatabaseDSN     = "DB_DSN"
+	DatabaseDialect          = "DB_DIALECT"
+	DatabaseDSN              = "DB_DSN"
+	DatabaseMaxOpenConns     = "DB_MAX_OPEN_CONNS"
+	DatabaseMaxIdleConns     = "DB_MAX_IDLE_CONNS"
+	DatabaseConnMaxLifetime  = "DB_CONN_MAX_LIFETIME"
+	DatabaseConnMaxIdleTime  = "DB_CONN_MAX_IDLE_TIME"
+	DatabaseStatementTimeout = "DB_STATEMENT_TIMEOUT"
+	DatabaseReplicas         = "DB_REPLICAS"
+	DatabaseHealthInterval   = "DB_HEALTH_INTERVAL"
 )
 
 // Environment definitions for http
@@ -22,3 +29,67 @@ var (
 	HttpServer = "HTTP_SERVER"
 	HttpPort   = "HTTP_pORT"
 )
+
+// Environment definitions for notifications
+var (
+	SmtpHost     = "SMTP_HOST"
+	SmtpPort     = "SMTP_PORT"
+	SmtpUsername = "SMTP_USERNAME"
+	SmtpPassword = "SMTP_PASSWORD"
+	SmtpFrom     = "SMTP_FROM"
+	SmtpTimeout  = "SMTP_TIMEOUT"
+)
+
+// Environment definitions for authentication
+var (
+	JwtIssuer     = "JWT_ISSUER"
+	JwtAudience   = "JWT_AUDIENCE"
+	JwtAlgorithms = "JWT_ALGORITHMS"
+	JwtSecret     = "JWT_SECRET"
+	JwtJwks       = "JWT_JWKS"
+)
+
+// Environment definitions for media storage
+var (
+	StorageDriver     = "STORAGE_DRIVER"
+	StoragePath       = "STORAGE_PATH"
+	StorageSigningKey = "STORAGE_SIGNING_KEY"
+	StorageMaxSize    = "STORAGE_MAX_SIZE"
+	S3Endpoint        = "S3_ENDPOINT"
+	S3Region          = "S3_REGION"
+	S3Bucket          = "S3_BUCKET"
+	S3AccessKey       = "S3_ACCESS_KEY"
+	S3SecretKey       = "S3_SECRET_KEY"
+	S3UseSSL          = "S3_USE_SSL"
+)
+
+// Environment definitions for full-text search
+var (
+	SearchDriver = "SEARCH_DRIVER"
+	SearchPath   = "SEARCH_PATH"
+)
+
+// Environment definitions for field encryption
+var (
+	CryptoKeys       = "CRYPTO_KEYS"
+	CryptoPrimaryKey = "CRYPTO_PRIMARY_KEY"
+	CryptoIndexKey   = "CRYPTO_INDEX_KEY"
+)
+
+// Environment definitions for multi-tenancy
+var (
+	Tenants      = "TENANTS"
+	TenantHeader = "TENANT_HEADER"
+	TenantClaim  = "TENANT_CLAIM"
+	TenantDomain = "TENANT_DOMAIN"
+	TenantHosts  = "TENANT_HOSTS"
+)
+
+// Environment definitions for caching
+var (
+	CacheDriver   = "CACHE_DRIVER"
+	CacheSize     = "CACHE_SIZE"
+	RedisAddr     = "REDIS_ADDR"
+	RedisPassword = "REDIS_PASSWORD"
+	RedisDB       = "REDIS_DB"
+)
@@ -17,10 +17,39 @@ func SetDefaults() map[string]interface{} {
 
 	def[DatabaseDialect] = "mysql"
 	def[DatabaseDSN] = "root:secret@tcp(127.0.0.1:3306)/civic?parseTime=true"
+	def[DatabaseMaxOpenConns] = 25
+	def[DatabaseMaxIdleConns] = 25
+	def[DatabaseConnMaxLifetime] = "30m"
+	def[DatabaseConnMaxIdleTime] = "5m"
+	def[DatabaseHealthInterval] = "10s"
 
 	def[HttpServer] = "0.0.0.0"
 	def[HttpPort] = "3000"
 
+	def[SmtpHost] = "127.0.0.1"
+	def[SmtpPort] = "1025"
+	def[SmtpFrom] = "CivicSpot <no-reply@civicspot.local>"
+	def[SmtpTimeout] = "10s"
+
+	def[JwtIssuer] = "civicspot"
+	def[JwtAlgorithms] = "HS256,RS256,EdDSA"
+
+	def[StorageDriver] = "local"
+	def[StoragePath] = "./data/media"
+	def[StorageMaxSize] = "10485760"
+	def[S3Bucket] = "civicspot-media"
+	def[S3UseSSL] = true
+
+	def[SearchDriver] = "bleve"
+	def[SearchPath] = "./data/search.bleve"
+
+	def[CacheDriver] = "memory"
+	def[CacheSize] = 10000
+	def[RedisAddr] = "127.0.0.1:6379"
+
+	def[TenantHeader] = "X-Tenant-ID"
+	def[TenantClaim] = "tenant"
+
 	return def
 }
 
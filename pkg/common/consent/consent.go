@@ -0,0 +1,270 @@
+package consent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"gorm.io/gorm"
+)
+
+// Policy kinds every citizen is asked to accept.
+const (
+	// DataProcessing is the personal data processing policy required by Ley 1581 de 2012.
+	DataProcessing = "data_processing"
+	// TermsOfUse are the platform terms of use.
+	TermsOfUse = "terms_of_use"
+)
+
+// Policy is a published version of a policy document. Versions are immutable so
+// every acceptance proves which exact text was accepted. Policies are shared by
+// every tenant since the platform publishes them, so they have no tenant.
+type Policy struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Kind        string    `gorm:"size:64;uniqueIndex:idx_consent_policy_version" json:"kind"`    // Kind is the policy kind (e.g., DataProcessing).
+	Version     string    `gorm:"size:32;uniqueIndex:idx_consent_policy_version" json:"version"` // Version is the document version (e.g., "2025-03").
+	Title       string    `json:"title"`                                                         // Title is the document title.
+	Body        string    `gorm:"type:text" json:"body"`                                         // Body is the full document text.
+	Digest      string    `gorm:"size:64" json:"digest"`                                         // Digest is the SHA-256 of the body.
+	EffectiveAt time.Time `gorm:"index" json:"effective_at"`                                     // EffectiveAt is when the version becomes the current one.
+	CreatedAt   time.Time `json:"created_at"`                                                    // CreatedAt is when the version was published.
+}
+
+// TableName returns the policy table.
+func (Policy) TableName() string { return "consent_policies" }
+
+// Acceptance records that a user accepted a policy version. Like the user
+// accounts, acceptances are shared by every tenant.
+type Acceptance struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      string     `gorm:"size:64;index:idx_consent_acceptance_user" json:"user_id"` // UserID is the accepting user.
+	Kind        string     `gorm:"size:64;index:idx_consent_acceptance_user" json:"kind"`    // Kind is the policy kind.
+	Version     string     `gorm:"size:32" json:"version"`                                   // Version is the accepted version.
+	PolicyID    uint       `gorm:"index" json:"policy_id"`                                   // PolicyID references the accepted Policy.
+	IP          string     `gorm:"size:64" json:"ip"`                                        // IP is the client address of the acceptance request.
+	UserAgent   string     `gorm:"size:512" json:"user_agent"`                               // UserAgent is the client user agent.
+	AcceptedAt  time.Time  `json:"accepted_at"`                                              // AcceptedAt is when the policy was accepted.
+	WithdrawnAt *time.Time `json:"withdrawn_at,omitempty"`                                   // WithdrawnAt is when the consent was withdrawn, if it was.
+}
+
+// TableName returns the acceptance table.
+func (Acceptance) TableName() string { return "consent_acceptances" }
+
+// Migrate creates the consent tables.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Policy{}, &Acceptance{})
+}
+
+// Evidence describes the client accepting a policy.
+type Evidence struct {
+	IP        string // IP is the client address.
+	UserAgent string // UserAgent is the client user agent.
+}
+
+// Service records policy versions and user acceptances.
+type Service struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// New creates a Service on the database. Run Migrate first.
+func New(db *gorm.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// Publish stores a new policy version. The digest is computed from the body and
+// an immediate effective date is used when none is given.
+func (s *Service) Publish(ctx context.Context, p *Policy) error {
+	if p.Kind == "" || p.Version == "" || p.Body == "" {
+		return transport.BadRequest("policy kind, version and body are required")
+	}
+	var n int64
+	err := s.db.WithContext(ctx).Model(&Policy{}).Where("kind = ? AND version = ?", p.Kind, p.Version).Count(&n).Error
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return transport.Conflict(fmt.Sprintf("policy %s version %s already exists", p.Kind, p.Version))
+	}
+
+	sum := sha256.Sum256([]byte(p.Body))
+	p.Digest = hex.EncodeToString(sum[:])
+	if p.EffectiveAt.IsZero() {
+		p.EffectiveAt = s.now()
+	}
+	return s.db.WithContext(ctx).Create(p).Error
+}
+
+// Current returns the effective version of a policy kind.
+func (s *Service) Current(ctx context.Context, kind string) (*Policy, error) {
+	p, err := s.current(ctx, kind)
+	if err == nil && p == nil {
+		return nil, transport.NotFound(fmt.Sprintf("no %s policy is in effect", kind))
+	}
+	return p, err
+}
+
+// current returns the effective version of a policy kind, or nil if there is none.
+func (s *Service) current(ctx context.Context, kind string) (*Policy, error) {
+	var p Policy
+	err := s.db.WithContext(ctx).
+		Where("kind = ? AND effective_at <= ?", kind, s.now()).
+		Order("effective_at DESC, id DESC").
+		First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Versions returns every version of a policy kind, newest first.
+func (s *Service) Versions(ctx context.Context, kind string) ([]Policy, error) {
+	var out []Policy
+	err := s.db.WithContext(ctx).Where("kind = ?", kind).Order("effective_at DESC, id DESC").Find(&out).Error
+	return out, err
+}
+
+// Accept records the acceptance of a policy version. Only the current version can
+// be accepted, so users never consent to a superseded text.
+func (s *Service) Accept(ctx context.Context, userID, kind, version string, ev Evidence) (*Acceptance, error) {
+	p, err := s.Current(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	if version != p.Version {
+		return nil, transport.Conflict(fmt.Sprintf("the current %s policy version is %s", kind, p.Version))
+	}
+
+	a := &Acceptance{
+		UserID:     userID,
+		Kind:       kind,
+		Version:    p.Version,
+		PolicyID:   p.ID,
+		IP:         ev.IP,
+		UserAgent:  ev.UserAgent,
+		AcceptedAt: s.now(),
+	}
+	if err := s.db.WithContext(ctx).Create(a).Error; err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Withdraw revokes the active acceptances of a policy kind by the user. The
+// acceptances are kept as evidence of the consent given until then.
+func (s *Service) Withdraw(ctx context.Context, userID, kind string) error {
+	res := s.db.WithContext(ctx).Model(&Acceptance{}).
+		Where("user_id = ? AND kind = ? AND withdrawn_at IS NULL", userID, kind).
+		Update("withdrawn_at", s.now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return transport.NotFound(fmt.Sprintf("no active %s consent to withdraw", kind))
+	}
+	return nil
+}
+
+// Pending returns the current policy versions among the given kinds that the
+// user has not accepted, or whose acceptance was withdrawn. Kinds without a
+// version in effect require nothing.
+func (s *Service) Pending(ctx context.Context, userID string, kinds ...string) ([]Policy, error) {
+	var out []Policy
+	for _, kind := range kinds {
+		p, err := s.current(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			continue
+		}
+		var n int64
+		err = s.db.WithContext(ctx).Model(&Acceptance{}).
+			Where("user_id = ? AND policy_id = ? AND withdrawn_at IS NULL", userID, p.ID).
+			Count(&n).Error
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+// History returns the acceptances of a user, newest first.
+func (s *Service) History(ctx context.Context, userID string) ([]Acceptance, error) {
+	var out []Acceptance
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("accepted_at DESC, id DESC").Find(&out).Error
+	return out, err
+}
+
+// Forget clears the client evidence of the acceptances of a user on erasure,
+// returning the number of acceptances changed. The acceptances are kept so the
+// consent given until then and the rates stay consistent.
+func (s *Service) Forget(ctx context.Context, userID string) (int64, error) {
+	res := s.db.WithContext(ctx).Model(&Acceptance{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{"ip": "", "user_agent": ""})
+	return res.RowsAffected, res.Error
+}
+
+// Rate summarizes the acceptances of a policy version.
+type Rate struct {
+	Version   string  `json:"version"`   // Version is the policy version.
+	Accepted  int64   `json:"accepted"`  // Accepted is the number of users who accepted the version.
+	Active    int64   `json:"active"`    // Active is the number of those users whose consent stands.
+	Withdrawn int64   `json:"withdrawn"` // Withdrawn is Accepted minus Active.
+	Share     float64 `json:"share"`     // Share is Active over the users who ever accepted any version of the kind.
+}
+
+// Rates returns the acceptance rates of every version of a policy kind, newest first.
+func (s *Service) Rates(ctx context.Context, kind string) ([]Rate, error) {
+	versions, err := s.Versions(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var users int64
+	err = s.db.WithContext(ctx).Model(&Acceptance{}).Where("kind = ?", kind).Distinct("user_id").Count(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		PolicyID uint
+		Accepted int64
+		Active   int64
+	}
+	err = s.db.WithContext(ctx).Model(&Acceptance{}).
+		Select("policy_id, COUNT(DISTINCT user_id) AS accepted, "+
+			"COUNT(DISTINCT CASE WHEN withdrawn_at IS NULL THEN user_id END) AS active").
+		Where("kind = ?", kind).
+		Group("policy_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Rate, len(versions))
+	for i, v := range versions {
+		out[i].Version = v.Version
+		for _, r := range rows {
+			if r.PolicyID == v.ID {
+				out[i].Accepted, out[i].Active = r.Accepted, r.Active
+			}
+		}
+		out[i].Withdrawn = out[i].Accepted - out[i].Active
+		if users > 0 {
+			out[i].Share = float64(out[i].Active) / float64(users)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,121 @@
+package consent
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newService returns a Service on a migrated sqlite database.
+func newService(t *testing.T) *Service {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "consent.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(gdb))
+	require.NoError(t, tenant.Register(gdb), "consent is shared by every tenant")
+	return New(gdb)
+}
+
+// TestPublish checks versions are immutable and the current one is effective.
+func TestPublish(t *testing.T) {
+	s := newService(t)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	_, err := s.Current(ctx, DataProcessing)
+	assert.Equal(t, 404, transport.CodeOf(err))
+
+	require.NoError(t, s.Publish(ctx, &Policy{Kind: DataProcessing, Version: "v1", Body: "Tratamos sus datos."}))
+	require.NoError(t, s.Publish(ctx, &Policy{Kind: DataProcessing, Version: "v2", Body: "Nueva política.", EffectiveAt: now.AddDate(0, 1, 0)}))
+	err = s.Publish(ctx, &Policy{Kind: DataProcessing, Version: "v1", Body: "Otro texto."})
+	assert.Equal(t, 409, transport.CodeOf(err))
+
+	p, err := s.Current(ctx, DataProcessing)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", p.Version)
+	assert.Len(t, p.Digest, 64)
+
+	now = now.AddDate(0, 2, 0)
+	p, err = s.Current(ctx, DataProcessing)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", p.Version)
+}
+
+// TestAcceptance checks acceptance, withdrawal and pending versions.
+func TestAcceptance(t *testing.T) {
+	s := newService(t)
+	ctx := context.Background()
+	ev := Evidence{IP: "190.0.0.1", UserAgent: "test"}
+
+	pending, err := s.Pending(ctx, "u1", DataProcessing)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "nothing to accept before a policy is published")
+
+	require.NoError(t, s.Publish(ctx, &Policy{Kind: DataProcessing, Version: "v1", Body: "Tratamos sus datos."}))
+	pending, err = s.Pending(ctx, "u1", DataProcessing)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "v1", pending[0].Version)
+
+	_, err = s.Accept(ctx, "u1", DataProcessing, "v0", ev)
+	assert.Equal(t, 409, transport.CodeOf(err))
+	a, err := s.Accept(ctx, "u1", DataProcessing, "v1", ev)
+	require.NoError(t, err)
+	assert.Equal(t, "190.0.0.1", a.IP)
+
+	pending, err = s.Pending(ctx, "u1", DataProcessing)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	require.NoError(t, s.Withdraw(ctx, "u1", DataProcessing))
+	assert.Equal(t, 404, transport.CodeOf(s.Withdraw(ctx, "u1", DataProcessing)))
+	pending, err = s.Pending(ctx, "u1", DataProcessing)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	history, err := s.History(ctx, "u1")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.NotNil(t, history[0].WithdrawnAt)
+
+	n, err := s.Forget(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	history, err = s.History(ctx, "u1")
+	require.NoError(t, err)
+	assert.Empty(t, history[0].IP+history[0].UserAgent, "erasure clears the client evidence")
+}
+
+// TestRates checks the acceptance rates per version.
+func TestRates(t *testing.T) {
+	s := newService(t)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Publish(ctx, &Policy{Kind: TermsOfUse, Version: "v1", Body: "Términos."}))
+	for _, u := range []string{"u1", "u2", "u3"} {
+		_, err := s.Accept(ctx, u, TermsOfUse, "v1", Evidence{})
+		require.NoError(t, err)
+	}
+	now = now.Add(time.Hour)
+	require.NoError(t, s.Publish(ctx, &Policy{Kind: TermsOfUse, Version: "v2", Body: "Términos nuevos."}))
+	_, err := s.Accept(ctx, "u1", TermsOfUse, "v2", Evidence{})
+	require.NoError(t, err)
+	require.NoError(t, s.Withdraw(ctx, "u3", TermsOfUse))
+
+	rates, err := s.Rates(ctx, TermsOfUse)
+	require.NoError(t, err)
+	assert.Equal(t, []Rate{
+		{Version: "v2", Accepted: 1, Active: 1, Share: 1.0 / 3},
+		{Version: "v1", Accepted: 3, Active: 2, Withdrawn: 1, Share: 2.0 / 3},
+	}, rates)
+}
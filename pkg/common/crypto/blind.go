@@ -0,0 +1,56 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BlindIndex computes keyed hashes of encrypted values so they can still be
+// matched by equality. The key must differ from the encryption keys and cannot
+// be rotated without recomputing every index column.
+type BlindIndex struct {
+	key []byte
+}
+
+// NewBlindIndex creates a BlindIndex with the given HMAC key.
+func NewBlindIndex(key []byte) *BlindIndex {
+	return &BlindIndex{key: key}
+}
+
+// Sum returns the index of value within a purpose (e.g., "document"), so equal
+// values indexed for different purposes are not linkable. Values are normalized
+// first: case, spaces and punctuation such as "1.020.304-5" do not matter.
+func (b *BlindIndex) Sum(purpose, value string) string {
+	value = Normalize(value)
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Match returns a query function selecting rows whose index column matches value.
+func (b *BlindIndex) Match(column, purpose, value string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{Column: clause.Column{Name: column}, Value: b.Sum(purpose, value)})
+	}
+}
+
+// Normalize uppercases value and drops every character but letters and digits.
+func Normalize(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, value)
+}
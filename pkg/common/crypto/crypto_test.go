@@ -0,0 +1,190 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// citizen is a model with encrypted personal data and a blind index.
+type citizen struct {
+	ID            uint    `gorm:"primaryKey"`
+	FirstName     string  `gorm:"serializer:encrypted"`
+	DocumentID    string  `gorm:"serializer:encrypted"`
+	DocumentIndex string  `gorm:"size:64;index"`
+	Address       *string `gorm:"serializer:encrypted"`
+	City          string
+	UpdatedAt     time.Time
+}
+
+// keyringOf returns a keyring with keys "k1" and "k2" and the given primary.
+func keyringOf(t *testing.T, primary string) *Keyring {
+	k, err := NewKeyring(primary, map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, KeySize),
+		"k2": bytes.Repeat([]byte{2}, KeySize),
+	})
+	require.NoError(t, err)
+	return k
+}
+
+// TestKeyring checks envelope encryption, additional data and key IDs.
+func TestKeyring(t *testing.T) {
+	k := keyringOf(t, "k1")
+
+	a, err := k.Encrypt([]byte("1020304050"), []byte("users.document_id"))
+	require.NoError(t, err)
+	b, err := k.Encrypt([]byte("1020304050"), []byte("users.document_id"))
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b, "every value uses a fresh data key")
+	assert.NotContains(t, a, "1020304050")
+
+	plain, err := k.Decrypt(a, []byte("users.document_id"))
+	require.NoError(t, err)
+	assert.Equal(t, "1020304050", string(plain))
+
+	_, err = k.Decrypt(a, []byte("users.address"))
+	assert.Error(t, err)
+	_, err = k.Decrypt("not encrypted", nil)
+	assert.ErrorIs(t, err, ErrMalformed)
+
+	id, ok := KeyID(a)
+	assert.True(t, ok)
+	assert.Equal(t, "k1", id)
+	assert.False(t, k.Stale(a))
+	assert.True(t, keyringOf(t, "k2").Stale(a))
+	assert.True(t, k.Stale("plaintext"))
+
+	other, err := NewKeyring("k3", map[string][]byte{"k3": bytes.Repeat([]byte{3}, KeySize)})
+	require.NoError(t, err)
+	_, err = other.Decrypt(a, []byte("users.document_id"))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = NewKeyring("missing", map[string][]byte{"k1": bytes.Repeat([]byte{1}, KeySize)})
+	assert.Error(t, err)
+	_, err = NewKeyring("k1", map[string][]byte{"k1": []byte("short")})
+	assert.Error(t, err)
+
+	keys, err := ParseKeys("k1:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=, k2:AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI=")
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{2}, KeySize), keys["k2"])
+}
+
+// TestBlindIndex checks normalization and purpose separation.
+func TestBlindIndex(t *testing.T) {
+	idx := NewBlindIndex([]byte("index-key"))
+	assert.Equal(t, idx.Sum("document", "1.020.304-50"), idx.Sum("document", "102030450"))
+	assert.NotEqual(t, idx.Sum("document", "102030450"), idx.Sum("phone", "102030450"))
+	assert.NotEqual(t, idx.Sum("document", "102030450"), NewBlindIndex([]byte("other")).Sum("document", "102030450"))
+	assert.Empty(t, idx.Sum("document", " - "))
+}
+
+// newDB returns a sqlite database with the citizen table.
+func newDB(t *testing.T) *gorm.DB {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "crypto.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&citizen{}))
+	return gdb
+}
+
+// TestSerializer checks fields are stored encrypted and found by blind index.
+func TestSerializer(t *testing.T) {
+	Use(keyringOf(t, "k1"))
+	gdb := newDB(t)
+	idx := NewBlindIndex([]byte("index-key"))
+
+	address := "Calle 10 # 5-20"
+	c := citizen{FirstName: "Ana", DocumentID: "1020304050", DocumentIndex: idx.Sum("document", "1020304050"), Address: &address, City: "Bogotá"}
+	require.NoError(t, gdb.Create(&c).Error)
+	require.NoError(t, gdb.Create(&citizen{FirstName: "Luis", DocumentID: "99", City: "Cali"}).Error)
+
+	var raw map[string]any
+	require.NoError(t, gdb.Table("citizens").Where("id = ?", c.ID).Take(&raw).Error)
+	assert.True(t, strings.HasPrefix(raw["document_id"].(string), prefix))
+	assert.True(t, strings.HasPrefix(raw["address"].(string), prefix))
+	assert.Equal(t, "Bogotá", raw["city"])
+
+	var found citizen
+	require.NoError(t, gdb.Scopes(idx.Match("document_index", "document", "1.020.304.050")).First(&found).Error)
+	assert.Equal(t, "Ana", found.FirstName)
+	assert.Equal(t, "1020304050", found.DocumentID)
+	require.NotNil(t, found.Address)
+	assert.Equal(t, address, *found.Address)
+
+	var luis citizen
+	require.NoError(t, gdb.Where("city = ?", "Cali").First(&luis).Error)
+	assert.Nil(t, luis.Address)
+}
+
+// TestRotator checks stale and plaintext values are re-encrypted by the job.
+func TestRotator(t *testing.T) {
+	Use(keyringOf(t, "k1"))
+	gdb := newDB(t)
+	require.NoError(t, jobs.Migrate(gdb))
+	ctx := context.Background()
+
+	require.NoError(t, gdb.Create(&citizen{FirstName: "Ana", DocumentID: "1020304050"}).Error)
+	require.NoError(t, gdb.Exec("INSERT INTO citizens (first_name, document_id, city) VALUES (?, ?, ?)", "Luis", "99", "Cali").Error)
+	before := time.Now().Add(-time.Hour)
+	require.NoError(t, gdb.Model(&citizen{}).Where("1 = 1").UpdateColumn("updated_at", before).Error)
+
+	Use(keyringOf(t, "k2"))
+	r := NewRotator(gdb, 1)
+	require.NoError(t, r.Add(&citizen{}))
+	assert.Error(t, r.Add(&jobs.Job{}))
+
+	w := jobs.NewWorker(gdb, jobs.WorkerConfig{})
+	r.Register(w)
+	require.NoError(t, r.Enqueue(ctx, jobs.NewQueue(gdb)))
+	ran, err := w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	var rows []map[string]any
+	require.NoError(t, gdb.Table("citizens").Order("id").Find(&rows).Error)
+	require.Len(t, rows, 2)
+	for _, row := range rows {
+		id, ok := KeyID(row["document_id"].(string))
+		assert.True(t, ok)
+		assert.Equal(t, "k2", id)
+		assert.Nil(t, row["address"], "nil values stay null")
+	}
+
+	var all []citizen
+	require.NoError(t, gdb.Order("id").Find(&all).Error)
+	assert.Equal(t, "Luis", all[1].FirstName)
+	assert.Equal(t, "99", all[1].DocumentID)
+	assert.WithinDuration(t, before, all[0].UpdatedAt, time.Second, "timestamps are kept")
+
+	n, err := r.Reencrypt(ctx, "citizens")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// A user edit between the read and the rewrite of a batch is kept.
+	Use(keyringOf(t, "k1"))
+	edited := false
+	require.NoError(t, gdb.Callback().Query().After("gorm:query").Register("test:edit", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*[]citizen); ok && !edited {
+			edited = true
+			require.NoError(t, gdb.Model(&citizen{}).Where("id = ?", 1).Update("first_name", "Ana María").Error)
+		}
+	}))
+	r = NewRotator(gdb, 10)
+	require.NoError(t, r.Add(&citizen{}))
+	n, err = r.Reencrypt(ctx, "citizens")
+	require.NoError(t, err)
+	require.True(t, edited)
+	assert.Equal(t, 1, n, "the edited row is skipped")
+
+	var ana citizen
+	require.NoError(t, gdb.First(&ana, 1).Error)
+	assert.Equal(t, "Ana María", ana.FirstName)
+}
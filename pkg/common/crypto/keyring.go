@@ -0,0 +1,183 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length of key encryption keys and data keys (AES-256).
+const KeySize = 32
+
+// prefix marks values produced by Encrypt, followed by the format version.
+const prefix = "enc:v1:"
+
+// ErrMalformed is returned when decrypting a value not produced by Encrypt.
+var ErrMalformed = errors.New("crypto: malformed ciphertext")
+
+// ErrUnknownKey is returned when a value was encrypted with a key missing from the keyring.
+var ErrUnknownKey = errors.New("crypto: unknown key")
+
+// Keyring holds the key encryption keys by ID. New values are encrypted with the
+// primary key; the others are kept to decrypt values until they are re-encrypted.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring creates a Keyring encrypting with the primary key. Key IDs must not contain ':'.
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	k := &Keyring{primary: primary, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("crypto: invalid key ID %q", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %s: %w", id, err)
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[primary]; !ok {
+		return nil, fmt.Errorf("crypto: primary key %q is not in the keyring", primary)
+	}
+	return k, nil
+}
+
+// ParseKeys parses a "id:base64,id:base64" list of keys.
+func ParseKeys(s string) (map[string][]byte, error) {
+	keys := map[string][]byte{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("crypto: key %q is not in id:base64 form", part)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %s: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+// Primary returns the ID of the key encrypting new values.
+func (k *Keyring) Primary() string { return k.primary }
+
+// Encrypt seals plaintext with a fresh data key, itself sealed with the primary
+// key. The additional data binds the value to its location (e.g., table and
+// column) and must be given again to decrypt.
+func (k *Keyring) Encrypt(plaintext, additional []byte) (string, error) {
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := seal(k.keys[k.primary], dek, []byte(k.primary))
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(data, plaintext, additional)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	return prefix + k.primary + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with the same additional data.
+func (k *Keyring) Decrypt(value string, additional []byte) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if !strings.HasPrefix(value, prefix) || len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	kek, ok := k.keys[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, parts[0])
+	}
+
+	enc := base64.RawURLEncoding
+	wrapped, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	sealed, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	dek, err := open(kek, wrapped, []byte(parts[0]))
+	if err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, sealed, additional)
+}
+
+// KeyID returns the ID of the key that sealed a value produced by Encrypt.
+func KeyID(value string) (string, bool) {
+	if !strings.HasPrefix(value, prefix) {
+		return "", false
+	}
+	id, _, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id, ok
+}
+
+// Stale reports whether a value must be re-encrypted: it is plaintext or it was
+// sealed with a key other than the primary one. Empty values are never stale.
+func (k *Keyring) Stale(value string) bool {
+	if value == "" {
+		return false
+	}
+	id, ok := KeyID(value)
+	return !ok || id != k.primary
+}
+
+// newAEAD returns AES-GCM for a 256-bit key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("crypto: keys must be %d bytes long", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce prepended to the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+// open decrypts a value produced by seal.
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	n := aead.NonceSize()
+	plaintext, err := aead.Open(nil, sealed[:n], sealed[n:], additional)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: decryption failed: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,204 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// JobKind is the job kind re-encrypting the registered models.
+const JobKind = "crypto.reencrypt"
+
+// Reencryption selects the table re-encrypted by a job; an empty table means every model.
+type Reencryption struct {
+	Table string // Table is the table of a registered model.
+}
+
+// Rotator re-encrypts the encrypted fields of models after the primary key
+// changes, and encrypts plaintext values left from before a field was encrypted.
+type Rotator struct {
+	db     *gorm.DB
+	batch  int
+	models map[string]*schema.Schema
+}
+
+// NewRotator creates a Rotator reading batch rows at a time (default: 500).
+func NewRotator(db *gorm.DB, batch int) *Rotator {
+	if batch <= 0 {
+		batch = 500
+	}
+	return &Rotator{db: db, batch: batch, models: map[string]*schema.Schema{}}
+}
+
+// Add registers models with encrypted fields and a single primary key.
+func (r *Rotator) Add(models ...any) error {
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: r.db}
+		if err := stmt.Parse(m); err != nil {
+			return err
+		}
+		s := stmt.Schema
+		if s.PrioritizedPrimaryField == nil {
+			return fmt.Errorf("crypto: %s has no single primary key", s.Name)
+		}
+		if len(encrypted(s)) == 0 {
+			return fmt.Errorf("crypto: %s has no encrypted fields", s.Name)
+		}
+		r.models[s.Table] = s
+	}
+	return nil
+}
+
+// Tables returns the tables of the registered models in order.
+func (r *Rotator) Tables() []string {
+	tables := make([]string, 0, len(r.models))
+	for t := range r.models {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// Reencrypt rewrites the rows of a registered table holding a stale value and
+// returns how many were rewritten. Only the encrypted columns are written, so
+// timestamps and hooks are left untouched. Rows of every tenant are rewritten.
+// Rows are read from the primary and only rewritten while their encrypted
+// columns still hold the values read, so concurrent edits are never lost.
+// It can be interrupted and run again.
+func (r *Rotator) Reencrypt(ctx context.Context, table string) (int, error) {
+	s, ok := r.models[table]
+	if !ok {
+		return 0, fmt.Errorf("crypto: table %s is not registered", table)
+	}
+	k, err := current()
+	if err != nil {
+		return 0, err
+	}
+
+	fields := encrypted(s)
+	pk := s.PrioritizedPrimaryField
+	columns := []string{pk.DBName}
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		columns = append(columns, f.DBName)
+		names[i] = f.Name
+	}
+
+	tx := r.db.WithContext(db.WithPrimary(tenant.WithoutScope(ctx)))
+	total := 0
+	var last any
+	for {
+		q := tx.Table(s.Table).Select(columns).Order(pk.DBName).Limit(r.batch)
+		if last != nil {
+			q = q.Where(clause.Gt{Column: clause.Column{Name: pk.DBName}, Value: last})
+		}
+		var rows []map[string]any
+		if err := q.Find(&rows).Error; err != nil {
+			return total, err
+		}
+		if len(rows) == 0 {
+			return total, nil
+		}
+		last = rows[len(rows)-1][pk.DBName]
+
+		var ids []any
+		read := map[string]map[string]any{}
+		for _, row := range rows {
+			for _, f := range fields {
+				if k.Stale(stored(row[f.DBName])) {
+					ids = append(ids, row[pk.DBName])
+					read[fmt.Sprint(row[pk.DBName])] = row
+					break
+				}
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		dest := reflect.New(reflect.SliceOf(s.ModelType))
+		err := tx.Unscoped().Where(clause.IN{Column: clause.Column{Name: pk.DBName}, Values: ids}).Find(dest.Interface()).Error
+		if err != nil {
+			return total, err
+		}
+		for i := 0; i < dest.Elem().Len(); i++ {
+			v := dest.Elem().Index(i)
+			id, _ := pk.ValueOf(ctx, v)
+			row, ok := read[fmt.Sprint(id)]
+			if !ok {
+				continue
+			}
+
+			// A row edited since it was read no longer matches and keeps the edit;
+			// if it is still stale, the next run rewrites it.
+			unchanged := make([]clause.Expression, len(fields))
+			for j, f := range fields {
+				unchanged[j] = clause.Eq{Column: clause.Column{Name: f.DBName}, Value: row[f.DBName]}
+			}
+			m := v.Addr().Interface()
+			res := tx.Unscoped().Model(m).Clauses(clause.Where{Exprs: unchanged}).Select(names).UpdateColumns(m)
+			if res.Error != nil {
+				return total, res.Error
+			}
+			total += int(res.RowsAffected)
+		}
+	}
+}
+
+// Register handles re-encryption jobs on the worker.
+func (r *Rotator) Register(w *jobs.Worker) {
+	w.Handle(JobKind, func(ctx context.Context, job *jobs.Job) error {
+		var req Reencryption
+		if err := job.Bind(&req); err != nil {
+			return err
+		}
+		tables := []string{req.Table}
+		if req.Table == "" {
+			tables = r.Tables()
+		}
+		for _, t := range tables {
+			if _, err := r.Reencrypt(ctx, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Enqueue schedules the re-encryption of every registered model, e.g., after
+// deploying a new primary key.
+func (r *Rotator) Enqueue(ctx context.Context, q *jobs.Queue) error {
+	_, err := q.Enqueue(ctx, JobKind, Reencryption{})
+	return err
+}
+
+// encrypted returns the fields using the encrypted serializer.
+func encrypted(s *schema.Schema) []*schema.Field {
+	var out []*schema.Field
+	for _, f := range s.Fields {
+		if _, ok := f.Serializer.(Serializer); ok && f.DBName != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// stored returns a raw column value as a string.
+func stored(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,127 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the GORM serializer of encrypted fields:
+//
+//	DocumentID string `gorm:"serializer:encrypted"`
+//
+// It supports string, *string and []byte fields.
+const SerializerName = "encrypted"
+
+// keyring is the keyring used by the serializer.
+var keyring atomic.Pointer[Keyring]
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// Use sets the keyring of the encrypted serializer. GORM serializers are global,
+// so it is called once at startup, before any encrypted field is read or written.
+func Use(k *Keyring) {
+	keyring.Store(k)
+}
+
+// Serializer encrypts fields with the keyring set by Use. Values are bound to
+// their table and column, so a ciphertext copied to another column fails to
+// decrypt. Empty values are stored as is, and plaintext values found in the
+// database are read unchanged, so existing columns can be encrypted in place
+// by the re-encryption job.
+type Serializer struct{}
+
+// current returns the keyring set by Use.
+func current() (*Keyring, error) {
+	k := keyring.Load()
+	if k == nil {
+		return nil, fmt.Errorf("crypto: no keyring configured, call crypto.Use first")
+	}
+	return k, nil
+}
+
+// additional returns the additional data binding a value to its column.
+func additional(field *schema.Field) []byte {
+	return []byte(field.Schema.Table + "." + field.DBName)
+}
+
+// Scan implements schema.SerializerInterface.
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	fieldValue := reflect.New(field.FieldType)
+
+	var stored string
+	switch v := dbValue.(type) {
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	case nil:
+		field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+		return nil
+	default:
+		return fmt.Errorf("crypto: unsupported database value %T for %s", dbValue, field.Name)
+	}
+
+	plaintext := []byte(stored)
+	if strings.HasPrefix(stored, prefix) {
+		k, err := current()
+		if err != nil {
+			return err
+		}
+		if plaintext, err = k.Decrypt(stored, additional(field)); err != nil {
+			return fmt.Errorf("crypto: field %s: %w", field.Name, err)
+		}
+	}
+
+	elem := fieldValue.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem.Set(reflect.New(elem.Type().Elem()))
+		elem = elem.Elem()
+	}
+	switch elem.Kind() {
+	case reflect.String:
+		elem.SetString(string(plaintext))
+	case reflect.Slice:
+		elem.SetBytes(plaintext)
+	default:
+		return fmt.Errorf("crypto: unsupported field type %s for %s", field.FieldType, field.Name)
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerInterface.
+func (Serializer) Value(_ context.Context, field *schema.Field, _ reflect.Value, fieldValue any) (any, error) {
+	var plaintext []byte
+	switch v := fieldValue.(type) {
+	case string:
+		plaintext = []byte(v)
+	case *string:
+		if v == nil {
+			return nil, nil
+		}
+		plaintext = []byte(*v)
+	case []byte:
+		if v == nil {
+			return nil, nil
+		}
+		plaintext = v
+	default:
+		return nil, fmt.Errorf("crypto: unsupported field type %T for %s", fieldValue, field.Name)
+	}
+	if len(plaintext) == 0 {
+		return "", nil
+	}
+
+	k, err := current()
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt(plaintext, additional(field))
+}
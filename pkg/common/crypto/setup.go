@@ -0,0 +1,33 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+)
+
+// SetupEnvironmentKeyring creates the Keyring and BlindIndex from the provided
+// environment and sets the keyring of the encrypted serializer.
+// CRYPTO_KEYS lists "id:base64" keys, CRYPTO_PRIMARY_KEY selects the one encrypting
+// new values and CRYPTO_INDEX_KEY is the base64 key of the blind indexes.
+func SetupEnvironmentKeyring() (*Keyring, *BlindIndex, error) {
+
+	keys, err := ParseKeys(config.MustGet(config.CryptoKeys))
+	if err != nil {
+		return nil, nil, err
+	}
+	k, err := NewKeyring(config.MustGet(config.CryptoPrimaryKey), keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(config.MustGet(config.CryptoIndexKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("crypto: index key: %w", err)
+	}
+
+	Use(k)
+	return k, NewBlindIndex(indexKey), nil
+
+}
@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultBatchSize is the number of rows written per statement by batch operations.
+const DefaultBatchSize = 500
+
+// RowError is the failure of a row of a batch.
+type RowError struct {
+	Index int   // Index is the position of the row in the batch.
+	Err   error // Err is the error writing the row.
+}
+
+// Error returns the error of the row.
+func (e RowError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error of the row.
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON encodes the row error with its message.
+func (e RowError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Index int    `json:"index"`
+		Error string `json:"error"`
+	}{e.Index, e.Err.Error()})
+}
+
+// BatchResult is the outcome of a batch write.
+type BatchResult struct {
+	Affected int64      `json:"affected"`         // Affected is the number of rows written.
+	Errors   []RowError `json:"errors,omitempty"` // Errors lists the rows not written, by position.
+}
+
+// BatchOption customizes a batch write.
+type BatchOption func(*batchOptions)
+
+// batchOptions holds the settings of a batch write.
+type batchOptions struct {
+	size     int
+	conflict []string
+	update   []string
+}
+
+// newBatchOptions applies the given options over the defaults.
+func newBatchOptions(opts []BatchOption) *batchOptions {
+	o := &batchOptions{size: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.size <= 0 {
+		o.size = DefaultBatchSize
+	}
+	return o
+}
+
+// WithBatchSize sets the number of rows written per statement.
+func WithBatchSize(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.size = n
+	}
+}
+
+// WithConflict sets the columns identifying existing rows on upserts (default: the primary key).
+func WithConflict(columns ...string) BatchOption {
+	return func(o *batchOptions) {
+		o.conflict = columns
+	}
+}
+
+// WithUpdateColumns sets the columns overwritten on upserts (default: all).
+func WithUpdateColumns(columns ...string) BatchOption {
+	return func(o *batchOptions) {
+		o.update = columns
+	}
+}
+
+// CreateBatch inserts models in batches, each in its own transaction. When a
+// batch fails its rows are retried one by one, so a bad row is reported in the
+// result without rejecting the others. The error is only set when the batch
+// cannot go on, such as when the context is done.
+func (r *repository[T]) CreateBatch(ctx context.Context, models []*T, opts ...BatchOption) (*BatchResult, error) {
+	o := newBatchOptions(opts)
+	return r.batch(ctx, models, o.size, func(tx *gorm.DB, rows []*T) *gorm.DB {
+		return tx.Create(&rows)
+	})
+}
+
+// UpsertBatch inserts models in batches like CreateBatch, updating the rows
+// that already exist.
+func (r *repository[T]) UpsertBatch(ctx context.Context, models []*T, opts ...BatchOption) (*BatchResult, error) {
+	o := newBatchOptions(opts)
+	oc := clause.OnConflict{UpdateAll: len(o.update) == 0}
+	for _, c := range o.conflict {
+		oc.Columns = append(oc.Columns, clause.Column{Name: c})
+	}
+	if len(o.update) > 0 {
+		oc.DoUpdates = clause.AssignmentColumns(o.update)
+	}
+	return r.batch(ctx, models, o.size, func(tx *gorm.DB, rows []*T) *gorm.DB {
+		return tx.Clauses(oc).Create(&rows)
+	})
+}
+
+// batch writes models in batches of size, each in its own transaction, retrying
+// the rows of a failed batch one by one.
+func (r *repository[T]) batch(ctx context.Context, models []*T, size int, write func(*gorm.DB, []*T) *gorm.DB) (*BatchResult, error) {
+	res := &BatchResult{}
+	db := r.db.WithContext(ctx)
+	for start := 0; start < len(models); start += size {
+		rows := models[start:min(start+size, len(models))]
+		if n, err := transaction(db, rows, write); err == nil {
+			res.Affected += n
+			continue
+		}
+		for i := range rows {
+			if err := ctx.Err(); err != nil {
+				return res, err
+			}
+			n, err := transaction(db, rows[i:i+1], write)
+			if err != nil {
+				res.Errors = append(res.Errors, RowError{Index: start + i, Err: err})
+				continue
+			}
+			res.Affected += n
+		}
+	}
+	return res, nil
+}
+
+// transaction writes rows in their own transaction, returning how many were affected.
+func transaction[T any](db *gorm.DB, rows []*T, write func(*gorm.DB, []*T) *gorm.DB) (int64, error) {
+	var n int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := write(tx, rows)
+		n = res.RowsAffected
+		return res.Error
+	})
+	return n, err
+}
+
+// UpdateWhere sets values on every record matched by the query functions in
+// a single statement, returning how many were updated. Values are keyed by
+// column. Updates without conditions are rejected.
+func (r *repository[T]) UpdateWhere(ctx context.Context, values map[string]any, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	q := r.db.WithContext(ctx).Model(new(T))
+	for _, fn := range queryFns {
+		q = fn(q)
+	}
+	res := q.Updates(values)
+	return res.RowsAffected, res.Error
+}
+
+// DeleteWhere removes every record matched by the query functions in a single
+// statement, returning how many were deleted. Deletes without conditions are
+// rejected.
+func (r *repository[T]) DeleteWhere(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	q := r.db.WithContext(ctx)
+	for _, fn := range queryFns {
+		q = fn(q)
+	}
+	res := q.Delete(new(T))
+	return res.RowsAffected, res.Error
+}
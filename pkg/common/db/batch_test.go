@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// member is a model with a unique column, to make single rows of a batch fail.
+type member struct {
+	ID    uint   `gorm:"primaryKey"`
+	Email string `gorm:"uniqueIndex"`
+	Name  string
+}
+
+// newMembers returns a repository of members over sqlite.
+func newMembers(t *testing.T) Repository[member] {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "batch.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&member{}))
+	return NewRepository[member](gdb)
+}
+
+// TestCreateBatch checks rows are written in batches and failed rows are reported by position.
+func TestCreateBatch(t *testing.T) {
+	repo := newMembers(t)
+	ctx := context.Background()
+
+	models := []*member{
+		{Email: "ana@civic.spot"}, {Email: "beto@civic.spot"}, {Email: "caro@civic.spot"},
+		{Email: "ana@civic.spot"}, {Email: "dani@civic.spot"},
+	}
+	res, err := repo.CreateBatch(ctx, models, WithBatchSize(2))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), res.Affected)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, 3, res.Errors[0].Index)
+	assert.Zero(t, models[3].ID)
+	assert.NotZero(t, models[4].ID)
+
+	b, err := json.Marshal(res.Errors[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"index":3`)
+
+	list, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 4)
+
+	r := <-repo.Async().CreateBatchAsync(ctx, []*member{{Email: "eli@civic.spot"}})
+	require.NoError(t, r.Err)
+	assert.Equal(t, int64(1), r.Data.Affected)
+}
+
+// TestUpsertBatch checks existing rows are updated and new ones created.
+func TestUpsertBatch(t *testing.T) {
+	repo := newMembers(t)
+	ctx := context.Background()
+
+	ana := &member{Email: "ana@civic.spot", Name: "Ana"}
+	require.NoError(t, repo.Create(ctx, ana))
+
+	res, err := repo.UpsertBatch(ctx, []*member{{Email: "ana@civic.spot", Name: "Ana María"}, {Email: "beto@civic.spot", Name: "Beto"}},
+		WithConflict("email"), WithUpdateColumns("name"))
+	require.NoError(t, err)
+	assert.Empty(t, res.Errors)
+
+	got, err := repo.GetByID(ctx, ana.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ana María", got.Name)
+	list, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+}
+
+// TestUpdateDeleteWhere checks bulk writes report the matched rows and require conditions.
+func TestUpdateDeleteWhere(t *testing.T) {
+	repo := newMembers(t)
+	ctx := context.Background()
+
+	_, err := repo.CreateBatch(ctx, []*member{
+		{Email: "ana@civic.spot", Name: "new"}, {Email: "beto@civic.spot", Name: "new"}, {Email: "caro@civic.spot", Name: "old"},
+	})
+	require.NoError(t, err)
+	byName := func(name string) func(*gorm.DB) *gorm.DB {
+		return func(q *gorm.DB) *gorm.DB { return q.Where("name = ?", name) }
+	}
+
+	n, err := repo.UpdateWhere(ctx, map[string]any{"name": "seen"}, byName("new"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	_, err = repo.UpdateWhere(ctx, map[string]any{"name": "all"})
+	assert.ErrorIs(t, err, gorm.ErrMissingWhereClause)
+	_, err = repo.DeleteWhere(ctx)
+	assert.ErrorIs(t, err, gorm.ErrMissingWhereClause)
+
+	r := <-repo.Async().DeleteWhereAsync(ctx, byName("seen"))
+	require.NoError(t, r.Err)
+	assert.Equal(t, int64(2), r.Data)
+	list, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "old", list[0].Name)
+}
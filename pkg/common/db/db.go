@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/ianfedev/civicspot-backend/pkg/common/logger"
 	"strings"
+	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -18,6 +19,15 @@ type Config struct {
 	Dialect  string // mysql, postgres, sqlite
 	DSN      string // data source name
 	LogLevel string // silent, error, warn, info
+
+	MaxOpenConns     int           // MaxOpenConns bounds the open connections of each database (default: unlimited).
+	MaxIdleConns     int           // MaxIdleConns bounds the idle connections of each database (default: 2).
+	ConnMaxLifetime  time.Duration // ConnMaxLifetime closes connections older than this (default: never).
+	ConnMaxIdleTime  time.Duration // ConnMaxIdleTime closes connections idle for longer than this (default: never).
+	StatementTimeout time.Duration // StatementTimeout bounds queries and writes whose context has no deadline (default: none).
+
+	Replicas       []string      // Replicas are DSNs of read replicas using the same dialect.
+	HealthInterval time.Duration // HealthInterval is the period of replica health checks (default: 10s).
 }
 
 // New creates a new *gorm.DB instance with the given configuration.
@@ -37,10 +47,44 @@ func New(cfg Config) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := configurePool(db, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.StatementTimeout > 0 {
+		if err := registerTimeout(db, cfg.StatementTimeout); err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.Replicas) > 0 {
+		if err := db.Use(newRouter(cfg)); err != nil {
+			return nil, err
+		}
+	}
 
 	return db, nil
 }
 
+// configurePool applies the connection pool settings to a database.
+func configurePool(db *gorm.DB, cfg Config) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+	return nil
+}
+
 // getDialect returns a GORM driver based on dialect.
 func getDialect(cfg Config) (gorm.Dialector, error) {
 	switch strings.ToLower(cfg.Dialect) {
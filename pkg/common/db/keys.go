@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// PrimaryKeys returns the non-zero primary keys of a row or a slice of rows.
+func PrimaryKeys(ctx context.Context, s *schema.Schema, rv reflect.Value) []any {
+	rv = reflect.Indirect(rv)
+	var out []any
+	add := func(row reflect.Value) {
+		if v, zero := s.PrioritizedPrimaryField.ValueOf(ctx, reflect.Indirect(row)); !zero {
+			out = append(out, v)
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			add(rv.Index(i))
+		}
+	case reflect.Struct:
+		add(rv)
+	}
+	return out
+}
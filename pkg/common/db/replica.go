@@ -0,0 +1,276 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// routerName is the name of the replica router plugin.
+const routerName = "db:replicas"
+
+type stickyKey struct{}
+
+type primaryKey struct{}
+
+// sticky records whether a request wrote to the primary.
+type sticky struct {
+	written atomic.Bool
+}
+
+// WithReadYourWrites returns a copy of ctx whose reads go to the primary once
+// a write was made with it, so a request sees its own changes despite
+// replication lag. Use one per request.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(stickyKey{}).(*sticky); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, stickyKey{}, &sticky{})
+}
+
+// WithPrimary returns a copy of ctx whose reads always go to the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// onPrimary reports whether reads with ctx must go to the primary.
+func onPrimary(ctx context.Context) bool {
+	if v, _ := ctx.Value(primaryKey{}).(bool); v {
+		return true
+	}
+	s, ok := ctx.Value(stickyKey{}).(*sticky)
+	return ok && s.written.Load()
+}
+
+// replica is a read replica connection pool.
+type replica struct {
+	pool    *sql.DB
+	healthy atomic.Bool
+}
+
+// router sends reads outside transactions to healthy replicas in turn and
+// evicts replicas failing their health check until they recover.
+type router struct {
+	cfg      Config
+	replicas []*replica
+	next     atomic.Uint64
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// newRouter creates a router for the replicas of cfg.
+func newRouter(cfg Config) *router {
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = 10 * time.Second
+	}
+	return &router{cfg: cfg, stop: make(chan struct{})}
+}
+
+// Name returns the plugin name.
+func (r *router) Name() string { return routerName }
+
+// Initialize opens the replicas, registers the routing callbacks and starts
+// the health checks. Replicas down at startup are evicted, not fatal.
+func (r *router) Initialize(db *gorm.DB) error {
+	for _, dsn := range r.cfg.Replicas {
+		dialect, err := getDialect(Config{Dialect: r.cfg.Dialect, DSN: dsn})
+		if err != nil {
+			return errors.Join(err, r.close())
+		}
+		rdb, err := gorm.Open(dialect, &gorm.Config{Logger: gormLogger.Discard, DisableAutomaticPing: true})
+		if err != nil {
+			return errors.Join(err, r.close())
+		}
+		if err := configurePool(rdb, r.cfg); err != nil {
+			return errors.Join(err, r.close())
+		}
+		pool, _ := rdb.DB()
+		r.replicas = append(r.replicas, &replica{pool: pool})
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Query().Before("gorm:query").Register("db:replica_query", r.route),
+		cb.Row().Before("gorm:row").Register("db:replica_row", r.route),
+		cb.Create().After("gorm:create").Register("db:written_create", written),
+		cb.Update().After("gorm:update").Register("db:written_update", written),
+		cb.Delete().After("gorm:delete").Register("db:written_delete", written),
+		cb.Raw().After("gorm:raw").Register("db:written_raw", written),
+	} {
+		if err != nil {
+			return errors.Join(err, r.close())
+		}
+	}
+
+	r.check()
+	go r.monitor()
+	return nil
+}
+
+// route sends a model read, such as List or GetByID, to a replica unless it
+// runs in a transaction, locks rows or must see the writes of its request.
+// Raw SQL and table reads, including those of migrations, use the primary.
+func (r *router) route(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.SQL.Len() > 0 || onPrimary(stmt.Context) {
+		return
+	}
+	if _, ok := stmt.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	if _, ok := stmt.Clauses["FOR"]; ok {
+		return
+	}
+	if rep := r.pick(); rep != nil {
+		stmt.ConnPool = rep.pool
+	}
+}
+
+// written marks the request of a successful write as sticky.
+func written(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if s, ok := db.Statement.Context.Value(stickyKey{}).(*sticky); ok {
+		s.written.Store(true)
+	}
+}
+
+// pick returns the next healthy replica, or nil when all are evicted.
+func (r *router) pick() *replica {
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+// monitor checks the replicas periodically until the router is closed.
+func (r *router) monitor() {
+	t := time.NewTicker(r.cfg.HealthInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-t.C:
+			r.check()
+		}
+	}
+}
+
+// check pings every replica, evicting or restoring it.
+func (r *router) check() {
+	for i, rep := range r.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), r.cfg.HealthInterval)
+		err := rep.pool.PingContext(ctx)
+		cancel()
+
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			logger.L().Info("database replica restored", zap.Int("replica", i))
+		} else {
+			logger.L().Warn("database replica evicted", zap.Int("replica", i), zap.Error(err))
+		}
+	}
+}
+
+// close stops the health checks and closes the replicas.
+func (r *router) close() error {
+	r.once.Do(func() { close(r.stop) })
+	var errs []error
+	for _, rep := range r.replicas {
+		errs = append(errs, rep.pool.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Replicas returns how many replicas of a database created by New are
+// healthy, out of the configured ones.
+func Replicas(db *gorm.DB) (healthy, total int) {
+	r, ok := db.Config.Plugins[routerName].(*router)
+	if !ok {
+		return 0, 0
+	}
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			healthy++
+		}
+	}
+	return healthy, len(r.replicas)
+}
+
+// Close closes a database created by New and its replicas.
+func Close(db *gorm.DB) error {
+	var errs []error
+	if r, ok := db.Config.Plugins[routerName].(*router); ok {
+		errs = append(errs, r.close())
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	return errors.Join(append(errs, sqlDB.Close())...)
+}
+
+// timeout holds the context replaced by a statement timeout.
+type timeout struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// timeoutKey stores the statement timeout in the statement.
+const timeoutKey = "db:timeout"
+
+// registerTimeout bounds queries and writes without a context deadline. Rows
+// returned to the caller, such as those of Stream, are not bounded.
+func registerTimeout(db *gorm.DB, d time.Duration) error {
+	start := func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Context.Deadline(); ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(tx.Statement.Context, d)
+		tx.InstanceSet(timeoutKey, timeout{parent: tx.Statement.Context, cancel: cancel})
+		tx.Statement.Context = ctx
+	}
+	end := func(tx *gorm.DB) {
+		if v, ok := tx.InstanceGet(timeoutKey); ok {
+			t := v.(timeout)
+			t.cancel()
+			tx.Statement.Context = t.parent
+		}
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Query().Before("gorm:query").Register("db:timeout_query", start),
+		cb.Query().After("gorm:query").Register("db:timeout_query_end", end),
+		cb.Create().Before("gorm:create").Register("db:timeout_create", start),
+		cb.Create().After("gorm:create").Register("db:timeout_create_end", end),
+		cb.Update().Before("gorm:update").Register("db:timeout_update", start),
+		cb.Update().After("gorm:update").Register("db:timeout_update_end", end),
+		cb.Delete().Before("gorm:delete").Register("db:timeout_delete", start),
+		cb.Delete().After("gorm:delete").Register("db:timeout_delete_end", end),
+		cb.Raw().Before("gorm:raw").Register("db:timeout_raw", start),
+		cb.Raw().After("gorm:raw").Register("db:timeout_raw_end", end),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
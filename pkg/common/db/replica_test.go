@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// place is a model stored differently on the primary and the replica, to
+// tell which one served a read.
+type place struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+// newReplicated returns a database with a replica holding other rows.
+func newReplicated(t *testing.T, cfg Config) *gorm.DB {
+	logger.Init(logger.Config{Level: "error"})
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+
+	rdb, err := gorm.Open(sqlite.Open(replica), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, rdb.AutoMigrate(&place{}))
+	require.NoError(t, rdb.Create(&place{ID: 1, Name: "replica"}).Error)
+
+	cfg.Dialect, cfg.DSN, cfg.LogLevel, cfg.Replicas = "sqlite", primary, "silent", []string{replica}
+	gdb, err := New(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = Close(gdb) })
+	require.NoError(t, gdb.AutoMigrate(&place{}))
+	require.NoError(t, gdb.Create(&place{ID: 1, Name: "primary"}).Error)
+	return gdb
+}
+
+// TestReplicaRouting checks reads go to replicas unless they must see the primary.
+func TestReplicaRouting(t *testing.T) {
+	gdb := newReplicated(t, Config{MaxOpenConns: 4, HealthInterval: 10 * time.Millisecond})
+	repo := NewRepository[place](gdb)
+	ctx := context.Background()
+
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 4, sqlDB.Stats().MaxOpenConnections)
+	healthy, total := Replicas(gdb)
+	assert.Equal(t, 1, healthy)
+	assert.Equal(t, 1, total)
+
+	p, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "replica", p.Name)
+	list, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []place{{ID: 1, Name: "replica"}}, list)
+
+	p, err = repo.GetByID(WithPrimary(ctx), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", p.Name)
+
+	require.NoError(t, gdb.Transaction(func(tx *gorm.DB) error {
+		var in place
+		require.NoError(t, tx.First(&in, 1).Error)
+		assert.Equal(t, "primary", in.Name, "transactions read the primary")
+		return nil
+	}))
+
+	sticky := WithReadYourWrites(ctx)
+	p, err = repo.GetByID(sticky, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "replica", p.Name)
+	require.NoError(t, repo.Create(sticky, &place{ID: 2, Name: "new"}))
+	p, err = repo.GetByID(sticky, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "new", p.Name, "reads after a write see it")
+	assert.Equal(t, sticky, WithReadYourWrites(sticky))
+
+	r := gdb.Config.Plugins[routerName].(*router)
+	require.NoError(t, r.replicas[0].pool.Close())
+	assert.Eventually(t, func() bool {
+		healthy, _ := Replicas(gdb)
+		return healthy == 0
+	}, time.Second, 5*time.Millisecond, "failing replicas are evicted")
+	p, err = repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", p.Name)
+}
+
+// TestStatementTimeout checks statements without a deadline get one, restored after the statement.
+func TestStatementTimeout(t *testing.T) {
+	gdb := newReplicated(t, Config{StatementTimeout: time.Minute})
+
+	var during, after bool
+	require.NoError(t, gdb.Callback().Query().Before("gorm:query").After("db:timeout_query").Register("test:during", func(tx *gorm.DB) {
+		_, during = tx.Statement.Context.Deadline()
+	}))
+	require.NoError(t, gdb.Callback().Query().After("db:timeout_query_end").Register("test:after", func(tx *gorm.DB) {
+		_, after = tx.Statement.Context.Deadline()
+	}))
+
+	_, err := NewRepository[place](gdb).List(context.Background())
+	require.NoError(t, err)
+	assert.True(t, during)
+	assert.False(t, after)
+}
@@ -12,6 +12,11 @@ type Repository[T any] interface {
 	Update(ctx context.Context, model *T) error
 	Delete(ctx context.Context, id any) error
 	List(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) ([]T, error)
+	Stream(ctx context.Context, fn func(*T) error, queryFns ...func(*gorm.DB) *gorm.DB) error
+	CreateBatch(ctx context.Context, models []*T, opts ...BatchOption) (*BatchResult, error)
+	UpsertBatch(ctx context.Context, models []*T, opts ...BatchOption) (*BatchResult, error)
+	UpdateWhere(ctx context.Context, values map[string]any, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error)
+	DeleteWhere(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error)
 	Async() AsyncRepository[T]
 }
 
@@ -22,6 +27,10 @@ type AsyncRepository[T any] interface {
 	UpdateAsync(ctx context.Context, model *T) <-chan error
 	DeleteAsync(ctx context.Context, id any) <-chan error
 	ListAsync(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) <-chan Result[[]T]
+	CreateBatchAsync(ctx context.Context, models []*T, opts ...BatchOption) <-chan Result[*BatchResult]
+	UpsertBatchAsync(ctx context.Context, models []*T, opts ...BatchOption) <-chan Result[*BatchResult]
+	UpdateWhereAsync(ctx context.Context, values map[string]any, queryFns ...func(*gorm.DB) *gorm.DB) <-chan Result[int64]
+	DeleteWhereAsync(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) <-chan Result[int64]
 }
 
 // Result wraps data or error for async calls.
@@ -77,14 +86,45 @@ func (r *repository[T]) List(ctx context.Context, queryFns ...func(*gorm.DB) *go
 	return out, err
 }
 
+// Stream calls fn for every record of type T, one row at a time, so large tables
+// are never loaded into memory. Iteration stops at the first error returned by fn.
+func (r *repository[T]) Stream(ctx context.Context, fn func(*T) error, queryFns ...func(*gorm.DB) *gorm.DB) error {
+	q := r.db.WithContext(ctx).Model(new(T))
+	for _, f := range queryFns {
+		q = f(q)
+	}
+	rows, err := q.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var out T
+		if err := q.ScanRows(rows, &out); err != nil {
+			return err
+		}
+		if err := fn(&out); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // Async returns an async wrapper for the repository.
 func (r *repository[T]) Async() AsyncRepository[T] {
-	return &asyncRepository[T]{repo: r}
+	return NewAsyncRepository[T](r)
+}
+
+// NewAsyncRepository returns an async wrapper running repo calls in goroutines,
+// for repositories decorating another one.
+func NewAsyncRepository[T any](repo Repository[T]) AsyncRepository[T] {
+	return &asyncRepository[T]{repo: repo}
 }
 
 // asyncRepository wraps sync repo with goroutine-based methods.
 type asyncRepository[T any] struct {
-	repo *repository[T]
+	repo Repository[T]
 }
 
 // CreateAsync creates a record in a new goroutine.
@@ -131,3 +171,43 @@ func (a *asyncRepository[T]) ListAsync(ctx context.Context, queryFns ...func(*go
 	}()
 	return ch
 }
+
+// CreateBatchAsync creates records in batches in a new goroutine.
+func (a *asyncRepository[T]) CreateBatchAsync(ctx context.Context, models []*T, opts ...BatchOption) <-chan Result[*BatchResult] {
+	ch := make(chan Result[*BatchResult], 1)
+	go func() {
+		data, err := a.repo.CreateBatch(ctx, models, opts...)
+		ch <- Result[*BatchResult]{Data: data, Err: err}
+	}()
+	return ch
+}
+
+// UpsertBatchAsync upserts records in batches in a new goroutine.
+func (a *asyncRepository[T]) UpsertBatchAsync(ctx context.Context, models []*T, opts ...BatchOption) <-chan Result[*BatchResult] {
+	ch := make(chan Result[*BatchResult], 1)
+	go func() {
+		data, err := a.repo.UpsertBatch(ctx, models, opts...)
+		ch <- Result[*BatchResult]{Data: data, Err: err}
+	}()
+	return ch
+}
+
+// UpdateWhereAsync updates the matched records in a new goroutine.
+func (a *asyncRepository[T]) UpdateWhereAsync(ctx context.Context, values map[string]any, queryFns ...func(*gorm.DB) *gorm.DB) <-chan Result[int64] {
+	ch := make(chan Result[int64], 1)
+	go func() {
+		n, err := a.repo.UpdateWhere(ctx, values, queryFns...)
+		ch <- Result[int64]{Data: n, Err: err}
+	}()
+	return ch
+}
+
+// DeleteWhereAsync deletes the matched records in a new goroutine.
+func (a *asyncRepository[T]) DeleteWhereAsync(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) <-chan Result[int64] {
+	ch := make(chan Result[int64], 1)
+	go func() {
+		n, err := a.repo.DeleteWhere(ctx, queryFns...)
+		ch <- Result[int64]{Data: n, Err: err}
+	}()
+	return ch
+}
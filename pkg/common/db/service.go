@@ -12,6 +12,10 @@ type Service[T any] interface {
 	Update(ctx context.Context, model *T) error
 	Delete(ctx context.Context, id any) error
 	List(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) ([]T, error)
+	CreateBatch(ctx context.Context, models []*T, opts ...BatchOption) (*BatchResult, error)
+	UpsertBatch(ctx context.Context, models []*T, opts ...BatchOption) (*BatchResult, error)
+	UpdateWhere(ctx context.Context, values map[string]any, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error)
+	DeleteWhere(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error)
 }
 
 // service implements the Service interface.
@@ -48,3 +52,23 @@ func (s *service[T]) Delete(ctx context.Context, id any) error {
 func (s *service[T]) List(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) ([]T, error) {
 	return s.repo.List(ctx, queryFns...)
 }
+
+// CreateBatch creates models in batches, reporting the rows that failed.
+func (s *service[T]) CreateBatch(ctx context.Context, models []*T, opts ...BatchOption) (*BatchResult, error) {
+	return s.repo.CreateBatch(ctx, models, opts...)
+}
+
+// UpsertBatch creates or updates models in batches, reporting the rows that failed.
+func (s *service[T]) UpsertBatch(ctx context.Context, models []*T, opts ...BatchOption) (*BatchResult, error) {
+	return s.repo.UpsertBatch(ctx, models, opts...)
+}
+
+// UpdateWhere sets values on the models matched by the queries.
+func (s *service[T]) UpdateWhere(ctx context.Context, values map[string]any, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	return s.repo.UpdateWhere(ctx, values, queryFns...)
+}
+
+// DeleteWhere removes the models matched by the queries.
+func (s *service[T]) DeleteWhere(ctx context.Context, queryFns ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	return s.repo.DeleteWhere(ctx, queryFns...)
+}
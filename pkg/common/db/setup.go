@@ -1,21 +1,37 @@
 package db
 
 import (
+	"strings"
+
 	config "github.com/ianfedev/civicspot-backend/pkg/common/config"
 	"gorm.io/gorm"
 )
 
-// SetupEnvironmentDatabase creates a database from the provided environment
+// SetupEnvironmentDatabase creates a database from the provided environment.
+// DB_REPLICAS lists the DSNs of read replicas separated by commas.
 func SetupEnvironmentDatabase() (*gorm.DB, error) {
 
 	dsn := config.MustGet(config.DatabaseDSN)
 	d := config.MustGet(config.DatabaseDialect)
 	lvl := config.MustGet(config.LogLevel)
+	env := config.Get()
 
 	cfg := Config{
 		Dialect:  d,
 		LogLevel: lvl,
 		DSN:      dsn,
+
+		MaxOpenConns:     env.GetInt(config.DatabaseMaxOpenConns),
+		MaxIdleConns:     env.GetInt(config.DatabaseMaxIdleConns),
+		ConnMaxLifetime:  env.GetDuration(config.DatabaseConnMaxLifetime),
+		ConnMaxIdleTime:  env.GetDuration(config.DatabaseConnMaxIdleTime),
+		StatementTimeout: env.GetDuration(config.DatabaseStatementTimeout),
+		HealthInterval:   env.GetDuration(config.DatabaseHealthInterval),
+	}
+	for _, r := range strings.Split(env.GetString(config.DatabaseReplicas), ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			cfg.Replicas = append(cfg.Replicas, r)
+		}
 	}
 
 	return New(cfg)
@@ -0,0 +1,15 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewID returns a random 128-bit hex identifier.
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
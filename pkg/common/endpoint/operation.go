@@ -0,0 +1,52 @@
+package endpoint
+
+import gk "github.com/go-kit/kit/endpoint"
+
+// Operation names a generic CRUD operation.
+type Operation string
+
+const (
+	OpCreate Operation = "create"
+	OpGet    Operation = "get"
+	OpUpdate Operation = "update"
+	OpDelete Operation = "delete"
+	OpList   Operation = "list"
+)
+
+// Operations lists every CRUD operation.
+var Operations = []Operation{OpCreate, OpGet, OpUpdate, OpDelete, OpList}
+
+// Wrap applies the middleware to the endpoints of the given operations, or to
+// all when none is given. Bulk endpoints belong to the operation they extend:
+// CreateBatch to OpCreate, UpdateWhere to OpUpdate and DeleteWhere to OpDelete.
+// UpsertBatch both creates and updates, so it belongs to OpCreate and OpUpdate
+// and is wrapped once when both are given.
+func (e Endpoints[T]) Wrap(mw gk.Middleware, ops ...Operation) Endpoints[T] {
+	if len(ops) == 0 {
+		ops = Operations
+	}
+	upsert := false
+	for _, op := range ops {
+		switch op {
+		case OpCreate:
+			e.Create = mw(e.Create)
+			e.CreateBatch = mw(e.CreateBatch)
+			upsert = true
+		case OpGet:
+			e.Get = mw(e.Get)
+		case OpUpdate:
+			e.Update = mw(e.Update)
+			e.UpdateWhere = mw(e.UpdateWhere)
+			upsert = true
+		case OpDelete:
+			e.Delete = mw(e.Delete)
+			e.DeleteWhere = mw(e.DeleteWhere)
+		case OpList:
+			e.List = mw(e.List)
+		}
+	}
+	if upsert {
+		e.UpsertBatch = mw(e.UpsertBatch)
+	}
+	return e
+}
@@ -0,0 +1,54 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gk "github.com/go-kit/kit/endpoint"
+	"github.com/stretchr/testify/assert"
+)
+
+// errDenied is returned by the deny middleware.
+var errDenied = errors.New("denied")
+
+// deny is a middleware rejecting every request.
+func deny(gk.Endpoint) gk.Endpoint {
+	return func(context.Context, any) (any, error) { return nil, errDenied }
+}
+
+// TestWrap checks middlewares reach the bulk endpoints of their operations.
+func TestWrap(t *testing.T) {
+	ok := func(context.Context, any) (any, error) { return "ok", nil }
+	all := Endpoints[struct{}]{
+		Create: ok, Get: ok, Update: ok, Delete: ok, List: ok,
+		CreateBatch: ok, UpsertBatch: ok, UpdateWhere: ok, DeleteWhere: ok,
+	}
+	call := func(e gk.Endpoint) error {
+		_, err := e(context.Background(), nil)
+		return err
+	}
+
+	e := all.Wrap(deny, OpCreate)
+	assert.ErrorIs(t, call(e.CreateBatch), errDenied)
+	assert.ErrorIs(t, call(e.UpsertBatch), errDenied, "upserts create models")
+	assert.NoError(t, call(e.UpdateWhere))
+
+	e = all.Wrap(deny, OpUpdate)
+	assert.ErrorIs(t, call(e.UpsertBatch), errDenied)
+	assert.NoError(t, call(e.CreateBatch))
+
+	var calls int
+	count := func(next gk.Endpoint) gk.Endpoint {
+		return func(ctx context.Context, req any) (any, error) {
+			calls++
+			return next(ctx, req)
+		}
+	}
+	e = all.Wrap(count)
+	assert.NoError(t, call(e.UpsertBatch))
+	assert.Equal(t, 1, calls, "upserts are wrapped once")
+
+	e = all.Wrap(deny, OpGet, OpList, OpDelete)
+	assert.NoError(t, call(e.UpsertBatch))
+}
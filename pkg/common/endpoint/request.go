@@ -1,6 +1,9 @@
 package endpoint
 
-import "gorm.io/gorm"
+import (
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"gorm.io/gorm"
+)
 
 // CreateRequest defines a generic model request to be
 // parsed between endpoints and service-created.
@@ -30,6 +33,26 @@ type ListRequest struct {
 	QueryFns []func(*gorm.DB) *gorm.DB
 }
 
+// BatchRequest defines a generic batch of models to be
+// service-created or upserted.
+type BatchRequest[T any] struct {
+	Models  []*T
+	Options []db.BatchOption
+}
+
+// UpdateWhereRequest defines the values to set on the
+// models matched by the queries.
+type UpdateWhereRequest struct {
+	Values   map[string]any
+	QueryFns []func(*gorm.DB) *gorm.DB
+}
+
+// DeleteWhereRequest defines the queries matching the
+// models to be deleted.
+type DeleteWhereRequest struct {
+	QueryFns []func(*gorm.DB) *gorm.DB
+}
+
 // Response always returns model(s) and an error
 type Response[T any] struct {
 	Data T
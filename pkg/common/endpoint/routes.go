@@ -14,6 +14,11 @@ type Endpoints[T any] struct {
 	Update gk.Endpoint
 	Delete gk.Endpoint
 	List   gk.Endpoint
+
+	CreateBatch gk.Endpoint
+	UpsertBatch gk.Endpoint
+	UpdateWhere gk.Endpoint
+	DeleteWhere gk.Endpoint
 }
 
 // NewEndpoints builds endpoints for the given db.Service
@@ -24,6 +29,11 @@ func NewEndpoints[T any](svc db.Service[T]) Endpoints[T] {
 		Update: makeUpdateEndpoint(svc),
 		Delete: makeDeleteEndpoint(svc),
 		List:   makeListEndpoint(svc),
+
+		CreateBatch: makeCreateBatchEndpoint(svc),
+		UpsertBatch: makeUpsertBatchEndpoint(svc),
+		UpdateWhere: makeUpdateWhereEndpoint(svc),
+		DeleteWhere: makeDeleteWhereEndpoint(svc),
 	}
 }
 
@@ -71,3 +81,39 @@ func makeListEndpoint[T any](svc db.Service[T]) gk.Endpoint {
 		return Response[[]T]{Data: data, Err: err}, nil
 	}
 }
+
+// makeCreateBatchEndpoint makes a bulk Create endpoint.
+func makeCreateBatchEndpoint[T any](svc db.Service[T]) gk.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(BatchRequest[T])
+		data, err := svc.CreateBatch(ctx, req.Models, req.Options...)
+		return Response[*db.BatchResult]{Data: data, Err: err}, nil
+	}
+}
+
+// makeUpsertBatchEndpoint makes a bulk create or update endpoint.
+func makeUpsertBatchEndpoint[T any](svc db.Service[T]) gk.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(BatchRequest[T])
+		data, err := svc.UpsertBatch(ctx, req.Models, req.Options...)
+		return Response[*db.BatchResult]{Data: data, Err: err}, nil
+	}
+}
+
+// makeUpdateWhereEndpoint makes a bulk Update endpoint.
+func makeUpdateWhereEndpoint[T any](svc db.Service[T]) gk.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UpdateWhereRequest)
+		n, err := svc.UpdateWhere(ctx, req.Values, req.QueryFns...)
+		return Response[int64]{Data: n, Err: err}, nil
+	}
+}
+
+// makeDeleteWhereEndpoint makes a bulk Delete endpoint.
+func makeDeleteWhereEndpoint[T any](svc db.Service[T]) gk.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DeleteWhereRequest)
+		n, err := svc.DeleteWhere(ctx, req.QueryFns...)
+		return Response[int64]{Data: n, Err: err}, nil
+	}
+}
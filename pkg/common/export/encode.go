@@ -0,0 +1,163 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// point is the location of a geolocated record.
+type point struct {
+	lat, lon float64
+}
+
+// encoder writes records one at a time.
+type encoder interface {
+	write(row []any, p *point) error
+	close() error
+}
+
+// newEncoder returns the encoder of a format, writing the header right away.
+func newEncoder(f Format, w io.Writer, cols []*column) (encoder, error) {
+	switch f {
+	case CSV:
+		return newCSVEncoder(w, cols)
+	case NDJSON:
+		return &jsonEncoder{w: bufio.NewWriter(w), cols: cols}, nil
+	case GeoJSON:
+		return newGeoJSONEncoder(w, cols)
+	default:
+		return newParquetEncoder(w, cols), nil
+	}
+}
+
+// csvEncoder writes CSV rows.
+type csvEncoder struct {
+	w   *csv.Writer
+	rec []string
+}
+
+// newCSVEncoder writes the header row.
+func newCSVEncoder(w io.Writer, cols []*column) (*csvEncoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w), rec: make([]string, len(cols))}
+	for i, c := range cols {
+		e.rec[i] = c.Name
+	}
+	return e, e.w.Write(e.rec)
+}
+
+// write appends a row, leaving missing values empty.
+func (e *csvEncoder) write(row []any, _ *point) error {
+	for i, v := range row {
+		switch v := v.(type) {
+		case nil:
+			e.rec[i] = ""
+		case string:
+			e.rec[i] = v
+		case int64:
+			e.rec[i] = strconv.FormatInt(v, 10)
+		case float64:
+			e.rec[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			e.rec[i] = strconv.FormatBool(v)
+		case time.Time:
+			e.rec[i] = v.Format(time.RFC3339)
+		}
+	}
+	return e.w.Write(e.rec)
+}
+
+// close flushes the buffered rows.
+func (e *csvEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonEncoder writes one JSON object per line, keeping the column order.
+type jsonEncoder struct {
+	w    *bufio.Writer
+	cols []*column
+}
+
+// object writes the row as a JSON object.
+func (e *jsonEncoder) object(row []any) error {
+	e.w.WriteByte('{')
+	for i, v := range row {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		name, _ := json.Marshal(e.cols[i].Name)
+		e.w.Write(name)
+		e.w.WriteByte(':')
+		if t, ok := v.(time.Time); ok {
+			v = t.Format(time.RFC3339)
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		e.w.Write(data)
+	}
+	return e.w.WriteByte('}')
+}
+
+// write appends a line.
+func (e *jsonEncoder) write(row []any, _ *point) error {
+	if err := e.object(row); err != nil {
+		return err
+	}
+	return e.w.WriteByte('\n')
+}
+
+// close flushes the buffered lines.
+func (e *jsonEncoder) close() error {
+	return e.w.Flush()
+}
+
+// geoJSONEncoder writes a FeatureCollection of points, with the columns as properties.
+type geoJSONEncoder struct {
+	jsonEncoder
+	count int
+}
+
+// newGeoJSONEncoder opens the feature collection.
+func newGeoJSONEncoder(w io.Writer, cols []*column) (*geoJSONEncoder, error) {
+	e := &geoJSONEncoder{jsonEncoder: jsonEncoder{w: bufio.NewWriter(w), cols: cols}}
+	_, err := e.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	return e, err
+}
+
+// write appends a feature. Records without a location have a null geometry.
+func (e *geoJSONEncoder) write(row []any, p *point) error {
+	if e.count > 0 {
+		e.w.WriteByte(',')
+	}
+	e.count++
+
+	e.w.WriteString(`{"type":"Feature","geometry":`)
+	if p == nil {
+		e.w.WriteString("null")
+	} else {
+		e.w.WriteString(`{"type":"Point","coordinates":[`)
+		e.w.WriteString(strconv.FormatFloat(p.lon, 'f', -1, 64))
+		e.w.WriteByte(',')
+		e.w.WriteString(strconv.FormatFloat(p.lat, 'f', -1, 64))
+		e.w.WriteString("]}")
+	}
+	e.w.WriteString(`,"properties":`)
+	if err := e.object(row); err != nil {
+		return err
+	}
+	return e.w.WriteByte('}')
+}
+
+// close ends the feature collection.
+func (e *geoJSONEncoder) close() error {
+	if _, err := e.w.WriteString("]}\n"); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
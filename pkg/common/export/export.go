@@ -0,0 +1,377 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Format is an open data file format.
+type Format string
+
+const (
+	// CSV is comma-separated values with a header row.
+	CSV Format = "csv"
+	// NDJSON is one JSON object per line.
+	NDJSON Format = "ndjson"
+	// GeoJSON is a FeatureCollection of points, for geolocated datasets only.
+	GeoJSON Format = "geojson"
+	// Parquet is the Apache Parquet columnar format.
+	Parquet Format = "parquet"
+)
+
+// Formats lists every supported format.
+var Formats = []Format{CSV, NDJSON, GeoJSON, Parquet}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	case GeoJSON:
+		return "application/geo+json"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+// ParseFormat validates a format name, defaulting to CSV when empty.
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return CSV, nil
+	}
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+	return "", transport.BadRequest(fmt.Sprintf("unsupported export format %q", s))
+}
+
+// Column publishes a model field under a public name.
+type Column struct {
+	Name  string // Name is the header or property name in the export.
+	Field string // Field is the Go field path in the model (e.g., "Status" or "Location.Latitude").
+}
+
+// Fields returns columns for the given field paths, named in snake_case.
+func Fields(paths ...string) []Column {
+	cols := make([]Column, len(paths))
+	for i, p := range paths {
+		cols[i] = Column{Name: snakeCase(p), Field: p}
+	}
+	return cols
+}
+
+// Config describes what a dataset publishes. Only the listed columns are ever
+// exported, so personal data stays out unless explicitly allowed.
+type Config struct {
+	Columns   []Column                  // Columns is the allowlist of exported fields, in order.
+	Latitude  string                    // Latitude is the field path of the latitude, enabling GeoJSON.
+	Longitude string                    // Longitude is the field path of the longitude, enabling GeoJSON.
+	Filters   []string                  // Filters are the column names accepted as equality filters.
+	Scopes    []func(*gorm.DB) *gorm.DB // Scopes always apply (e.g., only public records).
+}
+
+// Dataset streams the records of a repository in an open data format.
+type Dataset interface {
+	Name() string
+	Geolocated() bool
+	Check(f Format, filters map[string]string) error
+	Export(ctx context.Context, w io.Writer, f Format, filters map[string]string) error
+}
+
+// kind is the normalized type of an exported value.
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindTime
+)
+
+// column is a resolved Column.
+type column struct {
+	Column
+	index    [][]int // index is the field index at each pointer hop.
+	kind     kind
+	optional bool
+}
+
+// value returns the normalized value of the column in v, or nil.
+func (c *column) value(v reflect.Value) any {
+	for _, idx := range c.index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		v = v.FieldByIndex(idx)
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch c.kind {
+	case kindTime:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return nil
+		}
+		return t.UTC()
+	case kindInt:
+		if v.CanInt() {
+			return v.Int()
+		}
+		return int64(v.Uint())
+	case kindFloat:
+		return v.Float()
+	case kindBool:
+		return v.Bool()
+	default:
+		return v.String()
+	}
+}
+
+// parse converts a filter value to the column type.
+func (c *column) parse(s string) (any, error) {
+	switch c.kind {
+	case kindInt:
+		return strconv.ParseInt(s, 10, 64)
+	case kindFloat:
+		return strconv.ParseFloat(s, 64)
+	case kindBool:
+		return strconv.ParseBool(s)
+	case kindTime:
+		if t, err := time.Parse(time.DateOnly, s); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.RFC3339, s)
+	default:
+		return s, nil
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// resolve finds a field path in t.
+func resolve(t reflect.Type, c Column) (*column, error) {
+	out := &column{Column: c}
+	for _, name := range strings.Split(c.Field, ".") {
+		for t.Kind() == reflect.Pointer {
+			out.optional = true
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("export: field %q is not a struct path", c.Field)
+		}
+		f, ok := t.FieldByName(name)
+		if !ok || !f.IsExported() {
+			return nil, fmt.Errorf("export: unknown field %q", c.Field)
+		}
+		out.index = append(out.index, f.Index)
+		t = f.Type
+	}
+	for t.Kind() == reflect.Pointer {
+		out.optional = true
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		out.kind, out.optional = kindTime, true
+	case t.Kind() == reflect.String:
+		out.kind = kindString
+	case t.Kind() == reflect.Bool:
+		out.kind = kindBool
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		out.kind = kindFloat
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		out.kind = kindInt
+	default:
+		return nil, fmt.Errorf("export: field %q has unsupported type %s", c.Field, t)
+	}
+	return out, nil
+}
+
+// dataset is the Dataset of a repository of T.
+type dataset[T any] struct {
+	name      string
+	repo      db.Repository[T]
+	cfg       Config
+	columns   []*column
+	lat, lon  *column
+	dbColumns map[string]string
+}
+
+// NewDataset creates a Dataset publishing the configured columns of repo.
+// It fails when a column or filter does not match a supported model field.
+func NewDataset[T any](name string, repo db.Repository[T], cfg Config) (Dataset, error) {
+	t := reflect.TypeOf(new(T)).Elem()
+	d := &dataset[T]{name: name, repo: repo, cfg: cfg, dbColumns: map[string]string{}}
+
+	for _, c := range cfg.Columns {
+		col, err := resolve(t, c)
+		if err != nil {
+			return nil, err
+		}
+		d.columns = append(d.columns, col)
+	}
+
+	if cfg.Latitude != "" || cfg.Longitude != "" {
+		var err error
+		if d.lat, err = resolve(t, Column{Field: cfg.Latitude}); err != nil {
+			return nil, err
+		}
+		if d.lon, err = resolve(t, Column{Field: cfg.Longitude}); err != nil {
+			return nil, err
+		}
+		if d.lat.kind != kindFloat || d.lon.kind != kindFloat {
+			return nil, fmt.Errorf("export: coordinates of %s must be floats", name)
+		}
+	}
+
+	if len(cfg.Filters) > 0 {
+		s, err := schema.Parse(new(T), &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range cfg.Filters {
+			col := d.column(name)
+			if col == nil || strings.Contains(col.Field, ".") {
+				return nil, fmt.Errorf("export: filter %q is not a top-level column", name)
+			}
+			f := s.LookUpField(col.Field)
+			if f == nil || f.DBName == "" {
+				return nil, fmt.Errorf("export: filter %q is not a database column", name)
+			}
+			d.dbColumns[name] = f.DBName
+		}
+	}
+	return d, nil
+}
+
+// Name returns the dataset name.
+func (d *dataset[T]) Name() string { return d.name }
+
+// Geolocated reports whether the dataset can be exported as GeoJSON.
+func (d *dataset[T]) Geolocated() bool { return d.lat != nil }
+
+// column returns the column with the given public name.
+func (d *dataset[T]) column(name string) *column {
+	for _, c := range d.columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Check validates a format and filters before exporting, so errors can be
+// reported before any output is written.
+func (d *dataset[T]) Check(f Format, filters map[string]string) error {
+	_, err := d.scopes(f, filters)
+	return err
+}
+
+// scopes returns the configured scopes followed by the filters.
+func (d *dataset[T]) scopes(f Format, filters map[string]string) ([]func(*gorm.DB) *gorm.DB, error) {
+	if f == GeoJSON && !d.Geolocated() {
+		return nil, transport.BadRequest(fmt.Sprintf("dataset %s is not geolocated", d.name))
+	}
+
+	scopes := append([]func(*gorm.DB) *gorm.DB{}, d.cfg.Scopes...)
+	for name, raw := range filters {
+		dbName, ok := d.dbColumns[name]
+		if !ok {
+			return nil, transport.BadRequest(fmt.Sprintf("unsupported filter %q", name))
+		}
+		col := d.column(name)
+		var values []any
+		for _, s := range strings.Split(raw, ",") {
+			v, err := col.parse(strings.TrimSpace(s))
+			if err != nil {
+				return nil, transport.BadRequest(fmt.Sprintf("invalid value for filter %q", name))
+			}
+			values = append(values, v)
+		}
+		scopes = append(scopes, func(q *gorm.DB) *gorm.DB {
+			return q.Where(map[string]any{dbName: values})
+		})
+	}
+	return scopes, nil
+}
+
+// Export streams every matching record to w, one at a time. Filter values may
+// list several comma-separated alternatives.
+func (d *dataset[T]) Export(ctx context.Context, w io.Writer, f Format, filters map[string]string) error {
+	scopes, err := d.scopes(f, filters)
+	if err != nil {
+		return err
+	}
+
+	enc, err := newEncoder(f, w, d.columns)
+	if err != nil {
+		return err
+	}
+	row := make([]any, len(d.columns))
+	err = d.repo.Stream(ctx, func(m *T) error {
+		v := reflect.ValueOf(m).Elem()
+		for i, c := range d.columns {
+			row[i] = c.value(v)
+		}
+		var p *point
+		if d.lat != nil {
+			lat, lon := d.lat.value(v), d.lon.value(v)
+			if lat != nil && lon != nil {
+				p = &point{lat: lat.(float64), lon: lon.(float64)}
+			}
+		}
+		return enc.write(row, p)
+	}, scopes...)
+	if err != nil {
+		return err
+	}
+	return enc.close()
+}
+
+// snakeCase converts a field path such as "Location.CreatedAt" to "location_created_at".
+func snakeCase(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		switch {
+		case r == '.':
+			b.WriteByte('_')
+		case unicode.IsUpper(r):
+			prevLower := i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]))
+			nextLower := i > 0 && i+1 < len(rs) && unicode.IsUpper(rs[i-1]) && unicode.IsLower(rs[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
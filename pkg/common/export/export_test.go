@@ -0,0 +1,251 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+	"github.com/ianfedev/civicspot-backend/pkg/common/storage"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// report is a geolocated model with personal data.
+type report struct {
+	ID           string `gorm:"primaryKey"`
+	Title        string
+	Status       string
+	Votes        int
+	Latitude     *float64
+	Longitude    *float64
+	ReporterName string
+	CreatedAt    time.Time
+	Public       bool
+}
+
+// newDataset returns a reports dataset backed by a sqlite database with three rows.
+func newDataset(t *testing.T) (Dataset, *gorm.DB) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "export.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&report{}))
+
+	lat, lon := 4.711, -74.0721
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, gdb.Create(&[]report{
+		{ID: "r1", Title: "Hueco, grande", Status: "open", Votes: 3, Latitude: &lat, Longitude: &lon, ReporterName: "Ana", CreatedAt: at, Public: true},
+		{ID: "r2", Title: "Poste caído", Status: "resolved", Votes: 1, ReporterName: "Luis", CreatedAt: at.Add(time.Hour), Public: true},
+		{ID: "r3", Title: "Privado", Status: "open", ReporterName: "Eva", CreatedAt: at, Public: false},
+	}).Error)
+
+	ds, err := NewDataset("reports", db.NewRepository[report](gdb), Config{
+		Columns:   Fields("ID", "Title", "Status", "Votes", "CreatedAt"),
+		Latitude:  "Latitude",
+		Longitude: "Longitude",
+		Filters:   []string{"status", "votes"},
+		Scopes: []func(*gorm.DB) *gorm.DB{
+			func(q *gorm.DB) *gorm.DB { return q.Where("public = ?", true).Order("id") },
+		},
+	})
+	require.NoError(t, err)
+	return ds, gdb
+}
+
+// export returns the dataset export as a string.
+func export(t *testing.T, ds Dataset, f Format, filters map[string]string) string {
+	var buf bytes.Buffer
+	require.NoError(t, ds.Export(context.Background(), &buf, f, filters))
+	return buf.String()
+}
+
+// TestNewDataset checks the columns and filters are validated.
+func TestNewDataset(t *testing.T) {
+	_, gdb := newDataset(t)
+	repo := db.NewRepository[report](gdb)
+
+	_, err := NewDataset("x", repo, Config{Columns: Fields("Missing")})
+	assert.Error(t, err)
+	_, err = NewDataset("x", repo, Config{Columns: Fields("Title"), Filters: []string{"status"}})
+	assert.Error(t, err, "filters must be published columns")
+	_, err = NewDataset("x", repo, Config{Columns: Fields("Title"), Latitude: "Title", Longitude: "Title"})
+	assert.Error(t, err)
+
+	assert.Equal(t, []Column{{Name: "reporter_name", Field: "ReporterName"}, {Name: "id", Field: "ID"}}, Fields("ReporterName", "ID"))
+}
+
+// TestExportCSV checks the allowlist, scopes and filters.
+func TestExportCSV(t *testing.T) {
+	ds, _ := newDataset(t)
+
+	rows, err := csv.NewReader(strings.NewReader(export(t, ds, CSV, nil))).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"id", "title", "status", "votes", "created_at"},
+		{"r1", "Hueco, grande", "open", "3", "2026-03-01T10:00:00Z"},
+		{"r2", "Poste caído", "resolved", "1", "2026-03-01T11:00:00Z"},
+	}, rows)
+
+	out := export(t, ds, CSV, map[string]string{"status": "resolved,closed"})
+	assert.Equal(t, "id,title,status,votes,created_at\nr2,Poste caído,resolved,1,2026-03-01T11:00:00Z\n", out)
+
+	err = ds.Export(context.Background(), io.Discard, CSV, map[string]string{"reporter_name": "Ana"})
+	assert.Error(t, err)
+	err = ds.Export(context.Background(), io.Discard, CSV, map[string]string{"votes": "many"})
+	assert.Error(t, err)
+}
+
+// TestExportJSON checks NDJSON lines and GeoJSON features.
+func TestExportJSON(t *testing.T) {
+	ds, _ := newDataset(t)
+
+	lines := strings.Split(strings.TrimSpace(export(t, ds, NDJSON, nil)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"id":"r1","title":"Hueco, grande","status":"open","votes":3,"created_at":"2026-03-01T10:00:00Z"}`, lines[0])
+
+	var fc struct {
+		Type     string
+		Features []struct {
+			Geometry *struct {
+				Type        string
+				Coordinates []float64
+			}
+			Properties map[string]any
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(export(t, ds, GeoJSON, nil)), &fc))
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	require.Len(t, fc.Features, 2)
+	require.NotNil(t, fc.Features[0].Geometry)
+	assert.Equal(t, []float64{-74.0721, 4.711}, fc.Features[0].Geometry.Coordinates)
+	assert.Nil(t, fc.Features[1].Geometry)
+	assert.NotContains(t, fc.Features[0].Properties, "reporter_name")
+
+	empty := export(t, ds, GeoJSON, map[string]string{"status": "closed"})
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[]}`, empty)
+}
+
+// TestExportParquet checks the Parquet file can be read back.
+func TestExportParquet(t *testing.T) {
+	ds, _ := newDataset(t)
+
+	type row struct {
+		ID        string    `parquet:"id"`
+		Title     string    `parquet:"title"`
+		Votes     int64     `parquet:"votes"`
+		CreatedAt time.Time `parquet:"created_at,optional,timestamp(millisecond)"`
+	}
+	data := []byte(export(t, ds, Parquet, nil))
+	rows, err := parquet.Read[row](bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "r1", rows[0].ID)
+	assert.Equal(t, "Poste caído", rows[1].Title)
+	assert.Equal(t, int64(3), rows[0].Votes)
+	assert.True(t, rows[1].CreatedAt.Equal(time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)))
+}
+
+// TestCatalog checks snapshots are published by the scheduled job.
+func TestCatalog(t *testing.T) {
+	ds, gdb := newDataset(t)
+	require.NoError(t, jobs.Migrate(gdb))
+	ctx := context.Background()
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	c := NewCatalog(store, "open-data").Add(ds)
+	c.now = func() time.Time { return time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC) }
+
+	_, err = c.Get("missing")
+	assert.Error(t, err)
+
+	s := jobs.NewScheduler(jobs.NewQueue(gdb), nil, jobs.SchedulerConfig{})
+	require.NoError(t, c.Schedule(s, "0 3 * * *", nil, CSV, GeoJSON))
+	require.NoError(t, s.RunDue(ctx, time.Now().Add(48*time.Hour), true))
+
+	w := jobs.NewWorker(gdb, jobs.WorkerConfig{})
+	c.Register(w)
+	for {
+		ran, err := w.RunOnce(ctx)
+		require.NoError(t, err)
+		if !ran {
+			break
+		}
+	}
+
+	for _, key := range []string{"open-data/reports/2026-03-02.csv", "open-data/reports/latest.csv", "open-data/reports/latest.geojson"} {
+		r, _, err := store.Get(ctx, key)
+		require.NoError(t, err, key)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		assert.NotContains(t, string(data), "Ana")
+		assert.Contains(t, string(data), "r1")
+	}
+}
+
+// claim is a tenant-owned model.
+type claim struct {
+	ID string `gorm:"primaryKey"`
+	tenant.Owned
+	Title string
+}
+
+// TestCatalogTenant checks scheduled snapshots of tenant-owned datasets run in
+// each tenant and are kept apart.
+func TestCatalogTenant(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "export.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, tenant.Register(gdb))
+	require.NoError(t, gdb.AutoMigrate(&claim{}))
+	require.NoError(t, jobs.Migrate(gdb))
+	ctx := context.Background()
+	require.NoError(t, gdb.WithContext(tenant.NewContext(ctx, "bogota")).Create(&claim{ID: "c1", Title: "Hueco"}).Error)
+	require.NoError(t, gdb.WithContext(tenant.NewContext(ctx, "cali")).Create(&claim{ID: "c2", Title: "Poste"}).Error)
+
+	ds, err := NewDataset("claims", db.NewRepository[claim](gdb), Config{Columns: Fields("ID", "Title")})
+	require.NoError(t, err)
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	c := NewCatalog(store, "open-data").Add(ds)
+
+	s := jobs.NewScheduler(jobs.NewQueue(gdb), nil, jobs.SchedulerConfig{})
+	require.NoError(t, c.Schedule(s, "0 3 * * *", []string{"bogota", "cali"}, CSV))
+	require.NoError(t, s.RunDue(ctx, time.Now().Add(48*time.Hour), true))
+
+	w := jobs.NewWorker(gdb, jobs.WorkerConfig{})
+	c.Register(w)
+	for {
+		ran, err := w.RunOnce(ctx)
+		require.NoError(t, err)
+		if !ran {
+			break
+		}
+	}
+
+	var failed int64
+	require.NoError(t, gdb.Model(&jobs.Job{}).Where("status <> ?", jobs.StatusDone).Count(&failed).Error)
+	assert.Zero(t, failed)
+
+	for id, want := range map[string]string{"bogota": "c1", "cali": "c2"} {
+		r, _, err := store.Get(ctx, "open-data/"+id+"/claims/latest.csv")
+		require.NoError(t, err, id)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		assert.Contains(t, string(data), want)
+		assert.Equal(t, 2, strings.Count(string(data), "\n"), "only the rows of %s", id)
+	}
+	_, _, err = store.Get(ctx, "open-data/claims/latest.csv")
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// rowGroupSize bounds the rows buffered in memory before a row group is flushed.
+const rowGroupSize = 10000
+
+// parquetEncoder writes rows to a Parquet file.
+type parquetEncoder struct {
+	w        *parquet.Writer
+	leaves   []parquet.LeafColumn
+	optional []bool
+	row      parquet.Row
+}
+
+// newParquetEncoder derives the schema from the column types.
+func newParquetEncoder(w io.Writer, cols []*column) *parquetEncoder {
+	group := parquet.Group{}
+	for _, c := range cols {
+		var node parquet.Node
+		switch c.kind {
+		case kindInt:
+			node = parquet.Int(64)
+		case kindFloat:
+			node = parquet.Leaf(parquet.DoubleType)
+		case kindBool:
+			node = parquet.Leaf(parquet.BooleanType)
+		case kindTime:
+			node = parquet.Timestamp(parquet.Millisecond)
+		default:
+			node = parquet.String()
+		}
+		if c.optional {
+			node = parquet.Optional(node)
+		}
+		group[c.Name] = node
+	}
+	schema := parquet.NewSchema("export", group)
+
+	e := &parquetEncoder{
+		w:        parquet.NewWriter(w, schema, parquet.MaxRowsPerRowGroup(rowGroupSize)),
+		leaves:   make([]parquet.LeafColumn, len(cols)),
+		optional: make([]bool, len(cols)),
+		row:      make(parquet.Row, len(cols)),
+	}
+	for i, c := range cols {
+		e.leaves[i], _ = schema.Lookup(c.Name)
+		e.optional[i] = c.optional
+	}
+	return e
+}
+
+// write appends a row. Values are placed in schema order, which sorts columns by name.
+func (e *parquetEncoder) write(row []any, _ *point) error {
+	for i, v := range row {
+		idx := e.leaves[i].ColumnIndex
+		var pv parquet.Value
+		switch v := v.(type) {
+		case nil:
+			e.row[idx] = parquet.NullValue().Level(0, 0, idx)
+			continue
+		case string:
+			pv = parquet.ByteArrayValue([]byte(v))
+		case int64:
+			pv = parquet.Int64Value(v)
+		case float64:
+			pv = parquet.DoubleValue(v)
+		case bool:
+			pv = parquet.BooleanValue(v)
+		case time.Time:
+			pv = parquet.Int64Value(v.UnixMilli())
+		}
+		def := 0
+		if e.optional[i] {
+			def = 1
+		}
+		e.row[idx] = pv.Level(0, def, idx)
+	}
+	_, err := e.w.WriteRows([]parquet.Row{e.row})
+	return err
+}
+
+// close writes the remaining row group and the file footer.
+func (e *parquetEncoder) close() error {
+	return e.w.Close()
+}
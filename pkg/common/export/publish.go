@@ -0,0 +1,145 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+	"github.com/ianfedev/civicspot-backend/pkg/common/storage"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// JobKind is the job kind publishing a dataset snapshot.
+const JobKind = "export.snapshot"
+
+// Snapshot identifies a dataset snapshot to publish.
+type Snapshot struct {
+	Dataset string // Dataset is the dataset name.
+	Format  Format // Format is the file format.
+}
+
+// Catalog holds the published datasets and writes their snapshots to a store as
+// prefix/<dataset>/<date>.<format>, also replacing prefix/<dataset>/latest.<format>.
+// Snapshots taken in a tenant are kept apart under prefix/<tenant>/<dataset>.
+type Catalog struct {
+	datasets map[string]Dataset
+	store    storage.Store
+	prefix   string
+	now      func() time.Time
+}
+
+// NewCatalog creates a Catalog publishing snapshots under prefix in store.
+func NewCatalog(store storage.Store, prefix string) *Catalog {
+	return &Catalog{datasets: map[string]Dataset{}, store: store, prefix: prefix, now: time.Now}
+}
+
+// Add registers datasets, replacing any with the same name.
+func (c *Catalog) Add(datasets ...Dataset) *Catalog {
+	for _, d := range datasets {
+		c.datasets[d.Name()] = d
+	}
+	return c
+}
+
+// Get returns a dataset by name.
+func (c *Catalog) Get(name string) (Dataset, error) {
+	d, ok := c.datasets[name]
+	if !ok {
+		return nil, transport.NotFound(fmt.Sprintf("dataset %s not found", name))
+	}
+	return d, nil
+}
+
+// Names returns the dataset names in order.
+func (c *Catalog) Names() []string {
+	names := make([]string, 0, len(c.datasets))
+	for name := range c.datasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Publish exports a full snapshot of a dataset to the store and returns its key.
+// The export is piped to the store, so it is never held in memory.
+func (c *Catalog) Publish(ctx context.Context, s Snapshot) (string, error) {
+	d, err := c.Get(s.Dataset)
+	if err != nil {
+		return "", err
+	}
+	if err := d.Check(s.Format, nil); err != nil {
+		return "", err
+	}
+
+	dir := path.Join(c.prefix, d.Name())
+	if id, ok := tenant.FromContext(ctx); ok {
+		dir = path.Join(c.prefix, id, d.Name())
+	}
+	key := path.Join(dir, c.now().UTC().Format(time.DateOnly)+"."+string(s.Format))
+	if err := c.put(ctx, key, s.Format, func(w io.Writer) error { return d.Export(ctx, w, s.Format, nil) }); err != nil {
+		return "", err
+	}
+
+	r, _, err := c.store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	latest := path.Join(dir, "latest."+string(s.Format))
+	if err := c.store.Put(ctx, latest, r, -1, s.Format.ContentType()); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// put streams the output of write to key.
+func (c *Catalog) put(ctx context.Context, key string, f Format, write func(io.Writer) error) error {
+	pr, pw := io.Pipe()
+	go func() { pw.CloseWithError(write(pw)) }()
+	err := c.store.Put(ctx, key, pr, -1, f.ContentType())
+	pr.CloseWithError(err)
+	return err
+}
+
+// Register handles snapshot jobs on the worker.
+func (c *Catalog) Register(w *jobs.Worker) {
+	w.Handle(JobKind, func(ctx context.Context, job *jobs.Job) error {
+		var s Snapshot
+		if err := job.Bind(&s); err != nil {
+			return err
+		}
+		_, err := c.Publish(ctx, s)
+		return err
+	})
+}
+
+// Schedule publishes every dataset in the given formats on the cron spec, once
+// for each tenant, or once without a tenant when none is given. GeoJSON is
+// skipped for datasets without coordinates.
+func (c *Catalog) Schedule(s *jobs.Scheduler, spec string, tenants []string, formats ...Format) error {
+	if len(tenants) == 0 {
+		tenants = []string{""}
+	}
+	for _, id := range tenants {
+		for _, name := range c.Names() {
+			for _, f := range formats {
+				if f == GeoJSON && !c.datasets[name].Geolocated() {
+					continue
+				}
+				entry := fmt.Sprintf("export:%s:%s", name, f)
+				if id != "" {
+					entry = id + ":" + entry
+				}
+				if err := s.Add(entry, spec, JobKind, Snapshot{Dataset: name, Format: f}, jobs.InTenant(id)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
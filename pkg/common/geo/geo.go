@@ -0,0 +1,67 @@
+package geo
+
+import (
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// earthRadius is the mean Earth radius in meters.
+const earthRadius = 6_371_000.0
+
+// Point is a WGS84 coordinate.
+type Point struct {
+	Lat float64 // Lat is the latitude in decimal degrees.
+	Lon float64 // Lon is the longitude in decimal degrees.
+}
+
+// Distance returns the great-circle distance between two points in meters (haversine).
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(b.Lon - a.Lon)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Box is a latitude and longitude range.
+type Box struct {
+	MinLat, MaxLat float64 // MinLat and MaxLat bound the latitude.
+	MinLon, MaxLon float64 // MinLon and MaxLon bound the longitude.
+}
+
+// Around returns the smallest box containing every point within radius meters of center.
+// It is meant as an index-friendly prefilter before computing exact distances.
+func Around(center Point, radius float64) Box {
+	dLat := degrees(radius / earthRadius)
+	dLon := 180.0
+	if c := math.Cos(radians(center.Lat)); c > 1e-9 {
+		dLon = math.Min(180, degrees(radius/(earthRadius*c)))
+	}
+	return Box{
+		MinLat: math.Max(-90, center.Lat-dLat),
+		MaxLat: math.Min(90, center.Lat+dLat),
+		MinLon: center.Lon - dLon,
+		MaxLon: center.Lon + dLon,
+	}
+}
+
+// Contains reports whether the box contains p.
+func (b Box) Contains(p Point) bool {
+	return p.Lat >= b.MinLat && p.Lat <= b.MaxLat && p.Lon >= b.MinLon && p.Lon <= b.MaxLon
+}
+
+// Scope filters rows whose latitude and longitude columns fall inside the box.
+func (b Box) Scope(latColumn, lonColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(latColumn+" BETWEEN ? AND ?", b.MinLat, b.MaxLat).
+			Where(lonColumn+" BETWEEN ? AND ?", b.MinLon, b.MaxLon)
+	}
+}
+
+// radians converts degrees to radians.
+func radians(d float64) float64 { return d * math.Pi / 180 }
+
+// degrees converts radians to degrees.
+func degrees(r float64) float64 { return r * 180 / math.Pi }
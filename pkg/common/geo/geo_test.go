@@ -0,0 +1,27 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDistance checks the haversine distance between known places.
+func TestDistance(t *testing.T) {
+	bolivar := Point{Lat: 4.5981, Lon: -74.0760}
+	medellin := Point{Lat: 6.2442, Lon: -75.5812}
+
+	assert.InDelta(t, 245_000, Distance(bolivar, medellin), 3_000)
+	assert.Zero(t, Distance(bolivar, bolivar))
+}
+
+// TestAround checks the box contains every point within the radius.
+func TestAround(t *testing.T) {
+	center := Point{Lat: 4.6097, Lon: -74.0817}
+	box := Around(center, 200)
+
+	near := Point{Lat: 4.6110, Lon: -74.0805}
+	assert.Less(t, Distance(center, near), 200.0)
+	assert.True(t, box.Contains(near))
+	assert.False(t, box.Contains(Point{Lat: 4.6200, Lon: -74.0817}))
+}
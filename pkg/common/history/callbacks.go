@@ -0,0 +1,188 @@
+package history
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/audit"
+	commondb "github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// idsKey stores the primary keys targeted by an update or delete in the statement.
+const idsKey = "history:ids"
+
+// tracked returns the schema of the statement when its model is tracked.
+func (t *Tracker) tracked(db *gorm.DB) (*schema.Schema, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, false
+	}
+	return t.schemaOf(db.Statement.Schema.Table)
+}
+
+// afterCreate writes the first version of the created rows.
+func (t *Tracker) afterCreate(db *gorm.DB) {
+	s, ok := t.tracked(db)
+	if !ok || db.Statement.RowsAffected == 0 {
+		return
+	}
+	ids := commondb.PrimaryKeys(db.Statement.Context, s, db.Statement.ReflectValue)
+	t.write(db, s, ids, t.cfg.Now().UTC(), audit.Actor(db.Statement.Context))
+}
+
+// before finds the rows targeted by an update or delete and writes the
+// current state of rows changed for the first time since they were tracked. It
+// fails the statement with ErrTooManyRows when they exceed MaxRows.
+func (t *Tracker) before(db *gorm.DB) {
+	s, ok := t.tracked(db)
+	if !ok {
+		return
+	}
+	stmt := db.Statement
+	pk := s.PrioritizedPrimaryField.DBName
+
+	var conds []clause.Expression
+	if w, ok := stmt.Clauses["WHERE"]; ok && w.Expression != nil {
+		conds = append(conds, w.Expression)
+	}
+	if ids := commondb.PrimaryKeys(stmt.Context, s, stmt.ReflectValue); len(ids) > 0 {
+		conds = append(conds, clause.IN{Column: clause.Column{Name: pk}, Values: ids})
+	}
+	if len(conds) == 0 {
+		return
+	}
+
+	tx := db.Session(&gorm.Session{NewDB: true})
+	q := tx.Model(reflect.New(s.ModelType).Interface()).Clauses(conds...).Limit(t.cfg.MaxRows + 1)
+	if stmt.Unscoped {
+		q = q.Unscoped()
+	}
+	var ids []any
+	if err := q.Pluck(pk, &ids).Error; err != nil {
+		_ = db.AddError(fmt.Errorf("history: %w", err))
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if len(ids) > t.cfg.MaxRows {
+		_ = db.AddError(ErrTooManyRows)
+		return
+	}
+	db.InstanceSet(idsKey, ids)
+
+	var versioned []any
+	err := tx.Table(Table(s.Table)).Distinct(pk).Where(clause.IN{Column: clause.Column{Name: pk}, Values: ids}).Pluck(pk, &versioned).Error
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("history: %w", err))
+		return
+	}
+	seen := map[string]bool{}
+	for _, id := range versioned {
+		seen[key(id)] = true
+	}
+	var missing []any
+	for _, id := range ids {
+		if !seen[key(id)] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	// The state before the first tracked change is valid since the row was
+	// created, when known.
+	var from any = time.Unix(0, 0).UTC()
+	if f := s.LookUpField("CreatedAt"); f != nil && f.DBName != "" {
+		from = clause.Column{Table: "t", Name: f.DBName}
+	}
+	t.write(db, s, missing, from, "")
+}
+
+// afterUpdate closes the current versions of the updated rows and writes new ones.
+func (t *Tracker) afterUpdate(db *gorm.DB) {
+	s, ids, ok := t.loaded(db)
+	if !ok {
+		return
+	}
+	now := t.cfg.Now().UTC()
+	if t.close(db, s, ids, now) {
+		t.write(db, s, ids, now, audit.Actor(db.Statement.Context))
+	}
+}
+
+// afterDelete closes the current versions of the deleted rows.
+func (t *Tracker) afterDelete(db *gorm.DB) {
+	s, ids, ok := t.loaded(db)
+	if !ok {
+		return
+	}
+	t.close(db, s, ids, t.cfg.Now().UTC())
+}
+
+// loaded returns the primary keys stored by before when the mutation succeeded.
+func (t *Tracker) loaded(db *gorm.DB) (*schema.Schema, []any, bool) {
+	s, ok := t.tracked(db)
+	if !ok || db.Statement.RowsAffected == 0 {
+		return nil, nil, false
+	}
+	v, ok := db.InstanceGet(idsKey)
+	if !ok {
+		return nil, nil, false
+	}
+	return s, v.([]any), true
+}
+
+// close ends the current versions of the given rows at the given time.
+func (t *Tracker) close(db *gorm.DB, s *schema.Schema, ids []any, at time.Time) bool {
+	err := db.Session(&gorm.Session{NewDB: true}).Table(Table(s.Table)).
+		Where(clause.IN{Column: clause.Column{Name: s.PrioritizedPrimaryField.DBName}, Values: ids}).
+		Where("valid_to IS NULL").
+		Update("valid_to", at).Error
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("history: %w", err))
+		return false
+	}
+	return true
+}
+
+// write copies the current rows into the history table as their next version,
+// valid from the given time or column.
+func (t *Tracker) write(db *gorm.DB, s *schema.Schema, ids []any, from any, actor string) {
+	if len(ids) == 0 {
+		return
+	}
+	tx := db.Session(&gorm.Session{NewDB: true})
+	q := tx.Statement.Quote
+	hist, pk := q(Table(s.Table)), q(s.PrioritizedPrimaryField.DBName)
+
+	var cols, sel []string
+	for _, f := range s.Fields {
+		if f.DBName != "" {
+			cols = append(cols, q(f.DBName))
+			sel = append(sel, "t."+q(f.DBName))
+		}
+	}
+	sql := fmt.Sprintf(
+		"INSERT INTO %s (%s, history_version, valid_from, changed_by) "+
+			"SELECT %s, COALESCE((SELECT MAX(h.history_version) FROM %s h WHERE h.%s = t.%s), 0) + 1, ?, ? "+
+			"FROM %s t WHERE t.%s IN ?",
+		hist, strings.Join(cols, ", "), strings.Join(sel, ", "), hist, pk, pk, q(s.Table), pk,
+	)
+	if err := tx.Exec(sql, from, actor, ids).Error; err != nil {
+		_ = db.AddError(fmt.Errorf("history: %w", err))
+	}
+}
+
+// key returns a primary key as text, since drivers may scan it with another type.
+func key(v any) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,189 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrNotTracked is returned when reading the history of a model not passed to Track.
+var ErrNotTracked = errors.New("history: model is not tracked")
+
+// ErrTooManyRows is returned by updates and deletes of tracked models touching more
+// than Config.MaxRows rows, so no row changes without a version to restore.
+var ErrTooManyRows = errors.New("history: statement matches more rows than MaxRows")
+
+// asOfKey stores the point in time read by a query.
+const asOfKey = "history:as_of"
+
+// Table returns the history table of an entity table.
+func Table(table string) string { return table + "_history" }
+
+// record holds the version columns added to every history table, next to a
+// copy of the entity columns.
+type record struct {
+	HistoryID      uint64     `gorm:"primaryKey"`
+	HistoryVersion int        `gorm:"not null"`                   // HistoryVersion numbers the versions of an entity from 1.
+	ValidFrom      time.Time  `gorm:"precision:6;not null;index"` // ValidFrom is when the version was written.
+	ValidTo        *time.Time `gorm:"precision:6;index"`          // ValidTo is when the version was replaced or deleted, nil for the current one.
+	ChangedBy      string     `gorm:"size:64"`                    // ChangedBy is the actor writing the version, empty when unknown.
+}
+
+// Config defines how versions are written.
+type Config struct {
+	MaxRows int              // MaxRows bounds the rows of a single bulk update or delete, failing larger ones (default: 1000).
+	Now     func() time.Time // Now returns the version time (default: time.Now).
+}
+
+// Tracker keeps a history table per tracked model, written in the transaction
+// of every create, update and delete made through GORM on its database.
+type Tracker struct {
+	db     *gorm.DB
+	cfg    Config
+	mu     sync.RWMutex
+	models map[string]*schema.Schema
+}
+
+// New registers the history callbacks on db. No model is versioned until
+// passed to Track.
+func New(db *gorm.DB, cfg Config) (*Tracker, error) {
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 1000
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	t := &Tracker{db: db, cfg: cfg, models: map[string]*schema.Schema{}}
+
+	const commit = "gorm:commit_or_rollback_transaction"
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().After("gorm:create").Before(commit).Register("history:after_create", t.afterCreate),
+		cb.Update().Before("gorm:update").Register("history:before_update", t.before),
+		cb.Update().After("gorm:update").Before(commit).Register("history:after_update", t.afterUpdate),
+		cb.Delete().Before("gorm:delete").Register("history:before_delete", t.before),
+		cb.Delete().After("gorm:delete").Before(commit).Register("history:after_delete", t.afterDelete),
+		cb.Query().Before("gorm:query").Register("history:as_of", t.asOf),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Track creates or extends the history tables of models with a single primary
+// key and starts versioning them. Columns added to a model later are added to
+// its history table on the next call; unique constraints are not copied.
+func (t *Tracker) Track(models ...any) error {
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: t.db}
+		if err := stmt.Parse(m); err != nil {
+			return err
+		}
+		s := stmt.Schema
+		if s.PrioritizedPrimaryField == nil {
+			return fmt.Errorf("history: %s has no single primary key", s.Name)
+		}
+		if err := t.migrate(s); err != nil {
+			return err
+		}
+		t.mu.Lock()
+		t.models[s.Table] = s
+		t.mu.Unlock()
+	}
+	return nil
+}
+
+// migrate creates the history table of a schema and adds its missing columns.
+func (t *Tracker) migrate(s *schema.Schema) error {
+	hist := Table(s.Table)
+	if err := t.db.Table(hist).AutoMigrate(&record{}); err != nil {
+		return err
+	}
+
+	m := t.db.Migrator()
+	for _, f := range s.Fields {
+		if f.DBName == "" || m.HasColumn(hist, f.DBName) {
+			continue
+		}
+		col := *f
+		col.PrimaryKey, col.AutoIncrement, col.Unique, col.NotNull = false, false, false, false
+		err := t.db.Exec("ALTER TABLE ? ADD ? ?", clause.Table{Name: hist}, clause.Column{Name: f.DBName}, m.FullDataTypeOf(&col)).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	idx := "idx_" + hist + "_entity"
+	if m.HasIndex(hist, idx) {
+		return nil
+	}
+	return t.db.Exec("CREATE INDEX ? ON ? (?, ?)", clause.Column{Name: idx}, clause.Table{Name: hist},
+		clause.Column{Name: s.PrioritizedPrimaryField.DBName}, clause.Column{Name: "history_version"}).Error
+}
+
+// Purge deletes every version of an entity of a tracked table, e.g., on a data
+// erasure request, and returns the number of versions deleted. Changes made to
+// the entity afterwards are versioned again.
+func (t *Tracker) Purge(ctx context.Context, table string, id any) (int64, error) {
+	s, ok := t.schemaOf(table)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrNotTracked, table)
+	}
+	res := t.db.WithContext(ctx).Table(Table(s.Table)).
+		Where(clause.Eq{Column: clause.Column{Name: s.PrioritizedPrimaryField.DBName}, Value: id}).
+		Delete(&record{})
+	return res.RowsAffected, res.Error
+}
+
+// schemaOf returns the schema of a tracked table.
+func (t *Tracker) schemaOf(table string) (*schema.Schema, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s, ok := t.models[table]
+	return s, ok
+}
+
+// AsOf is a query function reading a tracked model as it was at the given
+// time, e.g., repo.GetByID(ctx, id, history.AsOf(lastWeek)). Entities deleted
+// or not yet created at that time are not found. Conditions must not qualify
+// columns with the entity table name.
+func AsOf(at time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Set(asOfKey, at.UTC())
+	}
+}
+
+// asOf redirects a query with AsOf to the history table.
+func (t *Tracker) asOf(db *gorm.DB) {
+	v, ok := db.Get(asOfKey)
+	if !ok || db.Error != nil {
+		return
+	}
+	stmt := db.Statement
+	if stmt.Schema == nil {
+		_ = db.AddError(ErrNotTracked)
+		return
+	}
+	if _, ok := t.schemaOf(stmt.Schema.Table); !ok {
+		_ = db.AddError(fmt.Errorf("%w: %s", ErrNotTracked, stmt.Schema.Table))
+		return
+	}
+
+	at := v.(time.Time)
+	stmt.Table = Table(stmt.Schema.Table)
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Lte{Column: clause.Column{Table: clause.CurrentTable, Name: "valid_from"}, Value: at},
+		clause.Or(
+			clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "valid_to"}, Value: nil},
+			clause.Gt{Column: clause.Column{Table: clause.CurrentTable, Name: "valid_to"}, Value: at},
+		),
+	}})
+}
@@ -0,0 +1,186 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/audit"
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// report is a tracked model with a unique code.
+type report struct {
+	ID          uint   `gorm:"primaryKey"`
+	Code        string `gorm:"size:16;uniqueIndex"`
+	Description string
+	Status      string `gorm:"size:16"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt
+}
+
+// clock is a manual time source.
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+// newDB returns a sqlite database tracking reports with the given clock.
+func newDB(t *testing.T, c *clock) (*gorm.DB, *Tracker) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "history.db")), &gorm.Config{NowFunc: c.Now})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&report{}))
+	tr, err := New(gdb, Config{Now: c.Now})
+	require.NoError(t, err)
+	require.NoError(t, tr.Track(&report{}))
+	return gdb, tr
+}
+
+// TestTracker checks versions are written on create, update and delete and read as of a time.
+func TestTracker(t *testing.T) {
+	c := &clock{now: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)}
+	gdb, tr := newDB(t, c)
+	require.NoError(t, tr.Track(&report{}), "tracking again is a no-op")
+	repo := db.NewRepository[report](gdb)
+	ctx := audit.WithActor(context.Background(), "u1")
+
+	r := &report{Code: "R-1", Description: "Pothole", Status: "open"}
+	require.NoError(t, repo.Create(ctx, r))
+	c.now = c.now.Add(24 * time.Hour)
+	r.Description = "Large pothole"
+	require.NoError(t, repo.Update(ctx, r))
+	c.now = c.now.Add(24 * time.Hour)
+	require.NoError(t, gdb.Model(&report{}).Where("status = ?", "open").Update("status", "closed").Error)
+	c.now = c.now.Add(24 * time.Hour)
+	require.NoError(t, repo.Delete(ctx, r.ID))
+
+	reader, err := NewReader[report](gdb)
+	require.NoError(t, err)
+	versions, err := reader.Versions(context.Background(), r.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, "Pothole", versions[0].Entity.Description)
+	assert.Equal(t, "u1", versions[0].ChangedBy)
+	assert.Equal(t, "system", versions[2].ChangedBy)
+	assert.Equal(t, "closed", versions[2].Entity.Status)
+	for i, v := range versions {
+		assert.Equal(t, i+1, v.Version)
+		require.NotNil(t, v.ValidTo)
+		if i > 0 {
+			assert.True(t, versions[i-1].ValidTo.Equal(v.ValidFrom))
+		}
+	}
+
+	at := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+	old, err := repo.GetByID(context.Background(), r.ID, AsOf(at))
+	require.NoError(t, err)
+	assert.Equal(t, "Large pothole", old.Description)
+	assert.Equal(t, "open", old.Status)
+
+	old, err = reader.AsOf(context.Background(), r.ID, at.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "closed", old.Status)
+	_, err = reader.AsOf(context.Background(), r.ID, c.now.Add(time.Hour))
+	assert.Equal(t, 404, transport.CodeOf(err), "deleted entities are not found")
+	_, err = repo.GetByID(context.Background(), r.ID, AsOf(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	changes, err := reader.Diff(context.Background(), r.ID, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]audit.Change{
+		"description": {Before: "Pothole", After: "Large pothole"},
+		"status":      {Before: "open", After: "closed"},
+	}, changes)
+	_, err = reader.Diff(context.Background(), r.ID, 1, 9)
+	assert.Equal(t, 404, transport.CodeOf(err))
+}
+
+// TestTrackExisting checks rows created before tracking keep their state since creation.
+func TestTrackExisting(t *testing.T) {
+	c := &clock{now: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)}
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "history.db")), &gorm.Config{NowFunc: c.Now})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&report{}))
+	r := &report{Code: "R-1", Description: "Pothole"}
+	require.NoError(t, gdb.Create(r).Error)
+
+	tr, err := New(gdb, Config{Now: c.Now})
+	require.NoError(t, err)
+	_, err = db.NewRepository[report](gdb).GetByID(context.Background(), r.ID, AsOf(c.now))
+	assert.ErrorIs(t, err, ErrNotTracked)
+	require.NoError(t, tr.Track(&report{}))
+
+	c.now = c.now.Add(time.Hour)
+	require.NoError(t, gdb.Model(r).Update("description", "Large pothole").Error)
+
+	reader, err := NewReader[report](gdb)
+	require.NoError(t, err)
+	versions, err := reader.Versions(context.Background(), r.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "Pothole", versions[0].Entity.Description)
+	assert.Empty(t, versions[0].ChangedBy)
+	assert.True(t, versions[0].ValidFrom.Equal(r.CreatedAt))
+	assert.Nil(t, versions[1].ValidTo)
+
+	old, err := reader.AsOf(context.Background(), r.ID, c.now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "Pothole", old.Description)
+}
+
+// TestPurge checks the versions of an entity are deleted and versioned again afterwards.
+func TestPurge(t *testing.T) {
+	c := &clock{now: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)}
+	gdb, tr := newDB(t, c)
+	ctx := context.Background()
+	a, b := &report{Code: "R-1", Description: "Pothole"}, &report{Code: "R-2", Description: "Broken light"}
+	require.NoError(t, gdb.Create(a).Error)
+	require.NoError(t, gdb.Create(b).Error)
+	c.now = c.now.Add(time.Hour)
+	require.NoError(t, gdb.Model(a).Update("description", "Large pothole").Error)
+
+	n, err := tr.Purge(ctx, "reports", a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	_, err = tr.Purge(ctx, "users", a.ID)
+	assert.ErrorIs(t, err, ErrNotTracked)
+
+	reader, err := NewReader[report](gdb)
+	require.NoError(t, err)
+	versions, err := reader.Versions(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+	versions, err = reader.Versions(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 1, "other entities are kept")
+
+	c.now = c.now.Add(time.Hour)
+	require.NoError(t, gdb.Model(a).Update("description", "Repaired").Error)
+	versions, err = reader.Versions(ctx, a.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "Large pothole", versions[0].Entity.Description)
+}
+
+// TestMaxRows checks bulk mutations larger than MaxRows fail instead of going unversioned.
+func TestMaxRows(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "history.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&report{}))
+	tr, err := New(gdb, Config{MaxRows: 1})
+	require.NoError(t, err)
+	require.NoError(t, tr.Track(&report{}))
+	require.NoError(t, gdb.Create([]report{{Code: "R-1", Status: "open"}, {Code: "R-2", Status: "open"}}).Error)
+
+	err = gdb.Model(&report{}).Where("status = ?", "open").Update("status", "closed").Error
+	assert.ErrorIs(t, err, ErrTooManyRows)
+	var n int64
+	require.NoError(t, gdb.Model(&report{}).Where("status = ?", "closed").Count(&n).Error)
+	assert.Zero(t, n, "nothing is changed")
+}
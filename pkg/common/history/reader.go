@@ -0,0 +1,122 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/audit"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Version is a past or current state of an entity.
+type Version[T any] struct {
+	Version   int        `json:"version"`            // Version numbers the versions of the entity from 1.
+	ValidFrom time.Time  `json:"valid_from"`         // ValidFrom is when the version was written.
+	ValidTo   *time.Time `json:"valid_to,omitempty"` // ValidTo is when the version was replaced or deleted.
+	ChangedBy string     `json:"changed_by"`         // ChangedBy is the actor writing the version.
+	Entity    T          `json:"entity"`             // Entity is the state of the entity.
+}
+
+// Reader reads the versions of a tracked model T.
+type Reader[T any] struct {
+	db *gorm.DB
+	s  *schema.Schema
+}
+
+// NewReader creates a Reader for T.
+func NewReader[T any](db *gorm.DB) (*Reader[T], error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("history: %s has no single primary key", stmt.Schema.Name)
+	}
+	return &Reader[T]{db: db, s: stmt.Schema}, nil
+}
+
+// query returns the versions of an entity, optionally a single one.
+func (r *Reader[T]) query(ctx context.Context, id any) *gorm.DB {
+	return r.db.WithContext(ctx).Table(Table(r.s.Table)).Unscoped().
+		Where(clause.Eq{Column: clause.Column{Name: r.s.PrioritizedPrimaryField.DBName}, Value: id}).
+		Order("history_version")
+}
+
+// Versions returns the versions of an entity, oldest first.
+func (r *Reader[T]) Versions(ctx context.Context, id any) ([]Version[T], error) {
+	return r.find(r.query(ctx, id))
+}
+
+// Version returns a version of an entity.
+func (r *Reader[T]) Version(ctx context.Context, id any, version int) (*Version[T], error) {
+	out, err := r.find(r.query(ctx, id).Where("history_version = ?", version))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, transport.NotFound(fmt.Sprintf("version %d not found", version))
+	}
+	return &out[0], nil
+}
+
+// find scans the version columns and the entity columns of the matched rows.
+// Both queries use the model of T so they are filtered alike, e.g., by tenant.
+func (r *Reader[T]) find(q *gorm.DB) ([]Version[T], error) {
+	var records []record
+	if err := q.Session(&gorm.Session{}).Model(new(T)).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	var entities []T
+	if err := q.Session(&gorm.Session{}).Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	if len(entities) != len(records) {
+		return nil, errors.New("history: versions changed while reading")
+	}
+
+	out := make([]Version[T], len(records))
+	for i, rec := range records {
+		out[i] = Version[T]{
+			Version:   rec.HistoryVersion,
+			ValidFrom: rec.ValidFrom,
+			ValidTo:   rec.ValidTo,
+			ChangedBy: rec.ChangedBy,
+			Entity:    entities[i],
+		}
+	}
+	return out, nil
+}
+
+// AsOf returns an entity as it was at the given time.
+func (r *Reader[T]) AsOf(ctx context.Context, id any, at time.Time) (*T, error) {
+	var out T
+	err := r.db.WithContext(ctx).Scopes(AsOf(at)).First(&out, clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: r.s.PrioritizedPrimaryField.DBName}, Value: id,
+	}).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, transport.NotFound(fmt.Sprintf("no version at %s", at.Format(time.RFC3339)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Diff returns the fields changed from one version of an entity to another.
+func (r *Reader[T]) Diff(ctx context.Context, id any, from, to int) (map[string]audit.Change, error) {
+	a, err := r.Version(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.Version(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+	return audit.Diff(ctx, r.s, reflect.ValueOf(a.Entity), reflect.ValueOf(b.Entity)), nil
+}
@@ -0,0 +1,214 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+	"github.com/ianfedev/civicspot-backend/pkg/common/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// exifSegment builds an APP1 segment with the given orientation and a GPS
+// position of 4°42'39.6"N 74°4'19.56"W.
+func exifSegment(orientation uint16) []byte {
+	le := binary.LittleEndian
+	tiff := make([]byte, 140)
+	copy(tiff, "II")
+	le.PutUint16(tiff[2:], 42)
+	le.PutUint32(tiff[4:], 8)
+
+	entry := func(at int, tag, typ uint16, count, value uint32) {
+		le.PutUint16(tiff[at:], tag)
+		le.PutUint16(tiff[at+2:], typ)
+		le.PutUint32(tiff[at+4:], count)
+		le.PutUint32(tiff[at+8:], value)
+	}
+
+	// IFD0 at 8: orientation and the GPS IFD pointer.
+	le.PutUint16(tiff[8:], 2)
+	entry(10, 0x0112, 3, 1, uint32(orientation))
+	entry(22, 0x8825, 4, 1, 38)
+
+	// GPS IFD at 38: references and rational degrees, minutes and seconds.
+	le.PutUint16(tiff[38:], 4)
+	entry(40, 0x0001, 2, 2, uint32('N'))
+	entry(52, 0x0002, 5, 3, 92)
+	entry(64, 0x0003, 2, 2, uint32('W'))
+	entry(76, 0x0004, 5, 3, 116)
+
+	rationals := func(at int, values ...uint32) {
+		for i, v := range values {
+			le.PutUint32(tiff[at+4*i:], v)
+		}
+	}
+	rationals(92, 4, 1, 42, 1, 396, 10)
+	rationals(116, 74, 1, 4, 1, 1956, 100)
+
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(2+6+len(tiff)))
+	seg = append(seg, "Exif\x00\x00"...)
+	return append(seg, tiff...)
+}
+
+// photo returns a JPEG of the given size with EXIF orientation and GPS metadata.
+func photo(t *testing.T, w, h int, orientation uint16) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	return append(out, data[2:]...)
+}
+
+// TestReadMetadata checks orientation and GPS extraction.
+func TestReadMetadata(t *testing.T) {
+	md := ReadMetadata(photo(t, 8, 4, 6))
+	assert.Equal(t, 6, md.Orientation)
+	require.NotNil(t, md.GPS)
+	assert.InDelta(t, 4.711, md.GPS.Latitude, 1e-4)
+	assert.InDelta(t, -74.0721, md.GPS.Longitude, 1e-4)
+
+	assert.Equal(t, Metadata{}, ReadMetadata([]byte("not an image")))
+}
+
+// TestTransforms checks orientation, scaling and redaction.
+func TestTransforms(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 255})
+
+	rotated := Orient(src, 6)
+	assert.Equal(t, image.Rect(0, 0, 2, 4), rotated.Bounds())
+	r, _, _, _ := rotated.At(1, 0).RGBA()
+	assert.Equal(t, uint32(0xFFFF), r, "top-left pixel moves to the top-right corner")
+	assert.Same(t, src, Orient(src, 1))
+
+	big := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	assert.Equal(t, image.Rect(0, 0, 100, 50), Fit(big, 100).Bounds())
+	assert.Same(t, big, Fit(big, 1000))
+
+	checker := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if (x+y)%2 == 0 {
+				checker.Set(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				checker.Set(x, y, color.NRGBA{A: 255})
+			}
+		}
+	}
+	redacted := Redact(checker, []image.Rectangle{image.Rect(0, 0, 2, 2)}, 2)
+	assert.Equal(t, redacted.At(0, 0), redacted.At(1, 0))
+	assert.Equal(t, checker.At(3, 3), redacted.At(3, 3))
+}
+
+// newPipeline returns a Pipeline and queue backed by a local store and sqlite database.
+func newPipeline(t *testing.T) (*Pipeline, *jobs.Queue, *gorm.DB) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "media.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, storage.Migrate(gdb))
+	require.NoError(t, jobs.Migrate(gdb))
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	q := jobs.NewQueue(gdb)
+	p := New(Config{Sizes: []Size{{Name: "thumb", MaxEdge: 16}}})
+	p.cfg.Media = storage.NewMedia(storage.MediaConfig{
+		Store:    store,
+		DB:       gdb,
+		Signer:   storage.NewSigner([]byte("secret")),
+		BaseURL:  "/media",
+		OnUpload: p.OnUpload(q),
+	})
+	return p, q, gdb
+}
+
+// TestPipeline checks an upload is sanitized and thumbnailed by the background job.
+func TestPipeline(t *testing.T) {
+	p, _, gdb := newPipeline(t)
+	ctx := context.Background()
+	media := p.cfg.Media
+
+	w := jobs.NewWorker(gdb, jobs.WorkerConfig{})
+	p.Register(w)
+
+	a, err := media.Upload(ctx, "user-1", "hueco.jpg", bytes.NewReader(photo(t, 64, 32, 6)))
+	require.NoError(t, err)
+	rawKey := a.Key
+
+	ran, err := w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	a, err = media.Get(ctx, a.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, a.ProcessedAt)
+	assert.Equal(t, 32, a.Width)
+	assert.Equal(t, 64, a.Height)
+	require.NotNil(t, a.Latitude)
+	assert.InDelta(t, 4.711, *a.Latitude, 1e-4)
+
+	r, contentType, _, err := media.Open(ctx, a, "")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "image/jpeg", contentType)
+	assert.Equal(t, Metadata{}, ReadMetadata(data), "metadata is stripped")
+
+	variants, err := media.Variants(ctx, a.ID)
+	require.NoError(t, err)
+	names := map[string]storage.Variant{}
+	for _, v := range variants {
+		names[v.Name] = v
+	}
+	require.Contains(t, names, "thumb.webp")
+	require.Contains(t, names, "thumb.jpeg")
+	assert.Equal(t, 8, names["thumb.webp"].Width)
+	assert.Equal(t, 16, names["thumb.webp"].Height)
+
+	r, contentType, _, err = media.Open(ctx, a, "thumb.webp")
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "image/webp", contentType)
+
+	_, err = media.Store().Stat(ctx, rawKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "the raw upload is released")
+
+	// Reprocessing keeps the extracted location and the orientation.
+	a, err = p.Process(ctx, Request{AttachmentID: a.ID, Redact: []image.Rectangle{image.Rect(0, 0, 16, 16)}})
+	require.NoError(t, err)
+	assert.Equal(t, 32, a.Width)
+	require.NotNil(t, a.Latitude)
+}
+
+// TestPipelineLimits checks oversized images are rejected.
+func TestPipelineLimits(t *testing.T) {
+	p, _, _ := newPipeline(t)
+	p.cfg.MaxPixels = 100
+	ctx := context.Background()
+
+	a, err := p.cfg.Media.Upload(ctx, "user-1", "hueco.jpg", bytes.NewReader(photo(t, 64, 32, 1)))
+	require.NoError(t, err)
+
+	_, err = p.Process(ctx, Request{AttachmentID: a.ID})
+	assert.ErrorIs(t, err, ErrTooManyPixels)
+}
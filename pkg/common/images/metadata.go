@@ -0,0 +1,45 @@
+package images
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// GPS is a location read from image metadata.
+type GPS struct {
+	Latitude  float64 // Latitude in decimal degrees.
+	Longitude float64 // Longitude in decimal degrees.
+}
+
+// Metadata holds the EXIF fields used by the pipeline.
+type Metadata struct {
+	Orientation int        // Orientation is the EXIF orientation (1 to 8; 0 when absent).
+	GPS         *GPS       // GPS is the capture location, if recorded.
+	TakenAt     *time.Time // TakenAt is the capture time, if recorded.
+}
+
+// ReadMetadata extracts EXIF metadata from an encoded image. Images without
+// readable EXIF data return empty metadata and no error, since metadata is optional.
+func ReadMetadata(data []byte) Metadata {
+	var md Metadata
+
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return md
+	}
+
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if o, err := tag.Int(0); err == nil && o >= 1 && o <= 8 {
+			md.Orientation = o
+		}
+	}
+	if lat, lon, err := x.LatLong(); err == nil && (lat != 0 || lon != 0) {
+		md.GPS = &GPS{Latitude: lat, Longitude: lon}
+	}
+	if t, err := x.DateTime(); err == nil {
+		md.TakenAt = &t
+	}
+	return md
+}
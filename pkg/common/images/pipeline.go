@@ -0,0 +1,249 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+	"github.com/ianfedev/civicspot-backend/pkg/common/storage"
+
+	_ "golang.org/x/image/webp"
+)
+
+// JobKind is the job kind processing an uploaded image.
+const JobKind = "images.process"
+
+// ErrTooManyPixels is returned for images larger than the configured pixel limit.
+var ErrTooManyPixels = errors.New("images: image exceeds the pixel limit")
+
+// Format is an output encoding.
+type Format string
+
+const (
+	// WebP encodes losslessly with WebP.
+	WebP Format = "webp"
+	// JPEG encodes with JPEG at the configured quality.
+	JPEG Format = "jpeg"
+	// PNG encodes losslessly with PNG.
+	PNG Format = "png"
+)
+
+// Size is a thumbnail size.
+type Size struct {
+	Name    string // Name prefixes the variant name (e.g., "thumb" gives "thumb.webp").
+	MaxEdge int    // MaxEdge is the maximum width or height in pixels.
+}
+
+// Request is the payload of a processing job.
+type Request struct {
+	AttachmentID uint              // AttachmentID is the image to process.
+	Redact       []image.Rectangle // Redact lists regions to pixelate, in oriented image coordinates.
+}
+
+// Config defines the pipeline outputs.
+type Config struct {
+	Media       *storage.Media // Media stores the attachments and their variants.
+	Sizes       []Size         // Sizes are the thumbnails generated (default: thumb 160, medium 640, large 1280).
+	Formats     []Format       // Formats are the thumbnail encodings (default: WebP and JPEG).
+	JPEGQuality int            // JPEGQuality is the JPEG quality (default: 82).
+	MaxPixels   int            // MaxPixels rejects larger images to bound memory use (default: 40 megapixels).
+
+	// OnProcessed runs after an attachment is processed, e.g. to prefill the location
+	// of the owning report from the extracted GPS coordinates (optional).
+	OnProcessed func(ctx context.Context, a *storage.Attachment) error
+}
+
+// Pipeline sanitizes uploaded images and generates their thumbnails.
+type Pipeline struct {
+	cfg Config
+	now func() time.Time
+}
+
+// New creates a Pipeline with the given configuration.
+func New(cfg Config) *Pipeline {
+	if len(cfg.Sizes) == 0 {
+		cfg.Sizes = []Size{{Name: "thumb", MaxEdge: 160}, {Name: "medium", MaxEdge: 640}, {Name: "large", MaxEdge: 1280}}
+	}
+	if len(cfg.Formats) == 0 {
+		cfg.Formats = []Format{WebP, JPEG}
+	}
+	if cfg.JPEGQuality == 0 {
+		cfg.JPEGQuality = 82
+	}
+	if cfg.MaxPixels == 0 {
+		cfg.MaxPixels = 40_000_000
+	}
+	return &Pipeline{cfg: cfg, now: time.Now}
+}
+
+// Register handles processing jobs on the worker.
+func (p *Pipeline) Register(w *jobs.Worker) {
+	w.Handle(JobKind, func(ctx context.Context, job *jobs.Job) error {
+		var req Request
+		if err := job.Bind(&req); err != nil {
+			return err
+		}
+		_, err := p.Process(ctx, req)
+		return err
+	})
+}
+
+// Enqueue schedules the processing of an attachment.
+func (p *Pipeline) Enqueue(ctx context.Context, q *jobs.Queue, req Request) error {
+	_, err := q.Enqueue(ctx, JobKind, req, jobs.Unique(JobKind+":"+strconv.FormatUint(uint64(req.AttachmentID), 10)))
+	if errors.Is(err, jobs.ErrDuplicate) {
+		return nil
+	}
+	return err
+}
+
+// OnUpload returns a storage.MediaConfig hook enqueuing the processing of uploaded images.
+func (p *Pipeline) OnUpload(q *jobs.Queue) func(ctx context.Context, a *storage.Attachment) error {
+	return func(ctx context.Context, a *storage.Attachment) error {
+		if !strings.HasPrefix(a.ContentType, "image/") {
+			return nil
+		}
+		return p.Enqueue(ctx, q, Request{AttachmentID: a.ID})
+	}
+}
+
+// Process strips the metadata of an image attachment, keeping its GPS location on the
+// attachment, normalizes its orientation, pixelates the requested regions and stores
+// the sanitized image with its thumbnails. Attachments that are not images are left untouched.
+func (p *Pipeline) Process(ctx context.Context, req Request) (*storage.Attachment, error) {
+	media := p.cfg.Media
+
+	a, err := media.Get(ctx, req.AttachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(a.ContentType, "image/") {
+		return a, nil
+	}
+
+	r, _, _, err := media.Open(ctx, a, "")
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("images: attachment %d: %w", a.ID, err)
+	}
+	if cfg.Width*cfg.Height > p.cfg.MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	// Original files of processed attachments were already sanitized and oriented.
+	var md Metadata
+	if a.ProcessedAt == nil {
+		md = ReadMetadata(data)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("images: attachment %d: %w", a.ID, err)
+	}
+	img = Orient(img, md.Orientation)
+	if len(req.Redact) > 0 {
+		edge := max(img.Bounds().Dx(), img.Bounds().Dy())
+		img = Redact(img, req.Redact, max(8, edge/50))
+	}
+
+	prefix := "images/" + strconv.FormatUint(uint64(a.ID), 10) + "/"
+
+	// Re-encoding drops every metadata block of the upload.
+	originalFormat := PNG
+	switch format {
+	case "jpeg":
+		originalFormat = JPEG
+	case "webp":
+		originalFormat = WebP
+	}
+	original, err := p.put(ctx, prefix+"original", img, originalFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	var variants []storage.Variant
+	for _, size := range p.cfg.Sizes {
+		thumb := Fit(img, size.MaxEdge)
+		for _, f := range p.cfg.Formats {
+			v, err := p.put(ctx, prefix+size.Name, thumb, f)
+			if err != nil {
+				return nil, err
+			}
+			v.Name = size.Name + "." + string(f)
+			variants = append(variants, *v)
+		}
+	}
+
+	now := p.now()
+	a.Key = original.Key
+	a.ContentType = original.ContentType
+	a.Size = original.Size
+	a.Width = original.Width
+	a.Height = original.Height
+	a.ProcessedAt = &now
+	if md.GPS != nil {
+		a.Latitude = &md.GPS.Latitude
+		a.Longitude = &md.GPS.Longitude
+	}
+
+	if err := media.SaveProcessed(ctx, a, variants); err != nil {
+		return nil, err
+	}
+	if p.cfg.OnProcessed != nil {
+		if err := p.cfg.OnProcessed(ctx, a); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// put encodes an image and stores it under name with the format extension.
+func (p *Pipeline) put(ctx context.Context, name string, img image.Image, f Format) (*storage.Variant, error) {
+	var buf bytes.Buffer
+	var ext, contentType string
+	var err error
+
+	switch f {
+	case WebP:
+		ext, contentType = "webp", "image/webp"
+		err = nativewebp.Encode(&buf, img, nil)
+	case JPEG:
+		ext, contentType = "jpg", "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: p.cfg.JPEGQuality})
+	case PNG:
+		ext, contentType = "png", "image/png"
+		err = png.Encode(&buf, img)
+	default:
+		return nil, fmt.Errorf("images: unsupported format %q", f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := name + "." + ext
+	size := int64(buf.Len())
+	if err := p.cfg.Media.Store().Put(ctx, key, &buf, size, contentType); err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	return &storage.Variant{ContentType: contentType, Key: key, Width: b.Dx(), Height: b.Dy(), Size: size}, nil
+}
@@ -0,0 +1,117 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Orient rotates and flips an image so that EXIF orientation 1 applies.
+func Orient(src image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	// at maps a destination pixel to its source pixel.
+	at := func(x, y int) (int, int) {
+		switch orientation {
+		case 2:
+			return w - 1 - x, y
+		case 3:
+			return w - 1 - x, h - 1 - y
+		case 4:
+			return x, h - 1 - y
+		case 5:
+			return y, x
+		case 6:
+			return y, h - 1 - x
+		case 7:
+			return w - 1 - y, h - 1 - x
+		default: // 8
+			return w - 1 - y, x
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := at(x, y)
+			dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// Fit scales an image down so its longest edge is at most maxEdge, keeping the
+// aspect ratio. Smaller images are returned unchanged.
+func Fit(src image.Image, maxEdge int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxEdge <= 0 || (w <= maxEdge && h <= maxEdge) {
+		return src
+	}
+
+	if w >= h {
+		h = max(1, h*maxEdge/w)
+		w = maxEdge
+	} else {
+		w = max(1, w*maxEdge/h)
+		h = maxEdge
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, b, xdraw.Src, nil)
+	return dst
+}
+
+// Redact pixelates the given regions, e.g. faces or license plates, using square
+// blocks of the given size. Regions are clipped to the image bounds.
+func Redact(src image.Image, regions []image.Rectangle, block int) image.Image {
+	if len(regions) == 0 {
+		return src
+	}
+	if block < 2 {
+		block = 2
+	}
+
+	b := src.Bounds()
+	dst := image.NewNRGBA(b)
+	draw.Draw(dst, b, src, b.Min, draw.Src)
+
+	for _, r := range regions {
+		r = r.Add(b.Min).Intersect(b)
+		for by := r.Min.Y; by < r.Max.Y; by += block {
+			for bx := r.Min.X; bx < r.Max.X; bx += block {
+				cell := image.Rect(bx, by, bx+block, by+block).Intersect(r)
+				draw.Draw(dst, cell, &image.Uniform{C: average(dst, cell)}, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return dst
+}
+
+// average returns the mean color of a region.
+func average(img image.Image, r image.Rectangle) color.Color {
+	var rs, gs, bs, as, n uint64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rs, gs, bs, as = rs+uint64(c.R), gs+uint64(c.G), bs+uint64(c.B), as+uint64(c.A)
+			n++
+		}
+	}
+	if n == 0 {
+		return color.Transparent
+	}
+	return color.NRGBA{R: uint8(rs / n), G: uint8(gs / n), B: uint8(bs / n), A: uint8(as / n)}
+}
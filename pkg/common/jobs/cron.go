@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// SchedulerConfig defines how a Scheduler enqueues periodic jobs.
+type SchedulerConfig struct {
+	Location *time.Location // Location evaluates cron expressions (default: UTC).
+	Tick     time.Duration  // Tick is the interval between schedule checks (default: 1 second).
+	LeaseTTL time.Duration  // LeaseTTL is the leadership lease duration (default: 30 seconds).
+	OnError  func(error)    // OnError receives enqueue and election failures (optional).
+}
+
+// entry is a registered periodic job.
+type entry struct {
+	name     string
+	schedule cron.Schedule
+	kind     string
+	payload  any
+	opts     []EnqueueOption
+	next     time.Time
+}
+
+// Scheduler enqueues jobs on cron schedules. Only the replica holding the
+// leadership lease enqueues, and each run uses a unique key so a run is not
+// queued twice while leadership changes hands.
+type Scheduler struct {
+	queue   *Queue
+	elector *Elector
+	cfg     SchedulerConfig
+	parser  cron.Parser
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries []*entry
+}
+
+// NewScheduler creates a Scheduler enqueuing into queue and electing its leader with elector.
+func NewScheduler(queue *Queue, elector *Elector, cfg SchedulerConfig) *Scheduler {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	if cfg.Tick == 0 {
+		cfg.Tick = time.Second
+	}
+	if cfg.LeaseTTL == 0 {
+		cfg.LeaseTTL = 30 * time.Second
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+	return &Scheduler{
+		queue:   queue,
+		elector: elector,
+		cfg:     cfg,
+		parser:  cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor),
+		now:     time.Now,
+	}
+}
+
+// Add registers a periodic job. The spec is a cron expression with optional
+// seconds (e.g. "0 3 * * *") or a descriptor such as "@hourly" or "@every 10m".
+// Runs have no tenant unless opts include InTenant.
+func (s *Scheduler) Add(name, spec, kind string, payload any, opts ...EnqueueOption) error {
+	schedule, err := s.parser.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("jobs: invalid schedule %q: %w", spec, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.name == name {
+			return fmt.Errorf("jobs: schedule %q already registered", name)
+		}
+	}
+	s.entries = append(s.entries, &entry{
+		name:     name,
+		schedule: schedule,
+		kind:     kind,
+		payload:  payload,
+		opts:     opts,
+		next:     schedule.Next(s.now().In(s.cfg.Location)),
+	})
+	return nil
+}
+
+// Start checks the schedules every tick until the context is cancelled, then
+// releases the leadership lease.
+func (s *Scheduler) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.Tick)
+	defer ticker.Stop()
+
+	var leader bool
+	var renewed time.Time
+	for {
+		now := s.now()
+		if now.Sub(renewed) >= s.cfg.LeaseTTL/3 {
+			ok, err := s.elector.Acquire(ctx)
+			if err != nil && ctx.Err() == nil {
+				s.cfg.OnError(err)
+			}
+			leader, renewed = ok, now
+		}
+		if err := s.RunDue(ctx, now, leader); err != nil && ctx.Err() == nil {
+			s.cfg.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), s.elector.Release(context.WithoutCancel(ctx)))
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue advances the schedules due at now, enqueuing their runs when enqueue is true.
+// Followers advance without enqueuing so they do not replay old runs once elected.
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time, enqueue bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now = now.In(s.cfg.Location)
+	var errs []error
+	for _, e := range s.entries {
+		for !e.next.After(now) {
+			if enqueue {
+				key := fmt.Sprintf("cron:%s:%d", e.name, e.next.Unix())
+				opts := append(append([]EnqueueOption{}, e.opts...), Unique(key))
+				if _, err := s.queue.Enqueue(ctx, e.kind, e.payload, opts...); err != nil && !errors.Is(err, ErrDuplicate) {
+					errs = append(errs, fmt.Errorf("jobs: schedule %q: %w", e.name, err))
+					break
+				}
+			}
+			e.next = e.schedule.Next(now)
+		}
+	}
+	return errors.Join(errs...)
+}
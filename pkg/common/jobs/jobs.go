@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Status is the processing state of a job.
+type Status string
+
+const (
+	// StatusPending jobs wait until RunAt to be claimed.
+	StatusPending Status = "pending"
+	// StatusRunning jobs are claimed by a worker until LockedUntil.
+	StatusRunning Status = "running"
+	// StatusDone jobs completed successfully.
+	StatusDone Status = "done"
+	// StatusDead jobs exhausted their attempts.
+	StatusDead Status = "dead"
+)
+
+// DefaultQueue is the queue used when none is given.
+const DefaultQueue = "default"
+
+// ErrDuplicate is returned when a unique job with the same key is already pending or running.
+var ErrDuplicate = errors.New("jobs: duplicate unique job")
+
+// Job is a unit of deferred work persisted in the database.
+type Job struct {
+	ID          uint       `gorm:"primarykey"`
+	Queue       string     `gorm:"index:idx_job_claim,priority:1"` // Queue groups jobs processed by the same workers.
+	Status      Status     `gorm:"index:idx_job_claim,priority:2"` // Status is the processing state.
+	RunAt       time.Time  `gorm:"index:idx_job_claim,priority:3"` // RunAt is the earliest time the job can run.
+	Kind        string     // Kind selects the handler.
+	Payload     []byte     // Payload is the JSON encoded job argument.
+	Tenant      string     `gorm:"size:64"` // Tenant is the tenant of the enqueuing context; the handler runs in it.
+	Priority    int        // Priority orders claimable jobs; higher runs first.
+	UniqueKey   *string    `gorm:"uniqueIndex"` // UniqueKey prevents duplicates while the job is pending or running.
+	Attempts    int        // Attempts counts the executions started.
+	MaxAttempts int        // MaxAttempts is the number of executions before the job is dead.
+	LockedBy    string     // LockedBy identifies the worker running the job.
+	LockedUntil *time.Time // LockedUntil is when a running job is considered abandoned.
+	LastError   string     // LastError is the error of the last failed attempt.
+	FinishedAt  *time.Time // FinishedAt is when the job was done or declared dead.
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Bind decodes the job payload into v.
+func (j *Job) Bind(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Handler processes a job. Returning an error schedules a retry with backoff.
+type Handler func(ctx context.Context, job *Job) error
+
+// Backoff returns the delay before the given attempt is retried.
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff doubles the delay from base on each attempt, up to max.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		return min(d, max)
+	}
+}
+
+// EnqueueOption customizes an enqueued job.
+type EnqueueOption func(*Job)
+
+// OnQueue places the job in the named queue.
+func OnQueue(name string) EnqueueOption {
+	return func(j *Job) { j.Queue = name }
+}
+
+// At schedules the job to run at t.
+func At(t time.Time) EnqueueOption {
+	return func(j *Job) { j.RunAt = t.UTC() }
+}
+
+// In schedules the job to run after d.
+func In(d time.Duration) EnqueueOption {
+	return func(j *Job) { j.RunAt = j.RunAt.Add(d) }
+}
+
+// Unique rejects the job with ErrDuplicate while another job with the same key is pending or running.
+func Unique(key string) EnqueueOption {
+	return func(j *Job) { j.UniqueKey = &key }
+}
+
+// InTenant runs the job in the tenant id instead of the tenant of the enqueuing context.
+func InTenant(id string) EnqueueOption {
+	return func(j *Job) { j.Tenant = id }
+}
+
+// MaxAttempts sets the number of executions before the job is dead (default: 5).
+func MaxAttempts(n int) EnqueueOption {
+	return func(j *Job) { j.MaxAttempts = n }
+}
+
+// Priority sets the job priority; higher values run first.
+func Priority(p int) EnqueueOption {
+	return func(j *Job) { j.Priority = p }
+}
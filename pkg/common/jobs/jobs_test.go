@@ -0,0 +1,311 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openDB returns a migrated sqlite database in a temporary file.
+func openDB(t *testing.T) *gorm.DB {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "jobs.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(gdb))
+	return gdb
+}
+
+type greeting struct {
+	Name string
+}
+
+// TestWorkerRunsJobs checks that enqueued jobs reach their handler once.
+func TestWorkerRunsJobs(t *testing.T) {
+	gdb := openDB(t)
+	q := NewQueue(gdb)
+	w := NewWorker(gdb, WorkerConfig{})
+	ctx := context.Background()
+
+	var got string
+	w.Handle("greet", func(_ context.Context, job *Job) error {
+		var g greeting
+		require.NoError(t, job.Bind(&g))
+		got = g.Name
+		return nil
+	})
+
+	job, err := q.Enqueue(ctx, "greet", greeting{Name: "Bogotá"})
+	require.NoError(t, err)
+
+	ran, err := w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, "Bogotá", got)
+
+	stored, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, stored.Status)
+	assert.NotNil(t, stored.FinishedAt)
+
+	ran, err = w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.False(t, ran)
+}
+
+// TestWorkerRunsJobsInTheirTenant checks handlers run in the tenant enqueuing the job,
+// or the one given with InTenant, on a database scoped by tenant.
+func TestWorkerRunsJobsInTheirTenant(t *testing.T) {
+	gdb := openDB(t)
+	require.NoError(t, tenant.Register(gdb))
+	q := NewQueue(gdb)
+	w := NewWorker(gdb, WorkerConfig{})
+
+	var got []string
+	w.Handle("greet", func(ctx context.Context, _ *Job) error {
+		id, _ := tenant.FromContext(ctx)
+		got = append(got, id)
+		return nil
+	})
+	_, err := q.Enqueue(tenant.NewContext(context.Background(), "bogota"), "greet", greeting{})
+	require.NoError(t, err)
+	_, err = q.Enqueue(context.Background(), "greet", greeting{})
+	require.NoError(t, err)
+	_, err = q.Enqueue(tenant.NewContext(context.Background(), "bogota"), "greet", greeting{}, InTenant("cali"))
+	require.NoError(t, err)
+
+	for range 3 {
+		ran, err := w.RunOnce(context.Background())
+		require.NoError(t, err)
+		assert.True(t, ran)
+	}
+	assert.Equal(t, []string{"bogota", "", "cali"}, got)
+}
+
+// TestWorkerRetriesWithBackoff checks retries are delayed and jobs die after max attempts.
+func TestWorkerRetriesWithBackoff(t *testing.T) {
+	gdb := openDB(t)
+	q := NewQueue(gdb)
+	w := NewWorker(gdb, WorkerConfig{Backoff: ExponentialBackoff(time.Minute, time.Hour)})
+	ctx := context.Background()
+
+	w.Handle("fail", func(context.Context, *Job) error { return errors.New("boom") })
+	job, err := q.Enqueue(ctx, "fail", nil, MaxAttempts(2))
+	require.NoError(t, err)
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	_, err = w.RunOnce(ctx)
+	require.ErrorContains(t, err, "boom")
+
+	stored, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, stored.Status)
+	assert.Equal(t, "boom", stored.LastError)
+	assert.WithinDuration(t, now.Add(time.Minute), stored.RunAt, time.Second)
+
+	ran, err := w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.False(t, ran, "retry must wait for the backoff")
+
+	now = now.Add(2 * time.Minute)
+	_, err = w.RunOnce(ctx)
+	require.Error(t, err)
+
+	stored, err = q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDead, stored.Status)
+	assert.Equal(t, 2, stored.Attempts)
+}
+
+// TestWorkerRecoversPanics checks a panicking handler fails the attempt instead of the worker.
+func TestWorkerRecoversPanics(t *testing.T) {
+	gdb := openDB(t)
+	q := NewQueue(gdb)
+	w := NewWorker(gdb, WorkerConfig{})
+	ctx := context.Background()
+
+	w.Handle("panic", func(context.Context, *Job) error { panic("bad payload") })
+	_, err := q.Enqueue(ctx, "panic", nil)
+	require.NoError(t, err)
+
+	_, err = w.RunOnce(ctx)
+	assert.ErrorContains(t, err, "bad payload")
+}
+
+// TestUniqueJobs checks duplicates are rejected until the job finishes.
+func TestUniqueJobs(t *testing.T) {
+	gdb := openDB(t)
+	q := NewQueue(gdb)
+	w := NewWorker(gdb, WorkerConfig{})
+	ctx := context.Background()
+	w.Handle("cleanup", func(context.Context, *Job) error { return nil })
+
+	_, err := q.Enqueue(ctx, "cleanup", nil, Unique("cleanup:uploads"))
+	require.NoError(t, err)
+
+	_, err = q.Enqueue(ctx, "cleanup", nil, Unique("cleanup:uploads"))
+	assert.ErrorIs(t, err, ErrDuplicate)
+
+	_, err = w.RunOnce(ctx)
+	require.NoError(t, err)
+
+	_, err = q.Enqueue(ctx, "cleanup", nil, Unique("cleanup:uploads"))
+	assert.NoError(t, err)
+}
+
+// TestAbandonedJobsAreReclaimed checks that jobs locked by a crashed worker run again.
+func TestAbandonedJobsAreReclaimed(t *testing.T) {
+	gdb := openDB(t)
+	q := NewQueue(gdb)
+	ctx := context.Background()
+
+	crashed := NewWorker(gdb, WorkerConfig{LockTimeout: time.Minute})
+	_, err := q.Enqueue(ctx, "report", nil)
+	require.NoError(t, err)
+	job, err := crashed.claim(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	w := NewWorker(gdb, WorkerConfig{})
+	w.Handle("report", func(context.Context, *Job) error { return nil })
+
+	ran, err := w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.False(t, ran)
+
+	w.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	ran, err = w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+}
+
+// TestAbandonedJobsOutOfAttemptsAreDead checks that a job crashing its worker on
+// the last attempt is declared dead instead of being reclaimed forever.
+func TestAbandonedJobsOutOfAttemptsAreDead(t *testing.T) {
+	gdb := openDB(t)
+	q := NewQueue(gdb)
+	ctx := context.Background()
+
+	crashed := NewWorker(gdb, WorkerConfig{LockTimeout: time.Minute})
+	job, err := q.Enqueue(ctx, "report", nil, MaxAttempts(1))
+	require.NoError(t, err)
+	_, err = crashed.claim(ctx)
+	require.NoError(t, err)
+
+	var reported []error
+	w := NewWorker(gdb, WorkerConfig{OnError: func(err error) { reported = append(reported, err) }})
+	w.Handle("report", func(context.Context, *Job) error { return nil })
+	w.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	ran, err := w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.False(t, ran)
+	assert.Len(t, reported, 1)
+
+	got, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDead, got.Status)
+	assert.Equal(t, 1, got.Attempts)
+}
+
+// TestWorkerGracefulShutdown checks running jobs complete after cancellation.
+func TestWorkerGracefulShutdown(t *testing.T) {
+	gdb := openDB(t)
+	q := NewQueue(gdb)
+	w := NewWorker(gdb, WorkerConfig{PollInterval: 10 * time.Millisecond})
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	w.Handle("slow", func(ctx context.Context, _ *Job) error {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		finished.Store(ctx.Err() == nil)
+		return nil
+	})
+
+	job, err := q.Enqueue(context.Background(), "slow", nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Start(ctx) }()
+
+	<-started
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.True(t, finished.Load())
+
+	stored, err := q.Get(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, stored.Status)
+}
+
+// TestElector checks only one replica holds the lease until it expires or is released.
+func TestElector(t *testing.T) {
+	gdb := openDB(t)
+	ctx := context.Background()
+
+	a := NewElector(gdb, "cron", time.Minute)
+	b := NewElector(gdb, "cron", time.Minute)
+
+	ok, err := a.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = b.Acquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = a.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok, "leader renews its lease")
+
+	b.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	ok, err = b.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok, "expired lease is taken over")
+
+	require.NoError(t, b.Release(ctx))
+	ok, err = a.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// TestSchedulerEnqueuesOnlyAsLeader checks runs are enqueued once and only by the leader.
+func TestSchedulerEnqueuesOnlyAsLeader(t *testing.T) {
+	gdb := openDB(t)
+	q := NewQueue(gdb)
+	ctx := context.Background()
+
+	start := time.Date(2025, time.March, 3, 2, 59, 30, 0, time.UTC)
+	newScheduler := func() *Scheduler {
+		s := NewScheduler(q, NewElector(gdb, "cron", time.Minute), SchedulerConfig{})
+		s.now = func() time.Time { return start }
+		require.NoError(t, s.Add("nightly-cleanup", "0 3 * * *", "cleanup", nil))
+		return s
+	}
+	leader, follower := newScheduler(), newScheduler()
+
+	require.Error(t, leader.Add("broken", "not a cron", "cleanup", nil))
+
+	at := start.Add(time.Minute)
+	require.NoError(t, follower.RunDue(ctx, at, false))
+	require.NoError(t, leader.RunDue(ctx, at, true))
+	require.NoError(t, leader.RunDue(ctx, at, true))
+
+	var jobs []Job
+	require.NoError(t, gdb.Find(&jobs).Error)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "cleanup", jobs[0].Kind)
+	assert.Equal(t, "cron:nightly-cleanup:"+strconv.FormatInt(time.Date(2025, time.March, 3, 3, 0, 0, 0, time.UTC).Unix(), 10), *jobs[0].UniqueKey)
+}
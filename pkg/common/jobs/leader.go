@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lease is a named lock held by one replica until it expires.
+type Lease struct {
+	Name      string    `gorm:"primarykey"` // Name identifies the lease.
+	Holder    string    // Holder identifies the replica holding the lease.
+	ExpiresAt time.Time // ExpiresAt is when other replicas may take the lease.
+}
+
+// TableName returns the lease table name.
+func (Lease) TableName() string { return "job_leases" }
+
+// Elector elects a single leader among replicas using a lease row.
+type Elector struct {
+	db     *gorm.DB
+	name   string
+	holder string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewElector creates an Elector competing for the named lease. The leader must
+// renew the lease before ttl elapses or another replica takes over.
+func NewElector(db *gorm.DB, name string, ttl time.Duration) *Elector {
+	return &Elector{db: db, name: name, holder: rand.Text(), ttl: ttl, now: time.Now}
+}
+
+// Acquire takes or renews the lease, reporting whether this replica is the leader.
+func (e *Elector) Acquire(ctx context.Context) (bool, error) {
+	now := e.now().UTC()
+	expires := now.Add(e.ttl)
+	db := e.db.WithContext(ctx)
+
+	res := db.Model(&Lease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", e.name, e.holder, now).
+		Updates(map[string]any{"holder": e.holder, "expires_at": expires})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return true, nil
+	}
+
+	res = db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Lease{Name: e.name, Holder: e.holder, ExpiresAt: expires})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// Release gives up the lease if this replica holds it.
+func (e *Elector) Release(ctx context.Context) error {
+	return e.db.WithContext(ctx).
+		Where("name = ? AND holder = ?", e.name, e.holder).
+		Delete(&Lease{}).Error
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Queue enqueues jobs in the database.
+type Queue struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewQueue creates a Queue on the given database.
+func NewQueue(db *gorm.DB) *Queue {
+	return &Queue{db: db, now: time.Now}
+}
+
+// Migrate creates the job and lease tables.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Job{}, &Lease{})
+}
+
+// Enqueue stores a job of the given kind with a JSON encoded payload, run in the
+// tenant of ctx, if any.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, opts ...EnqueueOption) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{
+		Queue:       DefaultQueue,
+		Status:      StatusPending,
+		RunAt:       q.now().UTC(),
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: 5,
+	}
+	job.Tenant, _ = tenant.FromContext(ctx)
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	res := q.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrDuplicate
+	}
+	return job, nil
+}
+
+// Get returns a job by ID.
+func (q *Queue) Get(ctx context.Context, id uint) (*Job, error) {
+	var job Job
+	err := q.db.WithContext(ctx).First(&job, id).Error
+	return &job, err
+}
+
+// Cancel deletes a job that is still pending.
+func (q *Queue) Cancel(ctx context.Context, id uint) (bool, error) {
+	res := q.db.WithContext(ctx).Where("status = ?", StatusPending).Delete(&Job{}, id)
+	return res.RowsAffected > 0, res.Error
+}
+
+// Prune deletes jobs finished before the given time.
+func (q *Queue) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res := q.db.WithContext(ctx).
+		Where("status IN ? AND finished_at < ?", []Status{StatusDone, StatusDead}, before.UTC()).
+		Delete(&Job{})
+	return res.RowsAffected, res.Error
+}
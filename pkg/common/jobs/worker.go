@@ -0,0 +1,302 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WorkerConfig defines how a Worker claims and runs jobs.
+type WorkerConfig struct {
+	ID              string        // ID identifies the worker in job locks (default: random).
+	Queues          []string      // Queues are the queues to process (default: DefaultQueue).
+	Concurrency     int           // Concurrency is the number of jobs run in parallel (default: 4, always 1 on sqlite).
+	PollInterval    time.Duration // PollInterval is the wait when no job is available (default: 1 second).
+	LockTimeout     time.Duration // LockTimeout is the time after which a running job is considered abandoned (default: 5 minutes).
+	ShutdownTimeout time.Duration // ShutdownTimeout bounds the wait for running jobs on shutdown (default: 30 seconds).
+	Backoff         Backoff       // Backoff is the retry delay (default: exponential from 10 seconds up to 1 hour).
+	OnError         func(error)   // OnError receives job and storage failures (optional).
+}
+
+// Worker claims jobs from the database and dispatches them to handlers.
+//
+// On postgres and mysql jobs are claimed with SELECT ... FOR UPDATE SKIP LOCKED so
+// several workers and replicas can share the queues. sqlite has no row locks, so a
+// single worker goroutine is used and only one process should run workers.
+type Worker struct {
+	db         *gorm.DB
+	cfg        WorkerConfig
+	skipLocked bool
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewWorker creates a Worker on the given database.
+func NewWorker(db *gorm.DB, cfg WorkerConfig) *Worker {
+	if cfg.ID == "" {
+		cfg.ID = rand.Text()
+	}
+	if len(cfg.Queues) == 0 {
+		cfg.Queues = []string{DefaultQueue}
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.LockTimeout == 0 {
+		cfg.LockTimeout = 5 * time.Minute
+	}
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = ExponentialBackoff(10*time.Second, time.Hour)
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+
+	skipLocked := false
+	switch db.Dialector.Name() {
+	case "postgres", "mysql":
+		skipLocked = true
+	default:
+		cfg.Concurrency = 1
+	}
+
+	return &Worker{db: db, cfg: cfg, skipLocked: skipLocked, now: time.Now, handlers: make(map[string]Handler)}
+}
+
+// Handle registers the handler of a job kind.
+func (w *Worker) Handle(kind string, h Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[kind] = h
+}
+
+// Start processes jobs until the context is cancelled, then stops claiming and
+// waits up to ShutdownTimeout for running jobs before cancelling them.
+func (w *Worker) Start(ctx context.Context) error {
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+	for range w.cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, jobCtx)
+		}()
+	}
+
+	<-ctx.Done()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(w.cfg.ShutdownTimeout):
+		cancelJobs()
+		<-done
+	}
+	return ctx.Err()
+}
+
+// RunOnce claims and runs a single job, reporting whether one was available.
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	job, err := w.claim(ctx)
+	if err != nil || job == nil {
+		return false, err
+	}
+	return true, w.run(ctx, job)
+}
+
+// loop claims jobs until ctx is cancelled; jobs run with jobCtx.
+func (w *Worker) loop(ctx, jobCtx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.cfg.OnError(err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.cfg.PollInterval):
+			}
+			continue
+		}
+		if err := w.run(jobCtx, job); err != nil {
+			w.cfg.OnError(err)
+		}
+	}
+}
+
+// claim locks the next runnable job, including running jobs whose lock expired.
+// Abandoned jobs out of attempts, such as those crashing their worker, are
+// declared dead instead of being run again.
+func (w *Worker) claim(ctx context.Context) (*Job, error) {
+	for {
+		job, dead, err := w.claimNext(ctx)
+		if err != nil || !dead {
+			return job, err
+		}
+		w.cfg.OnError(fmt.Errorf("jobs: %s #%d abandoned after %d attempts", job.Kind, job.ID, job.Attempts))
+	}
+}
+
+// claimNext locks the next runnable job, reporting whether it was declared dead instead.
+func (w *Worker) claimNext(ctx context.Context) (*Job, bool, error) {
+	now := w.now().UTC()
+	until := now.Add(w.cfg.LockTimeout)
+
+	var job Job
+	dead := false
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Where("queue IN ?", w.cfg.Queues).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)", StatusPending, now, StatusRunning, now).
+			Order("priority DESC, run_at ASC, id ASC").
+			Limit(1)
+		if w.skipLocked {
+			q = q.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		if err := q.Find(&job).Error; err != nil {
+			return err
+		}
+		if job.ID == 0 {
+			return nil
+		}
+
+		if job.Status == StatusRunning && job.Attempts >= job.MaxAttempts {
+			dead = true
+			job.Status = StatusDead
+			return tx.Model(&Job{}).Where("id = ?", job.ID).Updates(map[string]any{
+				"status":       job.Status,
+				"locked_by":    "",
+				"locked_until": nil,
+				"unique_key":   nil,
+				"finished_at":  now,
+				"last_error":   "jobs: lock expired on the last attempt",
+				"updated_at":   now,
+			}).Error
+		}
+
+		job.Status = StatusRunning
+		job.Attempts++
+		job.LockedBy = w.cfg.ID
+		job.LockedUntil = &until
+		return tx.Model(&Job{}).Where("id = ?", job.ID).Updates(map[string]any{
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"locked_by":    job.LockedBy,
+			"locked_until": job.LockedUntil,
+			"updated_at":   now,
+		}).Error
+	})
+	if err != nil || job.ID == 0 {
+		return nil, false, err
+	}
+	return &job, dead, nil
+}
+
+// run executes a claimed job, keeps its lock alive and records the outcome.
+func (w *Worker) run(ctx context.Context, job *Job) error {
+	w.mu.RLock()
+	h, ok := w.handlers[job.Kind]
+	w.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = fmt.Errorf("jobs: no handler for kind %q", job.Kind)
+	} else {
+		stop := w.heartbeat(ctx, job)
+		jobCtx := ctx
+		if job.Tenant != "" {
+			jobCtx = tenant.NewContext(ctx, job.Tenant)
+		}
+		err = safeCall(jobCtx, h, job)
+		stop()
+	}
+	return w.finish(ctx, job, err)
+}
+
+// heartbeat extends the job lock periodically until the returned function is called.
+func (w *Worker) heartbeat(ctx context.Context, job *Job) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(w.cfg.LockTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				until := w.now().UTC().Add(w.cfg.LockTimeout)
+				err := w.db.WithContext(ctx).Model(&Job{}).
+					Where("id = ? AND locked_by = ?", job.ID, w.cfg.ID).
+					Update("locked_until", until).Error
+				if err != nil {
+					w.cfg.OnError(err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// finish records a job outcome, scheduling a retry or declaring it dead on failure.
+func (w *Worker) finish(ctx context.Context, job *Job, runErr error) error {
+	now := w.now().UTC()
+	updates := map[string]any{"locked_by": "", "locked_until": nil, "updated_at": now}
+
+	switch {
+	case runErr == nil:
+		updates["status"] = StatusDone
+		updates["finished_at"] = now
+		updates["unique_key"] = nil
+	case job.Attempts >= job.MaxAttempts:
+		updates["status"] = StatusDead
+		updates["finished_at"] = now
+		updates["unique_key"] = nil
+		updates["last_error"] = runErr.Error()
+	default:
+		updates["status"] = StatusPending
+		updates["run_at"] = now.Add(w.cfg.Backoff(job.Attempts))
+		updates["last_error"] = runErr.Error()
+	}
+
+	// The job may have been reclaimed after its lock expired; only the lock holder records the outcome.
+	err := w.db.WithContext(context.WithoutCancel(ctx)).Model(&Job{}).
+		Where("id = ? AND locked_by = ?", job.ID, w.cfg.ID).
+		Updates(updates).Error
+	if err != nil {
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("jobs: %s #%d attempt %d: %w", job.Kind, job.ID, job.Attempts, runErr)
+	}
+	return nil
+}
+
+// safeCall runs a handler converting panics into errors.
+func safeCall(ctx context.Context, h Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, job)
+}
@@ -0,0 +1,119 @@
+package moderation
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/text"
+)
+
+// Action is the outcome of moderating a text. Later actions are stricter.
+type Action int
+
+const (
+	// Allow publishes the text as written.
+	Allow Action = iota
+	// Redact publishes the text with the offending spans masked.
+	Redact
+	// Hold keeps the text hidden until a moderator reviews it.
+	Hold
+	// Reject refuses the text.
+	Reject
+)
+
+// String returns the action name.
+func (a Action) String() string {
+	switch a {
+	case Redact:
+		return "redact"
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+// Finding is a span of text matched by a rule.
+type Finding struct {
+	Rule     string // Rule is the name of the matching rule.
+	Category string // Category classifies the match (e.g., "profanity", "phone").
+	Start    int    // Start is the byte offset of the match.
+	End      int    // End is the byte offset after the match.
+	Action   Action // Action is the action requested by the rule.
+}
+
+// Rule inspects a text and reports the spans it objects to.
+type Rule interface {
+	Name() string
+	Check(s string) []Finding
+}
+
+// Result is the moderation outcome of a text.
+type Result struct {
+	Action   Action    // Action is the strictest action requested by the findings.
+	Findings []Finding // Findings are the matches ordered by position.
+	Text     string    // Text is the text with redacted spans masked.
+}
+
+// Categories returns the distinct categories of the findings.
+func (r *Result) Categories() []string {
+	var out []string
+	for _, f := range r.Findings {
+		if !slices.Contains(out, f.Category) {
+			out = append(out, f.Category)
+		}
+	}
+	return out
+}
+
+// Chain evaluates rules in order and combines their findings.
+type Chain struct {
+	rules []Rule
+	mask  rune
+}
+
+// NewChain creates a Chain of the given rules.
+func NewChain(rules ...Rule) *Chain {
+	return &Chain{rules: rules, mask: '*'}
+}
+
+// Use appends rules to the chain.
+func (c *Chain) Use(rules ...Rule) *Chain {
+	c.rules = append(c.rules, rules...)
+	return c
+}
+
+// Evaluate runs every rule on s. The result action is the strictest requested one
+// and the result text masks the spans of the findings requesting at least Redact.
+// A rejected text stops the chain early since no later rule can change the outcome.
+func (c *Chain) Evaluate(s string) Result {
+	res := Result{Text: s}
+	for _, r := range c.rules {
+		for _, f := range r.Check(s) {
+			res.Findings = append(res.Findings, f)
+			res.Action = max(res.Action, f.Action)
+		}
+		if res.Action == Reject {
+			break
+		}
+	}
+	sort.SliceStable(res.Findings, func(i, j int) bool { return res.Findings[i].Start < res.Findings[j].Start })
+
+	var b strings.Builder
+	last := 0
+	for _, f := range res.Findings {
+		if f.Action < Redact || f.End <= last {
+			continue
+		}
+		start := max(f.Start, last)
+		b.WriteString(s[last:start])
+		b.WriteString(text.Mask(s[start:f.End], c.mask))
+		last = f.End
+	}
+	b.WriteString(s[last:])
+	res.Text = b.String()
+	return res
+}
@@ -0,0 +1,67 @@
+package moderation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProfanity checks insults are found despite accents, substitutions and repeated letters.
+func TestProfanity(t *testing.T) {
+	l := SpanishProfanity(Redact)
+
+	for _, s := range []string{"Ese alcalde es un HIJUEPUTA", "qué gonorreaaa", "p3ndej0s", "hijo de puta", "son unos malparidos", "perraaa", "unas zorras"} {
+		assert.NotEmpty(t, l.Check(s), s)
+	}
+	for _, s := range []string{"Hay un hueco en la vía", "la computadora del computo", "salgo a las 3 de la tarde", "Compré peras", "vi un zorro en el parque", "zoras"} {
+		assert.Empty(t, l.Check(s), s)
+	}
+}
+
+// TestPersonalData checks phones, e-mails and document numbers are detected.
+func TestPersonalData(t *testing.T) {
+	cases := map[string]*Pattern{
+		"llámeme al 310 555 1234":                   Phones(Redact),
+		"mi fijo es +57 601 555 1234":               Phones(Redact),
+		"escriba a vecino.norte@correo.com":         Emails(Redact),
+		"mi cédula es 1.020.304.050":                Documents(Redact),
+		"C.C. 52123456 de Bogotá":                   Documents(Redact),
+		"documento No. 1020304050 expedido":         Documents(Redact),
+		"cédula de ciudadanía número 1.020.304.050": Documents(Redact),
+		"NIT: 900.123.456-7":                        Documents(Redact),
+	}
+	for s, rule := range cases {
+		assert.Len(t, rule.Check(s), 1, s)
+	}
+
+	assert.Empty(t, Phones(Redact).Check("el radicado 20254567 del 2025"))
+	assert.Empty(t, Documents(Redact).Check("la calle 26 # 68-35"))
+	assert.Empty(t, Documents(Redact).Check("el municipio tiene 1.998.000 habitantes"))
+	assert.Empty(t, Documents(Redact).Check("el contrato costó $ 1.250.000.000"))
+}
+
+// TestChain checks actions combine and only redacted spans are masked.
+func TestChain(t *testing.T) {
+	c := DefaultChain()
+
+	res := c.Evaluate("Ese malparido no arregla nada, llamen al 3105551234")
+	assert.Equal(t, Redact, res.Action)
+	assert.Equal(t, "Ese ********* no arregla nada, llamen al **********", res.Text)
+	assert.Equal(t, []string{"profanity", "phone"}, res.Categories())
+
+	res = c.Evaluate("fuera ese veneco del barrio")
+	assert.Equal(t, Hold, res.Action)
+
+	res = c.Evaluate("Compré peras para 1.998.000 habitantes")
+	assert.Equal(t, Allow, res.Action)
+	assert.Equal(t, "Compré peras para 1.998.000 habitantes", res.Text)
+
+	res = c.Evaluate("El semáforo de la 80 está dañado")
+	assert.Equal(t, Allow, res.Action)
+	assert.Empty(t, res.Findings)
+
+	c.Use(NewWordList("banned", "spam", Reject, "compre ya"))
+	res = c.Evaluate("COMPRE YA seguidores")
+	assert.Equal(t, Reject, res.Action)
+	assert.Equal(t, "reject", res.Action.String())
+}
@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Pattern matches a regular expression. An optional validator discards false positives.
+type Pattern struct {
+	name     string
+	category string
+	action   Action
+	re       *regexp.Regexp
+	valid    func(match string) bool
+}
+
+// NewPattern creates a Pattern rule requesting action on every match of re.
+// When re has capture groups only the first participating group is reported.
+func NewPattern(name, category string, action Action, re *regexp.Regexp, valid func(match string) bool) *Pattern {
+	return &Pattern{name: name, category: category, action: action, re: re, valid: valid}
+}
+
+// Name returns the rule name.
+func (p *Pattern) Name() string { return p.name }
+
+// Check reports every valid match in s.
+func (p *Pattern) Check(s string) []Finding {
+	var out []Finding
+	for _, m := range p.re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[0], m[1]
+		for g := 2; g+1 < len(m); g += 2 {
+			if m[g] >= 0 {
+				start, end = m[g], m[g+1]
+				break
+			}
+		}
+		if p.valid != nil && !p.valid(s[start:end]) {
+			continue
+		}
+		out = append(out, Finding{Rule: p.name, Category: p.category, Start: start, End: end, Action: p.action})
+	}
+	return out
+}
+
+var (
+	// phonePattern matches Colombian mobile (3xx) and landline (60x) numbers with an optional +57 prefix.
+	phonePattern = regexp.MustCompile(`(?:^|[^\d+])((?:\+?57[\s.-]?)?(?:3\d{2}|60\d)[\s.-]?\d{3}[\s.-]?\d{2}[\s.-]?\d{2})(?:$|\D)`)
+	// emailPattern matches e-mail addresses.
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// documentPattern matches numbers introduced by a document type, allowing a few
+	// words in between ("C.C. 52123456", "cédula de ciudadanía número 1.020.304.050").
+	// Bare dotted numbers are left alone since they are usually counts or amounts.
+	documentPattern = regexp.MustCompile(`(?i)\b(?:c\.?\s?c|c\.?\s?e|t\.?\s?i|nit|c[eé]dula|documento|pasaporte)\b\.?(?:\s*(?:[a-záéíóúñ]+\.?|[#:°º])){0,4}?\s*(\d[\d.\s-]{4,13}\d)`)
+)
+
+// Phones returns a Pattern masking Colombian phone numbers.
+func Phones(action Action) *Pattern {
+	return NewPattern("phones", "phone", action, phonePattern, nil)
+}
+
+// Emails returns a Pattern masking e-mail addresses.
+func Emails(action Action) *Pattern {
+	return NewPattern("emails", "email", action, emailPattern, nil)
+}
+
+// Documents returns a Pattern masking identity document numbers such as cédulas and NITs.
+func Documents(action Action) *Pattern {
+	return NewPattern("documents", "document", action, documentPattern, func(m string) bool {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, m)
+		return len(digits) >= 6 && len(digits) <= 11
+	})
+}
+
+// DefaultChain returns the rules applied to public text: personal data and insults
+// are masked and slurs are held for review.
+func DefaultChain() *Chain {
+	return NewChain(
+		Phones(Redact),
+		Emails(Redact),
+		Documents(Redact),
+		SpanishProfanity(Redact),
+		SpanishSlurs(Hold),
+	)
+}
@@ -0,0 +1,147 @@
+package moderation
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/text"
+)
+
+// WordList matches words and phrases from a list. Matching ignores case, accents,
+// common character substitutions ("p3rr0"), elongated letters ("mierdaaa") and plurals.
+type WordList struct {
+	name     string
+	category string
+	action   Action
+	phrases  [][]string
+}
+
+// NewWordList creates a WordList rule requesting action on every match.
+func NewWordList(name, category string, action Action, words ...string) *WordList {
+	l := &WordList{name: name, category: category, action: action}
+	l.Add(words...)
+	return l
+}
+
+// Add appends words or phrases to the list.
+func (l *WordList) Add(words ...string) *WordList {
+	for _, w := range words {
+		tokens := text.Tokenize(w)
+		if len(tokens) == 0 {
+			continue
+		}
+		phrase := make([]string, len(tokens))
+		for i, t := range tokens {
+			phrase[i] = normalize(t.Text)
+		}
+		l.phrases = append(l.phrases, phrase)
+	}
+	return l
+}
+
+// Name returns the rule name.
+func (l *WordList) Name() string { return l.name }
+
+// Check reports every listed word or phrase in s.
+func (l *WordList) Check(s string) []Finding {
+	tokens := text.Tokenize(s)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = normalize(t.Text)
+	}
+
+	var out []Finding
+	for i := range words {
+		for _, p := range l.phrases {
+			if i+len(p) > len(words) || !matchPhrase(words[i:i+len(p)], p) {
+				continue
+			}
+			out = append(out, Finding{
+				Rule:     l.name,
+				Category: l.category,
+				Start:    tokens[i].Start,
+				End:      tokens[i+len(p)-1].End,
+				Action:   l.action,
+			})
+			break
+		}
+	}
+	return out
+}
+
+// matchPhrase reports whether words spell the phrase, allowing a plural last word.
+func matchPhrase(words, phrase []string) bool {
+	for i, w := range phrase {
+		if matchWord(words[i], w) {
+			continue
+		}
+		if i == len(phrase)-1 && (matchWord(words[i], w+"s") || matchWord(words[i], w+"es")) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// matchWord reports whether w spells word with some letters repeated. Both must
+// collapse to the same letters and w must repeat each letter at least as often
+// as word, so "perraaa" matches "perra" but "peras" does not.
+func matchWord(w, word string) bool {
+	if w == word {
+		return true
+	}
+	letters, counts := runs(w)
+	wantLetters, wantCounts := runs(word)
+	if !slices.Equal(letters, wantLetters) {
+		return false
+	}
+	for i, n := range wantCounts {
+		if counts[i] < n {
+			return false
+		}
+	}
+	return true
+}
+
+// runs splits w into its runs of repeated letters, returning each letter and run length.
+func runs(w string) ([]rune, []int) {
+	var letters []rune
+	var counts []int
+	for _, r := range w {
+		if n := len(letters); n > 0 && letters[n-1] == r {
+			counts[n-1]++
+			continue
+		}
+		letters = append(letters, r)
+		counts = append(counts, 1)
+	}
+	return letters, counts
+}
+
+// leet undoes the usual character substitutions used to dodge filters.
+var leet = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s")
+
+// normalize folds a word and undoes character substitutions.
+func normalize(w string) string {
+	return leet.Replace(text.Fold(w))
+}
+
+// SpanishProfanity returns a WordList of common Spanish and Colombian insults.
+func SpanishProfanity(action Action) *WordList {
+	return NewWordList("spanish-profanity", "profanity", action,
+		"hijueputa", "hijuemadre", "hijo de puta", "hdp", "hp", "malparido", "malparida", "gonorrea",
+		"carechimba", "careverga", "pirobo", "piroba", "puta", "puto", "mierda", "cabron", "cabrona",
+		"pendejo", "pendeja", "huevon", "huevona", "guevon", "guevona", "imbecil", "idiota",
+		"estupido", "estupida", "malnacido", "malnacida", "zorra", "perra", "lambon", "lambona",
+		"verga", "culicagado", "marica", "sinverguenza",
+	)
+}
+
+// SpanishSlurs returns a WordList of discriminatory slurs, which call for review
+// rather than masking.
+func SpanishSlurs(action Action) *WordList {
+	return NewWordList("spanish-slurs", "hate", action,
+		"maricon", "cacorro", "arepera", "veneco", "veneca", "indio patirrajado", "negro hijueputa",
+		"sudaca", "machorra",
+	)
+}
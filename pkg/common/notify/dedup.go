@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Deduplicator remembers delivered keys to suppress repeated notifications.
+type Deduplicator interface {
+	// Seen records key and reports whether it was already recorded within the window.
+	Seen(ctx context.Context, key string) (bool, error)
+
+	// Forget removes key so a failed delivery can be attempted again.
+	Forget(ctx context.Context, key string) error
+}
+
+// memoryDeduplicator keeps keys in memory for a fixed window.
+type memoryDeduplicator struct {
+	mu     sync.Mutex
+	window time.Duration
+	keys   map[string]time.Time
+	now    func() time.Time
+}
+
+// NewMemoryDeduplicator returns a process-local Deduplicator that forgets keys after window.
+func NewMemoryDeduplicator(window time.Duration) Deduplicator {
+	return &memoryDeduplicator{window: window, keys: make(map[string]time.Time), now: time.Now}
+}
+
+// Seen records key and reports whether it was recorded within the window.
+func (d *memoryDeduplicator) Seen(_ context.Context, key string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for k, at := range d.keys {
+		if now.Sub(at) > d.window {
+			delete(d.keys, k)
+		}
+	}
+
+	if _, ok := d.keys[key]; ok {
+		return true, nil
+	}
+	d.keys[key] = now
+	return false, nil
+}
+
+// Forget removes key from the recorded keys.
+func (d *memoryDeduplicator) Forget(_ context.Context, key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.keys, key)
+	return nil
+}
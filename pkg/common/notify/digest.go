@@ -0,0 +1,386 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"gorm.io/gorm"
+)
+
+// Frequency defines how often a user receives digests on a channel.
+type Frequency string
+
+const (
+	// Immediate delivers every notification as it happens.
+	Immediate Frequency = ""
+	// Daily batches notifications into one digest per day.
+	Daily Frequency = "daily"
+	// Weekly batches notifications into one digest per week.
+	Weekly Frequency = "weekly"
+)
+
+// Category groups notifications inside a digest summary.
+type Category string
+
+const (
+	// CategoryComment groups new comments on followed issues.
+	CategoryComment Category = "comment"
+	// CategoryStatusChange groups status transitions of followed issues.
+	CategoryStatusChange Category = "status_change"
+	// CategoryVoteMilestone groups vote milestones reached by followed issues.
+	CategoryVoteMilestone Category = "vote_milestone"
+)
+
+// PendingItem is a rendered notification waiting to be included in a digest.
+type PendingItem struct {
+	db.BaseModel
+	UserID      string      `gorm:"index:idx_pending_user_channel"` // UserID is the recipient of the item.
+	Channel     ChannelKind `gorm:"index:idx_pending_user_channel"` // Channel is the channel the digest is sent through.
+	Category    Category    // Category groups the item in the summary.
+	EntityID    string      // EntityID references the entity the item is about.
+	Subject     string      // Subject is the rendered subject of the original notification.
+	Text        string      // Text is the rendered body of the original notification.
+	DigestID    *uint       `gorm:"index"` // DigestID is set once the item is claimed by a digest.
+	DeliveredAt *time.Time  // DeliveredAt is set once the digest holding the item was sent.
+}
+
+// Digest is a batch of pending items claimed for a user, channel and period.
+// The unique period key guarantees a single digest per period even across restarts.
+type Digest struct {
+	db.BaseModel
+	UserID    string      `gorm:"uniqueIndex:idx_digest_period"` // UserID is the recipient of the digest.
+	Channel   ChannelKind `gorm:"uniqueIndex:idx_digest_period"` // Channel is the delivery channel.
+	Period    string      `gorm:"uniqueIndex:idx_digest_period"` // Period identifies the scheduled slot (e.g., "daily:2025-03-10").
+	Frequency Frequency   // Frequency is the user's digest frequency when the digest was claimed.
+	Scheduled time.Time   // Scheduled is the local send instant of the period.
+	SendingAt *time.Time  // SendingAt is set when an engine claims the digest for delivery.
+	SentAt    *time.Time  // SentAt is set once the digest was delivered.
+}
+
+// DigestSummary is the data passed to the digest template.
+type DigestSummary struct {
+	Frequency      Frequency     // Frequency is the digest frequency.
+	Period         time.Time     // Period is the local instant the digest was scheduled for.
+	Comments       []PendingItem // Comments holds new comment notifications.
+	StatusChanges  []PendingItem // StatusChanges holds status transition notifications.
+	VoteMilestones []PendingItem // VoteMilestones holds vote milestone notifications.
+	Other          []PendingItem // Other holds uncategorized notifications.
+	Total          int           // Total is the number of items in the digest.
+}
+
+// DigestQueue stores notifications to be delivered later as part of a digest.
+type DigestQueue interface {
+	Enqueue(ctx context.Context, item *PendingItem) error
+}
+
+// digestQueue is the GORM implementation of DigestQueue.
+type digestQueue struct {
+	db *gorm.DB
+}
+
+// NewDigestQueue returns a DigestQueue storing pending items in the given database.
+func NewDigestQueue(db *gorm.DB) DigestQueue {
+	return &digestQueue{db}
+}
+
+// Enqueue stores a pending item.
+func (q *digestQueue) Enqueue(ctx context.Context, item *PendingItem) error {
+	return q.db.WithContext(ctx).Create(item).Error
+}
+
+// RecipientResolver loads the contact details of a user.
+type RecipientResolver func(ctx context.Context, userID string) (Recipient, error)
+
+// DigestConfig defines the digest engine dependencies and schedule.
+type DigestConfig struct {
+	DB          *gorm.DB          // DB stores pending items and digests.
+	Dispatcher  *Dispatcher       // Dispatcher delivers the rendered digests.
+	Renderer    *Renderer         // Renderer renders the digest template.
+	Preferences PreferenceStore   // Preferences provides each user's digest frequency.
+	Recipients  RecipientResolver // Recipients resolves contact details and timezone.
+	Template    string            // Template is the digest template name (default: "digest").
+	SendAt      time.Duration     // SendAt is the local time of day digests are sent (default: 08:00).
+	Weekday     time.Weekday      // Weekday is the local day weekly digests are sent (default: Sunday).
+	OnError     func(error)       // OnError receives the failures of scheduled runs (optional).
+}
+
+// DigestEngine batches pending notifications and sends them on schedule.
+type DigestEngine struct {
+	cfg DigestConfig
+	now func() time.Time
+}
+
+// NewDigestEngine creates a DigestEngine with the given configuration.
+func NewDigestEngine(cfg DigestConfig) *DigestEngine {
+	if cfg.Template == "" {
+		cfg.Template = "digest"
+	}
+	if cfg.SendAt == 0 {
+		cfg.SendAt = 8 * time.Hour
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+	return &DigestEngine{cfg: cfg, now: time.Now}
+}
+
+// Start runs the engine every interval until ctx is cancelled. Failed runs
+// are reported to OnError and retried on the next tick.
+func (e *DigestEngine) Start(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := e.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			e.cfg.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce resumes unsent digests no engine is sending, claims due pending items and sends the resulting digests.
+func (e *DigestEngine) RunOnce(ctx context.Context) error {
+	var errs []error
+
+	var unsent []Digest
+	if err := e.cfg.DB.WithContext(ctx).Where("sent_at IS NULL AND sending_at IS NULL").Find(&unsent).Error; err != nil {
+		return err
+	}
+	for i := range unsent {
+		errs = append(errs, e.send(ctx, &unsent[i]))
+	}
+
+	var groups []struct {
+		UserID  string
+		Channel ChannelKind
+	}
+	err := e.cfg.DB.WithContext(ctx).Model(&PendingItem{}).
+		Where("digest_id IS NULL").
+		Distinct("user_id", "channel").
+		Find(&groups).Error
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		digest, err := e.claim(ctx, g.UserID, g.Channel)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if digest != nil {
+			errs = append(errs, e.send(ctx, digest))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// claim atomically creates the digest of the current period and assigns the pending items to it.
+// It returns nil when the period was already claimed or no pending item is due yet.
+func (e *DigestEngine) claim(ctx context.Context, userID string, channel ChannelKind) (*Digest, error) {
+	freq, err := e.frequency(ctx, userID, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	rcpt, err := e.cfg.Recipients(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := e.cfg.Dispatcher.location(rcpt.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	period := e.lastSlot(e.now().In(loc), freq)
+	digest := &Digest{
+		UserID:    userID,
+		Channel:   channel,
+		Period:    fmt.Sprintf("%s:%s", freq, period.Format(time.DateOnly)),
+		Frequency: freq,
+		Scheduled: period,
+	}
+
+	// Only items created before the slot belong to this period; later ones wait for the next one.
+	due := func(q *gorm.DB) *gorm.DB {
+		return q.Where("user_id = ? AND channel = ? AND digest_id IS NULL AND created_at <= ?", userID, channel, period.Local())
+	}
+
+	err = e.cfg.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var claimed, pending int64
+		err := tx.Model(&Digest{}).
+			Where("user_id = ? AND channel = ? AND period = ?", userID, channel, digest.Period).
+			Count(&claimed).Error
+		if err != nil {
+			return err
+		}
+		if err := due(tx.Model(&PendingItem{})).Count(&pending).Error; err != nil {
+			return err
+		}
+		if claimed > 0 || pending == 0 {
+			return errNothingToClaim
+		}
+
+		if err := tx.Create(digest).Error; err != nil {
+			return err
+		}
+
+		return due(tx.Model(&PendingItem{})).Update("digest_id", digest.ID).Error
+	})
+	if errors.Is(err, errNothingToClaim) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// errNothingToClaim aborts a claim transaction when the period was already claimed or has no due items.
+var errNothingToClaim = errors.New("notify: nothing to claim")
+
+// send claims a digest and delivers it, marking it and its items as delivered.
+// The claim keeps other engines from sending the digest concurrently. It is
+// released when no channel sent the digest so a later run retries it; a digest
+// whose engine stopped between the delivery and the mark stays claimed and is
+// never sent twice.
+func (e *DigestEngine) send(ctx context.Context, digest *Digest) error {
+	res := e.cfg.DB.WithContext(ctx).Model(&Digest{}).
+		Where("id = ? AND sent_at IS NULL AND sending_at IS NULL", digest.ID).
+		Update("sending_at", e.now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := e.deliver(ctx, digest); err != nil {
+		return errors.Join(err, e.cfg.DB.WithContext(ctx).Model(&Digest{}).Where("id = ?", digest.ID).Update("sending_at", nil).Error)
+	}
+
+	now := e.now()
+	return e.cfg.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&PendingItem{}).Where("digest_id = ?", digest.ID).Update("delivered_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(digest).Update("sent_at", now).Error
+	})
+}
+
+// deliver renders a claimed digest and delivers it on its channel.
+func (e *DigestEngine) deliver(ctx context.Context, digest *Digest) error {
+	var items []PendingItem
+	if err := e.cfg.DB.WithContext(ctx).Where("digest_id = ?", digest.ID).Order("created_at").Find(&items).Error; err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	rcpt, err := e.cfg.Recipients(ctx, digest.UserID)
+	if err != nil {
+		return err
+	}
+
+	n := Notification{
+		Recipient: rcpt,
+		Template:  e.cfg.Template,
+		Data:      map[string]any{"Digest": summarize(digest, items)},
+		DedupKey:  fmt.Sprintf("digest-%d", digest.ID),
+		Channels:  []ChannelKind{digest.Channel},
+		Urgent:    true,
+	}
+	msg, err := e.cfg.Renderer.Render(n)
+	if err != nil {
+		return err
+	}
+	results, err := e.cfg.Dispatcher.Deliver(ctx, n, msg)
+	if err != nil {
+		return err
+	}
+	if !delivered(results) {
+		return fmt.Errorf("notify: digest %d was not delivered on %s", digest.ID, digest.Channel)
+	}
+	return nil
+}
+
+// delivered reports whether a channel sent the digest, now or in an earlier
+// run whose completion was not recorded.
+func delivered(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusSent || r.Status == StatusDuplicate {
+			return true
+		}
+	}
+	return false
+}
+
+// frequency returns the digest frequency of the user on the channel, defaulting to Daily.
+func (e *DigestEngine) frequency(ctx context.Context, userID string, channel ChannelKind) (Frequency, error) {
+	if e.cfg.Preferences == nil {
+		return Daily, nil
+	}
+	prefs, err := e.cfg.Preferences.List(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range prefs {
+		if p.Channel == channel && p.Digest != Immediate {
+			return p.Digest, nil
+		}
+	}
+	return Daily, nil
+}
+
+// lastSlot returns the most recent scheduled send instant at or before now, in now's location.
+func (e *DigestEngine) lastSlot(now time.Time, freq Frequency) time.Time {
+	slot := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(e.cfg.SendAt)
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if freq == Weekly {
+		for slot.Weekday() != e.cfg.Weekday {
+			slot = slot.AddDate(0, 0, -1)
+		}
+	}
+	return slot
+}
+
+// summarize groups the digest items by category.
+func summarize(digest *Digest, items []PendingItem) DigestSummary {
+	s := DigestSummary{Frequency: digest.Frequency, Period: digest.Scheduled, Total: len(items)}
+	for _, it := range items {
+		switch it.Category {
+		case CategoryComment:
+			s.Comments = append(s.Comments, it)
+		case CategoryStatusChange:
+			s.StatusChanges = append(s.StatusChanges, it)
+		case CategoryVoteMilestone:
+			s.VoteMilestones = append(s.VoteMilestones, it)
+		default:
+			s.Other = append(s.Other, it)
+		}
+	}
+	return s
+}
+
+// newPendingItem builds a pending item from a rendered notification.
+func newPendingItem(kind ChannelKind, n Notification, msg Message) *PendingItem {
+	return &PendingItem{
+		UserID:   n.Recipient.UserID,
+		Channel:  kind,
+		Category: n.Category,
+		EntityID: n.EntityID,
+		Subject:  msg.Subject,
+		Text:     msg.Text,
+	}
+}
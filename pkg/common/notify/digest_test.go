@@ -0,0 +1,219 @@
+package notify
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestDigestEngine verifies queued notifications are batched once per period and never resent.
+func TestDigestEngine(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open("file:digest?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&PendingItem{}, &Digest{}))
+	require.NoError(t, tenant.Register(gdb), "digests are shared by every tenant")
+
+	fsys := templates()
+	fsys["digest.es.subject.tmpl"] = &fstest.MapFile{Data: []byte("Resumen: {{.Digest.Total}} novedades")}
+	fsys["digest.es.txt.tmpl"] = &fstest.MapFile{Data: []byte("{{len .Digest.Comments}} comentarios, {{len .Digest.StatusChanges}} cambios")}
+	renderer := NewRenderer(fsys, "es", nil)
+
+	ch := &fakeChannel{kind: Email}
+	prefs := memoryPreferences{{UserID: "u1", Channel: Email, Enabled: true, Digest: Daily}}
+	d := NewDispatcher(Config{
+		Renderer:        renderer,
+		Channels:        []Channel{ch},
+		DefaultChannels: []ChannelKind{Email},
+		Preferences:     prefs,
+		Queue:           NewDigestQueue(gdb),
+	})
+
+	ctx := context.Background()
+	for _, c := range []Category{CategoryComment, CategoryComment, CategoryStatusChange} {
+		results, err := d.Send(ctx, Notification{Recipient: Recipient{UserID: "u1"}, Template: "resolved", Category: c})
+		require.NoError(t, err)
+		assert.Equal(t, StatusQueued, results[0].Status)
+	}
+	assert.Empty(t, ch.sent)
+
+	newEngine := func() *DigestEngine {
+		e := NewDigestEngine(DigestConfig{
+			DB:          gdb,
+			Dispatcher:  d,
+			Renderer:    renderer,
+			Preferences: prefs,
+			Recipients: func(_ context.Context, userID string) (Recipient, error) {
+				return Recipient{UserID: userID, Timezone: "America/Bogota"}, nil
+			},
+		})
+		e.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+		return e
+	}
+
+	require.NoError(t, newEngine().RunOnce(ctx))
+	require.Len(t, ch.sent, 1)
+	assert.Equal(t, "Resumen: 3 novedades", ch.sent[0].Subject)
+	assert.Equal(t, "2 comentarios, 1 cambios", ch.sent[0].Text)
+
+	// A restarted engine must not send the same period again.
+	require.NoError(t, newEngine().RunOnce(ctx))
+	assert.Len(t, ch.sent, 1)
+
+	var pending int64
+	require.NoError(t, gdb.Model(&PendingItem{}).Where("delivered_at IS NULL").Count(&pending).Error)
+	assert.Zero(t, pending)
+}
+
+// TestDigestEngineUndelivered verifies a digest no channel sent stays pending
+// and failed runs do not stop the engine.
+func TestDigestEngineUndelivered(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "digest.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&PendingItem{}, &Digest{}))
+
+	fsys := templates()
+	fsys["digest.es.subject.tmpl"] = &fstest.MapFile{Data: []byte("Resumen")}
+	fsys["digest.es.txt.tmpl"] = &fstest.MapFile{Data: []byte("{{.Digest.Total}}")}
+	renderer := NewRenderer(fsys, "es", nil)
+
+	ctx := context.Background()
+	require.NoError(t, NewDigestQueue(gdb).Enqueue(ctx, &PendingItem{UserID: "u1", Channel: Email, Subject: "hola"}))
+
+	var errs atomic.Int32
+	e := NewDigestEngine(DigestConfig{
+		DB:         gdb,
+		Dispatcher: NewDispatcher(Config{Renderer: renderer}),
+		Renderer:   renderer,
+		Recipients: func(_ context.Context, userID string) (Recipient, error) {
+			return Recipient{UserID: userID}, nil
+		},
+		OnError: func(error) { errs.Add(1) },
+	})
+	e.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+
+	assert.Error(t, e.RunOnce(ctx), "no email channel is registered")
+	var digest Digest
+	require.NoError(t, gdb.First(&digest).Error)
+	assert.Nil(t, digest.SentAt)
+	var pending int64
+	require.NoError(t, gdb.Model(&PendingItem{}).Where("delivered_at IS NULL").Count(&pending).Error)
+	assert.Equal(t, int64(1), pending)
+
+	run, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, e.Start(run, 10*time.Millisecond), context.DeadlineExceeded)
+	assert.Greater(t, errs.Load(), int32(1))
+}
+
+// TestDigestLastSlot checks daily and weekly slot computation.
+func TestDigestLastSlot(t *testing.T) {
+	e := NewDigestEngine(DigestConfig{Weekday: time.Monday})
+	wed := time.Date(2025, 3, 12, 7, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 3, 11, 8, 0, 0, 0, time.UTC), e.lastSlot(wed, Daily))
+	assert.Equal(t, time.Date(2025, 3, 12, 8, 0, 0, 0, time.UTC), e.lastSlot(wed.Add(2*time.Hour), Daily))
+	assert.Equal(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC), e.lastSlot(wed, Weekly))
+}
+
+// newClaimEngine returns a digest engine on gdb delivering through ch, running two days ahead.
+func newClaimEngine(gdb *gorm.DB, ch Channel) *DigestEngine {
+	fsys := templates()
+	fsys["digest.es.subject.tmpl"] = &fstest.MapFile{Data: []byte("Resumen")}
+	fsys["digest.es.txt.tmpl"] = &fstest.MapFile{Data: []byte("{{.Digest.Total}}")}
+	renderer := NewRenderer(fsys, "es", nil)
+
+	e := NewDigestEngine(DigestConfig{
+		DB:         gdb,
+		Dispatcher: NewDispatcher(Config{Renderer: renderer, Channels: []Channel{ch}}),
+		Renderer:   renderer,
+		Recipients: func(_ context.Context, userID string) (Recipient, error) {
+			return Recipient{UserID: userID}, nil
+		},
+	})
+	e.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	return e
+}
+
+// cancelChannel sends messages and then cancels the run, as if the process
+// stopped right after the delivery.
+type cancelChannel struct {
+	fakeChannel
+	cancel context.CancelFunc
+}
+
+func (c *cancelChannel) Send(ctx context.Context, msg Message) error {
+	defer c.cancel()
+	return c.fakeChannel.Send(ctx, msg)
+}
+
+// TestDigestEngineStoppedAfterDelivery verifies a digest delivered by a run that
+// could not record it is not sent again by a restarted engine.
+func TestDigestEngineStoppedAfterDelivery(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "digest.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&PendingItem{}, &Digest{}))
+	require.NoError(t, NewDigestQueue(gdb).Enqueue(context.Background(), &PendingItem{UserID: "u1", Channel: Email, Subject: "hola"}))
+
+	run, cancel := context.WithCancel(context.Background())
+	ch := &cancelChannel{fakeChannel: fakeChannel{kind: Email}, cancel: cancel}
+	assert.ErrorIs(t, newClaimEngine(gdb, ch).RunOnce(run), context.Canceled)
+	require.Len(t, ch.sent, 1)
+
+	var digest Digest
+	require.NoError(t, gdb.First(&digest).Error)
+	assert.Nil(t, digest.SentAt)
+	assert.NotNil(t, digest.SendingAt)
+
+	restarted := &fakeChannel{kind: Email}
+	require.NoError(t, newClaimEngine(gdb, restarted).RunOnce(context.Background()))
+	assert.Empty(t, restarted.sent)
+}
+
+// blockingChannel holds the first send until release is closed.
+type blockingChannel struct {
+	sending chan struct{}
+	release chan struct{}
+	sent    atomic.Int32
+}
+
+func (b *blockingChannel) Kind() ChannelKind { return Email }
+
+func (b *blockingChannel) Send(context.Context, Message) error {
+	if b.sent.Add(1) == 1 {
+		close(b.sending)
+		<-b.release
+	}
+	return nil
+}
+
+// TestDigestEngineConcurrent verifies two engines on the same database send a digest once.
+func TestDigestEngineConcurrent(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "digest.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&PendingItem{}, &Digest{}))
+	ctx := context.Background()
+	require.NoError(t, NewDigestQueue(gdb).Enqueue(ctx, &PendingItem{UserID: "u1", Channel: Email, Subject: "hola"}))
+
+	ch := &blockingChannel{sending: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() { done <- newClaimEngine(gdb, ch).RunOnce(ctx) }()
+
+	<-ch.sending
+	require.NoError(t, newClaimEngine(gdb, ch).RunOnce(ctx), "the digest is claimed by the first engine")
+	close(ch.release)
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(1), ch.sent.Load())
+
+	var digest Digest
+	require.NoError(t, gdb.First(&digest).Error)
+	assert.NotNil(t, digest.SentAt)
+}
@@ -0,0 +1,284 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+)
+
+// DeferredJobKind is the job kind re-delivering notifications deferred by quiet hours.
+const DeferredJobKind = "notify.deferred"
+
+// Status describes the outcome of a delivery on a single channel.
+type Status string
+
+const (
+	// StatusSent means the channel accepted the message.
+	StatusSent Status = "sent"
+	// StatusSkipped means the channel was disabled or could not reach the recipient.
+	StatusSkipped Status = "skipped"
+	// StatusDuplicate means the message was already delivered within the dedup window.
+	StatusDuplicate Status = "duplicate"
+	// StatusQueued means the notification was queued for the user's next digest.
+	StatusQueued Status = "queued"
+	// StatusDeferred means the recipient is in quiet hours; the delivery is retried at NotBefore.
+	StatusDeferred Status = "deferred"
+	// StatusFailed means every attempt failed.
+	StatusFailed Status = "failed"
+)
+
+// Result is the outcome of a delivery on one channel.
+type Result struct {
+	Channel   ChannelKind // Channel is the channel the result belongs to.
+	Status    Status      // Status is the delivery outcome.
+	Attempts  int         // Attempts is the number of send attempts made.
+	NotBefore time.Time   // NotBefore is set for deferred deliveries.
+	Err       error       // Err is the last error for failed deliveries.
+}
+
+// RetryPolicy defines how failed sends are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // MaxAttempts is the total number of attempts (default: 1).
+	Backoff     time.Duration // Backoff is the wait before the second attempt; it doubles afterwards.
+	MaxBackoff  time.Duration // MaxBackoff caps the wait between attempts (optional).
+}
+
+// Config defines the dispatcher dependencies and policies.
+type Config struct {
+	Renderer        *Renderer       // Renderer renders notification templates.
+	Channels        []Channel       // Channels are the available delivery channels.
+	DefaultChannels []ChannelKind   // DefaultChannels are used when the user has no preference for a channel.
+	Preferences     PreferenceStore // Preferences holds per-user channel settings (optional).
+	Dedup           Deduplicator    // Dedup suppresses repeated notifications (optional).
+	Queue           DigestQueue     // Queue stores notifications of users receiving digests (optional).
+	Jobs            *jobs.Queue     // Jobs schedules the re-delivery of deferred notifications (optional).
+	Retry           RetryPolicy     // Retry controls retries of failed sends.
+	DefaultTimezone string          // DefaultTimezone is used when the recipient has none (default: UTC).
+}
+
+// Dispatcher renders notifications and delivers them through the enabled channels.
+type Dispatcher struct {
+	cfg      Config
+	channels map[ChannelKind]Channel
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+// NewDispatcher creates a Dispatcher with the given configuration.
+func NewDispatcher(cfg Config) *Dispatcher {
+	channels := make(map[ChannelKind]Channel, len(cfg.Channels))
+	for _, ch := range cfg.Channels {
+		channels[ch.Kind()] = ch
+	}
+	if cfg.Retry.MaxAttempts < 1 {
+		cfg.Retry.MaxAttempts = 1
+	}
+	return &Dispatcher{cfg: cfg, channels: channels, now: time.Now, sleep: sleepContext}
+}
+
+// Send renders the notification and delivers it on every enabled channel.
+// The returned error joins the errors of failed channels.
+func (d *Dispatcher) Send(ctx context.Context, n Notification) ([]Result, error) {
+	msg, err := d.cfg.Renderer.Render(n)
+	if err != nil {
+		return nil, err
+	}
+	return d.Deliver(ctx, n, msg)
+}
+
+// Deliver sends an already rendered message, applying preferences, quiet hours, dedup and retries.
+func (d *Dispatcher) Deliver(ctx context.Context, n Notification, msg Message) ([]Result, error) {
+	prefs, err := d.preferences(ctx, n.Recipient.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := d.location(n.Recipient.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	var errs []error
+	for _, kind := range d.candidates(n, prefs) {
+		res := d.deliverOne(ctx, kind, n, msg, prefs[kind], loc)
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", kind, res.Err))
+		}
+		results = append(results, res)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// deliverOne delivers the message on a single channel.
+func (d *Dispatcher) deliverOne(ctx context.Context, kind ChannelKind, n Notification, msg Message, pref *Preference, loc *time.Location) Result {
+	ch, ok := d.channels[kind]
+	if !ok || (pref != nil && !pref.Enabled) {
+		return Result{Channel: kind, Status: StatusSkipped}
+	}
+
+	if pref != nil && !n.Urgent && pref.Digest != Immediate && d.cfg.Queue != nil {
+		if err := d.cfg.Queue.Enqueue(ctx, newPendingItem(kind, n, msg)); err != nil {
+			return Result{Channel: kind, Status: StatusFailed, Err: err}
+		}
+		return Result{Channel: kind, Status: StatusQueued}
+	}
+
+	if pref != nil && !n.Urgent {
+		quiet, err := ParseQuietHours(pref.QuietStart, pref.QuietEnd)
+		if err != nil {
+			return Result{Channel: kind, Status: StatusFailed, Err: err}
+		}
+		now := d.now().In(loc)
+		if until := quiet.Until(now); until.After(now) {
+			return d.deferOne(ctx, kind, n, msg, until)
+		}
+	}
+
+	if d.cfg.Dedup == nil || n.DedupKey == "" {
+		return d.sendWithRetry(ctx, ch, msg)
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", kind, n.Recipient.UserID, n.DedupKey)
+	seen, err := d.cfg.Dedup.Seen(ctx, key)
+	if err != nil {
+		return Result{Channel: kind, Status: StatusFailed, Err: err}
+	}
+	if seen {
+		return Result{Channel: kind, Status: StatusDuplicate}
+	}
+
+	res := d.sendWithRetry(ctx, ch, msg)
+	if res.Status != StatusSent {
+		res.Err = errors.Join(res.Err, d.cfg.Dedup.Forget(ctx, key))
+	}
+	return res
+}
+
+// deferred is the payload of a deferred delivery job.
+type deferred struct {
+	Channel      ChannelKind  // Channel is the channel the delivery was deferred on.
+	Notification Notification // Notification is the original notification.
+	Message      Message      // Message is the rendered message.
+}
+
+// deferOne schedules the delivery of the message on a single channel at until.
+// Without a job queue the caller is left to retry at NotBefore.
+func (d *Dispatcher) deferOne(ctx context.Context, kind ChannelKind, n Notification, msg Message, until time.Time) Result {
+	if d.cfg.Jobs != nil {
+		payload := deferred{Channel: kind, Notification: n, Message: msg}
+		if _, err := d.cfg.Jobs.Enqueue(ctx, DeferredJobKind, payload, jobs.At(until)); err != nil {
+			return Result{Channel: kind, Status: StatusFailed, Err: err}
+		}
+	}
+	return Result{Channel: kind, Status: StatusDeferred, NotBefore: until}
+}
+
+// Register handles deferred delivery jobs on the worker. A delivery still in
+// quiet hours, e.g. after the recipient moved them, is deferred again.
+func (d *Dispatcher) Register(w *jobs.Worker) {
+	w.Handle(DeferredJobKind, func(ctx context.Context, job *jobs.Job) error {
+		var p deferred
+		if err := job.Bind(&p); err != nil {
+			return err
+		}
+		p.Notification.Channels = []ChannelKind{p.Channel}
+		_, err := d.Deliver(ctx, p.Notification, p.Message)
+		return err
+	})
+}
+
+// sendWithRetry sends the message, retrying with exponential backoff.
+func (d *Dispatcher) sendWithRetry(ctx context.Context, ch Channel, msg Message) Result {
+	res := Result{Channel: ch.Kind()}
+	backoff := d.cfg.Retry.Backoff
+
+	for res.Attempts < d.cfg.Retry.MaxAttempts {
+		res.Attempts++
+		res.Err = ch.Send(ctx, msg)
+		switch {
+		case res.Err == nil:
+			res.Status = StatusSent
+			return res
+		case errors.Is(res.Err, ErrNoRecipientAddress):
+			res.Status, res.Err = StatusSkipped, nil
+			return res
+		}
+
+		if res.Attempts == d.cfg.Retry.MaxAttempts {
+			break
+		}
+		if err := d.sleep(ctx, backoff); err != nil {
+			res.Err = err
+			break
+		}
+		backoff *= 2
+		if d.cfg.Retry.MaxBackoff > 0 && backoff > d.cfg.Retry.MaxBackoff {
+			backoff = d.cfg.Retry.MaxBackoff
+		}
+	}
+
+	res.Status = StatusFailed
+	return res
+}
+
+// candidates returns the channels to attempt for the notification.
+func (d *Dispatcher) candidates(n Notification, prefs map[ChannelKind]*Preference) []ChannelKind {
+	if len(n.Channels) > 0 {
+		return n.Channels
+	}
+
+	out := slices.Clone(d.cfg.DefaultChannels)
+	for kind, p := range prefs {
+		if p.Enabled && !slices.Contains(out, kind) {
+			out = append(out, kind)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// preferences loads the user's preferences indexed by channel.
+func (d *Dispatcher) preferences(ctx context.Context, userID string) (map[ChannelKind]*Preference, error) {
+	out := make(map[ChannelKind]*Preference)
+	if d.cfg.Preferences == nil || userID == "" {
+		return out, nil
+	}
+
+	prefs, err := d.cfg.Preferences.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range prefs {
+		out[prefs[i].Channel] = &prefs[i]
+	}
+	return out, nil
+}
+
+// location resolves the recipient timezone, falling back to the configured default.
+func (d *Dispatcher) location(tz string) (*time.Location, error) {
+	if tz == "" {
+		tz = d.cfg.DefaultTimezone
+	}
+	if tz == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
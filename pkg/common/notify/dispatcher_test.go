@@ -0,0 +1,326 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+	"github.com/ianfedev/civicspot-backend/pkg/common/notify/smtptest"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// templates returns an in-memory template set used across tests.
+func templates() fstest.MapFS {
+	return fstest.MapFS{
+		"resolved.es.subject.tmpl": {Data: []byte("Reporte {{.ID}} resuelto")},
+		"resolved.es.txt.tmpl":     {Data: []byte("Hola {{.Name}}, tu reporte fue resuelto.")},
+		"resolved.es.html.tmpl":    {Data: []byte("<p>Hola {{.Name}}</p>")},
+		"resolved.en.subject.tmpl": {Data: []byte("Report {{.ID}} resolved")},
+		"resolved.en.txt.tmpl":     {Data: []byte("Hi {{.Name}}, your report was resolved.")},
+	}
+}
+
+// fakeChannel records messages and fails a configurable number of times.
+type fakeChannel struct {
+	kind     ChannelKind
+	failures int
+	sent     []Message
+}
+
+func (f *fakeChannel) Kind() ChannelKind { return f.kind }
+
+func (f *fakeChannel) Send(_ context.Context, msg Message) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("temporary failure")
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// memoryPreferences is an in-memory PreferenceStore.
+type memoryPreferences []Preference
+
+func (m memoryPreferences) List(_ context.Context, userID string) ([]Preference, error) {
+	var out []Preference
+	for _, p := range m {
+		if p.UserID == userID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m memoryPreferences) Save(context.Context, *Preference) error { return nil }
+
+// TestRendererLocaleFallback verifies regional locales fall back to their base language.
+func TestRendererLocaleFallback(t *testing.T) {
+	r := NewRenderer(templates(), "en", nil)
+
+	msg, err := r.Render(Notification{
+		Recipient: Recipient{Locale: "es-CO"},
+		Template:  "resolved",
+		Data:      map[string]any{"ID": 7, "Name": "<Ana>"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Reporte 7 resuelto", msg.Subject)
+	assert.Equal(t, "Hola <Ana>, tu reporte fue resuelto.", msg.Text)
+	assert.Equal(t, "<p>Hola &lt;Ana&gt;</p>", msg.HTML)
+
+	msg, err = r.Render(Notification{Recipient: Recipient{Locale: "fr"}, Template: "resolved", Data: map[string]any{"ID": 1}})
+	require.NoError(t, err)
+	assert.Equal(t, "Report 1 resolved", msg.Subject)
+	assert.Empty(t, msg.HTML)
+
+	_, err = r.Render(Notification{Template: "missing"})
+	assert.Error(t, err)
+}
+
+// TestQuietHoursUntil checks windows inside a day and wrapping past midnight.
+func TestQuietHoursUntil(t *testing.T) {
+	day := func(h, m int) time.Time { return time.Date(2025, 3, 10, h, m, 0, 0, time.UTC) }
+
+	q, err := ParseQuietHours("22:00", "07:00")
+	require.NoError(t, err)
+	assert.Equal(t, day(7, 0).AddDate(0, 0, 1), q.Until(day(23, 30)))
+	assert.Equal(t, day(7, 0), q.Until(day(3, 0)))
+	assert.Equal(t, day(12, 0), q.Until(day(12, 0)))
+
+	q, err = ParseQuietHours("13:00", "14:00")
+	require.NoError(t, err)
+	assert.Equal(t, day(14, 0), q.Until(day(13, 15)))
+	assert.Equal(t, day(15, 0), q.Until(day(15, 0)))
+
+	_, err = ParseQuietHours("25:00", "07:00")
+	assert.Error(t, err)
+}
+
+// TestDispatcherEmailDelivery sends a multipart email to the local SMTP sink.
+func TestDispatcherEmailDelivery(t *testing.T) {
+	srv, err := smtptest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = srv.Close() })
+
+	host, port, _ := strings.Cut(srv.Addr(), ":")
+	p, _ := strconv.Atoi(port)
+
+	d := NewDispatcher(Config{
+		Renderer:        NewRenderer(templates(), "es", nil),
+		Channels:        []Channel{NewEmailChannel(SMTPConfig{Host: host, Port: p, From: "no-reply@civicspot.local"})},
+		DefaultChannels: []ChannelKind{Email},
+	})
+
+	results, err := d.Send(context.Background(), Notification{
+		Recipient: Recipient{UserID: "u1", Email: "ana@example.com"},
+		Template:  "resolved",
+		Data:      map[string]any{"ID": 3, "Name": "Ana"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusSent, results[0].Status)
+
+	msgs := srv.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, []string{"ana@example.com"}, msgs[0].To)
+
+	parsed, err := msgs[0].Parse()
+	require.NoError(t, err)
+	assert.Contains(t, parsed.Header.Get("Subject"), "Reporte 3 resuelto")
+	assert.Contains(t, parsed.Header.Get("Content-Type"), "multipart/alternative")
+}
+
+// TestEmailChannelTimeout checks a stalled SMTP server does not block the sender.
+func TestEmailChannelTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := strings.Cut(ln.Addr().String(), ":")
+	p, _ := strconv.Atoi(port)
+	msg := Message{Recipient: Recipient{Email: "ana@example.com"}, Subject: "hola", Text: "hola"}
+
+	start := time.Now()
+	err = NewEmailChannel(SMTPConfig{Host: host, Port: p, From: "no-reply@civicspot.local", Timeout: 50 * time.Millisecond}).Send(context.Background(), msg)
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = NewEmailChannel(SMTPConfig{Host: host, Port: p, From: "no-reply@civicspot.local"}).Send(ctx, msg)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+// TestDispatcherRetriesAndDeduplicates checks retries succeed and repeated keys are suppressed.
+func TestDispatcherRetriesAndDeduplicates(t *testing.T) {
+	ch := &fakeChannel{kind: Webhook, failures: 2}
+	d := NewDispatcher(Config{
+		Renderer:        NewRenderer(templates(), "es", nil),
+		Channels:        []Channel{ch},
+		DefaultChannels: []ChannelKind{Webhook},
+		Dedup:           NewMemoryDeduplicator(time.Hour),
+		Retry:           RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	})
+
+	n := Notification{Recipient: Recipient{UserID: "u1"}, Template: "resolved", DedupKey: "report-3-resolved"}
+
+	results, err := d.Send(context.Background(), n)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, results[0].Status)
+	assert.Equal(t, 3, results[0].Attempts)
+
+	results, err = d.Send(context.Background(), n)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDuplicate, results[0].Status)
+	assert.Len(t, ch.sent, 1)
+}
+
+// TestDispatcherRetriesFailedDeduplicated checks a failed delivery does not suppress later attempts.
+func TestDispatcherRetriesFailedDeduplicated(t *testing.T) {
+	ch := &fakeChannel{kind: Webhook, failures: 2}
+	d := NewDispatcher(Config{
+		Renderer:        NewRenderer(templates(), "es", nil),
+		Channels:        []Channel{ch},
+		DefaultChannels: []ChannelKind{Webhook},
+		Dedup:           NewMemoryDeduplicator(time.Hour),
+		Retry:           RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+	})
+
+	n := Notification{Recipient: Recipient{UserID: "u1"}, Template: "resolved", DedupKey: "report-4-resolved"}
+
+	results, err := d.Send(context.Background(), n)
+	require.Error(t, err)
+	assert.Equal(t, StatusFailed, results[0].Status)
+
+	results, err = d.Send(context.Background(), n)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, results[0].Status)
+	assert.Len(t, ch.sent, 1)
+}
+
+// TestDispatcherPreferences verifies disabled channels are skipped and quiet hours defer delivery.
+func TestDispatcherPreferences(t *testing.T) {
+	email := &fakeChannel{kind: Email}
+	hook := &fakeChannel{kind: Webhook}
+	d := NewDispatcher(Config{
+		Renderer:        NewRenderer(templates(), "es", nil),
+		Channels:        []Channel{email, hook},
+		DefaultChannels: []ChannelKind{Email, Webhook},
+		Preferences: memoryPreferences{
+			{UserID: "u1", Channel: Email, Enabled: false},
+			{UserID: "u1", Channel: Webhook, Enabled: true, QuietStart: "22:00", QuietEnd: "07:00"},
+		},
+	})
+	d.now = func() time.Time { return time.Date(2025, 3, 10, 4, 0, 0, 0, time.UTC) } // 23:00 in Bogotá
+
+	n := Notification{Recipient: Recipient{UserID: "u1", Timezone: "America/Bogota"}, Template: "resolved"}
+	results, err := d.Send(context.Background(), n)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, StatusSkipped, results[0].Status)
+	assert.Equal(t, StatusDeferred, results[1].Status)
+	assert.Equal(t, time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC), results[1].NotBefore.UTC())
+
+	n.Urgent = true
+	results, err = d.Send(context.Background(), n)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSent, results[1].Status)
+	assert.Empty(t, email.sent)
+}
+
+// TestDispatcherDeferred verifies a delivery deferred by quiet hours is sent by the job once they end.
+func TestDispatcherDeferred(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "deferred.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, jobs.Migrate(gdb))
+	ctx := context.Background()
+
+	hook := &fakeChannel{kind: Webhook}
+	d := NewDispatcher(Config{
+		Renderer: NewRenderer(templates(), "es", nil),
+		Channels: []Channel{hook},
+		Preferences: memoryPreferences{
+			{UserID: "u1", Channel: Webhook, Enabled: true, QuietStart: "22:00", QuietEnd: "07:00"},
+		},
+		Jobs: jobs.NewQueue(gdb),
+	})
+	d.now = func() time.Time { return time.Date(2025, 3, 10, 4, 0, 0, 0, time.UTC) } // 23:00 in Bogotá
+
+	n := Notification{Recipient: Recipient{UserID: "u1", Timezone: "America/Bogota"}, Template: "resolved", Data: map[string]any{"ID": 7}}
+	results, err := d.Send(ctx, n)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusDeferred, results[0].Status)
+	assert.Empty(t, hook.sent)
+
+	var job jobs.Job
+	require.NoError(t, gdb.First(&job).Error)
+	assert.Equal(t, DeferredJobKind, job.Kind)
+	assert.Equal(t, results[0].NotBefore.UTC(), job.RunAt.UTC())
+
+	w := jobs.NewWorker(gdb, jobs.WorkerConfig{})
+	d.Register(w)
+	d.now = func() time.Time { return time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC) } // 07:30 in Bogotá
+
+	ran, err := w.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	require.Len(t, hook.sent, 1)
+	assert.Equal(t, "Reporte 7 resuelto", hook.sent[0].Subject)
+
+	require.NoError(t, gdb.First(&job, job.ID).Error)
+	assert.Equal(t, jobs.StatusDone, job.Status)
+}
+
+// TestInboxChannel stores messages and marks them as read.
+func TestInboxChannel(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&InboxItem{}))
+	require.NoError(t, tenant.Register(gdb), "inboxes are shared by every tenant")
+
+	inbox := NewInboxChannel(db.NewRepository[InboxItem](gdb))
+	ctx := context.Background()
+
+	require.NoError(t, inbox.Send(ctx, Message{Recipient: Recipient{UserID: "u1"}, Subject: "Hola", Text: "Texto"}))
+	assert.ErrorIs(t, inbox.Send(ctx, Message{}), ErrNoRecipientAddress)
+
+	unread, err := inbox.Unread(ctx, "u1")
+	require.NoError(t, err)
+	require.Len(t, unread, 1)
+	assert.Equal(t, "Hola", unread[0].Subject)
+
+	assert.Error(t, inbox.MarkRead(ctx, "u2", unread[0].ID))
+	require.NoError(t, inbox.MarkRead(ctx, "u1", unread[0].ID))
+
+	unread, err = inbox.Unread(ctx, "u1")
+	require.NoError(t, err)
+	assert.Empty(t, unread)
+
+	n, err := inbox.Erase(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	items, err := inbox.List(ctx, "u1")
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig defines the options of the SMTP email channel.
+type SMTPConfig struct {
+	Host     string        // Host is the SMTP server host name.
+	Port     int           // Port is the SMTP server port.
+	Username string        // Username enables PLAIN authentication when set.
+	Password string        // Password is used together with Username.
+	From     string        // From is the sender address.
+	Timeout  time.Duration // Timeout bounds dialing and sending a message (default: 10s).
+}
+
+// EmailChannel delivers messages through an SMTP server.
+type EmailChannel struct {
+	cfg SMTPConfig
+}
+
+// NewEmailChannel creates an EmailChannel with the given configuration.
+func NewEmailChannel(cfg SMTPConfig) *EmailChannel {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &EmailChannel{cfg: cfg}
+}
+
+// Kind returns Email.
+func (c *EmailChannel) Kind() ChannelKind {
+	return Email
+}
+
+// Send delivers the message to the recipient's email address.
+func (c *EmailChannel) Send(ctx context.Context, msg Message) error {
+	if msg.Recipient.Email == "" {
+		return ErrNoRecipientAddress
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if c.cfg.Username != "" {
+		auth = smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+	}
+
+	body, err := buildMIME(c.cfg.From, msg)
+	if err != nil {
+		return err
+	}
+
+	return c.send(ctx, auth, msg.Recipient.Email, body)
+}
+
+// send delivers body like smtp.SendMail, aborting when ctx is done or the
+// timeout of the channel elapses.
+func (c *EmailChannel) send(ctx context.Context, a smtp.Auth, to string, body []byte) error {
+	if strings.ContainsAny(c.cfg.From+to, "\r\n") {
+		return errors.New("notify: email address contains a line break")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(c.cfg.Host, strconv.Itoa(c.cfg.Port))
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if a != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(a); err != nil {
+				return err
+			}
+		}
+	}
+	if err := client.Mail(c.cfg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMIME builds a plain-text or multipart/alternative email.
+func buildMIME(from string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.Recipient.Email)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	parts := []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	}
+	for _, p := range parts {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", p.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, p.body); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable encodes s as quoted-printable into buf.
+func writeQuotedPrintable(buf *bytes.Buffer, s string) error {
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// randomBoundary returns a random MIME boundary.
+func randomBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
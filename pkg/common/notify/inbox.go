@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"gorm.io/gorm"
+)
+
+// InboxItem is a notification stored in the user's in-app inbox. Notifications
+// follow their recipient, whose account is shared by every tenant, so inbox
+// items, pending items, digests and preferences have no tenant.
+type InboxItem struct {
+	db.BaseModel
+	UserID   string     `gorm:"index"` // UserID is the owner of the inbox item.
+	Template string     // Template is the name of the template the item was rendered from.
+	Subject  string     // Subject is the rendered subject line.
+	Body     string     // Body is the rendered plain-text body.
+	ReadAt   *time.Time // ReadAt is set once the user reads the item.
+}
+
+// InboxChannel stores notifications in a database-backed inbox.
+type InboxChannel struct {
+	repo db.Repository[InboxItem]
+}
+
+// NewInboxChannel creates an InboxChannel on top of the given repository.
+func NewInboxChannel(repo db.Repository[InboxItem]) *InboxChannel {
+	return &InboxChannel{repo: repo}
+}
+
+// Kind returns InApp.
+func (c *InboxChannel) Kind() ChannelKind {
+	return InApp
+}
+
+// Send stores the message in the recipient's inbox.
+func (c *InboxChannel) Send(ctx context.Context, msg Message) error {
+	if msg.Recipient.UserID == "" {
+		return ErrNoRecipientAddress
+	}
+	return c.repo.Create(ctx, &InboxItem{
+		UserID:   msg.Recipient.UserID,
+		Template: msg.Template,
+		Subject:  msg.Subject,
+		Body:     msg.Text,
+	})
+}
+
+// Unread lists the unread items of a user, newest first.
+func (c *InboxChannel) Unread(ctx context.Context, userID string) ([]InboxItem, error) {
+	return c.repo.List(ctx, func(q *gorm.DB) *gorm.DB {
+		return q.Where("user_id = ? AND read_at IS NULL", userID).Order("created_at DESC")
+	})
+}
+
+// MarkRead marks an inbox item as read by its owner.
+func (c *InboxChannel) MarkRead(ctx context.Context, userID string, id uint) error {
+	item, err := c.repo.GetByID(ctx, id, func(q *gorm.DB) *gorm.DB {
+		return q.Where("user_id = ?", userID)
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	item.ReadAt = &now
+	return c.repo.Update(ctx, item)
+}
+
+// List returns every item of a user, newest first.
+func (c *InboxChannel) List(ctx context.Context, userID string) ([]InboxItem, error) {
+	return c.repo.List(ctx, func(q *gorm.DB) *gorm.DB {
+		return q.Where("user_id = ?", userID).Order("created_at DESC")
+	})
+}
+
+// Erase deletes every item of a user, returning the number deleted.
+func (c *InboxChannel) Erase(ctx context.Context, userID string) (int64, error) {
+	return c.repo.DeleteWhere(ctx, func(q *gorm.DB) *gorm.DB {
+		return q.Where("user_id = ?", userID)
+	})
+}
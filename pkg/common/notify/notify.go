@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// ChannelKind identifies a notification delivery channel.
+type ChannelKind string
+
+const (
+	// InApp delivers notifications to the user's in-app inbox.
+	InApp ChannelKind = "in_app"
+	// Email delivers notifications through SMTP.
+	Email ChannelKind = "email"
+	// Webhook delivers notifications as JSON to an HTTP endpoint.
+	Webhook ChannelKind = "webhook"
+)
+
+// ErrNoRecipientAddress is returned by channels when the recipient lacks the address they need.
+var ErrNoRecipientAddress = errors.New("notify: recipient has no address for channel")
+
+// Recipient describes who receives a notification and how to reach them.
+type Recipient struct {
+	UserID     string // UserID identifies the user within the platform.
+	Email      string // Email is the address used by the email channel (optional).
+	WebhookURL string // WebhookURL is the endpoint used by the webhook channel (optional).
+	Locale     string // Locale selects the template translation (e.g., "es-CO").
+	Timezone   string // Timezone is the IANA zone used for quiet hours (e.g., "America/Bogota").
+}
+
+// Notification is a request to notify a recipient using a named template.
+type Notification struct {
+	Recipient Recipient      // Recipient is the target user.
+	Template  string         // Template is the name of the template to render.
+	Data      map[string]any // Data is passed to the template when rendering.
+	DedupKey  string         // DedupKey suppresses repeated deliveries of the same event (optional).
+	Category  Category       // Category groups the notification inside digests (optional).
+	EntityID  string         // EntityID references the entity the notification is about (optional).
+	Channels  []ChannelKind  // Channels limits delivery to the given channels when not empty.
+	Urgent    bool           // Urgent notifications ignore quiet hours.
+}
+
+// Message is a rendered notification ready to be delivered by a Channel.
+type Message struct {
+	Recipient Recipient      // Recipient is the target user.
+	Template  string         // Template is the name of the template the message was rendered from.
+	Subject   string         // Subject is the rendered subject line.
+	Text      string         // Text is the rendered plain-text body.
+	HTML      string         // HTML is the rendered HTML body, empty if the template has none.
+	Data      map[string]any // Data is the template data, forwarded to structured channels.
+}
+
+// Channel delivers rendered messages through a specific medium.
+type Channel interface {
+	Kind() ChannelKind
+	Send(ctx context.Context, msg Message) error
+}
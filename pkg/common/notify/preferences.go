@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Preference stores whether a user accepts notifications on a channel and when.
+type Preference struct {
+	UserID     string      `gorm:"primaryKey"` // UserID is the owner of the preference.
+	Channel    ChannelKind `gorm:"primaryKey"` // Channel is the channel the preference applies to.
+	Enabled    bool        // Enabled allows deliveries through the channel.
+	QuietStart string      // QuietStart is the "HH:MM" local time where quiet hours begin (optional).
+	QuietEnd   string      // QuietEnd is the "HH:MM" local time where quiet hours end (optional).
+	Digest     Frequency   // Digest batches non-urgent notifications when not Immediate.
+	UpdatedAt  time.Time
+}
+
+// PreferenceStore reads and writes user channel preferences.
+type PreferenceStore interface {
+	List(ctx context.Context, userID string) ([]Preference, error)
+	Save(ctx context.Context, p *Preference) error
+}
+
+// preferenceStore is the GORM implementation of PreferenceStore.
+type preferenceStore struct {
+	db *gorm.DB
+}
+
+// NewPreferenceStore returns a PreferenceStore backed by the given database.
+func NewPreferenceStore(db *gorm.DB) PreferenceStore {
+	return &preferenceStore{db}
+}
+
+// List returns every stored preference of the user.
+func (s *preferenceStore) List(ctx context.Context, userID string) ([]Preference, error) {
+	var out []Preference
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Find(&out).Error
+	return out, err
+}
+
+// Save inserts or replaces a preference.
+func (s *preferenceStore) Save(ctx context.Context, p *Preference) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(p).Error
+}
+
+// QuietHours is a daily local time window where non-urgent deliveries are deferred.
+type QuietHours struct {
+	Start time.Duration // Start is the offset from midnight where the window begins.
+	End   time.Duration // End is the offset from midnight where the window ends; it may wrap past midnight.
+}
+
+// ParseQuietHours parses "HH:MM" bounds. Empty bounds return a zero window.
+func ParseQuietHours(start, end string) (QuietHours, error) {
+	if start == "" || end == "" {
+		return QuietHours{}, nil
+	}
+	s, err := parseClock(start)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	return QuietHours{Start: s, End: e}, nil
+}
+
+// IsZero reports whether the window is empty.
+func (q QuietHours) IsZero() bool {
+	return q.Start == q.End
+}
+
+// Until returns the instant quiet hours end if t falls within them, or t itself otherwise.
+func (q QuietHours) Until(t time.Time) time.Time {
+	if q.IsZero() {
+		return t
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+
+	if q.Start < q.End {
+		if offset >= q.Start && offset < q.End {
+			return midnight.Add(q.End)
+		}
+		return t
+	}
+
+	// The window wraps past midnight, e.g. 22:00 to 07:00.
+	switch {
+	case offset >= q.Start:
+		return midnight.AddDate(0, 0, 1).Add(q.End)
+	case offset < q.End:
+		return midnight.Add(q.End)
+	default:
+		return t
+	}
+}
+
+// parseClock parses an "HH:MM" string into an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("notify: invalid clock %q: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
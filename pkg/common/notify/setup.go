@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"strconv"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+)
+
+// SetupEnvironmentEmailChannel creates an EmailChannel from the provided environment
+func SetupEnvironmentEmailChannel() (*EmailChannel, error) {
+
+	port, err := strconv.Atoi(config.MustGet(config.SmtpPort))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := SMTPConfig{
+		Host:     config.MustGet(config.SmtpHost),
+		Port:     port,
+		Username: config.Get().GetString(config.SmtpUsername),
+		Password: config.Get().GetString(config.SmtpPassword),
+		From:     config.MustGet(config.SmtpFrom),
+		Timeout:  config.Get().GetDuration(config.SmtpTimeout),
+	}
+
+	return NewEmailChannel(cfg), nil
+
+}
@@ -0,0 +1,168 @@
+package smtptest
+
+import (
+	"bufio"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+)
+
+// Message is an email captured by the Server.
+type Message struct {
+	From string   // From is the envelope sender.
+	To   []string // To holds the envelope recipients.
+	Data []byte   // Data is the raw message, headers included.
+}
+
+// Parse parses the raw message data.
+func (m Message) Parse() (*mail.Message, error) {
+	return mail.ReadMessage(strings.NewReader(string(m.Data)))
+}
+
+// Server is a minimal in-process SMTP sink that stores every accepted message.
+// It speaks just enough SMTP for net/smtp clients and must only be used in tests.
+type Server struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	messages []Message
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a Server on a random loopback port.
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{ln: ln}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the host:port the server listens on.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Messages returns a copy of the captured messages.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Close stops the server and waits for open sessions to finish.
+func (s *Server) Close() error {
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+// serve accepts connections until the listener is closed.
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle runs a single SMTP session.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	reply := func(line string) bool {
+		_, err := w.WriteString(line + "\r\n")
+		return err == nil && w.Flush() == nil
+	}
+
+	var current Message
+	if !reply("220 smtptest ready") {
+		return
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250 smtptest")
+		case "MAIL":
+			current = Message{From: addressArg(line)}
+			reply("250 OK")
+		case "RCPT":
+			current.To = append(current.To, addressArg(line))
+			reply("250 OK")
+		case "DATA":
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := readData(r)
+			if err != nil {
+				return
+			}
+			current.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			current = Message{}
+			reply("250 OK")
+		case "RSET":
+			current = Message{}
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// addressArg extracts the address between angle brackets of MAIL/RCPT commands.
+func addressArg(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.LastIndex(line, ">")
+	if start < 0 || end <= start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+// readData reads a DATA payload until the terminating dot line, undoing dot-stuffing.
+func readData(r *bufio.Reader) ([]byte, error) {
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		trimmed := strings.TrimRight(line, "\r\n")
+		if trimmed == "." {
+			return []byte(b.String()), nil
+		}
+		if strings.HasPrefix(trimmed, "..") {
+			trimmed = trimmed[1:]
+		}
+		b.WriteString(trimmed)
+		b.WriteString("\r\n")
+	}
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Renderer renders localized notification templates loaded from a file system.
+//
+// Templates are looked up as "<name>.<locale>.<part>.tmpl" where part is one of
+// "subject", "txt" or "html". The subject and txt parts are required; html is optional.
+// Locales fall back from "es-CO" to "es" and finally to the default locale.
+type Renderer struct {
+	fsys          fs.FS
+	defaultLocale string
+	funcs         map[string]any
+}
+
+// NewRenderer creates a Renderer reading templates from fsys.
+func NewRenderer(fsys fs.FS, defaultLocale string, funcs map[string]any) *Renderer {
+	return &Renderer{fsys: fsys, defaultLocale: defaultLocale, funcs: funcs}
+}
+
+// Render renders the named template for the recipient's locale.
+func (r *Renderer) Render(n Notification) (Message, error) {
+	locale, err := r.resolveLocale(n.Template, n.Recipient.Locale)
+	if err != nil {
+		return Message{}, err
+	}
+
+	subject, err := r.renderText(n.Template, locale, "subject", n.Data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	text, err := r.renderText(n.Template, locale, "txt", n.Data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	html, err := r.renderHTML(n.Template, locale, n.Data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Recipient: n.Recipient,
+		Template:  n.Template,
+		Subject:   strings.TrimSpace(subject),
+		Text:      text,
+		HTML:      html,
+		Data:      n.Data,
+	}, nil
+}
+
+// resolveLocale returns the first locale in the fallback chain with a subject template.
+func (r *Renderer) resolveLocale(name, locale string) (string, error) {
+	for _, candidate := range fallbackLocales(locale, r.defaultLocale) {
+		if _, err := fs.Stat(r.fsys, templatePath(name, candidate, "subject")); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("notify: template %q not found for locale %q", name, locale)
+}
+
+// renderText executes a text/template part.
+func (r *Renderer) renderText(name, locale, part string, data any) (string, error) {
+	path := templatePath(name, locale, part)
+	raw, err := fs.ReadFile(r.fsys, path)
+	if err != nil {
+		return "", fmt.Errorf("notify: reading %s: %w", path, err)
+	}
+
+	tpl, err := texttemplate.New(path).Funcs(r.funcs).Parse(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("notify: parsing %s: %w", path, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("notify: executing %s: %w", path, err)
+	}
+	return buf.String(), nil
+}
+
+// renderHTML executes the optional html/template part.
+func (r *Renderer) renderHTML(name, locale string, data any) (string, error) {
+	path := templatePath(name, locale, "html")
+	raw, err := fs.ReadFile(r.fsys, path)
+	if err != nil {
+		return "", nil
+	}
+
+	tpl, err := htmltemplate.New(path).Funcs(r.funcs).Parse(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("notify: parsing %s: %w", path, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("notify: executing %s: %w", path, err)
+	}
+	return buf.String(), nil
+}
+
+// templatePath builds the file name of a template part.
+func templatePath(name, locale, part string) string {
+	return fmt.Sprintf("%s.%s.%s.tmpl", name, locale, part)
+}
+
+// fallbackLocales lists the locales to try, most specific first.
+func fallbackLocales(locale, def string) []string {
+	var out []string
+	if locale != "" {
+		out = append(out, locale)
+		if base, _, ok := strings.Cut(locale, "-"); ok {
+			out = append(out, base)
+		}
+	}
+	if def != "" {
+		out = append(out, def)
+	}
+	return out
+}
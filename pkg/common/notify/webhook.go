@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the webhook body when a secret is configured.
+const SignatureHeader = "X-CivicSpot-Signature"
+
+// WebhookPayload is the JSON body posted by the webhook channel.
+type WebhookPayload struct {
+	UserID   string         `json:"user_id"`
+	Template string         `json:"template"`
+	Subject  string         `json:"subject"`
+	Text     string         `json:"text"`
+	Data     map[string]any `json:"data,omitempty"`
+	SentAt   time.Time      `json:"sent_at"`
+}
+
+// WebhookChannel posts messages as JSON to the recipient's webhook URL.
+type WebhookChannel struct {
+	client *http.Client
+	secret []byte
+}
+
+// NewWebhookChannel creates a WebhookChannel. When secret is not empty the body is signed.
+func NewWebhookChannel(client *http.Client, secret string) *WebhookChannel {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookChannel{client: client, secret: []byte(secret)}
+}
+
+// Kind returns Webhook.
+func (c *WebhookChannel) Kind() ChannelKind {
+	return Webhook
+}
+
+// Send posts the message to the recipient's webhook URL, failing on non-2xx responses.
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	if msg.Recipient.WebhookURL == "" {
+		return ErrNoRecipientAddress
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		UserID:   msg.Recipient.UserID,
+		Template: msg.Template,
+		Subject:  msg.Subject,
+		Text:     msg.Text,
+		Data:     msg.Data,
+		SentAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Recipient.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(c.secret, body))
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("notify: webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body, as sent in SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
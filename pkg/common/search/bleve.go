@@ -0,0 +1,190 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/lang/es"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+)
+
+// bleveIndex is an embedded inverted index backed by Bleve.
+type bleveIndex struct {
+	idx bleve.Index
+}
+
+// NewBleveIndex opens or creates a Bleve index at path, or an in-memory one when path is empty.
+// Text is analyzed in Spanish: lowercased, without stop words, accent-folded and stemmed.
+func NewBleveIndex(path string) (Index, error) {
+	if path == "" {
+		idx, err := bleve.NewMemOnly(newMapping())
+		return &bleveIndex{idx: idx}, err
+	}
+
+	idx, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		idx, err = bleve.New(path, newMapping())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bleveIndex{idx: idx}, nil
+}
+
+// newMapping returns the mapping of indexed documents.
+func newMapping() mapping.IndexMapping {
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = es.AnalyzerName
+	text.Store = true
+	text.IncludeTermVectors = true
+
+	exact := bleve.NewKeywordFieldMapping()
+
+	fields := bleve.NewDocumentMapping()
+	fields.DefaultAnalyzer = keyword.Name
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("tenant", exact)
+	doc.AddFieldMappingsAt("type", exact)
+	doc.AddFieldMappingsAt("id", exact)
+	doc.AddFieldMappingsAt("title", text)
+	doc.AddFieldMappingsAt("body", text)
+	doc.AddFieldMappingsAt("created", bleve.NewDateTimeFieldMapping())
+	doc.AddSubDocumentMapping("fields", fields)
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	m.DefaultAnalyzer = es.AnalyzerName
+	return m
+}
+
+// Index adds or replaces documents of the tenant of ctx, if any, in a single batch.
+func (b *bleveIndex) Index(ctx context.Context, docs ...Document) error {
+	owner, _ := tenant.FromContext(ctx)
+	batch := b.idx.NewBatch()
+	for _, d := range docs {
+		fields := make(map[string]any, len(d.Fields))
+		for k, v := range d.Fields {
+			fields[k] = v
+		}
+		err := batch.Index(key(d.Type, d.ID), map[string]any{
+			"tenant":  owner,
+			"type":    d.Type,
+			"id":      d.ID,
+			"title":   d.Title,
+			"body":    d.Body,
+			"created": d.CreatedAt,
+			"fields":  fields,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return b.idx.Batch(batch)
+}
+
+// Delete removes a document.
+func (b *bleveIndex) Delete(_ context.Context, docType, id string) error {
+	return b.idx.Delete(key(docType, id))
+}
+
+// Search runs a query on the documents of the tenant of ctx, if any, ranked by
+// relevance, or by recency without text.
+func (b *bleveIndex) Search(ctx context.Context, q Query) (*Result, error) {
+	q = q.normalize()
+
+	var conjuncts []query.Query
+	if owner, ok := tenant.FromContext(ctx); ok {
+		conjuncts = append(conjuncts, anyTerm("tenant", []string{owner}))
+	}
+	if strings.TrimSpace(q.Text) != "" {
+		title := bleve.NewMatchQuery(q.Text)
+		title.SetField("title")
+		title.SetBoost(2)
+		body := bleve.NewMatchQuery(q.Text)
+		body.SetField("body")
+		conjuncts = append(conjuncts, bleve.NewDisjunctionQuery(title, body))
+	}
+	if len(q.Types) > 0 {
+		conjuncts = append(conjuncts, anyTerm("type", q.Types))
+	}
+	for name, values := range q.Filters {
+		if len(values) > 0 {
+			conjuncts = append(conjuncts, anyTerm("fields."+name, values))
+		}
+	}
+
+	var root query.Query = bleve.NewMatchAllQuery()
+	if len(conjuncts) > 0 {
+		root = bleve.NewConjunctionQuery(conjuncts...)
+	}
+
+	req := bleve.NewSearchRequestOptions(root, q.Limit, q.Offset, false)
+	req.Fields = []string{"type", "id", "title"}
+	if strings.TrimSpace(q.Text) == "" {
+		req.SortBy([]string{"-created"})
+	} else {
+		req.Highlight = bleve.NewHighlightWithStyle(html.Name)
+		req.Highlight.AddField("title")
+		req.Highlight.AddField("body")
+	}
+	for _, f := range q.Facets {
+		req.AddFacet(f, bleve.NewFacetRequest("fields."+f, 50))
+	}
+
+	res, err := b.idx.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Result{Total: int(res.Total), Hits: make([]Hit, 0, len(res.Hits)), Facets: map[string][]FacetValue{}}
+	for _, h := range res.Hits {
+		hit := Hit{Score: h.Score, Highlights: h.Fragments}
+		hit.Type, _ = h.Fields["type"].(string)
+		hit.ID, _ = h.Fields["id"].(string)
+		hit.Title, _ = h.Fields["title"].(string)
+		out.Hits = append(out.Hits, hit)
+	}
+	for name, f := range res.Facets {
+		values := make([]FacetValue, 0, f.Terms.Len())
+		for _, t := range f.Terms.Terms() {
+			values = append(values, FacetValue{Value: t.Term, Count: t.Count})
+		}
+		sortFacet(values)
+		out.Facets[name] = values
+	}
+	return out, nil
+}
+
+// Close closes the index.
+func (b *bleveIndex) Close() error {
+	return b.idx.Close()
+}
+
+// anyTerm matches documents whose field equals one of the values.
+func anyTerm(field string, values []string) query.Query {
+	terms := make([]query.Query, len(values))
+	for i, v := range values {
+		t := bleve.NewTermQuery(v)
+		t.SetField(field)
+		terms[i] = t
+	}
+	return bleve.NewDisjunctionQuery(terms...)
+}
+
+// sortFacet orders facet values by count, then by value.
+func sortFacet(values []FacetValue) {
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+}
@@ -0,0 +1,165 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobKind is the job kind applying index changes.
+const JobKind = "search.index"
+
+// Change is a pending index update, enqueued when a searchable entity changes.
+type Change struct {
+	Document Document  // Document is the new version of the entity.
+	Deleted  bool      // Deleted removes the entity; only the document type and ID are used.
+	Version  time.Time // Version is when the entity changed; older changes of the document are skipped.
+}
+
+// latest is the newest change recorded for a document, so changes applied out of
+// order, retried or run by concurrent workers never leave stale state in the index.
+// Versions are shared by every tenant since document IDs are unique across them
+// and versions are never served.
+type latest struct {
+	ID       uint      `gorm:"primarykey"`
+	DocType  string    `gorm:"size:64;uniqueIndex:idx_search_version_doc"`
+	DocID    string    `gorm:"size:191;uniqueIndex:idx_search_version_doc"`
+	Version  time.Time // Version is the time of the newest change.
+	Deleted  bool      // Deleted reports whether the newest change removed the document.
+	Document Document  `gorm:"serializer:json"` // Document is the newest version of the entity.
+}
+
+// TableName returns the document version table.
+func (latest) TableName() string { return "search_versions" }
+
+// Indexer applies changes to an Index from background jobs, so domain services
+// only enqueue a job and never wait for the index. Changes are enqueued on the
+// queue database, outside the caller's transaction, so enqueue them once it commits.
+type Indexer struct {
+	index Index
+	db    *gorm.DB
+	queue *jobs.Queue
+	now   func() time.Time
+}
+
+// NewIndexer creates an Indexer enqueuing changes on q and recording document
+// versions in db. Run Migrate on db first.
+func NewIndexer(index Index, db *gorm.DB, q *jobs.Queue) *Indexer {
+	return &Indexer{index: index, db: db, queue: q, now: time.Now}
+}
+
+// Register handles indexing jobs on the worker.
+func (i *Indexer) Register(w *jobs.Worker) {
+	w.Handle(JobKind, func(ctx context.Context, job *jobs.Job) error {
+		var c Change
+		if err := job.Bind(&c); err != nil {
+			return err
+		}
+		return i.Apply(ctx, c)
+	})
+}
+
+// Apply writes a change to the index immediately, unless a newer change of the
+// same document was already recorded.
+func (i *Indexer) Apply(ctx context.Context, c Change) error {
+	newer, err := i.record(ctx, c)
+	if err != nil || !newer {
+		return err
+	}
+
+	// A concurrent worker may write an older version after this one; the newest
+	// recorded state is written until it stays current after the write.
+	written := time.Time{}
+	for {
+		var l latest
+		err := i.db.WithContext(db.WithPrimary(ctx)).
+			Where("doc_type = ? AND doc_id = ?", c.Document.Type, c.Document.ID).First(&l).Error
+		if err != nil {
+			return err
+		}
+		if !written.IsZero() && l.Version.Equal(written) {
+			return nil
+		}
+		if l.Deleted {
+			err = i.index.Delete(ctx, l.DocType, l.DocID)
+		} else {
+			err = i.index.Index(ctx, l.Document)
+		}
+		if err != nil {
+			return err
+		}
+		written = l.Version
+	}
+}
+
+// record stores c as the latest change of its document, reporting false when a
+// newer change was already recorded.
+func (i *Indexer) record(ctx context.Context, c Change) (bool, error) {
+	row := latest{
+		DocType:  c.Document.Type,
+		DocID:    c.Document.ID,
+		Version:  c.Version.UTC(),
+		Deleted:  c.Deleted,
+		Document: c.Document,
+	}
+	update := func() *gorm.DB {
+		return i.db.WithContext(ctx).Model(&latest{}).
+			Where("doc_type = ? AND doc_id = ? AND version <= ?", row.DocType, row.DocID, row.Version).
+			Select("version", "deleted", "document").Updates(&row)
+	}
+
+	res := update()
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error == nil, res.Error
+	}
+	res = i.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error == nil, res.Error
+	}
+	// Another change created the row concurrently; it wins only when it is newer.
+	res = update()
+	return res.RowsAffected > 0, res.Error
+}
+
+// Updated enqueues the indexing of a created or updated entity, versioned by
+// doc.UpdatedAt, or by the current time when it is zero.
+func (i *Indexer) Updated(ctx context.Context, doc Document) error {
+	version := doc.UpdatedAt
+	if version.IsZero() {
+		version = i.now()
+	}
+	_, err := i.queue.Enqueue(ctx, JobKind, Change{Document: doc, Version: version})
+	return err
+}
+
+// Deleted enqueues the removal of an entity deleted at the given time.
+func (i *Indexer) Deleted(ctx context.Context, docType, id string, at time.Time) error {
+	_, err := i.queue.Enqueue(ctx, JobKind, Change{Document: Document{Type: docType, ID: id}, Deleted: true, Version: at})
+	return err
+}
+
+// Reindex indexes every document produced by the source, in batches of size.
+// It is meant for backfills and for rebuilding the embedded index.
+func (i *Indexer) Reindex(ctx context.Context, size int, source func(yield func(Document) error) error) error {
+	if size <= 0 {
+		size = 500
+	}
+	batch := make([]Document, 0, size)
+	err := source(func(d Document) error {
+		batch = append(batch, d)
+		if len(batch) < size {
+			return nil
+		}
+		err := i.index.Index(ctx, batch...)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil || len(batch) == 0 {
+		return err
+	}
+	return i.index.Index(ctx, batch...)
+}
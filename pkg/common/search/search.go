@@ -0,0 +1,152 @@
+package search
+
+import (
+	"context"
+	"html"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/text"
+)
+
+// Document is an entity made searchable.
+type Document struct {
+	Type      string            // Type is the entity kind (e.g., "report", "comment", "user").
+	ID        string            // ID identifies the entity within its type.
+	Title     string            // Title is the main text, weighted above the body.
+	Body      string            // Body is the secondary text.
+	Fields    map[string]string // Fields are exact values used for filters and facets (e.g., category, status, municipality).
+	CreatedAt time.Time         // CreatedAt orders results without a text query.
+	UpdatedAt time.Time         // UpdatedAt versions the document so the Indexer skips stale changes.
+}
+
+// Query describes a search.
+type Query struct {
+	Text    string              // Text is the keyword query; empty matches every document.
+	Types   []string            // Types restricts the entity kinds (optional).
+	Filters map[string][]string // Filters keeps documents whose field has one of the values.
+	Facets  []string            // Facets are the fields whose value counts are returned.
+	Limit   int                 // Limit is the page size (default: 20, maximum: 100).
+	Offset  int                 // Offset skips results for pagination.
+}
+
+// Hit is a matching document.
+type Hit struct {
+	Type       string              `json:"type"`       // Type is the entity kind.
+	ID         string              `json:"id"`         // ID identifies the entity within its type.
+	Score      float64             `json:"score"`      // Score is the backend relevance; higher is better.
+	Title      string              `json:"title"`      // Title is the document title.
+	Highlights map[string][]string `json:"highlights"` // Highlights are HTML fragments with matches wrapped in <mark>, by field.
+}
+
+// FacetValue is the number of matching documents with a field value.
+type FacetValue struct {
+	Value string `json:"value"` // Value is the field value.
+	Count int    `json:"count"` // Count is the number of matching documents.
+}
+
+// Result is a page of hits with facet counts over every match.
+type Result struct {
+	Total  int                     `json:"total"`  // Total is the number of matching documents.
+	Hits   []Hit                   `json:"hits"`   // Hits is the requested page, best first.
+	Facets map[string][]FacetValue `json:"facets"` // Facets are the value counts by field, most frequent first.
+}
+
+// Index stores documents and runs ranked queries over them. Documents belong to
+// the tenant of the context indexing them and only match queries in that tenant.
+type Index interface {
+
+	// Index adds or replaces documents.
+	Index(ctx context.Context, docs ...Document) error
+
+	// Delete removes a document. Missing documents are ignored.
+	Delete(ctx context.Context, docType, id string) error
+
+	// Search runs a query.
+	Search(ctx context.Context, q Query) (*Result, error)
+
+	// Close releases the index resources.
+	Close() error
+}
+
+// normalize applies the Query defaults.
+func (q Query) normalize() Query {
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+	q.Limit = min(q.Limit, 100)
+	q.Offset = max(q.Offset, 0)
+	return q
+}
+
+// key returns the unique key of a document.
+func key(docType, id string) string {
+	return docType + ":" + id
+}
+
+// Highlight returns up to n HTML fragments of s around the words sharing a term
+// with terms, wrapped in <mark>. The rest of the text is escaped.
+func Highlight(s string, terms []string, n int) []string {
+	const around = 40
+
+	// Group nearby matches into windows of text.
+	type window struct {
+		start, end int
+		matches    []text.Token
+	}
+	var windows []window
+	for _, tok := range text.Tokenize(s) {
+		if !slices.Contains(terms, text.Stem(text.Fold(tok.Text))) {
+			continue
+		}
+		if k := len(windows) - 1; k >= 0 && tok.Start <= windows[k].end {
+			windows[k].end = runeEnd(s, tok.End+around)
+			windows[k].matches = append(windows[k].matches, tok)
+			continue
+		}
+		if len(windows) == n {
+			break
+		}
+		windows = append(windows, window{start: runeStart(s, tok.Start-around), end: runeEnd(s, tok.End+around), matches: []text.Token{tok}})
+	}
+
+	fragments := make([]string, 0, len(windows))
+	for _, w := range windows {
+		var b strings.Builder
+		if w.start > 0 {
+			b.WriteString("…")
+		}
+		pos := w.start
+		for _, m := range w.matches {
+			b.WriteString(html.EscapeString(s[pos:m.Start]))
+			b.WriteString("<mark>" + html.EscapeString(s[m.Start:m.End]) + "</mark>")
+			pos = m.End
+		}
+		b.WriteString(html.EscapeString(s[pos:w.end]))
+		if w.end < len(s) {
+			b.WriteString("…")
+		}
+		fragments = append(fragments, b.String())
+	}
+	return fragments
+}
+
+// runeStart clamps i to s and moves it back to the start of a character.
+func runeStart(s string, i int) int {
+	i = max(0, i)
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// runeEnd clamps i to s and moves it forward to the start of a character.
+func runeEnd(s string, i int) int {
+	i = min(len(s), i)
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return i
+}
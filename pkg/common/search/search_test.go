@@ -0,0 +1,171 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/jobs"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var docs = []Document{
+	{Type: "report", ID: "1", Title: "Hueco enorme en la vía", Body: "Un hueco en la Avenida Boyacá daña los carros.", Fields: map[string]string{"category": "vias", "status": "open"}, CreatedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	{Type: "report", ID: "2", Title: "Semáforo dañado", Body: "El semáforo de la calle 80 no funciona.", Fields: map[string]string{"category": "semaforos", "status": "open"}, CreatedAt: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)},
+	{Type: "report", ID: "3", Title: "Huecos en el andén", Body: "Varios huecos frente al colegio.", Fields: map[string]string{"category": "vias", "status": "closed"}, CreatedAt: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+	{Type: "comment", ID: "9", Title: "", Body: "Ese hueco sigue ahí, nadie lo arregla.", CreatedAt: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)},
+}
+
+// testIndex exercises the Index contract on any backend.
+func testIndex(t *testing.T, idx Index) {
+	ctx := context.Background()
+	require.NoError(t, idx.Index(ctx, docs...))
+
+	res, err := idx.Search(ctx, Query{Text: "HUECO via"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Total)
+	ids := map[string]bool{}
+	for _, h := range res.Hits {
+		ids[h.Type+":"+h.ID] = true
+	}
+	assert.True(t, ids["report:1"] && ids["report:3"] && ids["comment:9"])
+
+	res, err = idx.Search(ctx, Query{Text: "huecos", Types: []string{"report"}, Filters: map[string][]string{"status": {"open"}}, Facets: []string{"category"}})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Total)
+	assert.Equal(t, "1", res.Hits[0].ID)
+	assert.Equal(t, "Hueco enorme en la vía", res.Hits[0].Title)
+	assert.Contains(t, res.Hits[0].Highlights["title"][0], "<mark>Hueco</mark>")
+	assert.Equal(t, []FacetValue{{Value: "vias", Count: 1}}, res.Facets["category"])
+
+	res, err = idx.Search(ctx, Query{Facets: []string{"category", "status"}, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 4, res.Total)
+	assert.Len(t, res.Hits, 2)
+	assert.Equal(t, "9", res.Hits[0].ID, "without text the newest documents come first")
+	assert.Equal(t, []FacetValue{{Value: "vias", Count: 2}, {Value: "semaforos", Count: 1}}, res.Facets["category"])
+
+	require.NoError(t, idx.Delete(ctx, "report", "1"))
+	res, err = idx.Search(ctx, Query{Text: "hueco"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Total)
+
+	require.NoError(t, idx.Index(ctx, Document{Type: "report", ID: "2", Title: "Semáforo reparado", Fields: map[string]string{"status": "closed"}}))
+	res, err = idx.Search(ctx, Query{Text: "semaforo", Filters: map[string][]string{"status": {"open"}}})
+	require.NoError(t, err)
+	assert.Zero(t, res.Total, "reindexing replaces the document fields")
+}
+
+// TestBleveIndex checks the embedded backend.
+func TestBleveIndex(t *testing.T) {
+	idx, err := NewBleveIndex(filepath.Join(t.TempDir(), "search.bleve"))
+	require.NoError(t, err)
+	defer idx.Close()
+	testIndex(t, idx)
+}
+
+// openDB returns a migrated sqlite database.
+func openDB(t *testing.T) *gorm.DB {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "search.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(gdb))
+	return gdb
+}
+
+// TestSQLIndex checks the SQL backend with its development fallback.
+func TestSQLIndex(t *testing.T) {
+	testIndex(t, NewSQLIndex(openDB(t)))
+}
+
+// TestHighlight checks fragments mark stemmed, accent-insensitive matches and escape the text.
+func TestHighlight(t *testing.T) {
+	got := Highlight("<b>Árboles</b> caídos sobre la vía principal del barrio", []string{"arbol", "via"}, 3)
+	assert.Equal(t, []string{"&lt;b&gt;<mark>Árboles</mark>&lt;/b&gt; caídos sobre la <mark>vía</mark> principal del barrio"}, got)
+
+	long := "hueco ................................................................ hueco"
+	assert.Len(t, Highlight(long, []string{"hueco"}, 1), 1)
+	assert.Len(t, Highlight(long, []string{"hueco"}, 2), 2)
+}
+
+// TestIndexer checks enqueued changes reach the index through the worker and
+// stale changes never overwrite newer ones.
+func TestIndexer(t *testing.T) {
+	gdb := openDB(t)
+	require.NoError(t, jobs.Migrate(gdb))
+	idx := NewSQLIndex(gdb)
+	ctx := context.Background()
+
+	indexer := NewIndexer(idx, gdb, jobs.NewQueue(gdb))
+	w := jobs.NewWorker(gdb, jobs.WorkerConfig{})
+	indexer.Register(w)
+
+	doc := docs[0]
+	doc.UpdatedAt = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, indexer.Updated(ctx, doc))
+	_, err := w.RunOnce(ctx)
+	require.NoError(t, err)
+
+	res, err := idx.Search(ctx, Query{Text: "hueco"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Total)
+
+	require.NoError(t, indexer.Deleted(ctx, "report", "1", doc.UpdatedAt.Add(time.Hour)))
+	_, err = w.RunOnce(ctx)
+	require.NoError(t, err)
+
+	res, err = idx.Search(ctx, Query{Text: "hueco"})
+	require.NoError(t, err)
+	assert.Zero(t, res.Total)
+
+	stale := doc
+	stale.UpdatedAt = doc.UpdatedAt.Add(time.Minute)
+	require.NoError(t, indexer.Apply(ctx, Change{Document: stale, Version: stale.UpdatedAt}))
+	res, err = idx.Search(ctx, Query{Text: "hueco"})
+	require.NoError(t, err)
+	assert.Zero(t, res.Total, "an update older than the deletion is skipped")
+
+	newer := doc
+	newer.Title = "Hueco reparado"
+	newer.UpdatedAt = doc.UpdatedAt.Add(2 * time.Hour)
+	require.NoError(t, indexer.Apply(ctx, Change{Document: newer, Version: newer.UpdatedAt}))
+	require.NoError(t, indexer.Apply(ctx, Change{Document: doc, Version: doc.UpdatedAt}))
+	res, err = idx.Search(ctx, Query{Text: "reparado"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Total, "the newest version stays indexed")
+}
+
+// testTenants checks documents are only found in the tenant indexing them.
+func testTenants(t *testing.T, idx Index) {
+	bogota := tenant.NewContext(context.Background(), "bogota")
+	cali := tenant.NewContext(context.Background(), "cali")
+	require.NoError(t, idx.Index(bogota, docs[0]))
+	require.NoError(t, idx.Index(cali, docs[1]))
+
+	res, err := idx.Search(cali, Query{Text: "hueco", Facets: []string{"category"}})
+	require.NoError(t, err)
+	assert.Zero(t, res.Total)
+	assert.Empty(t, res.Facets["category"])
+
+	res, err = idx.Search(bogota, Query{Facets: []string{"category"}})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Total)
+	assert.Equal(t, "1", res.Hits[0].ID)
+	assert.Equal(t, []FacetValue{{Value: "vias", Count: 1}}, res.Facets["category"])
+}
+
+// TestTenants checks both backends keep the documents of each tenant apart.
+func TestTenants(t *testing.T) {
+	b, err := NewBleveIndex("")
+	require.NoError(t, err)
+	defer b.Close()
+	testTenants(t, b)
+
+	gdb := openDB(t)
+	require.NoError(t, tenant.Register(gdb))
+	testTenants(t, NewSQLIndex(gdb))
+}
@@ -0,0 +1,26 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+	"gorm.io/gorm"
+)
+
+// SetupEnvironmentIndex creates an Index from the provided environment.
+// SEARCH_DRIVER selects "bleve" (embedded index under SEARCH_PATH) or "sql" (the given database).
+func SetupEnvironmentIndex(gdb *gorm.DB) (Index, error) {
+
+	switch driver := config.MustGet(config.SearchDriver); driver {
+	case "bleve":
+		return NewBleveIndex(config.MustGet(config.SearchPath))
+	case "sql":
+		if err := Migrate(gdb); err != nil {
+			return nil, err
+		}
+		return NewSQLIndex(gdb), nil
+	default:
+		return nil, fmt.Errorf("unsupported search driver: %s", driver)
+	}
+
+}
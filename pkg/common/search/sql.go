@@ -0,0 +1,229 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/ianfedev/civicspot-backend/pkg/common/text"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// document is a row of the SQL index. Terms columns hold the folded, stemmed words
+// of the title and body, so every dialect matches accents and plurals alike.
+type document struct {
+	tenant.Owned
+	ID         uint      `gorm:"primarykey"`
+	DocType    string    `gorm:"size:64;uniqueIndex:idx_search_doc"`
+	DocID      string    `gorm:"size:191;uniqueIndex:idx_search_doc"`
+	Title      string    `gorm:"type:text"`
+	Body       string    `gorm:"type:text"`
+	TitleTerms string    `gorm:"type:text"`
+	BodyTerms  string    `gorm:"type:text"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+// TableName returns the SQL index table.
+func (document) TableName() string { return "search_documents" }
+
+// field is a filterable value of a document.
+type field struct {
+	tenant.Owned
+	ID      uint   `gorm:"primarykey"`
+	DocType string `gorm:"size:64;index:idx_search_field_doc"`
+	DocID   string `gorm:"size:191;index:idx_search_field_doc"`
+	Name    string `gorm:"size:64;index:idx_search_field"`
+	Value   string `gorm:"size:191;index:idx_search_field"`
+}
+
+// TableName returns the SQL index field table.
+func (field) TableName() string { return "search_fields" }
+
+// postgresVector is the weighted tsvector of a document, indexed by Migrate.
+const postgresVector = "(setweight(to_tsvector('spanish', title_terms), 'A') || setweight(to_tsvector('spanish', body_terms), 'B'))"
+
+// Migrate creates the SQL index tables with a Spanish tsvector GIN index on
+// postgres and a FULLTEXT index on MySQL, and the document versions of the Indexer.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&document{}, &field{}, &latest{}); err != nil {
+		return err
+	}
+
+	switch db.Dialector.Name() {
+	case "postgres":
+		return db.Exec("CREATE INDEX IF NOT EXISTS idx_search_content ON search_documents USING GIN (" + postgresVector + ")").Error
+	case "mysql":
+		if db.Migrator().HasIndex(&document{}, "idx_search_content") {
+			return nil
+		}
+		return db.Exec("ALTER TABLE search_documents ADD FULLTEXT INDEX idx_search_content (title_terms, body_terms)").Error
+	}
+	return nil
+}
+
+// sqlIndex stores documents in the application database.
+type sqlIndex struct {
+	db *gorm.DB
+}
+
+// NewSQLIndex creates an Index on the database, ranked with tsvector on postgres and
+// FULLTEXT on MySQL. Other dialects match terms with LIKE and order by recency,
+// which is only meant for development. Run Migrate first.
+func NewSQLIndex(db *gorm.DB) Index {
+	return &sqlIndex{db: db}
+}
+
+// Index adds or replaces documents and their fields in a transaction.
+func (s *sqlIndex) Index(ctx context.Context, docs ...Document) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, d := range docs {
+			row := document{
+				DocType:    d.Type,
+				DocID:      d.ID,
+				Title:      d.Title,
+				Body:       d.Body,
+				TitleTerms: strings.Join(text.Terms(d.Title), " "),
+				BodyTerms:  strings.Join(text.Terms(d.Body), " "),
+				CreatedAt:  d.CreatedAt,
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "doc_type"}, {Name: "doc_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"title", "body", "title_terms", "body_terms", "created_at"}),
+			}).Create(&row).Error
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Where("doc_type = ? AND doc_id = ?", d.Type, d.ID).Delete(&field{}).Error; err != nil {
+				return err
+			}
+			for name, value := range d.Fields {
+				if err := tx.Create(&field{DocType: d.Type, DocID: d.ID, Name: name, Value: value}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes a document and its fields.
+func (s *sqlIndex) Delete(ctx context.Context, docType, id string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("doc_type = ? AND doc_id = ?", docType, id).Delete(&field{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("doc_type = ? AND doc_id = ?", docType, id).Delete(&document{}).Error
+	})
+}
+
+// Search runs a query, highlighting the matched terms of each hit.
+func (s *sqlIndex) Search(ctx context.Context, q Query) (*Result, error) {
+	q = q.normalize()
+	terms := text.Terms(q.Text)
+	dialect := s.db.Dialector.Name()
+
+	var total int64
+	if err := s.matching(ctx, q, terms).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		DocType, DocID, Title, Body string
+		Score                       float64
+	}
+	expr, vars := rank(dialect, terms)
+	find := s.matching(ctx, q, terms).Select("doc_type, doc_id, title, body, "+expr+" AS score", vars...)
+	if vars != nil {
+		find = find.Order("score DESC")
+	}
+	find = find.Order("created_at DESC").Limit(q.Limit).Offset(q.Offset)
+	if err := find.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := &Result{Total: int(total), Hits: make([]Hit, 0, len(rows)), Facets: map[string][]FacetValue{}}
+	for _, r := range rows {
+		hit := Hit{Type: r.DocType, ID: r.DocID, Score: r.Score, Title: r.Title}
+		if len(terms) > 0 {
+			hit.Highlights = map[string][]string{}
+			if f := Highlight(r.Title, terms, 1); len(f) > 0 {
+				hit.Highlights["title"] = f
+			}
+			if f := Highlight(r.Body, terms, 3); len(f) > 0 {
+				hit.Highlights["body"] = f
+			}
+		}
+		out.Hits = append(out.Hits, hit)
+	}
+
+	for _, name := range q.Facets {
+		var values []FacetValue
+		err := s.db.WithContext(ctx).Model(&field{}).
+			Select("search_fields.value AS value, COUNT(*) AS count").
+			Joins("JOIN (?) AS d ON d.doc_type = search_fields.doc_type AND d.doc_id = search_fields.doc_id", s.matching(ctx, q, terms).Select("search_documents.doc_type, search_documents.doc_id")).
+			Where("search_fields.name = ?", name).
+			Group("search_fields.value").
+			Scan(&values).Error
+		if err != nil {
+			return nil, err
+		}
+		sortFacet(values)
+		out.Facets[name] = values
+	}
+	return out, nil
+}
+
+// Close is a no-op; the database is owned by the caller.
+func (s *sqlIndex) Close() error {
+	return nil
+}
+
+// matching returns a fresh query over the documents matching q.
+func (s *sqlIndex) matching(ctx context.Context, q Query, terms []string) *gorm.DB {
+	tx := s.db.WithContext(ctx).Model(&document{})
+
+	if len(terms) > 0 {
+		switch s.db.Dialector.Name() {
+		case "postgres":
+			tx = tx.Where(postgresVector+" @@ to_tsquery('spanish', ?)", strings.Join(terms, " | "))
+		case "mysql":
+			tx = tx.Where("MATCH(title_terms, body_terms) AGAINST (? IN NATURAL LANGUAGE MODE)", strings.Join(terms, " "))
+		default:
+			var conds []string
+			var vars []any
+			for _, t := range terms {
+				conds = append(conds, "(' ' || title_terms || ' ' || body_terms || ' ') LIKE ?")
+				vars = append(vars, "% "+t+" %")
+			}
+			tx = tx.Where(strings.Join(conds, " OR "), vars...)
+		}
+	}
+	if len(q.Types) > 0 {
+		tx = tx.Where("search_documents.doc_type IN ?", q.Types)
+	}
+	for name, values := range q.Filters {
+		if len(values) == 0 {
+			continue
+		}
+		tx = tx.Where("EXISTS (SELECT 1 FROM search_fields sf WHERE sf.doc_type = search_documents.doc_type AND sf.doc_id = search_documents.doc_id AND sf.name = ? AND sf.value IN ?)", name, values)
+	}
+	return tx
+}
+
+// rank returns the relevance expression of the dialect and its values. Dialects
+// without ranking return a constant expression and no values.
+func rank(dialect string, terms []string) (string, []any) {
+	if len(terms) == 0 {
+		return "0", nil
+	}
+	switch dialect {
+	case "postgres":
+		return "ts_rank(" + postgresVector + ", to_tsquery('spanish', ?))", []any{strings.Join(terms, " | ")}
+	case "mysql":
+		return "MATCH(title_terms, body_terms) AGAINST (? IN NATURAL LANGUAGE MODE)", []any{strings.Join(terms, " ")}
+	}
+	return "0", nil
+}
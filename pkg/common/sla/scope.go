@@ -0,0 +1,71 @@
+package sla
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"gorm.io/gorm"
+)
+
+// nextDueColumn is the SQL expression of the pending deadline of a Tracking row.
+const nextDueColumn = "CASE WHEN first_responded_at IS NULL AND first_response_due_at IS NOT NULL " +
+	"THEN first_response_due_at ELSE resolution_due_at END"
+
+// WithStatus filters entities in any of the given statuses.
+func WithStatus(statuses ...Status) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("sla_status IN ?", statuses)
+	}
+}
+
+// Open filters entities that are not resolved yet.
+func Open() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("resolved_at IS NULL")
+	}
+}
+
+// DueBefore filters open entities whose pending deadline is before t.
+func DueBefore(t time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return Open()(db).Where(nextDueColumn+" < ?", t)
+	}
+}
+
+// OrderByDue sorts open entities by their pending deadline, soonest first.
+func OrderByDue() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Order(nextDueColumn + " ASC")
+	}
+}
+
+// ListFilter builds list filters from the query parameters "sla_status" (comma
+// separated statuses) and "sla_due_within" (a duration such as "4h").
+func ListFilter(c *fiber.Ctx) ([]func(*gorm.DB) *gorm.DB, error) {
+	var fns []func(*gorm.DB) *gorm.DB
+
+	if raw := c.Query("sla_status"); raw != "" {
+		var statuses []Status
+		for _, s := range strings.Split(raw, ",") {
+			switch st := Status(strings.TrimSpace(s)); st {
+			case OnTrack, AtRisk, Breached, Met:
+				statuses = append(statuses, st)
+			default:
+				return nil, transport.BadRequest("unknown sla_status " + s)
+			}
+		}
+		fns = append(fns, WithStatus(statuses...))
+	}
+
+	if raw := c.Query("sla_due_within"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, transport.BadRequest("invalid sla_due_within: " + err.Error())
+		}
+		fns = append(fns, DueBefore(time.Now().Add(d)), OrderByDue())
+	}
+
+	return fns, nil
+}
@@ -0,0 +1,172 @@
+package sla
+
+import "time"
+
+// Status is the service level state of a tracked entity.
+type Status string
+
+const (
+	// OnTrack means the pending target is not close to its deadline.
+	OnTrack Status = "on_track"
+	// AtRisk means less than a fifth of the pending target time is left.
+	AtRisk Status = "at_risk"
+	// Breached means a target deadline passed before it was met.
+	Breached Status = "breached"
+	// Met means the entity was resolved within its targets.
+	Met Status = "met"
+)
+
+// atRiskRatio is the fraction of the target window left at which an entity becomes at risk.
+const atRiskRatio = 0.2
+
+// Clock measures target deadlines. A calendar.Calendar only counts business hours,
+// while WallClock counts every hour.
+type Clock interface {
+	Add(t time.Time, d time.Duration) time.Time
+	Between(a, b time.Time) time.Duration
+}
+
+// WallClock is a Clock counting elapsed time around the clock.
+type WallClock struct{}
+
+// Add returns t+d.
+func (WallClock) Add(t time.Time, d time.Duration) time.Time { return t.Add(d) }
+
+// Between returns b-a, or zero when b is not after a.
+func (WallClock) Between(a, b time.Time) time.Duration {
+	if !b.After(a) {
+		return 0
+	}
+	return b.Sub(a)
+}
+
+// Targets defines the deadlines applied to an entity.
+type Targets struct {
+	PolicyID      string        // PolicyID identifies the policy the targets come from.
+	FirstResponse time.Duration // FirstResponse is the time allowed until the first official response (0 disables it).
+	Resolution    time.Duration // Resolution is the time allowed until resolution (0 disables it).
+	Clock         Clock         // Clock measures the targets (default: WallClock).
+}
+
+// Tracking holds the service level fields of an entity. Embed it in models to
+// persist and filter by SLA state.
+type Tracking struct {
+	SLAPolicyID        string        `gorm:"index"` // SLAPolicyID is the policy applied to the entity.
+	SLAStatus          Status        `gorm:"index"` // SLAStatus is the state computed on the last evaluation.
+	SLAStartedAt       *time.Time    // SLAStartedAt is when the clock started.
+	FirstResponseDueAt *time.Time    `gorm:"index"` // FirstResponseDueAt is the first response deadline.
+	FirstRespondedAt   *time.Time    // FirstRespondedAt is when an official first responded.
+	ResolutionDueAt    *time.Time    `gorm:"index"` // ResolutionDueAt is the resolution deadline.
+	ResolvedAt         *time.Time    // ResolvedAt is when the entity was resolved.
+	BreachedAt         *time.Time    // BreachedAt is when a breach was first detected.
+	EscalationLevel    int           // EscalationLevel counts the escalations performed.
+	SLARemaining       time.Duration `gorm:"-"` // SLARemaining is the time left to the pending deadline on the last evaluation.
+}
+
+// Start applies targets to the entity, computing deadlines from the given time.
+func (t *Tracking) Start(targets Targets, at time.Time) {
+	clock := targets.Clock
+	if clock == nil {
+		clock = WallClock{}
+	}
+
+	*t = Tracking{SLAPolicyID: targets.PolicyID, SLAStatus: OnTrack, SLAStartedAt: &at}
+	if targets.FirstResponse > 0 {
+		due := clock.Add(at, targets.FirstResponse)
+		t.FirstResponseDueAt = &due
+	}
+	if targets.Resolution > 0 {
+		due := clock.Add(at, targets.Resolution)
+		t.ResolutionDueAt = &due
+	}
+	t.Evaluate(at)
+}
+
+// Respond records the first official response. Later responses are ignored.
+func (t *Tracking) Respond(at time.Time) {
+	if t.FirstRespondedAt == nil {
+		t.FirstRespondedAt = &at
+	}
+	t.Evaluate(at)
+}
+
+// Resolve records the resolution of the entity.
+func (t *Tracking) Resolve(at time.Time) {
+	if t.FirstRespondedAt == nil {
+		t.FirstRespondedAt = &at
+	}
+	t.ResolvedAt = &at
+	t.Evaluate(at)
+}
+
+// Reopen clears the resolution, resuming the resolution deadline.
+func (t *Tracking) Reopen(at time.Time) {
+	t.ResolvedAt = nil
+	t.Evaluate(at)
+}
+
+// NextDue returns the pending deadline, or nil when none is pending.
+func (t *Tracking) NextDue() *time.Time {
+	if t.ResolvedAt != nil {
+		return nil
+	}
+	if t.FirstRespondedAt == nil && t.FirstResponseDueAt != nil {
+		return t.FirstResponseDueAt
+	}
+	return t.ResolutionDueAt
+}
+
+// Evaluate updates and returns the status at the given time. A breach is final:
+// once a deadline was missed the entity stays breached even after resolution.
+func (t *Tracking) Evaluate(now time.Time) Status {
+	t.SLARemaining = 0
+
+	if missed, at := t.missed(now); missed {
+		if t.BreachedAt == nil {
+			t.BreachedAt = &at
+		}
+		t.SLAStatus = Breached
+		return t.SLAStatus
+	}
+
+	if t.ResolvedAt != nil {
+		t.SLAStatus = Met
+		return t.SLAStatus
+	}
+
+	due := t.NextDue()
+	if due == nil || t.SLAStartedAt == nil {
+		t.SLAStatus = OnTrack
+		return t.SLAStatus
+	}
+
+	t.SLARemaining = due.Sub(now)
+	window := due.Sub(*t.SLAStartedAt)
+	if float64(t.SLARemaining) < float64(window)*atRiskRatio {
+		t.SLAStatus = AtRisk
+	} else {
+		t.SLAStatus = OnTrack
+	}
+	return t.SLAStatus
+}
+
+// missed reports whether a deadline was missed by the given time and when.
+func (t *Tracking) missed(now time.Time) (bool, time.Time) {
+	check := func(due, done *time.Time) bool {
+		if due == nil {
+			return false
+		}
+		if done != nil {
+			return done.After(*due)
+		}
+		return now.After(*due)
+	}
+
+	if check(t.FirstResponseDueAt, t.FirstRespondedAt) {
+		return true, *t.FirstResponseDueAt
+	}
+	if check(t.ResolutionDueAt, t.ResolvedAt) {
+		return true, *t.ResolutionDueAt
+	}
+	return false, time.Time{}
+}
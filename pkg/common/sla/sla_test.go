@@ -0,0 +1,90 @@
+package sla
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type report struct {
+	db.BaseModel
+	Tracking
+	Title string
+}
+
+// TestTrackingLifecycle checks status transitions from start to resolution.
+func TestTrackingLifecycle(t *testing.T) {
+	start := time.Date(2025, time.March, 3, 8, 0, 0, 0, time.UTC)
+
+	var tr Tracking
+	tr.Start(Targets{PolicyID: "p1", FirstResponse: 10 * time.Hour, Resolution: 100 * time.Hour}, start)
+	assert.Equal(t, OnTrack, tr.SLAStatus)
+	assert.Equal(t, 10*time.Hour, tr.SLARemaining)
+
+	assert.Equal(t, AtRisk, tr.Evaluate(start.Add(9*time.Hour)))
+	assert.Equal(t, time.Hour, tr.SLARemaining)
+
+	tr.Respond(start.Add(9 * time.Hour))
+	assert.Equal(t, OnTrack, tr.SLAStatus)
+	assert.Equal(t, *tr.ResolutionDueAt, *tr.NextDue())
+
+	tr.Resolve(start.Add(50 * time.Hour))
+	assert.Equal(t, Met, tr.SLAStatus)
+	assert.Nil(t, tr.NextDue())
+	assert.Nil(t, tr.BreachedAt)
+}
+
+// TestTrackingBreach checks that a missed deadline is recorded and stays breached.
+func TestTrackingBreach(t *testing.T) {
+	start := time.Date(2025, time.March, 3, 8, 0, 0, 0, time.UTC)
+
+	var tr Tracking
+	tr.Start(Targets{FirstResponse: time.Hour}, start)
+
+	assert.Equal(t, Breached, tr.Evaluate(start.Add(2*time.Hour)))
+	require.NotNil(t, tr.BreachedAt)
+	assert.Equal(t, start.Add(time.Hour), *tr.BreachedAt)
+
+	tr.Resolve(start.Add(3 * time.Hour))
+	assert.Equal(t, Breached, tr.SLAStatus)
+}
+
+// TestScopes checks the list filters against a database.
+func TestScopes(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&report{}))
+	repo := db.NewRepository[report](gdb)
+	ctx := context.Background()
+
+	now := time.Now()
+	soon, later, done := report{Title: "soon"}, report{Title: "later"}, report{Title: "done"}
+	soon.Start(Targets{FirstResponse: time.Hour}, now)
+	later.Start(Targets{Resolution: 48 * time.Hour}, now)
+	done.Start(Targets{FirstResponse: time.Hour}, now)
+	done.Resolve(now)
+	for _, r := range []*report{&soon, &later, &done} {
+		require.NoError(t, repo.Create(ctx, r))
+	}
+
+	due, err := repo.List(ctx, DueBefore(now.Add(2*time.Hour)))
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "soon", due[0].Title)
+
+	met, err := repo.List(ctx, WithStatus(Met))
+	require.NoError(t, err)
+	require.Len(t, met, 1)
+	assert.Equal(t, "done", met[0].Title)
+
+	ordered, err := repo.List(ctx, Open(), OrderByDue())
+	require.NoError(t, err)
+	require.Len(t, ordered, 2)
+	assert.Equal(t, "soon", ordered[0].Title)
+}
@@ -0,0 +1,393 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTooLarge is returned when an upload exceeds the size limit.
+	ErrTooLarge = transport.New(http.StatusRequestEntityTooLarge, "file exceeds the size limit", nil)
+	// ErrUnsupportedType is returned when the sniffed content type is not allowed.
+	ErrUnsupportedType = transport.New(http.StatusUnsupportedMediaType, "file type is not allowed", nil)
+	// ErrPending is returned for images whose raw upload has not been sanitized yet.
+	ErrPending = transport.New(http.StatusConflict, "file is still being processed", nil)
+)
+
+// Attachment is an uploaded file. Attachments with the same content share one stored blob.
+type Attachment struct {
+	db.BaseModel
+	tenant.Owned
+	OwnerID     string     `gorm:"index"`                       // OwnerID is the user who uploaded the file.
+	EntityType  string     `gorm:"index:idx_attachment_entity"` // EntityType is the kind of entity the file belongs to (e.g., "report").
+	EntityID    string     `gorm:"index:idx_attachment_entity"` // EntityID is the entity the file belongs to.
+	Filename    string     // Filename is the original file name.
+	ContentType string     // ContentType is the sniffed MIME type.
+	Size        int64      // Size is the file length in bytes.
+	SHA256      string     `gorm:"index"` // SHA256 is the hex content hash of the upload.
+	Key         string     // Key is the blob key in the store.
+	Width       int        // Width is the image width in pixels, once processed.
+	Height      int        // Height is the image height in pixels, once processed.
+	Latitude    *float64   // Latitude is the location extracted from image metadata, if any. It is never served.
+	Longitude   *float64   // Longitude is the location extracted from image metadata, if any. It is never served.
+	ProcessedAt *time.Time // ProcessedAt is when the image pipeline finished.
+}
+
+// Variant is a file derived from an attachment, such as a thumbnail.
+type Variant struct {
+	db.BaseModel
+	tenant.Owned
+	AttachmentID uint   `gorm:"index"` // AttachmentID is the source attachment.
+	Name         string // Name identifies the variant (e.g., "thumb.webp").
+	ContentType  string // ContentType is the variant MIME type.
+	Key          string // Key is the blob key in the store.
+	Width        int    // Width is the variant width in pixels.
+	Height       int    // Height is the variant height in pixels.
+	Size         int64  // Size is the variant length in bytes.
+}
+
+// MediaConfig defines upload limits and download links.
+type MediaConfig struct {
+	Store        Store         // Store persists the blobs.
+	DB           *gorm.DB      // DB stores attachment records.
+	Signer       *Signer       // Signer signs download links served by the application.
+	BaseURL      string        // BaseURL is the prefix of the download route (e.g., "/media").
+	MaxSize      int64         // MaxSize is the upload limit in bytes (default: 10 MiB).
+	AllowedTypes []string      // AllowedTypes are MIME types or "type/*" wildcards (default: images and PDF).
+	LinkTTL      time.Duration // LinkTTL is the validity of download links (default: 15 minutes).
+	DirectLinks  bool          // DirectLinks uses presigned store URLs when the store supports them.
+
+	// OnUpload runs after an attachment is stored, e.g. to enqueue processing (optional).
+	// When it fails the upload is undone.
+	OnUpload func(ctx context.Context, a *Attachment) error
+}
+
+// Media stores uploads and issues download links.
+type Media struct {
+	cfg MediaConfig
+	now func() time.Time
+}
+
+// NewMedia creates a Media service with the given configuration.
+func NewMedia(cfg MediaConfig) *Media {
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 10 << 20
+	}
+	if len(cfg.AllowedTypes) == 0 {
+		cfg.AllowedTypes = []string{"image/jpeg", "image/png", "image/webp", "application/pdf"}
+	}
+	if cfg.LinkTTL == 0 {
+		cfg.LinkTTL = 15 * time.Minute
+	}
+	return &Media{cfg: cfg, now: time.Now}
+}
+
+// Migrate creates the attachment and variant tables.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Attachment{}, &Variant{})
+}
+
+// MaxSize returns the upload limit in bytes.
+func (m *Media) MaxSize() int64 {
+	return m.cfg.MaxSize
+}
+
+// Upload validates and stores a file, reusing the blob of identical previous uploads.
+func (m *Media) Upload(ctx context.Context, ownerID, filename string, r io.Reader) (*Attachment, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType := strings.SplitN(http.DetectContentType(head), ";", 2)[0]
+	if !m.allowed(contentType) {
+		return nil, ErrUnsupportedType
+	}
+
+	tmp, err := os.CreateTemp("", "civicspot-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(io.MultiReader(bytes.NewReader(head), r), m.cfg.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > m.cfg.MaxSize {
+		return nil, ErrTooLarge
+	}
+	if size == 0 {
+		return nil, transport.BadRequest("file is empty")
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	key := rawKey(sum)
+
+	a := &Attachment{
+		OwnerID:     ownerID,
+		Filename:    path.Base("/" + strings.ReplaceAll(filename, "\\", "/")),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      sum,
+		Key:         key,
+	}
+	if err := m.cfg.DB.WithContext(ctx).Create(a).Error; err != nil {
+		return nil, err
+	}
+
+	// The blob is checked once the row references it, so a concurrent Release of
+	// the last other reference cannot leave the new attachment without content.
+	if _, err := m.cfg.Store.Stat(ctx, key); errors.Is(err, ErrNotFound) {
+		if _, err = tmp.Seek(0, io.SeekStart); err == nil {
+			err = m.cfg.Store.Put(ctx, key, tmp, size, contentType)
+		}
+		if err != nil {
+			return nil, errors.Join(err, m.cfg.DB.WithContext(context.WithoutCancel(ctx)).Delete(a).Error)
+		}
+	} else if err != nil {
+		return nil, errors.Join(err, m.cfg.DB.WithContext(context.WithoutCancel(ctx)).Delete(a).Error)
+	}
+	if m.cfg.OnUpload != nil {
+		if err := m.cfg.OnUpload(ctx, a); err != nil {
+			undo := context.WithoutCancel(ctx)
+			return nil, errors.Join(err, m.cfg.DB.WithContext(undo).Delete(a).Error, m.Release(undo, key))
+		}
+	}
+	return a, nil
+}
+
+// Attach links an attachment to the entity it belongs to.
+func (m *Media) Attach(ctx context.Context, id uint, entityType, entityID string) error {
+	return m.cfg.DB.WithContext(ctx).Model(&Attachment{}).Where("id = ?", id).
+		Updates(map[string]any{"entity_type": entityType, "entity_id": entityID}).Error
+}
+
+// ListByEntity returns the attachments of an entity, oldest first.
+func (m *Media) ListByEntity(ctx context.Context, entityType, entityID string) ([]Attachment, error) {
+	var out []Attachment
+	err := m.cfg.DB.WithContext(ctx).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("id ASC").Find(&out).Error
+	return out, err
+}
+
+// ListByOwner returns the attachments uploaded by a user, oldest first.
+func (m *Media) ListByOwner(ctx context.Context, ownerID string) ([]Attachment, error) {
+	var out []Attachment
+	err := m.cfg.DB.WithContext(ctx).Where("owner_id = ?", ownerID).Order("id ASC").Find(&out).Error
+	return out, err
+}
+
+// Disown keeps an attachment referenced by public content but removes what
+// identifies its uploader: the owner, the original file name and the location.
+func (m *Media) Disown(ctx context.Context, id uint) error {
+	return m.cfg.DB.WithContext(ctx).Model(&Attachment{}).Where("id = ?", id).
+		Updates(map[string]any{"owner_id": "", "filename": "attachment", "latitude": nil, "longitude": nil}).Error
+}
+
+// Variants returns the variants of an attachment.
+func (m *Media) Variants(ctx context.Context, id uint) ([]Variant, error) {
+	var out []Variant
+	err := m.cfg.DB.WithContext(ctx).Where("attachment_id = ?", id).Order("id ASC").Find(&out).Error
+	return out, err
+}
+
+// Get returns an attachment by ID.
+func (m *Media) Get(ctx context.Context, id uint) (*Attachment, error) {
+	var a Attachment
+	err := m.cfg.DB.WithContext(ctx).First(&a, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, transport.NotFound("attachment not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Open returns the content of the attachment, or of its named variant when variant
+// is not empty, with its content type and size. The caller must close the reader.
+func (m *Media) Open(ctx context.Context, a *Attachment, variant string) (io.ReadCloser, string, int64, error) {
+	key, contentType, size := a.Key, a.ContentType, a.Size
+	if variant != "" {
+		var v Variant
+		err := m.cfg.DB.WithContext(ctx).Where("attachment_id = ? AND name = ?", a.ID, variant).First(&v).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", 0, transport.NotFound("variant not found")
+		}
+		if err != nil {
+			return nil, "", 0, err
+		}
+		key, contentType, size = v.Key, v.ContentType, v.Size
+	}
+	r, _, err := m.cfg.Store.Get(ctx, key)
+	return r, contentType, size, err
+}
+
+// Delete removes an attachment and its blob when no other attachment shares it.
+func (m *Media) Delete(ctx context.Context, id uint) error {
+	a, err := m.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	variants, err := m.Variants(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = m.cfg.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("attachment_id = ?", id).Delete(&Variant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(a).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := []string{a.Key, rawKey(a.SHA256)}
+	for _, v := range variants {
+		keys = append(keys, v.Key)
+	}
+	return m.Release(ctx, keys...)
+}
+
+// SaveProcessed stores the processing results of an attachment, replacing its
+// previous variants, and releases the blobs it no longer uses.
+func (m *Media) SaveProcessed(ctx context.Context, a *Attachment, variants []Variant) error {
+	previous, err := m.Variants(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+
+	err = m.cfg.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(a).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("attachment_id = ?", a.ID).Delete(&Variant{}).Error; err != nil {
+			return err
+		}
+		for i := range variants {
+			variants[i].ID = 0
+			variants[i].AttachmentID = a.ID
+		}
+		if len(variants) == 0 {
+			return nil
+		}
+		return tx.Create(&variants).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := []string{rawKey(a.SHA256)}
+	for _, v := range previous {
+		keys = append(keys, v.Key)
+	}
+	return m.Release(ctx, keys...)
+}
+
+// Release deletes the blobs no longer referenced by any attachment or variant
+// of any tenant, since identical uploads of every tenant share their blob.
+func (m *Media) Release(ctx context.Context, keys ...string) error {
+	tx := m.cfg.DB.WithContext(tenant.WithoutScope(ctx))
+	var errs []error
+	for _, k := range keys {
+		var refs int64
+		err := tx.Model(&Attachment{}).Where(map[string]any{"key": k}).Count(&refs).Error
+		if err == nil && refs == 0 {
+			err = tx.Model(&Variant{}).Where(map[string]any{"key": k}).Count(&refs).Error
+		}
+		if err == nil && refs == 0 {
+			err = m.cfg.Store.Delete(ctx, k)
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Store returns the blob store.
+func (m *Media) Store() Store {
+	return m.cfg.Store
+}
+
+// Pending reports whether a is an image awaiting the image pipeline, whose raw
+// upload may still carry metadata such as the GPS location.
+func (m *Media) Pending(a *Attachment) bool {
+	return a.ProcessedAt == nil && strings.HasPrefix(a.ContentType, "image/")
+}
+
+// Link returns an expiring download URL for the attachment, or for the variant v when it is not nil.
+// Pending images have no links until they are processed.
+func (m *Media) Link(ctx context.Context, a *Attachment, v *Variant) (string, time.Time, error) {
+	if m.Pending(a) {
+		return "", time.Time{}, ErrPending
+	}
+	exp := m.now().Add(m.cfg.LinkTTL)
+
+	key, path := a.Key, strconv.FormatUint(uint64(a.ID), 10)
+	if v != nil {
+		key, path = v.Key, path+"/variants/"+url.PathEscape(v.Name)
+	}
+
+	if p, ok := m.cfg.Store.(Presigner); ok && m.cfg.DirectLinks {
+		u, err := p.PresignGet(ctx, key, m.cfg.LinkTTL)
+		return u, exp, err
+	}
+	if m.cfg.Signer == nil {
+		return "", time.Time{}, errors.New("storage: a signer is required for download links")
+	}
+
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(exp.Unix(), 10))
+	q.Set("sig", m.cfg.Signer.Sign(path, exp))
+	return fmt.Sprintf("%s/%s/content?%s", strings.TrimSuffix(m.cfg.BaseURL, "/"), path, q.Encode()), exp, nil
+}
+
+// VerifyLink checks a download link issued by Link. resource is the attachment ID,
+// followed by "/variants/<name>" for variant links.
+func (m *Media) VerifyLink(resource, expires, signature string) error {
+	if m.cfg.Signer == nil {
+		return ErrInvalidLink
+	}
+	return m.cfg.Signer.Verify(resource, expires, signature)
+}
+
+// allowed reports whether a content type is accepted.
+func (m *Media) allowed(contentType string) bool {
+	for _, t := range m.cfg.AllowedTypes {
+		if t == contentType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawKey returns the blob key of uploaded content with the given hash.
+func rawKey(sum string) string {
+	return "blobs/" + sum[:2] + "/" + sum
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config defines the connection to an S3-compatible service.
+type S3Config struct {
+	Endpoint  string // Endpoint is the service host and port, without scheme.
+	Region    string // Region is the bucket region (optional).
+	Bucket    string // Bucket holds the objects.
+	AccessKey string // AccessKey is the access key ID.
+	SecretKey string // SecretKey is the secret access key.
+	UseSSL    bool   // UseSSL enables HTTPS.
+}
+
+// s3Store stores objects in an S3-compatible bucket.
+type s3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store returns a Store backed by an S3-compatible bucket, creating it if missing.
+func NewS3Store(ctx context.Context, cfg S3Config) (Store, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, err
+		}
+	}
+	return &s3Store{client: client, bucket: cfg.Bucket}, nil
+}
+
+// Put uploads the object.
+func (s *s3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+// Get downloads the object.
+func (s *s3Store) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	obj, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, mapS3Error(err)
+	}
+	return r, obj, nil
+}
+
+// Stat returns the object metadata.
+func (s *s3Store) Stat(ctx context.Context, key string) (*Object, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, mapS3Error(err)
+	}
+	return &Object{Key: key, Size: info.Size, ContentType: info.ContentType, ModTime: info.LastModified}, nil
+}
+
+// Delete removes the object.
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// PresignGet returns a presigned download URL valid for ttl.
+func (s *s3Store) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, ttl, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// mapS3Error converts missing object responses into ErrNotFound.
+func mapS3Error(err error) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+	"gorm.io/gorm"
+)
+
+// SetupEnvironmentStore creates a Store from the provided environment.
+// STORAGE_DRIVER selects "local" (files under STORAGE_PATH) or "s3".
+func SetupEnvironmentStore(ctx context.Context) (Store, error) {
+
+	cfg := config.Get()
+
+	switch driver := config.MustGet(config.StorageDriver); driver {
+	case "local":
+		return NewLocalStore(config.MustGet(config.StoragePath))
+	case "s3":
+		return NewS3Store(ctx, S3Config{
+			Endpoint:  config.MustGet(config.S3Endpoint),
+			Region:    cfg.GetString(config.S3Region),
+			Bucket:    config.MustGet(config.S3Bucket),
+			AccessKey: cfg.GetString(config.S3AccessKey),
+			SecretKey: cfg.GetString(config.S3SecretKey),
+			UseSSL:    cfg.GetBool(config.S3UseSSL),
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", driver)
+	}
+
+}
+
+// SetupEnvironmentMedia creates a Media service on the environment store, signing
+// links served under baseURL with STORAGE_SIGNING_KEY.
+func SetupEnvironmentMedia(ctx context.Context, gdb *gorm.DB, baseURL string) (*Media, error) {
+
+	store, err := SetupEnvironmentStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize, err := strconv.ParseInt(config.MustGet(config.StorageMaxSize), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMedia(MediaConfig{
+		Store:   store,
+		DB:      gdb,
+		Signer:  NewSigner([]byte(config.MustGet(config.StorageSigningKey))),
+		BaseURL: baseURL,
+		MaxSize: maxSize,
+	}), nil
+
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Object describes a stored object.
+type Object struct {
+	Key         string    // Key is the object path within the store.
+	Size        int64     // Size is the object length in bytes.
+	ContentType string    // ContentType is the MIME type given on upload, when known.
+	ModTime     time.Time // ModTime is the last modification time.
+}
+
+// Store persists binary objects by key.
+type Store interface {
+
+	// Put writes an object of the given size, replacing any object with the same key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get opens an object for reading. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+
+	// Stat returns the object metadata, or ErrNotFound.
+	Stat(ctx context.Context, key string) (*Object, error)
+
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Presigner is implemented by stores able to issue their own expiring download URLs.
+type Presigner interface {
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
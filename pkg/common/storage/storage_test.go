@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// testStore exercises the Store contract on any backend.
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+	data := []byte("evidencia")
+
+	require.NoError(t, s.Put(ctx, "reports/1/photo", bytes.NewReader(data), int64(len(data)), "text/plain"))
+
+	obj, err := s.Stat(ctx, "reports/1/photo")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), obj.Size)
+
+	r, _, err := s.Get(ctx, "reports/1/photo")
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, data, got)
+
+	require.NoError(t, s.Delete(ctx, "reports/1/photo"))
+	_, err = s.Stat(ctx, "reports/1/photo")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, s.Delete(ctx, "reports/1/photo"))
+}
+
+// TestLocalStore checks the local backend and rejects keys escaping its root.
+func TestLocalStore(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	testStore(t, s)
+
+	err = s.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "")
+	assert.Error(t, err)
+}
+
+// TestS3Store checks the S3 backend against an in-memory S3 stand-in.
+func TestS3Store(t *testing.T) {
+	srv := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+	defer srv.Close()
+
+	s, err := NewS3Store(context.Background(), S3Config{
+		Endpoint:  strings.TrimPrefix(srv.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "media",
+		AccessKey: "key",
+		SecretKey: "secret",
+	})
+	require.NoError(t, err)
+	testStore(t, s)
+
+	link, err := s.(Presigner).PresignGet(context.Background(), "reports/1/photo", time.Minute)
+	require.NoError(t, err)
+	assert.Contains(t, link, "X-Amz-Signature")
+}
+
+// pngBytes returns a small PNG image.
+func pngBytes(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+	return buf.Bytes()
+}
+
+// newMedia returns a Media service on a local store and in-memory database.
+func newMedia(t *testing.T, maxSize int64) *Media {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(gdb))
+
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	return NewMedia(MediaConfig{Store: store, DB: gdb, Signer: NewSigner([]byte("secret")), BaseURL: "/media", MaxSize: maxSize})
+}
+
+// TestMediaUpload checks sniffing, limits and content deduplication.
+func TestMediaUpload(t *testing.T) {
+	m := newMedia(t, 1024)
+	ctx := context.Background()
+	img := pngBytes(t)
+
+	a, err := m.Upload(ctx, "user-1", `C:\fotos\hueco.png`, bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", a.ContentType)
+	assert.Equal(t, "hueco.png", a.Filename)
+	assert.Equal(t, int64(len(img)), a.Size)
+
+	b, err := m.Upload(ctx, "user-2", "copia.png", bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, a.Key, b.Key)
+	assert.NotEqual(t, a.ID, b.ID)
+
+	_, err = m.Upload(ctx, "user-1", "script.html", strings.NewReader("<html><script>alert(1)</script></html>"))
+	assert.Equal(t, 415, transport.CodeOf(err))
+
+	big := append(append([]byte{}, img...), make([]byte, 2048)...)
+	_, err = m.Upload(ctx, "user-1", "big.png", bytes.NewReader(big))
+	assert.Equal(t, 413, transport.CodeOf(err))
+
+	owned, err := m.ListByOwner(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	assert.Equal(t, a.ID, owned[0].ID)
+
+	require.NoError(t, m.Delete(ctx, a.ID))
+	_, err = m.cfg.Store.Stat(ctx, b.Key)
+	require.NoError(t, err, "blob is kept while another attachment uses it")
+
+	require.NoError(t, m.Disown(ctx, b.ID))
+	b, err = m.Get(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Empty(t, b.OwnerID)
+	assert.Equal(t, "attachment", b.Filename)
+
+	require.NoError(t, m.Delete(ctx, b.ID))
+	_, err = m.cfg.Store.Stat(ctx, b.Key)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestMediaUploadRestoresReleasedBlob checks an upload stores its content again when
+// a concurrent release removed the shared blob.
+func TestMediaUploadRestoresReleasedBlob(t *testing.T) {
+	m := newMedia(t, 1024)
+	ctx := context.Background()
+	img := pngBytes(t)
+
+	a, err := m.Upload(ctx, "user-1", "hueco.png", bytes.NewReader(img))
+	require.NoError(t, err)
+	require.NoError(t, m.cfg.Store.Delete(ctx, a.Key))
+
+	b, err := m.Upload(ctx, "user-2", "copia.png", bytes.NewReader(img))
+	require.NoError(t, err)
+	_, err = m.cfg.Store.Stat(ctx, b.Key)
+	assert.NoError(t, err)
+}
+
+// TestMediaUploadHookFailure checks a failed upload hook leaves neither the attachment nor its blob.
+func TestMediaUploadHookFailure(t *testing.T) {
+	m := newMedia(t, 1024)
+	ctx := context.Background()
+	hookErr := errors.New("queue unavailable")
+	m.cfg.OnUpload = func(context.Context, *Attachment) error { return hookErr }
+
+	img := pngBytes(t)
+	_, err := m.Upload(ctx, "user-1", "hueco.png", bytes.NewReader(img))
+	assert.ErrorIs(t, err, hookErr)
+
+	var n int64
+	require.NoError(t, m.cfg.DB.Model(&Attachment{}).Count(&n).Error)
+	assert.Zero(t, n)
+	sum := sha256.Sum256(img)
+	_, err = m.cfg.Store.Stat(ctx, rawKey(hex.EncodeToString(sum[:])))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestMediaLinks checks signed links verify until they expire.
+func TestMediaLinks(t *testing.T) {
+	m := newMedia(t, 0)
+	ctx := context.Background()
+
+	a, err := m.Upload(ctx, "user-1", "hueco.png", bytes.NewReader(pngBytes(t)))
+	require.NoError(t, err)
+
+	_, _, err = m.Link(ctx, a, nil)
+	assert.ErrorIs(t, err, ErrPending, "raw images get no links")
+
+	processed := time.Now()
+	a.ProcessedAt = &processed
+	link, _, err := m.Link(ctx, a, nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(link, "/media/1/content?"))
+
+	q := link[strings.Index(link, "?")+1:]
+	values := map[string]string{}
+	for _, kv := range strings.Split(q, "&") {
+		k, v, _ := strings.Cut(kv, "=")
+		values[k] = v
+	}
+
+	assert.NoError(t, m.VerifyLink("1", values["expires"], values["sig"]))
+	assert.ErrorIs(t, m.VerifyLink("2", values["expires"], values["sig"]), ErrInvalidLink)
+
+	m.cfg.Signer.now = func() time.Time { return time.Now().Add(time.Hour) }
+	assert.ErrorIs(t, m.VerifyLink("1", values["expires"], values["sig"]), ErrInvalidLink)
+}
+
+// TestMediaTenants checks attachments are only found in their tenant while identical
+// uploads of every tenant share one blob.
+func TestMediaTenants(t *testing.T) {
+	m := newMedia(t, 1024)
+	require.NoError(t, tenant.Register(m.cfg.DB))
+	bogota := tenant.NewContext(context.Background(), "bogota")
+	cali := tenant.NewContext(context.Background(), "cali")
+	img := pngBytes(t)
+
+	a, err := m.Upload(bogota, "user-1", "hueco.png", bytes.NewReader(img))
+	require.NoError(t, err)
+	b, err := m.Upload(cali, "user-2", "hueco.png", bytes.NewReader(img))
+	require.NoError(t, err)
+
+	_, err = m.Get(cali, a.ID)
+	assert.Equal(t, 404, transport.CodeOf(err))
+
+	require.NoError(t, m.Delete(bogota, a.ID))
+	_, err = m.cfg.Store.Stat(context.Background(), b.Key)
+	assert.NoError(t, err, "the blob is kept while another tenant uses it")
+}
@@ -0,0 +1,116 @@
+package tenant
+
+import (
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// Resolver finds the tenant of a request from its host, a header and the
+// token claims.
+type Resolver struct {
+	Header  string            // Header carries the tenant ID (default: X-Tenant-ID).
+	Claim   string            // Claim is the token claim holding the tenant of the user (default: tenant).
+	Domain  string            // Domain resolves "<tenant>.<Domain>" hosts (optional).
+	Hosts   map[string]string // Hosts maps custom domains to tenants (optional).
+	Tenants []string          // Tenants lists the known tenants; any tenant is accepted when empty.
+}
+
+// Request holds the request values read by a Resolver.
+type Request struct {
+	Host   string         // Host is the request host, with or without port.
+	Header string         // Header is the value of the tenant header.
+	Claims map[string]any // Claims are the claims of the authenticated caller, if any.
+}
+
+// Resolve returns the tenant of a request. The token claim is authoritative:
+// a host or header naming another tenant is rejected, so users cannot reach
+// another municipality by changing the URL.
+func (r *Resolver) Resolve(req Request) (string, error) {
+	var found []string
+	if id := r.fromHost(req.Host); id != "" {
+		found = append(found, id)
+	}
+	if req.Header != "" {
+		found = append(found, req.Header)
+	}
+	if id, _ := req.Claims[r.claim()].(string); id != "" {
+		found = append(found, id)
+	}
+
+	if len(found) == 0 {
+		return "", transport.BadRequest("the tenant could not be resolved")
+	}
+	id := found[len(found)-1]
+	for _, other := range found {
+		if other != id {
+			return "", transport.Forbidden("the request targets another tenant")
+		}
+	}
+	if len(r.Tenants) > 0 && !slices.Contains(r.Tenants, id) {
+		return "", transport.NotFound("unknown tenant " + id)
+	}
+	return id, nil
+}
+
+// HeaderName returns the header carrying the tenant ID.
+func (r *Resolver) HeaderName() string {
+	if r.Header == "" {
+		return "X-Tenant-ID"
+	}
+	return r.Header
+}
+
+// claim returns the token claim holding the tenant.
+func (r *Resolver) claim() string {
+	if r.Claim == "" {
+		return "tenant"
+	}
+	return r.Claim
+}
+
+// fromHost returns the tenant of a host, if any.
+func (r *Resolver) fromHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if id, ok := r.Hosts[host]; ok {
+		return id
+	}
+	if r.Domain == "" {
+		return ""
+	}
+	sub, ok := strings.CutSuffix(host, "."+strings.ToLower(r.Domain))
+	if !ok || sub == "" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
+
+// SetupEnvironmentResolver creates a Resolver from the provided environment.
+// TENANT_HOSTS maps custom domains as "host=tenant" pairs separated by commas.
+func SetupEnvironmentResolver() *Resolver {
+	cfg := config.Get()
+	r := &Resolver{
+		Header: cfg.GetString(config.TenantHeader),
+		Claim:  cfg.GetString(config.TenantClaim),
+		Domain: cfg.GetString(config.TenantDomain),
+		Hosts:  map[string]string{},
+	}
+	for _, id := range strings.Split(cfg.GetString(config.Tenants), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			r.Tenants = append(r.Tenants, id)
+		}
+	}
+	for _, pair := range strings.Split(cfg.GetString(config.TenantHosts), ",") {
+		host, id, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok {
+			r.Hosts[strings.ToLower(strings.TrimSpace(host))] = strings.TrimSpace(id)
+		}
+	}
+	return r
+}
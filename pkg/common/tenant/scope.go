@@ -0,0 +1,237 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrNoTenant is returned when a tenant-scoped model is used without a tenant in the context.
+	ErrNoTenant = errors.New("tenant: no tenant in context")
+	// ErrCrossTenant is returned when writing a row of another tenant.
+	ErrCrossTenant = errors.New("tenant: row belongs to another tenant")
+)
+
+// Column is the column identifying the tenant of scoped models.
+const Column = "tenant_id"
+
+// scoper holds the scoping callbacks of a database.
+type scoper struct{}
+
+// Register scopes every model with a tenant_id column, such as those embedding
+// Owned, to the tenant of the statement context: queries, updates and deletes
+// only match its rows, creates are stamped with it and writes moving a row to
+// another tenant fail. Raw SQL is not scoped. Jobs run in the tenant enqueuing
+// them, so their writes are scoped alike.
+func Register(db *gorm.DB) error {
+	s := scoper{}
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("tenant:create", s.create),
+		cb.Query().Before("gorm:query").Register("tenant:query", s.query),
+		cb.Row().Before("gorm:row").Register("tenant:row", s.query),
+		cb.Update().Before("gorm:update").Register("tenant:update", s.update),
+		cb.Delete().Before("gorm:delete").Register("tenant:delete", s.delete),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field returns the tenant field of the statement model, if scoped.
+func field(db *gorm.DB) *schema.Field {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil
+	}
+	f := db.Statement.Schema.LookUpField(Column)
+	if f == nil || f.DBName != Column {
+		return nil
+	}
+	return f
+}
+
+// current returns the tenant of the statement, or false when not scoped.
+func current(db *gorm.DB) (string, bool) {
+	ctx := db.Statement.Context
+	if unscoped(ctx) {
+		return "", false
+	}
+	id, ok := FromContext(ctx)
+	if !ok {
+		_ = db.AddError(ErrNoTenant)
+	}
+	return id, ok
+}
+
+// create stamps the created rows with the tenant and restricts upserts to its rows.
+func (scoper) create(db *gorm.DB) {
+	f := field(db)
+	if f == nil {
+		return
+	}
+	stmt := db.Statement
+	id, ok := current(db)
+	if !ok {
+		if db.Error == nil {
+			each(stmt.ReflectValue, func(row reflect.Value) {
+				if v, zero := f.ValueOf(stmt.Context, row); zero || v == "" {
+					_ = db.AddError(fmt.Errorf("%w: %s has no tenant", ErrNoTenant, stmt.Schema.Name))
+				}
+			})
+		}
+		return
+	}
+
+	if m, ok := stmt.Dest.(map[string]any); ok {
+		if err := stamp(m, f, id); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+	each(stmt.ReflectValue, func(row reflect.Value) {
+		if err := set(stmt.Context, f, row, id); err != nil {
+			_ = db.AddError(err)
+		}
+	})
+
+	if c, ok := stmt.Clauses["ON CONFLICT"]; ok {
+		if oc, ok := c.Expression.(clause.OnConflict); ok && (oc.UpdateAll || len(oc.DoUpdates) > 0) {
+			if db.Dialector.Name() == "mysql" {
+				_ = db.AddError(fmt.Errorf("tenant: upserts of %s are not supported on mysql", stmt.Schema.Name))
+				return
+			}
+			oc.Where.Exprs = append(oc.Where.Exprs, clause.Eq{Column: clause.Column{Table: stmt.Table, Name: Column}, Value: id})
+			stmt.AddClause(oc)
+		}
+	}
+}
+
+// query restricts queries to the rows of the tenant.
+func (scoper) query(db *gorm.DB) {
+	if field(db) == nil {
+		return
+	}
+	if id, ok := current(db); ok {
+		where(db, id)
+	}
+}
+
+// update restricts updates to the rows of the tenant and keeps updated values in it.
+func (scoper) update(db *gorm.DB) {
+	f := field(db)
+	if f == nil {
+		return
+	}
+	id, ok := current(db)
+	if !ok {
+		return
+	}
+	stmt := db.Statement
+
+	switch d := stmt.Dest.(type) {
+	case map[string]any:
+		for _, k := range []string{f.DBName, f.Name} {
+			if v, ok := d[k]; ok && v != id {
+				_ = db.AddError(ErrCrossTenant)
+				return
+			}
+		}
+	default:
+		if rv := reflect.Indirect(reflect.ValueOf(d)); rv.Kind() == reflect.Struct && rv.Type() == stmt.Schema.ModelType && rv.CanAddr() {
+			if err := set(stmt.Context, f, rv, id); err != nil {
+				_ = db.AddError(err)
+				return
+			}
+		}
+	}
+	if guarded(db) {
+		where(db, id)
+	}
+}
+
+// delete restricts deletes to the rows of the tenant.
+func (scoper) delete(db *gorm.DB) {
+	if field(db) == nil {
+		return
+	}
+	if id, ok := current(db); ok && guarded(db) {
+		where(db, id)
+	}
+}
+
+// guarded reports whether an update or delete has conditions, so the tenant
+// condition does not turn a rejected global update into a tenant-wide one.
+func guarded(db *gorm.DB) bool {
+	stmt := db.Statement
+	if _, ok := stmt.Clauses["WHERE"]; ok || db.AllowGlobalUpdate {
+		return true
+	}
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return false
+	}
+	found := false
+	each(stmt.ReflectValue, func(row reflect.Value) {
+		if _, zero := pk.ValueOf(stmt.Context, row); !zero {
+			found = true
+		}
+	})
+	return found
+}
+
+// where adds the tenant condition to the statement. Existing conditions are
+// grouped first, so an OR condition cannot match rows of other tenants.
+func where(db *gorm.DB, id string) {
+	exprs := []clause.Expression{clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: Column}, Value: id}}
+	c, ok := db.Statement.Clauses["WHERE"]
+	if w, isWhere := c.Expression.(clause.Where); ok && isWhere && len(w.Exprs) > 0 {
+		exprs = append(exprs, clause.Expr{SQL: "(?)", Vars: []any{clause.AndConditions{Exprs: w.Exprs}}})
+	}
+	c.Name = "WHERE"
+	c.Expression = clause.Where{Exprs: exprs}
+	db.Statement.Clauses["WHERE"] = c
+}
+
+// set stamps a row with the tenant, failing when it names another one.
+func set(ctx context.Context, f *schema.Field, row reflect.Value, id string) error {
+	v, zero := f.ValueOf(ctx, row)
+	if zero || v == "" {
+		return f.Set(ctx, row, id)
+	}
+	if v != id {
+		return ErrCrossTenant
+	}
+	return nil
+}
+
+// stamp sets the tenant in a map of values, failing when it names another one.
+func stamp(m map[string]any, f *schema.Field, id string) error {
+	for _, k := range []string{f.DBName, f.Name} {
+		if v, ok := m[k]; ok && v != id && v != "" {
+			return ErrCrossTenant
+		}
+		delete(m, k)
+	}
+	m[f.DBName] = id
+	return nil
+}
+
+// each calls fn with every addressable row of a struct or slice value.
+func each(rv reflect.Value, fn func(reflect.Value)) {
+	rv = reflect.Indirect(rv)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			fn(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		fn(rv)
+	}
+}
@@ -0,0 +1,84 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+)
+
+// Owned is embedded by models belonging to a tenant. Every GORM query, update
+// and delete on such models is scoped to the tenant of the context once
+// Register is called, and creates are stamped with it.
+type Owned struct {
+	TenantID string `gorm:"size:64;index;not null" json:"tenant_id"` // TenantID is the municipality owning the row.
+}
+
+type idKey struct{}
+
+type unscopedKey struct{}
+
+// NewContext returns a copy of ctx carrying the tenant ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the tenant ID carried by ctx, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(idKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithoutScope returns a copy of ctx reading and writing every tenant, for
+// platform jobs such as key rotation. Creates must still set the tenant ID.
+func WithoutScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+// unscoped reports whether ctx was created by WithoutScope.
+func unscoped(ctx context.Context) bool {
+	v, _ := ctx.Value(unscopedKey{}).(bool)
+	return v
+}
+
+// nonWord matches the characters not allowed in environment variable names.
+var nonWord = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Key returns the config key overriding key for a tenant, e.g.,
+// TENANT_SAN_ANDRES_SMTP_FROM for tenant "san-andres" and SMTP_FROM.
+func Key(id, key string) string {
+	return "TENANT_" + strings.ToUpper(nonWord.ReplaceAllString(id, "_")) + "_" + key
+}
+
+// lookup returns the config key holding the value of key for the tenant of ctx.
+func lookup(ctx context.Context, key string) string {
+	if id, ok := FromContext(ctx); ok {
+		if k := Key(id, key); config.Get().IsSet(k) {
+			return k
+		}
+	}
+	return key
+}
+
+// String returns a config value for the tenant of ctx, falling back to the
+// shared value when the tenant does not override it.
+func String(ctx context.Context, key string) string {
+	return config.Get().GetString(lookup(ctx, key))
+}
+
+// Int returns an integer config value for the tenant of ctx.
+func Int(ctx context.Context, key string) int {
+	return config.Get().GetInt(lookup(ctx, key))
+}
+
+// Bool returns a boolean config value for the tenant of ctx.
+func Bool(ctx context.Context, key string) bool {
+	return config.Get().GetBool(lookup(ctx, key))
+}
+
+// Duration returns a duration config value for the tenant of ctx.
+func Duration(ctx context.Context, key string) time.Duration {
+	return config.Get().GetDuration(lookup(ctx, key))
+}
@@ -0,0 +1,165 @@
+package tenant
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ianfedev/civicspot-backend/pkg/common/config"
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// issue is a tenant-scoped model.
+type issue struct {
+	ID uint `gorm:"primaryKey"`
+	Owned
+	Title string
+}
+
+// category is a model shared by every tenant.
+type category struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+// newDB returns a sqlite database with tenant scoping and an issue per tenant.
+func newDB(t *testing.T) (*gorm.DB, *issue, *issue) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "tenant.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&issue{}, &category{}))
+	require.NoError(t, Register(gdb))
+
+	repo := db.NewRepository[issue](gdb)
+	a, b := &issue{Title: "Bache"}, &issue{Title: "Poste caído"}
+	require.NoError(t, repo.Create(NewContext(context.Background(), "bogota"), a))
+	require.NoError(t, repo.Create(NewContext(context.Background(), "cali"), b))
+	return gdb, a, b
+}
+
+// TestScopeReads checks a tenant cannot read the rows of another one.
+func TestScopeReads(t *testing.T) {
+	gdb, a, b := newDB(t)
+	repo := db.NewRepository[issue](gdb)
+	ctx := NewContext(context.Background(), "bogota")
+	assert.Equal(t, "bogota", a.TenantID)
+	assert.Equal(t, "cali", b.TenantID)
+
+	list, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, a.ID, list[0].ID)
+
+	_, err = repo.GetByID(ctx, b.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repo.GetByID(ctx, b.ID, func(q *gorm.DB) *gorm.DB { return q.Or("1 = 1") })
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "conditions cannot escape the tenant")
+
+	var count int64
+	require.NoError(t, gdb.WithContext(ctx).Model(&issue{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	var titles []string
+	require.NoError(t, gdb.WithContext(ctx).Model(&issue{}).Pluck("title", &titles).Error)
+	assert.Equal(t, []string{"Bache"}, titles)
+	rows, err := gdb.WithContext(ctx).Model(&issue{}).Rows()
+	require.NoError(t, err)
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	require.NoError(t, rows.Close())
+	assert.Equal(t, 1, n)
+
+	var streamed []uint
+	require.NoError(t, repo.Stream(ctx, func(i *issue) error { streamed = append(streamed, i.ID); return nil }))
+	assert.Equal(t, []uint{a.ID}, streamed)
+
+	_, err = repo.List(context.Background())
+	assert.ErrorIs(t, err, ErrNoTenant)
+	all, err := repo.List(WithoutScope(context.Background()))
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	require.NoError(t, gdb.Create(&category{Name: "Vías"}).Error, "models without tenant are shared")
+	var categories []category
+	require.NoError(t, gdb.Find(&categories).Error)
+	assert.Len(t, categories, 1)
+}
+
+// TestScopeWrites checks a tenant cannot create, update or delete the rows of another one.
+func TestScopeWrites(t *testing.T) {
+	gdb, a, b := newDB(t)
+	repo := db.NewRepository[issue](gdb)
+	ctx := NewContext(context.Background(), "bogota")
+
+	err := repo.Create(ctx, &issue{Owned: Owned{TenantID: "cali"}, Title: "X"})
+	assert.ErrorIs(t, err, ErrCrossTenant)
+	err = repo.Create(WithoutScope(context.Background()), &issue{Title: "X"})
+	assert.ErrorIs(t, err, ErrNoTenant)
+
+	require.NoError(t, repo.Update(ctx, &issue{ID: b.ID, Title: "Hijacked"}))
+	assert.ErrorIs(t, repo.Update(ctx, &issue{ID: b.ID, Owned: Owned{TenantID: "cali"}, Title: "Hijacked"}), ErrCrossTenant)
+	assert.ErrorIs(t, gdb.WithContext(ctx).Model(a).Update("tenant_id", "cali").Error, ErrCrossTenant)
+	require.NoError(t, gdb.WithContext(ctx).Model(&issue{}).Where("id = ?", b.ID).Update("title", "Hijacked").Error)
+	assert.ErrorIs(t, gdb.WithContext(ctx).Model(&issue{}).Update("title", "All").Error, gorm.ErrMissingWhereClause)
+
+	require.NoError(t, repo.Delete(ctx, b.ID))
+	require.NoError(t, gdb.WithContext(ctx).Where("1 = 1").Delete(&issue{}).Error)
+
+	all, err := repo.List(WithoutScope(context.Background()))
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, b.ID, all[0].ID)
+	assert.Equal(t, "Poste caído", all[0].Title)
+	assert.Equal(t, "cali", all[0].TenantID)
+
+	a.ID = 0
+	require.NoError(t, repo.Update(ctx, a), "saving a new row creates it in the tenant")
+	got, err := repo.GetByID(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "bogota", got.TenantID)
+}
+
+// TestResolver checks tenants are resolved from the host, the header and the claim.
+func TestResolver(t *testing.T) {
+	r := &Resolver{Domain: "civicspot.co", Hosts: map[string]string{"pqrs.cali.gov.co": "cali"}, Tenants: []string{"bogota", "cali"}}
+
+	id, err := r.Resolve(Request{Host: "bogota.civicspot.co:443"})
+	require.NoError(t, err)
+	assert.Equal(t, "bogota", id)
+	id, err = r.Resolve(Request{Host: "PQRS.cali.gov.co"})
+	require.NoError(t, err)
+	assert.Equal(t, "cali", id)
+	id, err = r.Resolve(Request{Host: "api.civicspot.io", Header: "cali", Claims: map[string]any{"tenant": "cali"}})
+	require.NoError(t, err)
+	assert.Equal(t, "cali", id)
+
+	_, err = r.Resolve(Request{Host: "bogota.civicspot.co", Claims: map[string]any{"tenant": "cali"}})
+	assert.Equal(t, 403, transport.CodeOf(err))
+	_, err = r.Resolve(Request{Host: "bogota.civicspot.co", Header: "cali"})
+	assert.Equal(t, 403, transport.CodeOf(err))
+	_, err = r.Resolve(Request{Header: "medellin"})
+	assert.Equal(t, 404, transport.CodeOf(err))
+	_, err = r.Resolve(Request{Host: "a.b.civicspot.co"})
+	assert.Equal(t, 400, transport.CodeOf(err))
+	assert.Equal(t, "X-Tenant-ID", r.HeaderName())
+}
+
+// TestConfig checks tenants override shared config values.
+func TestConfig(t *testing.T) {
+	config.Init("", nil)
+	t.Setenv("SMTP_FROM", "CivicSpot <no-reply@civicspot.co>")
+	t.Setenv("TENANT_SAN_ANDRES_SMTP_FROM", "Alcaldía <pqrs@sanandres.gov.co>")
+	t.Setenv("TENANT_SAN_ANDRES_STORAGE_MAX_SIZE", "1024")
+
+	ctx := NewContext(context.Background(), "san-andres")
+	assert.Equal(t, "TENANT_SAN_ANDRES_SMTP_FROM", Key("san-andres", config.SmtpFrom))
+	assert.Equal(t, "Alcaldía <pqrs@sanandres.gov.co>", String(ctx, config.SmtpFrom))
+	assert.Equal(t, 1024, Int(ctx, config.StorageMaxSize))
+	assert.Equal(t, "CivicSpot <no-reply@civicspot.co>", String(NewContext(context.Background(), "cali"), config.SmtpFrom))
+	assert.Equal(t, "CivicSpot <no-reply@civicspot.co>", String(context.Background(), config.SmtpFrom))
+}
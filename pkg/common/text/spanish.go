@@ -0,0 +1,53 @@
+package text
+
+import "strings"
+
+// stopwords are frequent Spanish words carrying no meaning for matching, already folded.
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a al algo algun alguna algunas alguno algunos ante antes aqui asi aun
+		bajo bien cada casi como con contra cual cuales cuando de del desde donde dos e el ella ellas ellos
+		en entre era eran es esa esas ese eso esos esta estaba estado estan estar estas este esto estos
+		fue fueron ha habia hace hacia han hasta hay la las le les lo los mas me mi mis mismo mucho muy
+		nada ni no nos nosotros o otra otras otro otros para pero poco por porque que quien se sea ser
+		si sido sin sobre solo son su sus tambien tan tanto te tiene tienen todo todos tras tu tus un una
+		unas uno unos usted ustedes va van y ya yo`) {
+		stopwords[w] = true
+	}
+}
+
+// IsStopword reports whether a folded word is a Spanish stop word.
+func IsStopword(w string) bool {
+	return stopwords[w]
+}
+
+// Stem reduces a folded Spanish word to a crude root by removing plural endings
+// and a final "e", so "huecos" and "hueco", "calles" and "calle" or "luces" and
+// "luz" share a term.
+func Stem(w string) string {
+	n := len(w)
+	switch {
+	case n > 4 && strings.HasSuffix(w, "ces"):
+		return w[:n-3] + "z"
+	case n > 4 && strings.HasSuffix(w, "es"):
+		return w[:n-2]
+	case n > 3 && strings.HasSuffix(w, "s"):
+		return w[:n-1]
+	case n > 3 && strings.HasSuffix(w, "e"):
+		return w[:n-1]
+	}
+	return w
+}
+
+// Terms returns the stemmed, folded words of s without stop words, for similarity and search.
+func Terms(s string) []string {
+	var terms []string
+	for _, w := range Words(s) {
+		if len(w) < 2 || IsStopword(w) {
+			continue
+		}
+		terms = append(terms, Stem(w))
+	}
+	return terms
+}
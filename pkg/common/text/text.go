@@ -0,0 +1,64 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a word of a text with its byte offsets.
+type Token struct {
+	Text  string // Text is the word as written.
+	Start int    // Start is the byte offset of the first character.
+	End   int    // End is the byte offset after the last character.
+}
+
+// Fold lowercases s and strips Spanish diacritics so "Vía" matches "via".
+func Fold(s string) string {
+	return folder.Replace(strings.ToLower(s))
+}
+
+var folder = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n", "à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u")
+
+// Tokenize splits s into words made of letters and digits.
+func Tokenize(s string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range s {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && start >= 0:
+			tokens = append(tokens, Token{Text: s[start:i], Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Text: s[start:], Start: start, End: len(s)})
+	}
+	return tokens
+}
+
+// Words returns the folded words of s.
+func Words(s string) []string {
+	tokens := Tokenize(s)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = Fold(t.Text)
+	}
+	return words
+}
+
+// Mask replaces every letter and digit of s with r, keeping its length in characters.
+func Mask(s string, r rune) string {
+	var b strings.Builder
+	b.Grow(utf8.RuneCountInString(s))
+	for _, c := range s {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			c = r
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFold checks case and accent folding.
+func TestFold(t *testing.T) {
+	assert.Equal(t, "via publica en el barrio san jose, pequeno", Fold("Vía Pública en el barrio San José, pequeño"))
+}
+
+// TestTokenize checks words keep their offsets in the original text.
+func TestTokenize(t *testing.T) {
+	s := "¡Hueco en la Cl. 26!"
+	tokens := Tokenize(s)
+	assert.Equal(t, []string{"Hueco", "en", "la", "Cl", "26"}, []string{tokens[0].Text, tokens[1].Text, tokens[2].Text, tokens[3].Text, tokens[4].Text})
+	for _, tok := range tokens {
+		assert.Equal(t, tok.Text, s[tok.Start:tok.End])
+	}
+	assert.Equal(t, []string{"arbol", "caido"}, Words("Árbol caído"))
+	assert.Equal(t, "*** ***-***", Mask("abc 123-456", '*'))
+}
+
+// TestTerms checks stop words are dropped and plurals share a stem.
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"hueco", "grand", "call", "semaforo", "luz"}, Terms("Los huecos grandes de la calle y los semáforos sin luces"))
+	assert.Equal(t, "arbol", Stem("arboles"))
+	assert.Equal(t, "via", Stem("vias"))
+	assert.Equal(t, Stem("calle"), Stem("calles"))
+}
+
+// TestCosine checks TF-IDF similarity ranks related texts above unrelated ones.
+func TestCosine(t *testing.T) {
+	docs := [][]string{
+		Terms("Hueco enorme en la avenida Boyacá con calle 80"),
+		Terms("Semáforo dañado en la calle 80"),
+		Terms("Basuras acumuladas en el parque"),
+	}
+	c := NewCorpus(docs...)
+	q := c.Vector(Terms("Huecos en la Av. Boyacá"))
+
+	hole := Cosine(q, c.Vector(docs[0]))
+	light := Cosine(q, c.Vector(docs[1]))
+	trash := Cosine(q, c.Vector(docs[2]))
+
+	assert.Greater(t, hole, light)
+	assert.Greater(t, light, trash-1e-9)
+	assert.Zero(t, trash)
+	assert.InDelta(t, 1, Cosine(q, q), 1e-9)
+}
@@ -0,0 +1,66 @@
+package text
+
+import "math"
+
+// Vector is a sparse term weight vector.
+type Vector map[string]float64
+
+// Corpus holds the document frequencies used to weight terms by TF-IDF.
+type Corpus struct {
+	docs int
+	df   map[string]int
+}
+
+// NewCorpus creates a Corpus from the terms of each document.
+func NewCorpus(docs ...[]string) *Corpus {
+	c := &Corpus{df: make(map[string]int)}
+	for _, d := range docs {
+		c.Add(d)
+	}
+	return c
+}
+
+// Add counts a document in the corpus.
+func (c *Corpus) Add(terms []string) {
+	c.docs++
+	seen := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			c.df[t]++
+		}
+	}
+}
+
+// Vector returns the TF-IDF vector of a document, using a smoothed IDF so terms
+// missing from the corpus still count.
+func (c *Corpus) Vector(terms []string) Vector {
+	v := make(Vector, len(terms))
+	for _, t := range terms {
+		v[t]++
+	}
+	for t, tf := range v {
+		idf := math.Log(float64(1+c.docs)/float64(1+c.df[t])) + 1
+		v[t] = tf * idf
+	}
+	return v
+}
+
+// Cosine returns the cosine similarity of two vectors, from 0 to 1 for non-negative weights.
+func Cosine(a, b Vector) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot, na, nb float64
+	for t, w := range a {
+		dot += w * b[t]
+		na += w * w
+	}
+	for _, w := range b {
+		nb += w * w
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -39,6 +39,16 @@ func BadRequest(msg string) *AppError {
 	return New(400, msg, nil)
 }
 
+// Unauthorized creates an unauthorized error
+func Unauthorized(msg string) *AppError {
+	return New(401, msg, nil)
+}
+
+// Forbidden creates a forbidden error
+func Forbidden(msg string) *AppError {
+	return New(403, msg, nil)
+}
+
 // NotFound creates a not found error
 func NotFound(msg string) *AppError {
 	return New(404, msg, nil)
@@ -0,0 +1,88 @@
+package fiber
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/analytics"
+	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// RegisterAnalyticsRoutes mounts read-only statistics routes (OpList):
+//
+//	GET basePath/counts?by=status|category|municipality|agency
+//	GET basePath/resolution
+//	GET basePath/sla
+//	GET basePath/top?limit=10
+//	GET basePath/series?interval=day|week|month
+//
+// Every route accepts the "municipality", "category" and "agency" filters and a
+// "from"/"to" range as RFC 3339 timestamps or YYYY-MM-DD dates.
+func RegisterAnalyticsRoutes(app *fiber.App, basePath string, svc *analytics.Service, opts ...RouteOption) {
+
+	o := newRouteOptions(opts)
+
+	route := func(path string, h func(c *fiber.Ctx, f analytics.Filter) (any, error)) {
+		app.Get(basePath+path, o.chain(endpoint.OpList, func(c *fiber.Ctx) error {
+			f, err := analyticsFilter(c)
+			if err != nil {
+				return EncodeError(c, err)
+			}
+			res, err := h(c, f)
+			if err != nil {
+				return EncodeError(c, err)
+			}
+			return c.JSON(res)
+		})...)
+	}
+
+	route("/counts", func(c *fiber.Ctx, f analytics.Filter) (any, error) {
+		return svc.Counts(c.UserContext(), f, c.Query("by", "status"))
+	})
+	route("/resolution", func(c *fiber.Ctx, f analytics.Filter) (any, error) {
+		return svc.Resolution(c.UserContext(), f)
+	})
+	route("/sla", func(c *fiber.Ctx, f analytics.Filter) (any, error) {
+		return svc.Compliance(c.UserContext(), f)
+	})
+	route("/top", func(c *fiber.Ctx, f analytics.Filter) (any, error) {
+		return svc.TopVoted(c.UserContext(), f, c.QueryInt("limit"))
+	})
+	route("/series", func(c *fiber.Ctx, f analytics.Filter) (any, error) {
+		return svc.Series(c.UserContext(), f, analytics.Interval(c.Query("interval")))
+	})
+
+}
+
+// analyticsFilter parses the common statistics query parameters.
+func analyticsFilter(c *fiber.Ctx) (analytics.Filter, error) {
+	f := analytics.Filter{
+		Municipality: c.Query("municipality"),
+		Category:     c.Query("category"),
+		AgencyID:     c.Query("agency"),
+	}
+	var err error
+	if f.From, err = parseInstant(c.Query("from")); err != nil {
+		return f, err
+	}
+	if f.To, err = parseInstant(c.Query("to")); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// parseInstant parses an RFC 3339 timestamp or a YYYY-MM-DD date; empty gives the zero time.
+func parseInstant(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, transport.BadRequest("invalid date " + s)
+	}
+	return t, nil
+}
@@ -0,0 +1,25 @@
+package fiber
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/audit"
+	types "github.com/ianfedev/civicspot-backend/pkg/common/domain"
+)
+
+// RequestID stores the X-Request-ID header, or a random ID when missing, in the
+// request user context so audit entries can be correlated. The ID is echoed in
+// the response header.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if id == "" || len(id) > 64 {
+			var err error
+			if id, err = types.NewID(); err != nil {
+				return EncodeError(c, err)
+			}
+		}
+		c.Set(fiber.HeaderXRequestID, id)
+		c.SetUserContext(audit.WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
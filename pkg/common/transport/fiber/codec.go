@@ -1,28 +1,42 @@
 package fiber
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
 	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 var validate = validator.New()
 
+// schemas caches the parsed models of bulk requests.
+var schemas sync.Map
+
 // EncodeResponse writes a generic response as JSON, handling errors if present.
 func EncodeResponse[T any](c *fiber.Ctx, resp endpoint.Response[T]) error {
 
 	if resp.Err != nil {
-		code := transport.CodeOf(resp.Err)
-		return c.Status(code).JSON(fiber.Map{
-			"error": resp.Err.Error(),
-		})
+		return EncodeError(c, resp.Err)
 	}
 
 	return c.JSON(resp.Data)
 }
 
+// EncodeError writes an endpoint error as JSON using its transport status code.
+func EncodeError(c *fiber.Ctx, err error) error {
+	return c.Status(transport.CodeOf(err)).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
 // DecodeCreateRequest decodes a JSON body into a CreateRequest[T].
 func DecodeCreateRequest[T any](c *fiber.Ctx) (endpoint.CreateRequest[T], error) {
 	var model T
@@ -67,3 +81,137 @@ func DecodeDeleteRequest(c *fiber.Ctx) endpoint.DeleteRequest {
 func DecodeListRequest(c *fiber.Ctx) endpoint.ListRequest {
 	return endpoint.ListRequest{QueryFns: []func(*gorm.DB) *gorm.DB{}}
 }
+
+// DecodeBatchRequest decodes a JSON array body into a BatchRequest[T],
+// rejecting it when any model is invalid.
+func DecodeBatchRequest[T any](c *fiber.Ctx) (endpoint.BatchRequest[T], error) {
+	var models []*T
+
+	if err := c.BodyParser(&models); err != nil {
+		return endpoint.BatchRequest[T]{}, err
+	}
+
+	if len(models) == 0 {
+		return endpoint.BatchRequest[T]{}, fiber.NewError(fiber.StatusBadRequest, "Provided batch is empty")
+	}
+
+	for i, m := range models {
+		if m == nil {
+			return endpoint.BatchRequest[T]{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Provided row %d is empty", i))
+		}
+		if err := validate.Struct(m); err != nil {
+			return endpoint.BatchRequest[T]{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Provided row %d is invalid: %s", i, err.Error()))
+		}
+	}
+
+	return endpoint.BatchRequest[T]{Models: models}, nil
+}
+
+// whereBody is the JSON body of bulk updates and deletes. Fields are named
+// as in the JSON of T or by column; array values match any of their items.
+type whereBody struct {
+	Where map[string]any `json:"where"`
+	Set   map[string]any `json:"set"`
+}
+
+// DecodeUpdateWhereRequest decodes a {"where": {...}, "set": {...}} JSON body
+// into an UpdateWhereRequest for T.
+func DecodeUpdateWhereRequest[T any](c *fiber.Ctx) (endpoint.UpdateWhereRequest, error) {
+	var body whereBody
+
+	if err := c.BodyParser(&body); err != nil {
+		return endpoint.UpdateWhereRequest{}, err
+	}
+
+	fields, err := fieldsOf[T]()
+	if err != nil {
+		return endpoint.UpdateWhereRequest{}, err
+	}
+
+	queryFns, err := whereFns(fields, body.Where)
+	if err != nil {
+		return endpoint.UpdateWhereRequest{}, err
+	}
+
+	if len(body.Set) == 0 {
+		return endpoint.UpdateWhereRequest{}, fiber.NewError(fiber.StatusBadRequest, "Provided body has no values to set")
+	}
+
+	values := make(map[string]any, len(body.Set))
+	for k, v := range body.Set {
+		f, ok := fields[k]
+		if !ok {
+			return endpoint.UpdateWhereRequest{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Provided field %q is unknown", k))
+		}
+		if f.PrimaryKey {
+			return endpoint.UpdateWhereRequest{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Provided field %q cannot be updated", k))
+		}
+		values[f.DBName] = v
+	}
+
+	return endpoint.UpdateWhereRequest{Values: values, QueryFns: queryFns}, nil
+}
+
+// DecodeDeleteWhereRequest decodes a {"where": {...}} JSON body into a
+// DeleteWhereRequest for T.
+func DecodeDeleteWhereRequest[T any](c *fiber.Ctx) (endpoint.DeleteWhereRequest, error) {
+	var body whereBody
+
+	if err := c.BodyParser(&body); err != nil {
+		return endpoint.DeleteWhereRequest{}, err
+	}
+
+	fields, err := fieldsOf[T]()
+	if err != nil {
+		return endpoint.DeleteWhereRequest{}, err
+	}
+
+	queryFns, err := whereFns(fields, body.Where)
+	if err != nil {
+		return endpoint.DeleteWhereRequest{}, err
+	}
+
+	return endpoint.DeleteWhereRequest{QueryFns: queryFns}, nil
+}
+
+// fieldsOf returns the database fields of T by JSON name and by column.
+func fieldsOf[T any]() (map[string]*schema.Field, error) {
+	s, err := schema.Parse(new(T), &schemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]*schema.Field, 2*len(s.DBNames))
+	for _, name := range s.DBNames {
+		f := s.FieldsByDBName[name]
+		fields[name] = f
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == "-" {
+			continue
+		} else if tag != "" {
+			fields[tag] = f
+		} else {
+			fields[f.Name] = f
+		}
+	}
+	return fields, nil
+}
+
+// whereFns builds equality conditions on known fields, requiring at least one.
+func whereFns(fields map[string]*schema.Field, where map[string]any) ([]func(*gorm.DB) *gorm.DB, error) {
+	if len(where) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Provided body has no conditions")
+	}
+	exprs := make([]clause.Expression, 0, len(where))
+	for k, v := range where {
+		f, ok := fields[k]
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Provided field %q is unknown", k))
+		}
+		if v != nil && reflect.TypeOf(v).Kind() == reflect.Map {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Provided condition on %q is invalid", k))
+		}
+		exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: v})
+	}
+	return []func(*gorm.DB) *gorm.DB{func(q *gorm.DB) *gorm.DB {
+		return q.Where(clause.And(exprs...))
+	}}, nil
+}
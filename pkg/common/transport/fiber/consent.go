@@ -0,0 +1,128 @@
+package fiber
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/consent"
+	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// OpConsentRates is the operation of the consent rates route. It is authorized
+// apart from OpList, which citizens use to read their own acceptance history.
+const OpConsentRates endpoint.Operation = "consent_rates"
+
+// acceptRequest is the body of an acceptance.
+type acceptRequest struct {
+	Version string `json:"version"`
+}
+
+// pendingPolicy is a policy version the caller must accept.
+type pendingPolicy struct {
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+}
+
+// RequireConsent blocks authenticated requests with 403 until the caller accepted
+// the current version of every given policy kind. The response lists the versions
+// to accept. Use it with WithMiddleware after WithAuth on endpoints storing personal data.
+func RequireConsent(svc *consent.Service, kinds ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := caller(c)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		pending, err := svc.Pending(c.UserContext(), user, kinds...)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		if len(pending) == 0 {
+			return c.Next()
+		}
+
+		required := make([]pendingPolicy, len(pending))
+		for i, p := range pending {
+			required[i] = pendingPolicy{Kind: p.Kind, Version: p.Version}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":    "the current policy versions must be accepted",
+			"required": required,
+		})
+	}
+}
+
+// RegisterConsentRoutes mounts the consent routes on basePath:
+//
+//	GET    basePath              (OpList)   acceptance history of the caller
+//	GET    basePath/:kind        (OpGet)    current policy version
+//	POST   basePath/:kind        (OpCreate) accept {"version": "..."}
+//	DELETE basePath/:kind        (OpDelete) withdraw consent
+//	GET    basePath/:kind/rates  (OpConsentRates) acceptance rates per version
+//
+// Every route but the current policy needs an authenticated caller. The rates
+// route is only mounted when a middleware restricts OpConsentRates, e.g.
+// WithMiddleware(adminOnly, OpConsentRates); WithAuth without operations does not cover it.
+func RegisterConsentRoutes(app *fiber.App, basePath string, svc *consent.Service, opts ...RouteOption) {
+
+	o := newRouteOptions(opts)
+
+	app.Get(basePath, o.chain(endpoint.OpList, func(c *fiber.Ctx) error {
+		user, err := caller(c)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		history, err := svc.History(c.UserContext(), user)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return c.JSON(history)
+	})...)
+
+	app.Get(basePath+"/:kind", o.chain(endpoint.OpGet, func(c *fiber.Ctx) error {
+		p, err := svc.Current(c.UserContext(), c.Params("kind"))
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return c.JSON(p)
+	})...)
+
+	app.Post(basePath+"/:kind", o.chain(endpoint.OpCreate, func(c *fiber.Ctx) error {
+		user, err := caller(c)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		var body acceptRequest
+		if err := c.BodyParser(&body); err != nil || body.Version == "" {
+			return EncodeError(c, transport.BadRequest("the accepted version is required"))
+		}
+		a, err := svc.Accept(c.UserContext(), user, c.Params("kind"), body.Version, consent.Evidence{
+			IP:        c.IP(),
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+		})
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(a)
+	})...)
+
+	app.Delete(basePath+"/:kind", o.chain(endpoint.OpDelete, func(c *fiber.Ctx) error {
+		user, err := caller(c)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		if err := svc.Withdraw(c.UserContext(), user, c.Params("kind")); err != nil {
+			return EncodeError(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})...)
+
+	if len(o.handlers[OpConsentRates]) > 0 {
+		app.Get(basePath+"/:kind/rates", o.chain(OpConsentRates, func(c *fiber.Ctx) error {
+			rates, err := svc.Rates(c.UserContext(), c.Params("kind"))
+			if err != nil {
+				return EncodeError(c, err)
+			}
+			return c.JSON(rates)
+		})...)
+	}
+
+}
@@ -0,0 +1,61 @@
+package fiber
+
+import (
+	"bufio"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/export"
+)
+
+// RegisterExportRoutes mounts the open data download route (OpList) on basePath:
+//
+//	GET basePath/:dataset?format=csv|ndjson|geojson|parquet&status=open,resolved
+//
+// Query parameters other than format are dataset filters. The file is streamed
+// as records are read, so the dataset is never loaded into memory. A failure
+// while streaming is logged and aborts the connection.
+func RegisterExportRoutes(app *fiber.App, basePath string, catalog *export.Catalog, opts ...RouteOption) {
+
+	o := newRouteOptions(opts)
+
+	app.Get(basePath+"/:dataset", o.chain(endpoint.OpList, func(c *fiber.Ctx) error {
+		ds, err := catalog.Get(c.Params("dataset"))
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		format, err := export.ParseFormat(c.Query("format"))
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		filters := map[string]string{}
+		for k, v := range c.Queries() {
+			if k != "format" {
+				filters[k] = v
+			}
+		}
+		if err := ds.Check(format, filters); err != nil {
+			return EncodeError(c, err)
+		}
+
+		// The writer runs after the handler returns, so it must not use c.
+		ctx, conn := c.UserContext(), c.Context().Conn()
+		c.Attachment(ds.Name() + "." + string(format))
+		c.Set(fiber.HeaderContentType, format.ContentType())
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			err := ds.Export(ctx, w, format, filters)
+			if err == nil {
+				err = w.Flush()
+			}
+			if err != nil {
+				// The status was already sent; closing the connection before the last
+				// chunk makes the download fail instead of ending with a truncated file.
+				log.Errorw("export stream failed", "dataset", ds.Name(), "format", format, "error", err)
+				_ = conn.Close()
+			}
+		})
+		return nil
+	})...)
+
+}
@@ -1,47 +1,168 @@
 package fiber
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
 	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
 )
 
-// RegisterCrudRoutes mounts generic CRUD routes for any entity T.
-func RegisterCrudRoutes[T any](app *fiber.App, basePath string, eps endpoint.Endpoints[T]) {
+// errRowNotWritten replaces the database errors of the failed rows of a batch.
+var errRowNotWritten = transport.New(fiber.StatusUnprocessableEntity, "the row could not be written", nil)
+
+// RegisterCrudRoutes mounts generic CRUD routes for any entity T, along with
+// bulk routes: POST basePath/batch creates and PUT basePath/batch upserts an
+// array of models, answering 207 when some rows failed; PATCH basePath sets
+// values on the matched models and POST basePath/delete deletes them. Upserts
+// run the handlers of both OpCreate and OpUpdate.
+func RegisterCrudRoutes[T any](app *fiber.App, basePath string, eps endpoint.Endpoints[T], opts ...RouteOption) {
+
+	o := newRouteOptions(opts)
 
-	app.Post(basePath, func(c *fiber.Ctx) error {
+	app.Post(basePath, o.chain(endpoint.OpCreate, func(c *fiber.Ctx) error {
 		req, err := DecodeCreateRequest[T](c)
 		if err != nil {
 			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 		}
-		resp, _ := eps.Create(c.Context(), req)
+		resp, err := eps.Create(c.UserContext(), req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
 		return EncodeResponse(c, resp.(endpoint.Response[any]))
-	})
+	})...)
+
+	app.Post(basePath+"/batch", o.chain(endpoint.OpCreate, func(c *fiber.Ctx) error {
+		req, err := DecodeBatchRequest[T](c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		resp, err := eps.CreateBatch(c.UserContext(), req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return encodeBatch(c, resp.(endpoint.Response[*db.BatchResult]))
+	})...)
+
+	app.Put(basePath+"/batch", o.chainAll(func(c *fiber.Ctx) error {
+		req, err := DecodeBatchRequest[T](c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		resp, err := eps.UpsertBatch(c.UserContext(), req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return encodeBatch(c, resp.(endpoint.Response[*db.BatchResult]))
+	}, endpoint.OpCreate, endpoint.OpUpdate)...)
 
-	app.Get(basePath+"/:id", func(c *fiber.Ctx) error {
+	app.Patch(basePath, o.chain(endpoint.OpUpdate, func(c *fiber.Ctx) error {
+		req, err := DecodeUpdateWhereRequest[T](c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		fns, err := o.filter(c)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		req.QueryFns = append(req.QueryFns, fns...)
+		resp, err := eps.UpdateWhere(c.UserContext(), req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return encodeAffected(c, resp.(endpoint.Response[int64]))
+	})...)
+
+	app.Post(basePath+"/delete", o.chain(endpoint.OpDelete, func(c *fiber.Ctx) error {
+		req, err := DecodeDeleteWhereRequest[T](c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		fns, err := o.filter(c)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		req.QueryFns = append(req.QueryFns, fns...)
+		resp, err := eps.DeleteWhere(c.UserContext(), req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return encodeAffected(c, resp.(endpoint.Response[int64]))
+	})...)
+
+	app.Get(basePath+"/:id", o.chain(endpoint.OpGet, func(c *fiber.Ctx) error {
 		req := DecodeGetRequest(c)
-		resp, _ := eps.Get(c.Context(), req)
+		resp, err := eps.Get(c.UserContext(), req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
 		return EncodeResponse(c, resp.(endpoint.Response[*T]))
-	})
+	})...)
 
-	app.Put(basePath+"/:id", func(c *fiber.Ctx) error {
+	app.Put(basePath+"/:id", o.chain(endpoint.OpUpdate, func(c *fiber.Ctx) error {
 		req, err := DecodeUpdateRequest[T](c)
 		if err != nil {
 			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 		}
-		resp, _ := eps.Update(c.Context(), req)
+		resp, err := eps.Update(c.UserContext(), req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
 		return EncodeResponse(c, resp.(endpoint.Response[any]))
-	})
+	})...)
 
-	app.Delete(basePath+"/:id", func(c *fiber.Ctx) error {
+	app.Delete(basePath+"/:id", o.chain(endpoint.OpDelete, func(c *fiber.Ctx) error {
 		req := DecodeDeleteRequest(c)
-		resp, _ := eps.Delete(c.Context(), req)
+		resp, err := eps.Delete(c.UserContext(), req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
 		return EncodeResponse(c, resp.(endpoint.Response[any]))
-	})
+	})...)
 
-	app.Post(basePath+"/list", func(c *fiber.Ctx) error {
+	app.Post(basePath+"/list", o.chain(endpoint.OpList, func(c *fiber.Ctx) error {
 		req := DecodeListRequest(c)
-		resp, _ := eps.List(c.Context(), req)
+		fns, err := o.filter(c)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		req.QueryFns = append(req.QueryFns, fns...)
+		resp, err := eps.List(c.UserContext(), req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
 		return EncodeResponse(c, resp.(endpoint.Response[[]T]))
-	})
+	})...)
+}
+
+// encodeBatch writes the result of a batch, with status 207 when some rows failed.
+// Row errors other than application errors are logged and answered with a
+// generic message, so driver errors never reach the client.
+func encodeBatch(c *fiber.Ctx, resp endpoint.Response[*db.BatchResult]) error {
+	if resp.Err == nil && resp.Data != nil && len(resp.Data.Errors) > 0 {
+		out := *resp.Data
+		out.Errors = make([]db.RowError, len(resp.Data.Errors))
+		for i, e := range resp.Data.Errors {
+			var appErr *transport.AppError
+			if errors.As(e.Err, &appErr) {
+				out.Errors[i] = db.RowError{Index: e.Index, Err: transport.New(appErr.Code, appErr.Message, nil)}
+				continue
+			}
+			log.Errorw("batch row failed", "path", c.Path(), "index", e.Index, "error", e.Err)
+			out.Errors[i] = db.RowError{Index: e.Index, Err: errRowNotWritten}
+		}
+		resp.Data = &out
+		c.Status(fiber.StatusMultiStatus)
+	}
+	return EncodeResponse(c, resp)
+}
 
+// encodeAffected writes the number of models changed by a bulk update or delete.
+func encodeAffected(c *fiber.Ctx, resp endpoint.Response[int64]) error {
+	if resp.Err != nil {
+		return EncodeError(c, resp.Err)
+	}
+	return c.JSON(fiber.Map{"affected": resp.Data})
 }
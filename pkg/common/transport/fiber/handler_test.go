@@ -0,0 +1,658 @@
+package fiber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ianfedev/civicspot-backend/pkg/common/analytics"
+	"github.com/ianfedev/civicspot-backend/pkg/common/audit"
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"github.com/ianfedev/civicspot-backend/pkg/common/consent"
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/export"
+	"github.com/ianfedev/civicspot-backend/pkg/common/history"
+	"github.com/ianfedev/civicspot-backend/pkg/common/search"
+	"github.com/ianfedev/civicspot-backend/pkg/common/storage"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type note struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// memoryService is an in-memory db.Service used to exercise the routes.
+type memoryService struct {
+	notes map[string]note
+}
+
+func (m *memoryService) Create(_ context.Context, n *note) error {
+	m.notes[n.ID] = *n
+	return nil
+}
+
+func (m *memoryService) Get(_ context.Context, id any, _ ...func(*gorm.DB) *gorm.DB) (*note, error) {
+	n := m.notes[id.(string)]
+	return &n, nil
+}
+
+func (m *memoryService) Update(ctx context.Context, n *note) error { return m.Create(ctx, n) }
+
+func (m *memoryService) Delete(_ context.Context, id any) error {
+	delete(m.notes, id.(string))
+	return nil
+}
+
+func (m *memoryService) List(context.Context, ...func(*gorm.DB) *gorm.DB) ([]note, error) {
+	var out []note
+	for _, n := range m.notes {
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func (m *memoryService) CreateBatch(ctx context.Context, ns []*note, _ ...db.BatchOption) (*db.BatchResult, error) {
+	for _, n := range ns {
+		_ = m.Create(ctx, n)
+	}
+	return &db.BatchResult{Affected: int64(len(ns))}, nil
+}
+
+func (m *memoryService) UpsertBatch(ctx context.Context, ns []*note, opts ...db.BatchOption) (*db.BatchResult, error) {
+	return m.CreateBatch(ctx, ns, opts...)
+}
+
+func (m *memoryService) UpdateWhere(context.Context, map[string]any, ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryService) DeleteWhere(context.Context, ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	return 0, nil
+}
+
+// TestRegisterCrudRoutesWithAuth verifies only the declared operations require a token.
+func TestRegisterCrudRoutesWithAuth(t *testing.T) {
+	svc := &memoryService{notes: map[string]note{"1": {ID: "1", Text: "hola"}}}
+	v := auth.NewValidator(auth.ValidatorConfig{Keys: auth.NewStaticKeySet(auth.Key{Material: []byte("secret")})})
+
+	app := fiber.New()
+	RegisterCrudRoutes(app, "/notes", endpoint.NewEndpoints[note](svc),
+		WithAuth(v, endpoint.OpCreate, endpoint.OpUpdate, endpoint.OpDelete))
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	do := func(method, path, body, token string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		return res.StatusCode
+	}
+
+	assert.Equal(t, 200, do(http.MethodGet, "/notes/1", "", ""))
+	assert.Equal(t, 200, do(http.MethodPost, "/notes/list", "", ""))
+	assert.Equal(t, 401, do(http.MethodPost, "/notes", `{"id":"2","text":"x"}`, ""))
+	assert.Equal(t, 200, do(http.MethodPost, "/notes", `{"id":"2","text":"x"}`, token))
+	assert.Equal(t, 401, do(http.MethodDelete, "/notes/2", "", ""))
+	assert.Equal(t, 200, do(http.MethodDelete, "/notes/2", "", token))
+
+	// Upserts create models, so they also run the handlers of OpCreate.
+	app = fiber.New()
+	RegisterCrudRoutes(app, "/notes", endpoint.NewEndpoints[note](svc), WithAuth(v, endpoint.OpCreate))
+	assert.Equal(t, 401, do(http.MethodPut, "/notes/batch", `[{"id":"3","text":"x"}]`, ""))
+}
+
+// TestRegisterCrudBulkRoutes verifies the bulk routes report failed rows and
+// only reach the models allowed by the list filters.
+func TestRegisterCrudBulkRoutes(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bulk.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&note{}))
+
+	app := fiber.New()
+	RegisterCrudRoutes(app, "/notes", endpoint.NewEndpoints[note](db.NewService(db.NewRepository[note](gdb))),
+		WithListFilter(func(*fiber.Ctx) ([]func(*gorm.DB) *gorm.DB, error) {
+			return []func(*gorm.DB) *gorm.DB{func(q *gorm.DB) *gorm.DB { return q.Where("id <> ?", "locked") }}, nil
+		}))
+
+	do := func(method, path, body string) (int, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		b, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(b)
+	}
+
+	code, body := do(http.MethodPost, "/notes/batch", `[{"id":"1","text":"a"},{"id":"2","text":"a"},{"id":"1","text":"dup"},{"id":"locked","text":"a"}]`)
+	assert.Equal(t, 207, code)
+	assert.JSONEq(t, `{"affected":3,"errors":[{"index":2,"error":"the row could not be written"}]}`, body, "driver errors are not exposed")
+
+	code, _ = do(http.MethodPut, "/notes/batch", `[{"id":"2","text":"b"},{"id":"3","text":"c"}]`)
+	assert.Equal(t, 200, code)
+	code, _ = do(http.MethodPost, "/notes/batch", `[]`)
+	assert.Equal(t, 400, code)
+
+	code, body = do(http.MethodPatch, "/notes", `{"where":{"text":"a"},"set":{"text":"z"}}`)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `{"affected":1}`, body)
+	code, _ = do(http.MethodPatch, "/notes", `{"where":{},"set":{"text":"z"}}`)
+	assert.Equal(t, 400, code)
+	code, _ = do(http.MethodPatch, "/notes", `{"where":{"secret":1},"set":{"text":"z"}}`)
+	assert.Equal(t, 400, code)
+	code, _ = do(http.MethodPatch, "/notes", `{"where":{"text":"z"},"set":{"id":"9"}}`)
+	assert.Equal(t, 400, code)
+
+	code, body = do(http.MethodPost, "/notes/delete", `{"where":{"id":["1","2","locked"]}}`)
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `{"affected":2}`, body)
+
+	var left []note
+	require.NoError(t, gdb.Order("id").Find(&left).Error)
+	assert.Equal(t, []note{{ID: "3", Text: "c"}, {ID: "locked", Text: "a"}}, left)
+}
+
+// TestRegisterMediaRoutes verifies an upload can be downloaded only through its signed link.
+func TestRegisterMediaRoutes(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, storage.Migrate(gdb))
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	media := storage.NewMedia(storage.MediaConfig{Store: store, DB: gdb, Signer: storage.NewSigner([]byte("secret")), BaseURL: "/media"})
+
+	v := auth.NewValidator(auth.ValidatorConfig{Keys: auth.NewStaticKeySet(auth.Key{Material: []byte("secret")})})
+	app := fiber.New()
+	RegisterMediaRoutes(app, "/media", media, WithAuth(v, endpoint.OpCreate, endpoint.OpDelete))
+
+	token := func(sub string) string {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": sub,
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return "Bearer " + s
+	}
+
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "hueco.png")
+	require.NoError(t, err)
+	_, err = fw.Write(img.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/media", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", token("user-1"))
+	res, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, 201, res.StatusCode)
+
+	var uploaded struct {
+		URL         string `json:"url"`
+		ContentType string `json:"content_type"`
+		Location    any    `json:"location"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&uploaded))
+	assert.Equal(t, "image/png", uploaded.ContentType)
+	assert.Empty(t, uploaded.URL, "raw images are not served before processing")
+
+	lat, lon := 4.711, -74.072
+	require.NoError(t, gdb.Model(&storage.Attachment{}).Where("id = 1").
+		Updates(map[string]any{"processed_at": time.Now(), "latitude": lat, "longitude": lon}).Error)
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/media/1", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&uploaded))
+	assert.Nil(t, uploaded.Location, "the extracted location is not served")
+	require.NotEmpty(t, uploaded.URL)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, uploaded.URL, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	got, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, img.Bytes(), got)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/media/1/content?expires=9999999999&sig=forged", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 403, res.StatusCode)
+
+	del := func(sub string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/media/1", nil)
+		req.Header.Set("Authorization", token(sub))
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		return res.StatusCode
+	}
+	assert.Equal(t, 403, del("user-2"), "only the uploader deletes an attachment")
+	assert.Equal(t, 204, del("user-1"))
+}
+
+// TestMediaDownloadTenant verifies signed downloads run the tenant resolver and
+// only serve attachments of the resolved tenant.
+func TestMediaDownloadTenant(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "media.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, storage.Migrate(gdb))
+	require.NoError(t, tenant.Register(gdb))
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	media := storage.NewMedia(storage.MediaConfig{Store: store, DB: gdb, Signer: storage.NewSigner([]byte("secret")), BaseURL: "/media"})
+
+	app := fiber.New()
+	RegisterMediaRoutes(app, "/media", media, WithMiddleware(Tenant(&tenant.Resolver{Domain: "civicspot.co"})))
+
+	bogota := tenant.NewContext(context.Background(), "bogota")
+	a, err := media.Upload(bogota, "user-1", "acta.pdf", strings.NewReader("%PDF-1.4 acta"))
+	require.NoError(t, err)
+	link, _, err := media.Link(bogota, a, nil)
+	require.NoError(t, err)
+
+	download := func(host string) int {
+		req := httptest.NewRequest(http.MethodGet, link, nil)
+		req.Host = host
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		return res.StatusCode
+	}
+	assert.Equal(t, 200, download("bogota.civicspot.co"))
+	assert.Equal(t, 404, download("cali.civicspot.co"), "other tenants do not see the attachment")
+	assert.Equal(t, 400, download("api.example.com"), "downloads need a tenant")
+}
+
+type moderationCase struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// memoryQueue is an in-memory ModerationQueue used to exercise the routes.
+type memoryQueue struct {
+	cases map[string]*moderationCase
+	flags []string
+}
+
+func (q *memoryQueue) Pending(context.Context, int, int) ([]moderationCase, error) {
+	var out []moderationCase
+	for _, c := range q.cases {
+		if c.Status == "pending" {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (q *memoryQueue) Approve(_ context.Context, id, _, _ string) (*moderationCase, error) {
+	c := q.cases[id]
+	c.Status = "approved"
+	return c, nil
+}
+
+func (q *memoryQueue) Reject(_ context.Context, id, _, reason string) (*moderationCase, error) {
+	if reason == "" {
+		return nil, transport.BadRequest("a reason is required")
+	}
+	c := q.cases[id]
+	c.Status = "rejected"
+	return c, nil
+}
+
+func (q *memoryQueue) Flag(_ context.Context, contentType, contentID, reporterID, _, _ string) (*string, error) {
+	f := contentType + "/" + contentID + " by " + reporterID
+	q.flags = append(q.flags, f)
+	return &f, nil
+}
+
+// TestRegisterModerationRoutes verifies the queue routes act on behalf of the caller.
+func TestRegisterModerationRoutes(t *testing.T) {
+	q := &memoryQueue{cases: map[string]*moderationCase{"1": {ID: "1", Status: "pending"}, "2": {ID: "2", Status: "pending"}}}
+
+	app := fiber.New()
+	isModerator := func(p *auth.Principal) bool { return strings.HasPrefix(p.Subject, "mod-") }
+	RegisterModerationRoutes[moderationCase, string](app, "/moderation", q, isModerator, WithMiddleware(func(c *fiber.Ctx) error {
+		if user := c.Get("X-User"); user != "" {
+			c.SetUserContext(auth.NewContext(c.UserContext(), &auth.Principal{Subject: user}))
+		}
+		return c.Next()
+	}))
+
+	do := func(method, path, body, user string) *http.Response {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		return res
+	}
+
+	assert.Equal(t, 401, do(http.MethodGet, "/moderation", "", "").StatusCode)
+	assert.Equal(t, 403, do(http.MethodGet, "/moderation", "", "user-1").StatusCode)
+	assert.Equal(t, 403, do(http.MethodPost, "/moderation/1/approve", "", "user-1").StatusCode)
+	assert.Equal(t, "pending", q.cases["1"].Status)
+	assert.Equal(t, 200, do(http.MethodPost, "/moderation/1/approve", "", "mod-1").StatusCode)
+	assert.Equal(t, 400, do(http.MethodPost, "/moderation/2/reject", `{}`, "mod-1").StatusCode)
+	assert.Equal(t, 200, do(http.MethodPost, "/moderation/2/reject", `{"reason":"insultos"}`, "mod-1").StatusCode)
+	assert.Equal(t, "rejected", q.cases["2"].Status)
+
+	res := do(http.MethodPost, "/moderation/flags", `{"content_type":"comment","content_id":"9","reason":"spam"}`, "user-1")
+	assert.Equal(t, 201, res.StatusCode)
+	assert.Equal(t, []string{"comment/9 by user-1"}, q.flags)
+
+	var pending []moderationCase
+	require.NoError(t, json.NewDecoder(do(http.MethodGet, "/moderation", "", "mod-1").Body).Decode(&pending))
+	assert.Empty(t, pending)
+}
+
+// TestRegisterSearchRoutes verifies query parameters become text, type and field filters.
+func TestRegisterSearchRoutes(t *testing.T) {
+	idx, err := search.NewBleveIndex("")
+	require.NoError(t, err)
+	defer idx.Close()
+	require.NoError(t, idx.Index(context.Background(),
+		search.Document{Type: "report", ID: "1", Title: "Hueco en la vía", Fields: map[string]string{"category": "vias"}},
+		search.Document{Type: "report", ID: "2", Title: "Hueco frente al parque", Fields: map[string]string{"category": "parques"}},
+	))
+
+	app := fiber.New()
+	RegisterSearchRoutes(app, "/search", idx, []string{"category"})
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/search?q=huecos&type=report&category=vias", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+
+	var got search.Result
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+	require.Equal(t, 1, got.Total)
+	assert.Equal(t, "1", got.Hits[0].ID)
+	assert.Equal(t, []search.FacetValue{{Value: "vias", Count: 1}}, got.Facets["category"])
+}
+
+// TestRegisterAnalyticsRoutes verifies filters are parsed and aggregates returned as JSON.
+func TestRegisterAnalyticsRoutes(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, analytics.Migrate(gdb))
+	svc := analytics.New(gdb, analytics.Config{Location: time.UTC})
+	require.NoError(t, svc.Record(context.Background(), &analytics.Issue{ID: "1", Status: "open", Municipality: "bogota", CreatedAt: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)}))
+
+	app := fiber.New()
+	RegisterAnalyticsRoutes(app, "/stats", svc)
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/stats/counts?by=status&municipality=bogota&from=2025-03-01", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+	var counts analytics.Counts
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&counts))
+	assert.Equal(t, []analytics.Count{{Key: "open", Value: 1}}, counts.Data)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/stats/series?interval=week&from=2025-03-01&to=2025-03-10", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+	var series analytics.Series
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&series))
+	assert.Len(t, series.Labels, 2)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/stats/resolution?from=ayer", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+}
+
+// exportRow is a model published by the export route test.
+type exportRow struct {
+	ID     string `gorm:"primaryKey"`
+	Status string
+	Email  string
+}
+
+// TestRegisterExportRoutes verifies datasets are streamed with filters and validated before streaming.
+func TestRegisterExportRoutes(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&exportRow{}))
+	require.NoError(t, gdb.Create(&[]exportRow{{ID: "1", Status: "open", Email: "a@b.co"}, {ID: "2", Status: "resolved"}}).Error)
+
+	ds, err := export.NewDataset("reports", db.NewRepository[exportRow](gdb), export.Config{
+		Columns: export.Fields("ID", "Status"),
+		Filters: []string{"status"},
+	})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	RegisterExportRoutes(app, "/open-data", export.NewCatalog(nil, "").Add(ds))
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/open-data/reports?format=csv&status=open", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", res.Header.Get(fiber.HeaderContentType))
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "id,status\n1,open\n", string(body))
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/open-data/reports?format=geojson", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/open-data/reports?email=a@b.co", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/open-data/missing", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+
+	// A failed stream closes a real connection, which the test transport cannot show.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	defer app.Shutdown()
+
+	require.NoError(t, gdb.Migrator().DropTable(&exportRow{}))
+	res, err = http.Get("http://" + ln.Addr().String() + "/open-data/reports?format=csv")
+	if err == nil {
+		_, err = io.ReadAll(res.Body)
+		res.Body.Close()
+	}
+	assert.Error(t, err, "a failed stream aborts the download")
+}
+
+// TestRegisterConsentRoutes verifies acceptance through the routes unblocks RequireConsent.
+func TestRegisterConsentRoutes(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, consent.Migrate(gdb))
+	svc := consent.New(gdb)
+	require.NoError(t, svc.Publish(context.Background(), &consent.Policy{Kind: consent.DataProcessing, Version: "v1", Body: "Tratamos sus datos."}))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if user := c.Get("X-User"); user != "" {
+			c.SetUserContext(auth.NewContext(c.UserContext(), &auth.Principal{Subject: user}))
+		}
+		return c.Next()
+	})
+	RegisterConsentRoutes(app, "/consent", svc, WithMiddleware(func(c *fiber.Ctx) error {
+		if c.Get("X-User") != "admin" {
+			return EncodeError(c, transport.Forbidden("admin role required"))
+		}
+		return c.Next()
+	}, OpConsentRates))
+	app.Post("/reports", RequireConsent(svc, consent.DataProcessing), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	as := func(user, method, path, body string) *http.Response {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		return res
+	}
+	do := func(method, path, body string) *http.Response {
+		return as("user-1", method, path, body)
+	}
+
+	res := do(http.MethodPost, "/reports", "")
+	require.Equal(t, 403, res.StatusCode)
+	var blocked struct {
+		Required []struct{ Kind, Version string }
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&blocked))
+	require.Len(t, blocked.Required, 1)
+	assert.Equal(t, "v1", blocked.Required[0].Version)
+
+	assert.Equal(t, 200, do(http.MethodGet, "/consent/data_processing", "").StatusCode)
+	assert.Equal(t, 400, do(http.MethodPost, "/consent/data_processing", `{}`).StatusCode)
+	assert.Equal(t, 201, do(http.MethodPost, "/consent/data_processing", `{"version":"v1"}`).StatusCode)
+	assert.Equal(t, 201, do(http.MethodPost, "/reports", "").StatusCode)
+
+	assert.Equal(t, 204, do(http.MethodDelete, "/consent/data_processing", "").StatusCode)
+	assert.Equal(t, 403, do(http.MethodPost, "/reports", "").StatusCode)
+
+	assert.Equal(t, 200, do(http.MethodGet, "/consent", "").StatusCode, "citizens list their own history")
+	assert.Equal(t, 403, do(http.MethodGet, "/consent/data_processing/rates", "").StatusCode)
+	res = as("admin", http.MethodGet, "/consent/data_processing/rates", "")
+	require.Equal(t, 200, res.StatusCode)
+	var rates []consent.Rate
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&rates))
+	assert.Equal(t, []consent.Rate{{Version: "v1", Accepted: 1, Withdrawn: 1}}, rates)
+}
+
+// TestRequestID checks request IDs are propagated to the user context and echoed.
+func TestRequestID(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(audit.RequestID(c.UserContext())) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "req-1")
+	res, err := app.Test(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, "req-1", string(body))
+	assert.Equal(t, "req-1", res.Header.Get(fiber.HeaderXRequestID))
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	body, _ = io.ReadAll(res.Body)
+	assert.Len(t, string(body), 32)
+	assert.Equal(t, string(body), res.Header.Get(fiber.HeaderXRequestID))
+}
+
+// historyRow is a model versioned by the history route test.
+type historyRow struct {
+	ID     uint `gorm:"primaryKey"`
+	Status string
+}
+
+// TestRegisterHistoryRoutes verifies versions, diffs and point-in-time reads.
+func TestRegisterHistoryRoutes(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&historyRow{}))
+	tr, err := history.New(gdb, history.Config{})
+	require.NoError(t, err)
+	require.NoError(t, tr.Track(&historyRow{}))
+
+	row := &historyRow{Status: "open"}
+	require.NoError(t, gdb.Create(row).Error)
+	require.NoError(t, gdb.Model(row).Update("status", "closed").Error)
+
+	reader, err := history.NewReader[historyRow](gdb)
+	require.NoError(t, err)
+	app := fiber.New()
+	RegisterHistoryRoutes(app, "/reports", reader)
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/reports/1/versions", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+	var versions []history.Version[historyRow]
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&versions))
+	require.Len(t, versions, 2)
+	assert.Equal(t, "closed", versions[1].Entity.Status)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/reports/1/versions/diff?from=1&to=2", nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+	var changes map[string]audit.Change
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&changes))
+	assert.Equal(t, audit.Change{Before: "open", After: "closed"}, changes["status"])
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/reports/1/as-of?at=2000-01-01", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/reports/1/versions/diff", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+}
+
+// TestTenant verifies the tenant is resolved into the user context and the claim prevails.
+func TestTenant(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if user := c.Get("X-User"); user != "" {
+			c.SetUserContext(auth.NewContext(c.UserContext(), &auth.Principal{Subject: user, Claims: map[string]any{"tenant": "cali"}}))
+		}
+		return c.Next()
+	})
+	app.Use(Tenant(&tenant.Resolver{Domain: "civicspot.co"}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		id, _ := tenant.FromContext(c.UserContext())
+		return c.SendString(id)
+	})
+
+	do := func(host, header, user string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = host
+		req.Header.Set("X-Tenant-ID", header)
+		req.Header.Set("X-User", user)
+		res, err := app.Test(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+
+	code, body := do("bogota.civicspot.co", "", "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "bogota", body)
+	code, body = do("api.example.com", "", "user-1")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "cali", body)
+	code, _ = do("bogota.civicspot.co", "", "user-1")
+	assert.Equal(t, 403, code)
+	code, _ = do("api.example.com", "", "")
+	assert.Equal(t, 400, code)
+}
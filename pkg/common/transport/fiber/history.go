@@ -0,0 +1,56 @@
+package fiber
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/history"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// RegisterHistoryRoutes mounts the version history of a tracked entity T:
+//
+//	GET basePath/:id/versions                      versions, oldest first
+//	GET basePath/:id/versions/diff?from=1&to=3     fields changed between two versions
+//	GET basePath/:id/as-of?at=2025-03-01T12:00:00Z entity as it was at a time or date
+//
+// Every route is registered under OpGet; restrict it to officials with WithMiddleware.
+func RegisterHistoryRoutes[T any](app *fiber.App, basePath string, r *history.Reader[T], opts ...RouteOption) {
+
+	o := newRouteOptions(opts)
+
+	app.Get(basePath+"/:id/versions", o.chain(endpoint.OpGet, func(c *fiber.Ctx) error {
+		versions, err := r.Versions(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return c.JSON(versions)
+	})...)
+
+	app.Get(basePath+"/:id/versions/diff", o.chain(endpoint.OpGet, func(c *fiber.Ctx) error {
+		from, to := c.QueryInt("from"), c.QueryInt("to")
+		if from <= 0 || to <= 0 {
+			return EncodeError(c, transport.BadRequest("the \"from\" and \"to\" versions are required"))
+		}
+		changes, err := r.Diff(c.UserContext(), c.Params("id"), from, to)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return c.JSON(changes)
+	})...)
+
+	app.Get(basePath+"/:id/as-of", o.chain(endpoint.OpGet, func(c *fiber.Ctx) error {
+		at, err := parseInstant(c.Query("at"))
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		if at.IsZero() {
+			return EncodeError(c, transport.BadRequest("the \"at\" time is required"))
+		}
+		entity, err := r.AsOf(c.UserContext(), c.Params("id"), at)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return c.JSON(entity)
+	})...)
+
+}
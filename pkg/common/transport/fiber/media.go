@@ -0,0 +1,182 @@
+package fiber
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/storage"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// RegisterMediaRoutes mounts upload and download routes for attachments:
+//
+//	POST   basePath              multipart upload of the "file" field (OpCreate)
+//	GET    basePath/:id          attachment metadata and signed download links once processed (OpGet)
+//	DELETE basePath/:id          attachment removal by its uploader (OpDelete)
+//	GET    basePath/:id/content  download authorized by the link signature
+//	GET    basePath/:id/variants/:name/content  variant download authorized by the link signature
+//
+// Downloads skip the operation handlers, so links work without a token, and only
+// run the middleware added for every operation, such as the tenant resolver.
+// The app BodyLimit must be larger than the media size limit for uploads to reach the handler.
+func RegisterMediaRoutes(app *fiber.App, basePath string, media *storage.Media, opts ...RouteOption) {
+
+	o := newRouteOptions(opts)
+
+	app.Post(basePath, o.chain(endpoint.OpCreate, func(c *fiber.Ctx) error {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return EncodeError(c, transport.BadRequest("a multipart \"file\" field is required"))
+		}
+		if fh.Size > media.MaxSize() {
+			return EncodeError(c, storage.ErrTooLarge)
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		defer f.Close()
+
+		var owner string
+		if p, ok := auth.FromContext(c.UserContext()); ok {
+			owner = p.Subject
+		}
+
+		a, err := media.Upload(c.UserContext(), owner, fh.Filename, f)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return encodeAttachment(c.Status(fiber.StatusCreated), media, a)
+	})...)
+
+	app.Get(basePath+"/:id", o.chain(endpoint.OpGet, func(c *fiber.Ctx) error {
+		a, err := attachment(c, media)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return encodeAttachment(c, media, a)
+	})...)
+
+	app.Delete(basePath+"/:id", o.chain(endpoint.OpDelete, func(c *fiber.Ctx) error {
+		user, err := caller(c)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		a, err := attachment(c, media)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		if a.OwnerID != user {
+			return EncodeError(c, transport.Forbidden("only the uploader can delete the attachment"))
+		}
+		if err := media.Delete(c.UserContext(), a.ID); err != nil {
+			return EncodeError(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})...)
+
+	app.Get(basePath+"/:id/content", o.chainCommon(func(c *fiber.Ctx) error {
+		return sendContent(c, media, c.Params("id"), "")
+	})...)
+
+	app.Get(basePath+"/:id/variants/:name/content", o.chainCommon(func(c *fiber.Ctx) error {
+		return sendContent(c, media, c.Params("id")+"/variants/"+c.Params("name"), c.Params("name"))
+	})...)
+
+}
+
+// sendContent streams an attachment or variant after checking the link signature of resource.
+func sendContent(c *fiber.Ctx, media *storage.Media, resource, variant string) error {
+	if err := media.VerifyLink(resource, c.Query("expires"), c.Query("sig")); err != nil {
+		return EncodeError(c, err)
+	}
+	a, err := attachment(c, media)
+	if err != nil {
+		return EncodeError(c, err)
+	}
+	if media.Pending(a) {
+		return EncodeError(c, storage.ErrPending)
+	}
+	r, contentType, size, err := media.Open(c.UserContext(), a, variant)
+	if err != nil {
+		return EncodeError(c, err)
+	}
+
+	c.Set("X-Content-Type-Options", "nosniff")
+	c.Attachment(a.Filename)
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.SendStream(r, int(size))
+}
+
+// attachmentID parses the ":id" path param.
+func attachmentID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, transport.NotFound("attachment not found")
+	}
+	return uint(id), nil
+}
+
+// attachment loads the attachment of the ":id" path param.
+func attachment(c *fiber.Ctx, media *storage.Media) (*storage.Attachment, error) {
+	id, err := attachmentID(c)
+	if err != nil {
+		return nil, err
+	}
+	return media.Get(c.UserContext(), id)
+}
+
+// encodeAttachment writes the attachment and its variants with fresh download links as JSON.
+// Pending images are written without links and the extracted location is never included.
+func encodeAttachment(c *fiber.Ctx, media *storage.Media, a *storage.Attachment) error {
+	ctx := c.UserContext()
+
+	body := fiber.Map{
+		"id":           a.ID,
+		"filename":     a.Filename,
+		"content_type": a.ContentType,
+		"size":         a.Size,
+		"sha256":       a.SHA256,
+		"width":        a.Width,
+		"height":       a.Height,
+		"processed":    a.ProcessedAt != nil,
+		"variants":     []fiber.Map{},
+	}
+	if media.Pending(a) {
+		return c.JSON(body)
+	}
+
+	link, exp, err := media.Link(ctx, a, nil)
+	if err != nil {
+		return EncodeError(c, err)
+	}
+
+	variants, err := media.Variants(ctx, a.ID)
+	if err != nil {
+		return EncodeError(c, err)
+	}
+	out := make([]fiber.Map, 0, len(variants))
+	for i := range variants {
+		v := &variants[i]
+		vlink, _, err := media.Link(ctx, a, v)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		out = append(out, fiber.Map{
+			"name":         v.Name,
+			"content_type": v.ContentType,
+			"width":        v.Width,
+			"height":       v.Height,
+			"size":         v.Size,
+			"url":          vlink,
+		})
+	}
+
+	body["variants"] = out
+	body["url"] = link
+	body["expires_at"] = exp
+	return c.JSON(body)
+}
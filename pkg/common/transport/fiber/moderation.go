@@ -0,0 +1,127 @@
+package fiber
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/transport"
+)
+
+// ModerationQueue is the review queue served by RegisterModerationRoutes,
+// with C the case type and F the abuse flag type.
+type ModerationQueue[C, F any] interface {
+	Pending(ctx context.Context, limit, offset int) ([]C, error)
+	Approve(ctx context.Context, id, moderatorID, note string) (*C, error)
+	Reject(ctx context.Context, id, moderatorID, reason string) (*C, error)
+	Flag(ctx context.Context, contentType, contentID, reporterID, reason, details string) (*F, error)
+}
+
+// flagRequest is the body of an abuse flag.
+type flagRequest struct {
+	ContentType string `json:"content_type"`
+	ContentID   string `json:"content_id"`
+	Reason      string `json:"reason"`
+	Details     string `json:"details"`
+}
+
+// reviewRequest is the body of a moderator decision.
+type reviewRequest struct {
+	Note   string `json:"note"`
+	Reason string `json:"reason"`
+}
+
+// RegisterModerationRoutes mounts the moderator queue and the abuse flag route:
+//
+//	GET  basePath              pending cases, paginated with "limit" and "offset" (OpList)
+//	POST basePath/:id/approve  publishes held content with an optional "note" (OpUpdate)
+//	POST basePath/:id/reject   keeps content hidden with a required "reason" (OpUpdate)
+//	POST basePath/flags        abuse flag by the caller (OpCreate)
+//
+// Every route requires an authenticated caller. The queue routes answer 403 unless
+// isModerator accepts the caller, e.g. func(p *auth.Principal) bool { return p.HasRole("moderator") }.
+func RegisterModerationRoutes[C, F any](app *fiber.App, basePath string, q ModerationQueue[C, F], isModerator func(*auth.Principal) bool, opts ...RouteOption) {
+
+	if isModerator == nil {
+		panic("fiber: RegisterModerationRoutes requires a moderator check")
+	}
+	o := newRouteOptions(opts)
+
+	app.Get(basePath, o.chain(endpoint.OpList, func(c *fiber.Ctx) error {
+		if _, err := moderator(c, isModerator); err != nil {
+			return EncodeError(c, err)
+		}
+		cases, err := q.Pending(c.UserContext(), c.QueryInt("limit"), c.QueryInt("offset"))
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return c.JSON(cases)
+	})...)
+
+	review := func(approve bool) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			moderatorID, err := moderator(c, isModerator)
+			if err != nil {
+				return EncodeError(c, err)
+			}
+			var body reviewRequest
+			if len(c.Body()) > 0 {
+				if err := c.BodyParser(&body); err != nil {
+					return EncodeError(c, transport.BadRequest("invalid review body"))
+				}
+			}
+
+			var res *C
+			if approve {
+				res, err = q.Approve(c.UserContext(), c.Params("id"), moderatorID, body.Note)
+			} else {
+				res, err = q.Reject(c.UserContext(), c.Params("id"), moderatorID, body.Reason)
+			}
+			if err != nil {
+				return EncodeError(c, err)
+			}
+			return c.JSON(res)
+		}
+	}
+	app.Post(basePath+"/:id/approve", o.chain(endpoint.OpUpdate, review(true))...)
+	app.Post(basePath+"/:id/reject", o.chain(endpoint.OpUpdate, review(false))...)
+
+	app.Post(basePath+"/flags", o.chain(endpoint.OpCreate, func(c *fiber.Ctx) error {
+		reporter, err := caller(c)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		var body flagRequest
+		if err := c.BodyParser(&body); err != nil {
+			return EncodeError(c, transport.BadRequest("invalid flag body"))
+		}
+		f, err := q.Flag(c.UserContext(), body.ContentType, body.ContentID, reporter, body.Reason, body.Details)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(f)
+	})...)
+
+}
+
+// caller returns the subject of the authenticated principal.
+func caller(c *fiber.Ctx) (string, error) {
+	p, ok := auth.FromContext(c.UserContext())
+	if !ok || p.Subject == "" {
+		return "", transport.Unauthorized("authentication required")
+	}
+	return p.Subject, nil
+}
+
+// moderator returns the subject of the authenticated principal when isModerator accepts it.
+func moderator(c *fiber.Ctx, isModerator func(*auth.Principal) bool) (string, error) {
+	p, ok := auth.FromContext(c.UserContext())
+	if !ok || p.Subject == "" {
+		return "", transport.Unauthorized("authentication required")
+	}
+	if !isModerator(p) {
+		return "", transport.Forbidden("moderator role required")
+	}
+	return p.Subject, nil
+}
@@ -0,0 +1,106 @@
+package fiber
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"gorm.io/gorm"
+)
+
+// RouteOption customizes the routes mounted by RegisterCrudRoutes.
+type RouteOption func(*routeOptions)
+
+// ListFilter builds query functions for the list operation, and for bulk
+// updates and deletes, from the request.
+type ListFilter func(c *fiber.Ctx) ([]func(*gorm.DB) *gorm.DB, error)
+
+// routeOptions holds the handlers to run before each operation and the list filters.
+type routeOptions struct {
+	handlers map[endpoint.Operation][]fiber.Handler
+	common   []fiber.Handler // common holds the middleware added for every operation.
+	filters  []ListFilter
+}
+
+// newRouteOptions applies the given options.
+func newRouteOptions(opts []RouteOption) *routeOptions {
+	o := &routeOptions{handlers: make(map[endpoint.Operation][]fiber.Handler)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// chain returns the route handlers of an operation followed by the final handler.
+func (o *routeOptions) chain(op endpoint.Operation, h fiber.Handler) []fiber.Handler {
+	return append(append([]fiber.Handler{}, o.handlers[op]...), h)
+}
+
+// chainAll returns the route handlers of every operation, in order, followed by
+// the final handler, for routes performing several operations.
+func (o *routeOptions) chainAll(h fiber.Handler, ops ...endpoint.Operation) []fiber.Handler {
+	var out []fiber.Handler
+	for _, op := range ops {
+		out = append(out, o.handlers[op]...)
+	}
+	return append(out, h)
+}
+
+// chainCommon returns the middleware added for every operation followed by the
+// final handler, for routes authorized by other means than the operation handlers.
+func (o *routeOptions) chainCommon(h fiber.Handler) []fiber.Handler {
+	return append(append([]fiber.Handler{}, o.common...), h)
+}
+
+// filter returns the query functions of the list filters for the request.
+func (o *routeOptions) filter(c *fiber.Ctx) ([]func(*gorm.DB) *gorm.DB, error) {
+	var out []func(*gorm.DB) *gorm.DB
+	for _, f := range o.filters {
+		fns, err := f(c)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, fns...)
+	}
+	return out, nil
+}
+
+// WithMiddleware runs h before the given operations, or before all when none is
+// given. Middleware added for all operations, such as Tenant, also runs on routes
+// authorized by other means, such as signed media downloads.
+func WithMiddleware(h fiber.Handler, ops ...endpoint.Operation) RouteOption {
+	return func(o *routeOptions) {
+		if len(ops) == 0 {
+			o.common = append(o.common, h)
+			ops = endpoint.Operations
+		}
+		for _, op := range ops {
+			o.handlers[op] = append(o.handlers[op], h)
+		}
+	}
+}
+
+// WithAuth authenticates bearer tokens on every route and requires them on the
+// given operations, or on all when none is given. Other operations accept
+// anonymous requests but still reject invalid tokens.
+func WithAuth(v *auth.Validator, ops ...endpoint.Operation) RouteOption {
+	return func(o *routeOptions) {
+		if len(ops) == 0 {
+			ops = endpoint.Operations
+		}
+		required := make(map[endpoint.Operation]bool, len(ops))
+		for _, op := range ops {
+			required[op] = true
+		}
+		for _, op := range endpoint.Operations {
+			o.handlers[op] = append(o.handlers[op], auth.Middleware(v, required[op]))
+		}
+	}
+}
+
+// WithListFilter applies the query functions built by f to the list operation,
+// and to bulk updates and deletes so they only reach listable models.
+func WithListFilter(f ListFilter) RouteOption {
+	return func(o *routeOptions) {
+		o.filters = append(o.filters, f)
+	}
+}
@@ -0,0 +1,15 @@
+package fiber
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/db"
+)
+
+// ReadYourWrites sends the reads of a request to the primary database once the
+// request wrote to it, so clients see their changes despite replica lag.
+func ReadYourWrites() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(db.WithReadYourWrites(c.UserContext()))
+		return c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package fiber
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/endpoint"
+	"github.com/ianfedev/civicspot-backend/pkg/common/search"
+)
+
+// RegisterSearchRoutes mounts the search route (OpList) on basePath:
+//
+//	GET basePath?q=hueco&type=report,comment&category=vias&status=open,in_progress&limit=20&offset=0
+//
+// Every name in fields is both a filter taking comma-separated values and a
+// facet returned with the results (e.g., "category", "status", "municipality").
+func RegisterSearchRoutes(app *fiber.App, basePath string, idx search.Index, fields []string, opts ...RouteOption) {
+
+	o := newRouteOptions(opts)
+
+	app.Get(basePath, o.chain(endpoint.OpList, func(c *fiber.Ctx) error {
+		q := search.Query{
+			Text:    c.Query("q"),
+			Types:   splitQuery(c.Query("type")),
+			Filters: map[string][]string{},
+			Facets:  fields,
+			Limit:   c.QueryInt("limit"),
+			Offset:  c.QueryInt("offset"),
+		}
+		for _, f := range fields {
+			if values := splitQuery(c.Query(f)); len(values) > 0 {
+				q.Filters[f] = values
+			}
+		}
+
+		res, err := idx.Search(c.UserContext(), q)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		return c.JSON(res)
+	})...)
+
+}
+
+// splitQuery splits a comma-separated query parameter, dropping empty values.
+func splitQuery(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,27 @@
+package fiber
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ianfedev/civicspot-backend/pkg/common/auth"
+	"github.com/ianfedev/civicspot-backend/pkg/common/tenant"
+)
+
+// Tenant resolves the tenant of each request from its host, the tenant header
+// and the token claim, and stores it in the request user context so every
+// db.Repository call is scoped to it. Requests without a tenant are rejected.
+// Add it with WithMiddleware after WithAuth so the claim of authenticated users
+// is enforced over the host and header.
+func Tenant(r *tenant.Resolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := tenant.Request{Host: c.Hostname(), Header: c.Get(r.HeaderName())}
+		if p, ok := auth.FromContext(c.UserContext()); ok {
+			req.Claims = p.Claims
+		}
+		id, err := r.Resolve(req)
+		if err != nil {
+			return EncodeError(c, err)
+		}
+		c.SetUserContext(tenant.NewContext(c.UserContext(), id))
+		return c.Next()
+	}
+}